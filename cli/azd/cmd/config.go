@@ -0,0 +1,205 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/commands"
+	"github.com/azure/azure-dev/cli/azd/pkg/config"
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+func configCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the Azure Developer CLI user configuration.",
+		Long: `Manage the Azure Developer CLI user configuration.
+
+The user configuration is stored in ` + withBackticks("~/.azd/"+config.FileName) + ` and holds defaults for all your projects. A value
+given by a flag takes precedence over the environment variable of the setting, which takes precedence over the user
+configuration.
+
+The supported settings are:
+
+` + configSettingsHelp(),
+	}
+
+	root.Flags().BoolP("help", "h", false, fmt.Sprintf("Gets help for %s.", root.Name()))
+	root.AddCommand(configGetCmd(rootOptions))
+	root.AddCommand(configSetCmd(rootOptions))
+	root.AddCommand(configUnsetCmd(rootOptions))
+	root.AddCommand(output.AddOutputParam(
+		configListCmd(rootOptions),
+		[]output.Format{output.JsonFormat, output.TableFormat},
+		output.TableFormat,
+	))
+
+	return root
+}
+
+func configSettingsHelp() string {
+	lines := make([]string, 0, len(config.Settings))
+	for _, setting := range config.Settings {
+		line := fmt.Sprintf("  %s: %s", withBackticks(setting.Key), setting.Description)
+		if setting.EnvVarName != "" {
+			line += fmt.Sprintf("\n    Environment variable: %s", withBackticks(setting.EnvVarName))
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func configGetCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	cmd := commands.Build(
+		commands.ActionFunc(func(_ context.Context, cmd *cobra.Command, args []string, _ *environment.AzdContext) error {
+			setting, err := config.GetSetting(args[0])
+			if err != nil {
+				return err
+			}
+
+			userConfig, _, err := loadUserConfig()
+			if err != nil {
+				return err
+			}
+
+			value := userConfig.Get(setting)
+			if value == "" {
+				return fmt.Errorf("'%s' is not set in the user configuration", setting.Key)
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		}),
+		rootOptions,
+		"get <key>",
+		"Get a value of the user configuration.",
+		"",
+	)
+	cmd.Args = cobra.ExactArgs(1)
+	return cmd
+}
+
+func configSetCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	cmd := commands.Build(
+		commands.ActionFunc(func(_ context.Context, _ *cobra.Command, args []string, _ *environment.AzdContext) error {
+			setting, err := config.GetSetting(args[0])
+			if err != nil {
+				return err
+			}
+
+			userConfig, store, err := loadUserConfig()
+			if err != nil {
+				return err
+			}
+
+			if err := userConfig.Set(setting, args[1]); err != nil {
+				return err
+			}
+
+			return store.Save(userConfig)
+		}),
+		rootOptions,
+		"set <key> <value>",
+		"Set a value of the user configuration.",
+		"",
+	)
+	cmd.Args = cobra.ExactArgs(2)
+	return cmd
+}
+
+func configUnsetCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	cmd := commands.Build(
+		commands.ActionFunc(func(_ context.Context, _ *cobra.Command, args []string, _ *environment.AzdContext) error {
+			setting, err := config.GetSetting(args[0])
+			if err != nil {
+				return err
+			}
+
+			userConfig, store, err := loadUserConfig()
+			if err != nil {
+				return err
+			}
+
+			userConfig.Unset(setting)
+			return store.Save(userConfig)
+		}),
+		rootOptions,
+		"unset <key>",
+		"Remove a value from the user configuration.",
+		"",
+	)
+	cmd.Args = cobra.ExactArgs(1)
+	return cmd
+}
+
+type configValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func configListCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the values of the user configuration.",
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			formatter, err := output.GetFormatter(cmd)
+			if err != nil {
+				return err
+			}
+
+			userConfig, _, err := loadUserConfig()
+			if err != nil {
+				return err
+			}
+
+			if formatter.Kind() == output.TableFormat {
+				values := []configValue{}
+				for key, value := range userConfig.Values() {
+					values = append(values, configValue{Key: key, Value: value})
+				}
+				sort.Slice(values, func(i, j int) bool { return values[i].Key < values[j].Key })
+
+				return formatter.Format(values, cmd.OutOrStdout(), output.TableFormatterOptions{
+					Columns: []output.Column{
+						{
+							Heading:       "KEY",
+							ValueTemplate: "{{.Key}}",
+						},
+						{
+							Heading:       "VALUE",
+							ValueTemplate: "{{.Value}}",
+						},
+					},
+				})
+			}
+
+			return formatter.Format(userConfig.Values(), cmd.OutOrStdout(), nil)
+		},
+	}
+	cmd.Flags().BoolP("help", "h", false, fmt.Sprintf("Gets help for %s.", cmd.Name()))
+	return cmd
+}
+
+func loadUserConfig() (*config.UserConfig, *config.Store, error) {
+	store, err := config.NewStore()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userConfig, err := store.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return userConfig, store, nil
+}
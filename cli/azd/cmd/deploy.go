@@ -6,7 +6,9 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/azure/azure-dev/cli/azd/pkg/azureutil"
@@ -17,6 +19,7 @@ import (
 	"github.com/azure/azure-dev/cli/azd/pkg/project"
 	"github.com/azure/azure-dev/cli/azd/pkg/spin"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/git"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -36,9 +39,19 @@ Examples:
 	$ azd deploy
 	$ azd deploy –-service api
 	$ azd deploy –-service web
+	$ azd deploy --parallelism 1
+	$ azd deploy --force
+
+Services that are independent of each other are deployed at the same time. A service can declare the services that must be deployed before it with the `+withBackticks("dependsOn")+` property in the *azure.yaml* file.
 	
-After the deployment is complete, the endpoint is printed. To start the service, select the endpoint or paste it in a browser.`,
+A service is skipped when its inputs are unchanged since its last deployment and the deployment is still found on its Azure resource: the files deployed from the service, which are not excluded by the `+withBackticks(".dockerignore")+` file of the Docker build context or by the `+withBackticks(".azdignore")+` file of the service, its section of the *azure.yaml* file and the environment values its deployment consumes. All the services are deployed again once the infrastructure is provisioned or deleted. The deploying and deployed hooks of skipped services still run, with `+withBackticks("AZD_DEPLOYMENT_SKIPPED")+` set to `+withBackticks("true")+`. Use `+withBackticks("--force")+` to deploy all the services.
+
+After the deployment is complete, the endpoint is printed. To start the service, select the endpoint or paste it in a browser.
+
+Each deployment is recorded in the deployment history of the environment. Use `+withBackticks("azd deploy history")+` to list the deployments and `+withBackticks("azd deploy rollback")+` to redeploy a previous deployment of a service.`,
 	)
+	cmd.AddCommand(deployHistoryCmd(rootOptions))
+	cmd.AddCommand(deployRollbackCmd(rootOptions))
 
 	return output.AddOutputParam(
 		cmd,
@@ -46,8 +59,13 @@ After the deployment is complete, the endpoint is printed. To start the service,
 		output.NoneFormat)
 }
 
+// The number of services deployed concurrently when --parallelism is not specified
+const defaultDeployParallelism = 4
+
 type deployAction struct {
 	serviceName string
+	parallelism int
+	force       bool
 	rootOptions *commands.GlobalCommandOptions
 }
 
@@ -61,12 +79,18 @@ func (d *deployAction) SetupFlags(
 	local *pflag.FlagSet,
 ) {
 	local.StringVar(&d.serviceName, "service", "", "Deploys a specific service (when the string is unspecified, all services that are listed in the "+environment.ProjectFileName+" file are deployed).")
+	local.IntVar(&d.parallelism, "parallelism", defaultDeployParallelism, "The maximum number of services that are deployed at the same time. Services are always deployed after the services they depend on.")
+	local.BoolVar(&d.force, "force", false, "Deploys the services even when they are unchanged since their last deployment.")
 }
 
 func (d *deployAction) Run(ctx context.Context, cmd *cobra.Command, args []string, azdCtx *environment.AzdContext) error {
 	azCli := commands.GetAzCliFromContext(ctx)
 	console := input.NewConsole(!d.rootOptions.NoPrompt)
 
+	if d.parallelism < 1 {
+		return fmt.Errorf("invalid value for --parallelism: %d, at least 1 service must be deployed at a time", d.parallelism)
+	}
+
 	if err := ensureProject(azdCtx.ProjectPath()); err != nil {
 		return err
 	}
@@ -118,55 +142,155 @@ func (d *deployAction) Run(ctx context.Context, cmd *cobra.Command, args []strin
 	}
 	interactive := formatter.Kind() == output.NoneFormat
 
-	var svcDeploymentResult project.ServiceDeploymentResult
-	var deploymentResults []project.ServiceDeploymentResult
-
+	// Skip all the other services when the user specified a service name
+	var services []*project.Service
 	for _, svc := range proj.Services {
-		// Skip this service if both cases are true:
-		// 1. The user specified a service name
-		// 2. This service is not the one the user specified
-		if d.serviceName != "" && svc.Config.Name != d.serviceName {
+		if d.serviceName == "" || svc.Config.Name == d.serviceName {
+			services = append(services, svc)
+		}
+	}
+
+	history, err := project.LoadDeploymentHistory(azdCtx.GetEnvironmentDeploymentsDirectory(env.GetEnvName()))
+	if err != nil {
+		return err
+	}
+
+	// The commit is recorded in the deployment history when the project is a git repository
+	gitCommit, err := git.NewGitCli().GetCurrentCommit(ctx, azdCtx.ProjectDirectory())
+	if err != nil {
+		log.Printf("unable to determine the current commit of the project: %v", err)
+	}
+
+	if err := projConfig.RaiseEvent(ctx, project.Deploying, nil); err != nil {
+		return err
+	}
+
+	// The inputs are hashed before any service is deployed, the deployments update the environment
+	inputHashes := map[string]string{}
+	for _, svc := range services {
+		inputHash, err := svc.InputHash(azdCtx, &env)
+		if err != nil {
+			log.Printf("unable to hash the inputs of service %s, the service will be deployed: %v", svc.Config.Name, err)
 			continue
 		}
 
-		deployAndReportProgress := func(showProgress func(string)) error {
-			result, progress := svc.Deploy(ctx, azdCtx)
+		inputHashes[svc.Config.Name] = inputHash
+	}
 
-			// Report any progress
-			go func() {
-				for message := range progress {
-					showProgress(fmt.Sprintf("- %s...", message))
-				}
-			}()
+	var spinner *spin.Spinner
+	if interactive {
+		spinner = spin.NewSpinner("Deploying services")
+		spinner.Start()
+	}
+
+	var resultsMutex sync.Mutex
+	serviceDeploymentResults := map[string]project.ServiceDeploymentResult{}
+
+	// Deploys a single service, running the hooks of the service before & after the deployment
+	deployService := func(ctx context.Context, svc *project.Service) (*project.ServiceDeploymentChannelResponse, error) {
+		if err := svc.Config.RaiseEvent(ctx, project.Deploying, nil); err != nil {
+			return nil, err
+		}
 
-			response := <-result
-			if response.Error != nil {
-				return fmt.Errorf("deploying service: %w", response.Error)
+		result, progress := svc.Deploy(ctx, azdCtx)
+
+		// Report any progress
+		go func() {
+			for message := range progress {
+				if interactive {
+					spinner.Title(fmt.Sprintf("Deploying service %s - %s...", svc.Config.Name, message))
+				}
 			}
+		}()
 
-			svcDeploymentResult = *response.Result
-			deploymentResults = append(deploymentResults, svcDeploymentResult)
+		response := <-result
+		if response.Error != nil {
+			return nil, fmt.Errorf("deploying service: %w", response.Error)
+		}
 
-			return nil
+		if err := svc.Config.RaiseEvent(ctx, project.Deployed, nil); err != nil {
+			return nil, err
 		}
 
-		if interactive {
-			deployMsg := fmt.Sprintf("Deploying service %s", svc.Config.Name)
-			fmt.Println(deployMsg)
-			spinner := spin.NewSpinner(deployMsg)
-			spinner.Start()
-			err = deployAndReportProgress(spinner.Title)
-			spinner.Stop()
-
-			if err == nil {
-				reportServiceDeploymentResultInteractive(svc, &svcDeploymentResult)
+		return response, nil
+	}
+
+	runResults := project.RunServices(ctx, services, d.parallelism, func(ctx context.Context, svc *project.Service) error {
+		inputHash := inputHashes[svc.Config.Name]
+
+		latest := history.Latest(svc.Config.Name)
+
+		if !d.force && inputHash != "" && latest != nil && latest.InputHash == inputHash {
+			if result, deployed := skippedServiceDeploymentResult(ctx, svc, latest); deployed {
+				return raiseSkippedDeploymentEvents(ctx, svc.Config, func() {
+					resultsMutex.Lock()
+					serviceDeploymentResults[svc.Config.Name] = result
+					resultsMutex.Unlock()
+
+					if interactive {
+						spinner.Println(formatServiceSkippedInteractive(svc, &result))
+					}
+				})
 			}
-		} else {
-			err = deployAndReportProgress(nil)
 		}
+
+		if interactive {
+			spinner.Println(fmt.Sprintf("Deploying service %s", svc.Config.Name))
+		}
+
+		response, err := deployService(ctx, svc)
 		if err != nil {
+			if interactive {
+				spinner.Println(fmt.Sprintf("Failed deploying service %s", svc.Config.Name))
+			}
+
 			return err
 		}
+
+		resultsMutex.Lock()
+		serviceDeploymentResults[svc.Config.Name] = *response.Result
+		resultsMutex.Unlock()
+
+		_, historyErr := history.Record(
+			svc.Config.Name, response.Result, response.Artifact, svc.Config.Path(), gitCommit, inputHash,
+		)
+
+		// The service was deployed, failing to record the deployment only prevents rolling back to it
+		if historyErr != nil {
+			log.Printf("recording deployment of service %s: %v", svc.Config.Name, historyErr)
+			if interactive {
+				spinner.Println(fmt.Sprintf("WARNING: Failed recording the deployment of service %s in the deployment history: %v", svc.Config.Name, historyErr))
+			}
+		}
+
+		if interactive {
+			spinner.Println(formatServiceDeploymentResultInteractive(svc, response.Result))
+		}
+
+		return nil
+	})
+
+	if interactive {
+		spinner.Stop()
+	}
+
+	var deploymentResults []project.ServiceDeploymentResult
+	var deploymentErrors []string
+	for _, runResult := range runResults {
+		if runResult.Error != nil {
+			deploymentErrors = append(deploymentErrors, fmt.Sprintf("%s: %s", runResult.Service.Config.Name, runResult.Error))
+			continue
+		}
+
+		deploymentResults = append(deploymentResults, serviceDeploymentResults[runResult.Service.Config.Name])
+	}
+
+	if len(deploymentErrors) > 0 {
+		return fmt.Errorf("failed deploying %d service(s):\n%s", len(deploymentErrors), strings.Join(deploymentErrors, "\n"))
+	}
+
+	if err := projConfig.RaiseEvent(ctx, project.Deployed, nil); err != nil {
+		return err
 	}
 
 	if formatter.Kind() == output.JsonFormat {
@@ -199,7 +323,7 @@ func (d *deployAction) Run(ctx context.Context, cmd *cobra.Command, args []strin
 	return nil
 }
 
-func reportServiceDeploymentResultInteractive(svc *project.Service, sdr *project.ServiceDeploymentResult) {
+func formatServiceDeploymentResultInteractive(svc *project.Service, sdr *project.ServiceDeploymentResult) string {
 	var builder strings.Builder
 
 	builder.WriteString(fmt.Sprintf("Deployed service %s\n", svc.Config.Name))
@@ -208,6 +332,59 @@ func reportServiceDeploymentResultInteractive(svc *project.Service, sdr *project
 		builder.WriteString(fmt.Sprintf(" - Endpoint: %s\n", withLinkFormat(endpoint)))
 	}
 
-	printWithStyling(builder.String())
-	fmt.Println()
+	return builder.String()
+}
+
+// raiseSkippedDeploymentEvents raises the Deploying & Deployed events of a service skipped as unchanged around skip, so
+// that the hooks of the service run on every deploy. The events are flagged with project.DeploymentSkippedEventArg.
+func raiseSkippedDeploymentEvents(ctx context.Context, config *project.ServiceConfig, skip func()) error {
+	args := map[string]any{project.DeploymentSkippedEventArg: true}
+	if err := config.RaiseEvent(ctx, project.Deploying, args); err != nil {
+		return err
+	}
+
+	skip()
+
+	return config.RaiseEvent(ctx, project.Deployed, args)
+}
+
+// The result of a service which is unchanged since its last deployment, with the endpoints read from the deployed
+// service. It returns false when the last deployment can't be confirmed on the target resource, ex) the resource was
+// deleted or a container app runs another image, in which case the service is deployed again.
+func skippedServiceDeploymentResult(
+	ctx context.Context,
+	svc *project.Service,
+	latest *project.DeploymentHistoryEntry,
+) (project.ServiceDeploymentResult, bool) {
+	endpoints, err := svc.Target.Endpoints(ctx)
+	if err != nil {
+		log.Printf("reading endpoints of service %s, the service will be deployed: %v", svc.Config.Name, err)
+		return project.ServiceDeploymentResult{}, false
+	}
+
+	result := project.ServiceDeploymentResult{
+		TargetResourceId: latest.TargetResourceId,
+		Kind:             latest.Kind,
+		Image:            latest.Image,
+		Endpoints:        endpoints,
+	}
+
+	if verifier, ok := svc.Target.(project.DeploymentVerifier); ok && !verifier.IsDeployed(ctx, &result) {
+		log.Printf("the last deployment of service %s is no longer deployed, the service will be deployed", svc.Config.Name)
+		return project.ServiceDeploymentResult{}, false
+	}
+
+	return result, true
+}
+
+func formatServiceSkippedInteractive(svc *project.Service, sdr *project.ServiceDeploymentResult) string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("Skipped service %s, unchanged since its last deployment\n", svc.Config.Name))
+
+	for _, endpoint := range sdr.Endpoints {
+		builder.WriteString(fmt.Sprintf(" - Endpoint: %s\n", withLinkFormat(endpoint)))
+	}
+
+	return builder.String()
 }
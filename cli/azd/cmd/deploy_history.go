@@ -0,0 +1,352 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/commands"
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/output"
+	"github.com/azure/azure-dev/cli/azd/pkg/project"
+	"github.com/azure/azure-dev/cli/azd/pkg/spin"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func deployHistoryCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	cmd := commands.Build(
+		&deployHistoryAction{rootOptions: rootOptions},
+		rootOptions,
+		"history",
+		"List the deployments of the application's services.",
+		`List the deployments of the application's services in the current environment, most recent first.
+
+Each deployment is identified by an ID, which can be passed to `+withBackticks("azd deploy rollback")+` to redeploy the artifact of the deployment.`,
+	)
+	cmd.Args = cobra.NoArgs
+
+	return output.AddOutputParam(
+		cmd,
+		[]output.Format{output.JsonFormat, output.TableFormat},
+		output.TableFormat)
+}
+
+type deployHistoryAction struct {
+	serviceName string
+	rootOptions *commands.GlobalCommandOptions
+}
+
+// A row of the table output of the deployment history
+type deploymentHistoryRow struct {
+	Id        string
+	Service   string
+	Timestamp string
+	Commit    string
+	Artifact  string
+	Rollback  string
+}
+
+func (d *deployHistoryAction) SetupFlags(
+	persis *pflag.FlagSet,
+	local *pflag.FlagSet,
+) {
+	local.StringVar(&d.serviceName, "service", "", "Lists only the deployments of a specific service.")
+}
+
+func (d *deployHistoryAction) Run(ctx context.Context, cmd *cobra.Command, args []string, azdCtx *environment.AzdContext) error {
+	azCli := commands.GetAzCliFromContext(ctx)
+	console := input.NewConsole(!d.rootOptions.NoPrompt)
+
+	if err := ensureProject(azdCtx.ProjectPath()); err != nil {
+		return err
+	}
+
+	if err := tools.EnsureInstalled(ctx, azCli); err != nil {
+		return err
+	}
+
+	formatter, err := output.GetFormatter(cmd)
+	if err != nil {
+		return err
+	}
+
+	env, err := loadOrInitEnvironment(ctx, &d.rootOptions.EnvironmentName, azdCtx, console)
+	if err != nil {
+		return fmt.Errorf("loading environment: %w", err)
+	}
+
+	history, err := project.LoadDeploymentHistory(azdCtx.GetEnvironmentDeploymentsDirectory(env.GetEnvName()))
+	if err != nil {
+		return err
+	}
+
+	entries := history.Entries(d.serviceName)
+
+	if formatter.Kind() == output.TableFormat {
+		rows := make([]deploymentHistoryRow, len(entries))
+		for i, entry := range entries {
+			rows[i] = deploymentHistoryRow{
+				Id:        entry.Id,
+				Service:   entry.Service,
+				Timestamp: entry.Timestamp.Local().Format(time.RFC3339),
+				Commit:    shortCommit(entry.GitCommit),
+				Artifact:  entry.Artifact(),
+				Rollback:  entry.RollbackOf,
+			}
+		}
+
+		columns := []output.Column{
+			{
+				Heading:       "ID",
+				ValueTemplate: "{{.Id}}",
+			},
+			{
+				Heading:       "SERVICE",
+				ValueTemplate: "{{.Service}}",
+			},
+			{
+				Heading:       "DEPLOYED",
+				ValueTemplate: "{{.Timestamp}}",
+			},
+			{
+				Heading:       "COMMIT",
+				ValueTemplate: "{{.Commit}}",
+			},
+			{
+				Heading:       "ARTIFACT",
+				ValueTemplate: "{{.Artifact}}",
+			},
+			{
+				Heading:       "ROLLBACK OF",
+				ValueTemplate: "{{.Rollback}}",
+			},
+		}
+
+		return formatter.Format(rows, cmd.OutOrStdout(), output.TableFormatterOptions{
+			Columns: columns,
+		})
+	}
+
+	return formatter.Format(entries, cmd.OutOrStdout(), nil)
+}
+
+func deployRollbackCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	cmd := commands.Build(
+		&deployRollbackAction{rootOptions: rootOptions},
+		rootOptions,
+		"rollback",
+		"Redeploy a previous deployment of a service.",
+		`Redeploy a previous deployment of a service.
+
+The artifact of the deployment, the container image or the deployed package, is deployed again without building the service. Use `+withBackticks("azd deploy history")+` to find the ID of the deployment.
+
+Examples:
+
+	$ azd deploy rollback --service api --to 3`,
+	)
+	cmd.Args = cobra.NoArgs
+
+	return output.AddOutputParam(
+		cmd,
+		[]output.Format{output.JsonFormat, output.NoneFormat},
+		output.NoneFormat)
+}
+
+type deployRollbackAction struct {
+	serviceName  string
+	deploymentId string
+	rootOptions  *commands.GlobalCommandOptions
+}
+
+func (r *deployRollbackAction) SetupFlags(
+	persis *pflag.FlagSet,
+	local *pflag.FlagSet,
+) {
+	local.StringVar(&r.serviceName, "service", "", "The service to roll back.")
+	local.StringVar(&r.deploymentId, "to", "", "The ID of the deployment to redeploy, as listed by azd deploy history.")
+}
+
+func (r *deployRollbackAction) Run(ctx context.Context, cmd *cobra.Command, args []string, azdCtx *environment.AzdContext) error {
+	azCli := commands.GetAzCliFromContext(ctx)
+	console := input.NewConsole(!r.rootOptions.NoPrompt)
+
+	if r.serviceName == "" {
+		return errors.New("the service to roll back must be specified with --service")
+	}
+
+	if r.deploymentId == "" {
+		return errors.New("the deployment to redeploy must be specified with --to")
+	}
+
+	if err := ensureProject(azdCtx.ProjectPath()); err != nil {
+		return err
+	}
+
+	if err := tools.EnsureInstalled(ctx, azCli); err != nil {
+		return err
+	}
+
+	if err := ensureLoggedIn(ctx); err != nil {
+		return fmt.Errorf("failed to ensure login: %w", err)
+	}
+
+	env, err := loadOrInitEnvironment(ctx, &r.rootOptions.EnvironmentName, azdCtx, console)
+	if err != nil {
+		return fmt.Errorf("loading environment: %w", err)
+	}
+
+	projConfig, err := project.LoadProjectConfig(azdCtx.ProjectPath(), &env)
+	if err != nil {
+		return fmt.Errorf("loading project: %w", err)
+	}
+
+	if !projConfig.HasService(r.serviceName) {
+		return fmt.Errorf("service name '%s' doesn't exist", r.serviceName)
+	}
+
+	history, err := project.LoadDeploymentHistory(azdCtx.GetEnvironmentDeploymentsDirectory(env.GetEnvName()))
+	if err != nil {
+		return err
+	}
+
+	entry, err := history.Get(r.serviceName, r.deploymentId)
+	if err != nil {
+		return err
+	}
+
+	proj, err := projConfig.GetProject(ctx, &env)
+	if err != nil {
+		return fmt.Errorf("creating project: %w", err)
+	}
+
+	var svc *project.Service
+	for _, s := range proj.Services {
+		if s.Config.Name == r.serviceName {
+			svc = s
+		}
+	}
+
+	if err := tools.EnsureInstalled(ctx, tools.Unique(svc.Target.RequiredExternalTools())...); err != nil {
+		return err
+	}
+
+	artifact, cleanup, err := rollbackArtifact(history, entry)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	formatter, err := output.GetFormatter(cmd)
+	if err != nil {
+		return err
+	}
+	interactive := formatter.Kind() == output.NoneFormat
+
+	var spinner *spin.Spinner
+	if interactive {
+		spinner = spin.NewSpinner(fmt.Sprintf("Rolling back service %s to deployment %s", r.serviceName, entry.Id))
+		spinner.Start()
+	}
+
+	result, progress := svc.Redeploy(ctx, azdCtx, artifact)
+
+	// Report any progress
+	go func() {
+		for message := range progress {
+			if interactive {
+				spinner.Title(fmt.Sprintf("Rolling back service %s - %s...", r.serviceName, message))
+			}
+		}
+	}()
+
+	response := <-result
+
+	if interactive {
+		spinner.Stop()
+	}
+
+	if response.Error != nil {
+		return fmt.Errorf("rolling back service: %w", response.Error)
+	}
+
+	rollbackEntry := project.NewDeploymentHistoryEntry(r.serviceName, response.Result)
+	rollbackEntry.GitCommit = entry.GitCommit
+	rollbackEntry.PackageHash = entry.PackageHash
+	rollbackEntry.RollbackOf = entry.Id
+
+	if _, err := history.Add(rollbackEntry); err != nil {
+		log.Printf("recording rollback of service %s: %v", r.serviceName, err)
+		fmt.Fprintf(os.Stderr, "WARNING: Failed recording the rollback in the deployment history: %v\n", err)
+	}
+
+	if formatter.Kind() == output.JsonFormat {
+		if fmtErr := formatter.Format(response.Result, cmd.OutOrStdout(), nil); fmtErr != nil {
+			return fmt.Errorf("deployment result could not be displayed: %w", fmtErr)
+		}
+
+		return nil
+	}
+
+	fmt.Print(formatServiceDeploymentResultInteractive(svc, response.Result))
+	return nil
+}
+
+// Gets the artifact to redeploy for the entry, returning a function which removes any temporary files
+func rollbackArtifact(history *project.DeploymentHistory, entry *project.DeploymentHistoryEntry) (string, func(), error) {
+	if entry.Image != "" {
+		return entry.Image, func() {}, nil
+	}
+
+	if entry.PackageHash == "" {
+		return "", nil, fmt.Errorf("deployment '%s' of service '%s' can't be rolled back to, it did not record the deployed artifact", entry.Id, entry.Service)
+	}
+
+	directory, err := os.MkdirTemp("", "azdrollback")
+	if err != nil {
+		return "", nil, fmt.Errorf("creating temporary directory: %w", err)
+	}
+
+	cleanup := func() {
+		os.RemoveAll(directory)
+	}
+
+	if err := history.ExtractPackage(entry, directory); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	return directory, cleanup, nil
+}
+
+// Gets the abbreviated form of a git commit hash
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+
+	return commit
+}
+
+// clearDeploymentInputs forgets the inputs recorded by the deployments of the environment once its infrastructure was
+// provisioned or deleted, so that the next deploy doesn't skip the services as unchanged
+func clearDeploymentInputs(azdCtx *environment.AzdContext, envName string) error {
+	history, err := project.LoadDeploymentHistory(azdCtx.GetEnvironmentDeploymentsDirectory(envName))
+	if err != nil {
+		return err
+	}
+
+	if err := history.ClearInputHashes(); err != nil {
+		return fmt.Errorf("updating deployment history: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/project"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_raiseSkippedDeploymentEvents(t *testing.T) {
+	const testProj = `
+name: test-proj
+services:
+  api:
+    project: src/api
+    language: js
+    host: appservice
+`
+
+	projectConfig, err := project.ParseProjectConfig(testProj, &environment.Environment{})
+	require.NoError(t, err)
+	serviceConfig := projectConfig.Services["api"]
+
+	calls := []string{}
+	for _, event := range []project.Event{project.Deploying, project.Deployed} {
+		event := event
+		err := serviceConfig.AddHandler(event, func(ctx context.Context, args project.ServiceLifecycleEventArgs) error {
+			require.Equal(t, true, args.Args[project.DeploymentSkippedEventArg])
+			calls = append(calls, string(event))
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	err = raiseSkippedDeploymentEvents(context.Background(), serviceConfig, func() {
+		calls = append(calls, "skip")
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"deploying", "skip", "deployed"}, calls)
+
+	// A failing Deploying hook fails the service before it is reported as skipped
+	require.NoError(t, serviceConfig.AddHandler(project.Deploying, func(ctx context.Context, args project.ServiceLifecycleEventArgs) error {
+		return errors.New("hook failed")
+	}))
+
+	calls = []string{}
+	err = raiseSkippedDeploymentEvents(context.Background(), serviceConfig, func() {
+		calls = append(calls, "skip")
+	})
+	require.Error(t, err)
+	require.NotContains(t, calls, "skip")
+}
@@ -5,15 +5,15 @@ package cmd
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"path/filepath"
 
 	"github.com/azure/azure-dev/cli/azd/pkg/commands"
 	"github.com/azure/azure-dev/cli/azd/pkg/environment"
-	"github.com/azure/azure-dev/cli/azd/pkg/iac/bicep"
+	"github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning"
 	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/keyvault"
 	"github.com/azure/azure-dev/cli/azd/pkg/output"
+	"github.com/azure/azure-dev/cli/azd/pkg/project"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
 	bicepTool "github.com/azure/azure-dev/cli/azd/pkg/tools/bicep"
@@ -56,41 +56,91 @@ You can find all environment configurations under the *.azure\<environment-name>
 }
 
 func envSetCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
-	actionFn := func(ctx context.Context, _ *cobra.Command, args []string, azdCtx *environment.AzdContext) error {
-		console := input.NewConsole(!rootOptions.NoPrompt)
-		azCli := commands.GetAzCliFromContext(ctx)
+	cmd := commands.Build(
+		&envSetAction{
+			rootOptions: rootOptions,
+		},
+		rootOptions,
+		"set <key> <value>",
+		"Set a value in the environment.",
+		`Set a value in the environment.
 
-		if err := ensureProject(azdCtx.ProjectPath()); err != nil {
-			return err
+When `+withBackticks("--secret")+` is set, the value is stored as a secret in Azure Key Vault and the environment file only
+contains a reference to the secret, in the `+withBackticks(environment.KeyVaultSecretScheme+"<vault-name>/<secret-name>")+` format.
+The secret is stored in the vault set by `+withBackticks("--vault")+`, otherwise in the vault of the `+withBackticks(environment.KeyVaultNameEnvVarName)+`
+environment value.`,
+	)
+	cmd.Args = cobra.ExactArgs(2)
+	return cmd
+}
+
+type envSetAction struct {
+	secret      bool
+	vaultName   string
+	rootOptions *commands.GlobalCommandOptions
+}
+
+func (e *envSetAction) SetupFlags(
+	persis *pflag.FlagSet,
+	local *pflag.FlagSet,
+) {
+	local.BoolVar(&e.secret, "secret", false, "Stores the value as a secret in Azure Key Vault.")
+	local.StringVar(&e.vaultName, "vault", "", "The name of the key vault where the secret is stored.")
+}
+
+func (e *envSetAction) Run(ctx context.Context, _ *cobra.Command, args []string, azdCtx *environment.AzdContext) error {
+	console := input.NewConsole(!e.rootOptions.NoPrompt)
+	azCli := commands.GetAzCliFromContext(ctx)
+
+	if err := ensureProject(azdCtx.ProjectPath()); err != nil {
+		return err
+	}
+
+	if err := tools.EnsureInstalled(ctx, azCli); err != nil {
+		return err
+	}
+
+	env, err := loadOrInitEnvironment(ctx, &e.rootOptions.EnvironmentName, azdCtx, console)
+	if err != nil {
+		return fmt.Errorf("loading environment: %w", err)
+	}
+
+	key, value := args[0], args[1]
+
+	if e.secret {
+		vaultName := e.vaultName
+		if vaultName == "" {
+			vaultName = env.Values[environment.KeyVaultNameEnvVarName]
 		}
 
-		if err := tools.EnsureInstalled(ctx, azCli); err != nil {
-			return err
+		if vaultName == "" {
+			return fmt.Errorf(
+				"no key vault to store the secret in, use --vault or set %s in the environment",
+				environment.KeyVaultNameEnvVarName,
+			)
 		}
 
-		env, err := loadOrInitEnvironment(ctx, &rootOptions.EnvironmentName, azdCtx, console)
+		proj, err := project.LoadProjectConfig(azdCtx.ProjectPath(), &env)
 		if err != nil {
-			return fmt.Errorf("loading environment: %w", err)
+			return fmt.Errorf("loading project: %w", err)
 		}
 
-		env.Values[args[0]] = args[1]
-
-		if err := env.Save(); err != nil {
-			return fmt.Errorf("saving environment: %w", err)
+		reference := environment.SecretReference{
+			VaultName:  vaultName,
+			SecretName: environment.SecretNameForKey(proj.Name, env.GetEnvName(), key),
+		}
+		if err := env.SetSecret(ctx, keyvault.NewSecretResolver(azCli), key, reference, value); err != nil {
+			return err
 		}
+	} else {
+		env.Values[key] = value
+	}
 
-		return nil
+	if err := env.Save(); err != nil {
+		return fmt.Errorf("saving environment: %w", err)
 	}
 
-	cmd := commands.Build(
-		commands.ActionFunc(actionFn),
-		rootOptions,
-		"set <key> <value>",
-		"Set a value in the environment.",
-		"",
-	)
-	cmd.Args = cobra.ExactArgs(2)
-	return cmd
+	return nil
 }
 
 func envSelectCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
@@ -224,14 +274,13 @@ func (en *envNewAction) Run(ctx context.Context, _ *cobra.Command, args []string
 func envRefreshCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
 	actionFn := func(ctx context.Context, cmd *cobra.Command, args []string, azdCtx *environment.AzdContext) error {
 		azCli := commands.GetAzCliFromContext(ctx)
-		bicepCli := bicepTool.NewBicepCli(bicepTool.NewBicepCliArgs{AzCli: azCli})
 		console := input.NewConsole(!rootOptions.NoPrompt)
 
 		if err := ensureProject(azdCtx.ProjectPath()); err != nil {
 			return err
 		}
 
-		if err := tools.EnsureInstalled(ctx, azCli, bicepCli); err != nil {
+		if err := tools.EnsureInstalled(ctx, azCli); err != nil {
 			return err
 		}
 
@@ -244,28 +293,39 @@ func envRefreshCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
 			return fmt.Errorf("loading environment: %w", err)
 		}
 
-		template, err := bicep.Compile(ctx, bicepCli, filepath.Join(azdCtx.InfrastructureDirectory(), "main.bicep"))
+		proj, err := project.LoadProjectConfig(azdCtx.ProjectPath(), &env)
 		if err != nil {
-			return err
+			return fmt.Errorf("loading project: %w", err)
 		}
 
-		res, err := azCli.GetSubscriptionDeployment(ctx, env.GetSubscriptionId(), env.GetEnvName())
-		if errors.Is(err, azcli.ErrDeploymentNotFound) {
-			return fmt.Errorf("no deployment for environment '%s' found. Have you run `infra create`?", rootOptions.EnvironmentName)
-		} else if err != nil {
-			return fmt.Errorf("fetching latest deployment: %w", err)
+		formatter, err := output.GetFormatter(cmd)
+		if err != nil {
+			return err
 		}
+		interactive := formatter.Kind() == output.NoneFormat
 
-		template.CanonicalizeDeploymentOutputs(&res.Properties.Outputs)
-		if err = saveEnvironmentValues(res, env); err != nil {
-			return err
+		// The provider reads the outputs of the latest provisioning, which the manager saves to the environment
+		infraManager, err := provisioning.NewManager(ctx, env, proj.Path, proj.Infra, !rootOptions.NoPrompt, console, bicepTool.NewBicepCliArgs{AzCli: azCli})
+		if err != nil {
+			return fmt.Errorf("creating provisioning manager: %w", err)
 		}
 
-		formatter, err := output.GetFormatter(cmd)
+		stateResult, err := infraManager.State(ctx, interactive)
 		if err != nil {
 			return err
 		}
+
 		if formatter.Kind() == output.JsonFormat {
+			// Keep the shape of the deployment result so existing consumers of the JSON output keep working
+			res := azcli.AzCliDeployment{
+				Properties: azcli.AzCliDeploymentProperties{
+					Outputs: make(map[string]azcli.AzCliDeploymentOutput, len(stateResult.Outputs)),
+				},
+			}
+			for name, param := range stateResult.Outputs {
+				res.Properties.Outputs[name] = azcli.AzCliDeploymentOutput{Type: param.Type, Value: param.Value}
+			}
+
 			err = formatter.Format(res, cmd.OutOrStdout(), nil)
 			if err != nil {
 				return fmt.Errorf("writing deployment result in JSON format: %w", err)
@@ -285,6 +345,8 @@ func envRefreshCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
 }
 
 func envGetValuesCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	var showSecrets bool
+
 	cmd := &cobra.Command{
 		Use:   "get-values",
 		Short: "Get all environment values.",
@@ -319,7 +381,12 @@ func envGetValuesCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command
 				return err
 			}
 
-			err = formatter.Format(env.Values, cmd.OutOrStdout(), nil)
+			values := env.MaskedValues()
+			if showSecrets {
+				values = env.Values
+			}
+
+			err = formatter.Format(values, cmd.OutOrStdout(), nil)
 			if err != nil {
 				return err
 			}
@@ -328,5 +395,6 @@ func envGetValuesCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command
 		},
 	}
 	cmd.Flags().BoolP("help", "h", false, fmt.Sprintf("Gets help for %s.", cmd.Name()))
+	cmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Shows the values of secrets instead of masking them.")
 	return cmd
 }
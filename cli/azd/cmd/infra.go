@@ -20,7 +20,7 @@ func infraCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
 
 	cmd.AddCommand(output.AddOutputParam(
 		infraCreateCmd(rootOptions),
-		[]output.Format{output.JsonFormat, output.NoneFormat},
+		[]output.Format{output.JsonFormat, output.TableFormat, output.NoneFormat},
 		output.NoneFormat,
 	))
 	cmd.AddCommand(infraDeleteCmd(rootOptions))
@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/azure/azure-dev/cli/azd/pkg/azure"
@@ -29,14 +31,17 @@ import (
 
 type infraCreateAction struct {
 	noProgress  bool
+	preview     bool
 	rootOptions *commands.GlobalCommandOptions
 }
 
 func infraCreateCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	action := &infraCreateAction{
+		rootOptions: rootOptions,
+	}
+
 	cmd := commands.Build(
-		&infraCreateAction{
-			rootOptions: rootOptions,
-		},
+		action,
 		rootOptions,
 		"create",
 		"Create Azure resources for an application.",
@@ -44,9 +49,15 @@ func infraCreateCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
 	)
 
 	cmd.Aliases = []string{"provision"}
+	action.setupPreviewFlag(cmd)
 	return cmd
 }
 
+// Registers the --preview flag, which is only available on the commands that solely provision infrastructure
+func (ica *infraCreateAction) setupPreviewFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&ica.preview, "preview", false, "Previews the changes to Azure resources without provisioning them.")
+}
+
 func (ica *infraCreateAction) SetupFlags(persis, local *pflag.FlagSet) {
 	local.BoolVar(&ica.noProgress, "no-progress", false, "Suppresses progress information.")
 }
@@ -56,11 +67,20 @@ func (ica *infraCreateAction) Run(ctx context.Context, cmd *cobra.Command, args
 	bicepCli := bicepTool.NewBicepCli(bicepTool.NewBicepCliArgs{AzCli: azCli})
 	console := input.NewConsole(!ica.rootOptions.NoPrompt)
 
+	formatter, err := output.GetFormatter(cmd)
+	if err != nil {
+		return err
+	}
+
+	if formatter.Kind() == output.TableFormat && !ica.preview {
+		return errors.New("the table output format is only supported with --preview")
+	}
+
 	if err := ensureProject(azdCtx.ProjectPath()); err != nil {
 		return err
 	}
 
-	if err := tools.EnsureInstalled(ctx, azCli, bicepCli); err != nil {
+	if err := tools.EnsureInstalled(ctx, azCli); err != nil {
 		return err
 	}
 
@@ -73,15 +93,36 @@ func (ica *infraCreateAction) Run(ctx context.Context, cmd *cobra.Command, args
 		return fmt.Errorf("loading environment: %w", err)
 	}
 
-	proj, err := project.LoadProjectConfig(azdCtx.ProjectPath(), &environment.Environment{})
+	// The project is loaded once the environment is known, so references to environment values are replaced and hooks
+	// run with the values of the environment
+	proj, err := project.LoadProjectConfig(azdCtx.ProjectPath(), &env)
 	if err != nil {
 		return fmt.Errorf("loading project: %w", err)
 	}
 
+	// Providers other than bicep are driven through the provisioning manager which ensures its own tools are installed
+	if isBicepProvider(proj.Infra) {
+		if err := tools.EnsureInstalled(ctx, bicepCli); err != nil {
+			return err
+		}
+	}
+
 	if err = proj.Initialize(ctx, &env); err != nil {
 		return err
 	}
 
+	if ica.preview {
+		return ica.previewChanges(ctx, cmd, proj, env, console, azCli)
+	}
+
+	if err := proj.RaiseEvent(ctx, project.Provisioning, nil); err != nil {
+		return err
+	}
+
+	if !isBicepProvider(proj.Infra) {
+		return ica.provision(ctx, cmd, azdCtx, proj, env, console, azCli)
+	}
+
 	const rootModule = "main"
 
 	// Copy the parameter template file to the environment working directory and do substitutions.
@@ -187,10 +228,6 @@ func (ica *infraCreateAction) Run(ctx context.Context, cmd *cobra.Command, args
 		location = selected
 	}
 
-	formatter, err := output.GetFormatter(cmd)
-	if err != nil {
-		return err
-	}
 	interactive := formatter.Kind() == output.NoneFormat
 
 	// Do the creating. The call to `DeployToSubscription` blocks until the deployment completes,
@@ -271,16 +308,23 @@ func (ica *infraCreateAction) Run(ctx context.Context, cmd *cobra.Command, args
 
 	template.CanonicalizeDeploymentOutputs(&res.Result.Properties.Outputs)
 
-	for _, svc := range proj.Services {
-		if err := svc.RaiseEvent(ctx, project.Deployed, map[string]any{"bicepOutput": res.Result.Properties.Outputs}); err != nil {
-			return err
-		}
+	// The services are deployed again to the provisioned resources, ex) resources recreated from scratch
+	if err := clearDeploymentInputs(azdCtx, env.GetEnvName()); err != nil {
+		return err
+	}
+
+	if err := raiseServicesProvisioned(ctx, proj, res.Result.Properties.Outputs); err != nil {
+		return err
 	}
 
 	if err = saveEnvironmentValues(res.Result, env); err != nil {
 		return err
 	}
 
+	if err := proj.RaiseEvent(ctx, project.Provisioned, nil); err != nil {
+		return err
+	}
+
 	if formatter.Kind() == output.JsonFormat {
 		if err = formatter.Format(res.Result, cmd.OutOrStdout(), nil); err != nil {
 			return fmt.Errorf("deployment result could not be displayed: %w", err)
@@ -290,6 +334,216 @@ func (ica *infraCreateAction) Run(ctx context.Context, cmd *cobra.Command, args
 	return nil
 }
 
+// Provisions the infrastructure of the project through the provisioning manager and the configured infra provider
+func (ica *infraCreateAction) provision(
+	ctx context.Context,
+	cmd *cobra.Command,
+	azdCtx *environment.AzdContext,
+	proj *project.ProjectConfig,
+	env environment.Environment,
+	console input.Console,
+	azCli azcli.AzCli,
+) error {
+	formatter, err := output.GetFormatter(cmd)
+	if err != nil {
+		return err
+	}
+	interactive := formatter.Kind() == output.NoneFormat
+
+	infraManager, err := provisioning.NewManager(ctx, env, proj.Path, proj.Infra, !ica.rootOptions.NoPrompt, console, bicepTool.NewBicepCliArgs{AzCli: azCli})
+	if err != nil {
+		return fmt.Errorf("creating provisioning manager: %w", err)
+	}
+
+	previewResult, err := infraManager.Preview(ctx, interactive)
+	if err != nil {
+		return fmt.Errorf("preparing infrastructure provisioning: %w", err)
+	}
+
+	deployResult, err := infraManager.Deploy(ctx, &previewResult.Preview, interactive)
+	if err != nil {
+		return fmt.Errorf("deployment failed: %w", err)
+	}
+
+	// The services are deployed again to the provisioned resources, ex) resources recreated from scratch
+	if err := clearDeploymentInputs(azdCtx, env.GetEnvName()); err != nil {
+		return err
+	}
+
+	deploymentOutputs := make(map[string]azcli.AzCliDeploymentOutput, len(deployResult.Outputs))
+	for key, param := range deployResult.Outputs {
+		deploymentOutputs[key] = azcli.AzCliDeploymentOutput{
+			Type:  param.Type,
+			Value: param.Value,
+		}
+	}
+
+	if err := raiseServicesProvisioned(ctx, proj, deploymentOutputs); err != nil {
+		return err
+	}
+
+	if err := provisioning.UpdateEnvironment(&env, &deployResult.Outputs); err != nil {
+		return err
+	}
+
+	if err := proj.RaiseEvent(ctx, project.Provisioned, nil); err != nil {
+		return err
+	}
+
+	if formatter.Kind() == output.JsonFormat {
+		if err = formatter.Format(deployResult, cmd.OutOrStdout(), nil); err != nil {
+			return fmt.Errorf("deployment result could not be displayed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// A predicted resource change as displayed within the preview table
+type resourceChangeRow struct {
+	ChangeType   provisioning.ResourceChangeType
+	ResourceType string
+	ResourceName string
+	Properties   string
+}
+
+// Previews the changes the provisioning would make to the Azure resources without deploying them
+func (ica *infraCreateAction) previewChanges(
+	ctx context.Context,
+	cmd *cobra.Command,
+	proj *project.ProjectConfig,
+	env environment.Environment,
+	console input.Console,
+	azCli azcli.AzCli,
+) error {
+	formatter, err := output.GetFormatter(cmd)
+	if err != nil {
+		return err
+	}
+	interactive := formatter.Kind() != output.JsonFormat
+
+	infraManager, err := provisioning.NewManager(ctx, env, proj.Path, proj.Infra, !ica.rootOptions.NoPrompt, console, bicepTool.NewBicepCliArgs{AzCli: azCli})
+	if err != nil {
+		return fmt.Errorf("creating provisioning manager: %w", err)
+	}
+
+	previewResult, err := infraManager.Preview(ctx, interactive)
+	if err != nil {
+		return fmt.Errorf("preparing infrastructure provisioning: %w", err)
+	}
+
+	whatIfResult, err := infraManager.WhatIf(ctx, &previewResult.Preview, interactive)
+	if err != nil {
+		return err
+	}
+
+	if formatter.Kind() == output.JsonFormat {
+		if err := formatter.Format(whatIfResult.Changes, cmd.OutOrStdout(), nil); err != nil {
+			return fmt.Errorf("preview result could not be displayed: %w", err)
+		}
+
+		return nil
+	}
+
+	// Resources which are left as is are only listed when debugging
+	rows := resourceChangeRows(whatIfResult.Changes, ica.rootOptions.EnableDebugLogging)
+	if len(rows) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No changes to Azure resources were found.")
+		return nil
+	}
+
+	// The table is also used for the default output since the changes are meant to be reviewed by a person
+	tableFormatter := &output.TableFormatter{}
+	err = tableFormatter.Format(rows, cmd.OutOrStdout(), output.TableFormatterOptions{
+		Columns: []output.Column{
+			{
+				Heading:       "Change",
+				ValueTemplate: "{{.ChangeType}}",
+			},
+			{
+				Heading:       "Resource type",
+				ValueTemplate: "{{.ResourceType}}",
+			},
+			{
+				Heading:       "Resource name",
+				ValueTemplate: "{{.ResourceName}}",
+			},
+			{
+				Heading:       "Properties",
+				ValueTemplate: "{{.Properties}}",
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("preview result could not be displayed: %w", err)
+	}
+
+	return nil
+}
+
+// Converts the predicted resource changes to the rows of the preview table, skipping the resources
+// which won't be changed unless includeUnchanged is set
+func resourceChangeRows(changes []provisioning.PreviewResourceChange, includeUnchanged bool) []resourceChangeRow {
+	rows := make([]resourceChangeRow, 0, len(changes))
+	for _, change := range changes {
+		if !includeUnchanged &&
+			(change.ChangeType == provisioning.ResourceChangeTypeNoChange ||
+				change.ChangeType == provisioning.ResourceChangeTypeIgnore) {
+			continue
+		}
+
+		rows = append(rows, resourceChangeRow{
+			ChangeType:   change.ChangeType,
+			ResourceType: change.ResourceType,
+			ResourceName: change.ResourceName,
+			Properties:   strings.Join(formatPropertyChanges(change.Delta), ", "),
+		})
+	}
+
+	return rows
+}
+
+// Formats the property changes of a resource as a flat list, ex) "~properties.sku.name: Basic => Standard"
+func formatPropertyChanges(changes []provisioning.PreviewPropertyChange) []string {
+	return formatNestedPropertyChanges("", false, changes)
+}
+
+func formatNestedPropertyChanges(parentPath string, parentIsArray bool, changes []provisioning.PreviewPropertyChange) []string {
+	var formatted []string
+
+	for _, change := range changes {
+		path := change.Path
+		if parentIsArray {
+			// Array items are identified by their index within the array
+			path = fmt.Sprintf("%s[%s]", parentPath, change.Path)
+		} else if parentPath != "" {
+			path = fmt.Sprintf("%s.%s", parentPath, change.Path)
+		}
+
+		switch change.ChangeType {
+		case provisioning.PropertyChangeTypeCreate:
+			formatted = append(formatted, fmt.Sprintf("+%s: %v", path, change.After))
+		case provisioning.PropertyChangeTypeDelete:
+			formatted = append(formatted, fmt.Sprintf("-%s", path))
+		case provisioning.PropertyChangeTypeModify:
+			if len(change.Children) > 0 {
+				formatted = append(formatted, formatNestedPropertyChanges(path, false, change.Children)...)
+			} else {
+				formatted = append(formatted, fmt.Sprintf("~%s: %v => %v", path, change.Before, change.After))
+			}
+		case provisioning.PropertyChangeTypeArray:
+			formatted = append(formatted, formatNestedPropertyChanges(path, true, change.Children)...)
+		}
+	}
+
+	return formatted
+}
+
+// Gets whether the infrastructure is provisioned with bicep, the default provider when none is configured
+func isBicepProvider(options provisioning.Options) bool {
+	return options.Provider == "" || options.Provider == provisioning.Bicep
+}
+
 type progressReport struct {
 	Timestamp  time.Time                      `json:"timestamp"`
 	Operations []azcli.AzCliResourceOperation `json:"operations"`
@@ -311,3 +565,24 @@ func reportDeploymentStatusJson(ctx context.Context, azCli azcli.AzCli, env envi
 
 	_ = formatter.Format(report, cmd.OutOrStdout(), nil)
 }
+
+// raiseServicesProvisioned raises the events of the services once the infrastructure is provisioned. The Deployed event
+// carries the outputs of the deployment, the .NET projects store them as user secrets, and the Provisioned event runs
+// the provisioned hooks of the services.
+func raiseServicesProvisioned(
+	ctx context.Context,
+	proj *project.ProjectConfig,
+	outputs map[string]azcli.AzCliDeploymentOutput,
+) error {
+	for _, svc := range proj.Services {
+		if err := svc.RaiseEvent(ctx, project.Deployed, map[string]any{project.ProvisioningOutputsEventArg: outputs}); err != nil {
+			return err
+		}
+
+		if err := svc.RaiseEvent(ctx, project.Provisioned, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
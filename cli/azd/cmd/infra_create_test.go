@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_formatPropertyChanges(t *testing.T) {
+	changes := []provisioning.PreviewPropertyChange{
+		{Path: "properties.sku", ChangeType: provisioning.PropertyChangeTypeModify, Before: "Basic", After: "Standard"},
+		{Path: "tags.env", ChangeType: provisioning.PropertyChangeTypeCreate, After: "dev"},
+		{Path: "properties.legacy", ChangeType: provisioning.PropertyChangeTypeDelete, Before: true},
+		{Path: "properties.unchanged", ChangeType: provisioning.PropertyChangeTypeNoEffect},
+		{
+			Path:       "properties.siteConfig.appSettings",
+			ChangeType: provisioning.PropertyChangeTypeArray,
+			Children: []provisioning.PreviewPropertyChange{
+				{
+					Path:       "0",
+					ChangeType: provisioning.PropertyChangeTypeModify,
+					Children: []provisioning.PreviewPropertyChange{
+						{Path: "value", ChangeType: provisioning.PropertyChangeTypeModify, Before: "a", After: "b"},
+					},
+				},
+			},
+		},
+	}
+
+	require.Equal(t, []string{
+		"~properties.sku: Basic => Standard",
+		"+tags.env: dev",
+		"-properties.legacy",
+		"~properties.siteConfig.appSettings[0].value: a => b",
+	}, formatPropertyChanges(changes))
+}
+
+func Test_resourceChangeRows(t *testing.T) {
+	changes := []provisioning.PreviewResourceChange{
+		{ChangeType: provisioning.ResourceChangeTypeCreate, ResourceType: "Microsoft.Web/sites", ResourceName: "web"},
+		{ChangeType: provisioning.ResourceChangeTypeNoChange, ResourceType: "Microsoft.Web/serverFarms", ResourceName: "plan"},
+		{ChangeType: provisioning.ResourceChangeTypeIgnore, ResourceType: "Microsoft.Storage/storageAccounts", ResourceName: "store"},
+		{
+			ChangeType:   provisioning.ResourceChangeTypeModify,
+			ResourceType: "Microsoft.KeyVault/vaults",
+			ResourceName: "vault",
+			Delta: []provisioning.PreviewPropertyChange{
+				{Path: "properties.sku", ChangeType: provisioning.PropertyChangeTypeModify, Before: "standard", After: "premium"},
+			},
+		},
+	}
+
+	names := func(rows []resourceChangeRow) []string {
+		var names []string
+		for _, row := range rows {
+			names = append(names, row.ResourceName)
+		}
+		return names
+	}
+
+	rows := resourceChangeRows(changes, false)
+	require.Equal(t, []string{"web", "vault"}, names(rows))
+	require.Equal(t, "~properties.sku: standard => premium", rows[1].Properties)
+
+	require.Equal(t, []string{"web", "plan", "store", "vault"}, names(resourceChangeRows(changes, true)))
+
+	// Nothing is listed when no resource is changed
+	require.Empty(t, resourceChangeRows(changes[1:3], false))
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/azure/azure-dev/cli/azd/pkg/environment"
 	"github.com/azure/azure-dev/cli/azd/pkg/iac/bicep"
 	"github.com/azure/azure-dev/cli/azd/pkg/infra"
+	"github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning"
 	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/project"
 	"github.com/azure/azure-dev/cli/azd/pkg/spin"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
@@ -53,7 +55,7 @@ func (a *infraDeleteAction) Run(ctx context.Context, _ *cobra.Command, args []st
 		return err
 	}
 
-	if err := tools.EnsureInstalled(ctx, azCli, bicepCli); err != nil {
+	if err := tools.EnsureInstalled(ctx, azCli); err != nil {
 		return err
 	}
 
@@ -66,6 +68,28 @@ func (a *infraDeleteAction) Run(ctx context.Context, _ *cobra.Command, args []st
 		return fmt.Errorf("loading environment: %w", err)
 	}
 
+	// The project is loaded once the environment is known, so references to environment values are replaced and hooks
+	// run with the values of the environment
+	proj, err := project.LoadProjectConfig(azdCtx.ProjectPath(), &env)
+	if err != nil {
+		return fmt.Errorf("loading project: %w", err)
+	}
+
+	// Providers other than bicep are driven through the provisioning manager which ensures its own tools are installed
+	if isBicepProvider(proj.Infra) {
+		if err := tools.EnsureInstalled(ctx, bicepCli); err != nil {
+			return err
+		}
+	}
+
+	if err := proj.RaiseEvent(ctx, project.Destroying, nil); err != nil {
+		return err
+	}
+
+	if !isBicepProvider(proj.Infra) {
+		return a.destroy(ctx, azdCtx, proj, env, console, azCli)
+	}
+
 	const rootModule = "main"
 
 	bicepPath := azdCtx.BicepModulePath(rootModule)
@@ -185,6 +209,11 @@ func (a *infraDeleteAction) Run(ctx context.Context, _ *cobra.Command, args []st
 		return fmt.Errorf("destroying: %w", err)
 	}
 
+	// The services are deployed again once the infrastructure is provisioned again
+	if err := clearDeploymentInputs(azdCtx, env.GetEnvName()); err != nil {
+		return err
+	}
+
 	// Remove any outputs from the template from the environment since destroying the infrastructure
 	// invalidated them all.
 	for outputName := range template.Outputs {
@@ -195,5 +224,40 @@ func (a *infraDeleteAction) Run(ctx context.Context, _ *cobra.Command, args []st
 		return fmt.Errorf("saving environment: %w", err)
 	}
 
-	return nil
+	return proj.RaiseEvent(ctx, project.Destroyed, nil)
+}
+
+// Destroys the infrastructure of the project through the provisioning manager and the configured infra provider
+func (a *infraDeleteAction) destroy(
+	ctx context.Context,
+	azdCtx *environment.AzdContext,
+	proj *project.ProjectConfig,
+	env environment.Environment,
+	console input.Console,
+	azCli azcli.AzCli,
+) error {
+	infraOptions := proj.Infra
+	infraOptions.Force = a.forceDelete
+
+	infraManager, err := provisioning.NewManager(ctx, env, proj.Path, infraOptions, !a.rootOptions.NoPrompt, console, bicepTool.NewBicepCliArgs{AzCli: azCli})
+	if err != nil {
+		return fmt.Errorf("creating provisioning manager: %w", err)
+	}
+
+	previewResult, err := infraManager.Preview(ctx, true)
+	if err != nil {
+		return fmt.Errorf("preparing infrastructure destroy: %w", err)
+	}
+
+	// The manager removes the outputs of the destroyed infrastructure from the environment
+	if _, err := infraManager.Destroy(ctx, &previewResult.Preview, true); err != nil {
+		return fmt.Errorf("destroying: %w", err)
+	}
+
+	// The services are deployed again once the infrastructure is provisioned again
+	if err := clearDeploymentInputs(azdCtx, env.GetEnvName()); err != nil {
+		return err
+	}
+
+	return proj.RaiseEvent(ctx, project.Destroyed, nil)
 }
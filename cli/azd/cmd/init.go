@@ -40,7 +40,11 @@ func initCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
 
 When no template is supplied, you can optionally select an Azure Developer CLI template for cloning. Otherwise, `+withBackticks("azd init")+` initializes the current directory and creates resources so that your project is compatible with Azure Developer CLI.
 
-When a template is provided, the sample code is cloned to the current directory.`,
+When a template is provided, the sample code is cloned to the current directory.
+
+Templates may declare parameters in an `+withBackticks(templates.ManifestFileName)+` file at their root. You are prompted for the value of each
+parameter, or the values can be given with `+withBackticks("--param <name>=<value>")+`. The `+withBackticks("{{<name>}}")+` placeholders in the
+contents and the names of the files of the template are replaced by the values before the files are copied.`,
 	)
 	return cmd
 }
@@ -48,6 +52,7 @@ When a template is provided, the sample code is cloned to the current directory.
 type initAction struct {
 	template       templates.Template
 	templateBranch string
+	templateParams []string
 	subscription   string
 	location       string
 	rootOptions    *commands.GlobalCommandOptions
@@ -57,8 +62,9 @@ func (i *initAction) SetupFlags(
 	persis *pflag.FlagSet,
 	local *pflag.FlagSet,
 ) {
-	local.StringVarP(&i.template.Name, "template", "t", "", "The template to use when you initialize the project. You can use Full URI, <owner>/<repository>, or <repository> if it's part of the azure-samples organization.")
+	local.StringVarP(&i.template.Name, "template", "t", "", "The template to use when you initialize the project. You can use Full URI, <owner>/<repository>, or <repository> if it's part of the azure-samples organization. A <repository> matching the name of a template of a template source uses that template.")
 	local.StringVarP(&i.templateBranch, "branch", "b", "", "The template branch to initialize from.")
+	local.StringArrayVar(&i.templateParams, "param", nil, "A template parameter value, as <name>=<value>. Can be repeated.")
 	local.StringVar(&i.subscription, "subscription", "", "Name or ID of an Azure subscription to use for the new environment")
 	local.StringVarP(&i.location, "location", "l", "", "Azure location for the new environment")
 }
@@ -80,6 +86,11 @@ func (i *initAction) Run(ctx context.Context, _ *cobra.Command, args []string, a
 		return errors.New("template name required when specifying a branch name")
 	}
 
+	templateParamValues, err := templates.ParseParameterValues(i.templateParams)
+	if err != nil {
+		return err
+	}
+
 	console := input.NewConsole(!i.rootOptions.NoPrompt)
 	azCli := commands.GetAzCliFromContext(ctx)
 	gitCli := git.NewGitCli()
@@ -108,12 +119,25 @@ func (i *initAction) Run(ctx context.Context, _ *cobra.Command, args []string, a
 		}
 	}
 
+	if i.template.Name == "" && len(templateParamValues) > 0 {
+		return errors.New("template parameters can only be given when initializing from a template")
+	}
+
 	if i.template.Name != "" {
 		var templateUrl string
 
-		if i.template.RepositoryPath == "" {
-			// using template name directly from command line
+		if i.template.RepositoryPath == "" && isTemplateRepository(i.template.Name) {
+			// URLs and <owner>/<repository> references are used as is, even when a template source lists a template
+			// with the same name
 			i.template.RepositoryPath = i.template.Name
+		} else if i.template.RepositoryPath == "" {
+			// using template name directly from command line, which may be the name of a template of a template source
+			if matchingTemplate, err := templates.NewTemplateManager().GetTemplate(ctx, i.template.Name); err == nil {
+				i.template = matchingTemplate
+			} else {
+				log.Printf("template '%s' is not listed by the template sources, using it as a repository path: %v", i.template.Name, err)
+				i.template.RepositoryPath = i.template.Name
+			}
 		}
 
 		// treat names that start with http or git as full URLs and don't change them
@@ -152,6 +176,10 @@ func (i *initAction) Run(ctx context.Context, _ *cobra.Command, args []string, a
 			return fmt.Errorf("fetching template: %w", err)
 		}
 
+		if err := i.renderTemplate(ctx, console, templateStagingDir, templateParamValues); err != nil {
+			return err
+		}
+
 		log.Printf("template init, checking for duplicates. source: %s target: %s", templateStagingDir, azdCtx.ProjectDirectory())
 
 		// If there are any existing files in the destination that would be overwritten by files from the
@@ -285,3 +313,79 @@ const (
 	// just set to 'true'.
 	RemoteContainersEnvVarName = "REMOTE_CONTAINERS"
 )
+
+// renderTemplate replaces the placeholders of the parameters declared by the template with their values, given by
+// the --param flags or prompted for, and removes the manifest of the template
+// isTemplateRepository returns true when the template given on the command line references a repository explicitly,
+// with a URL or as <owner>/<repository>, rather than by the name of a template
+func isTemplateRepository(template string) bool {
+	return strings.Contains(template, "/") || strings.Contains(template, ":")
+}
+
+func (i *initAction) renderTemplate(ctx context.Context, console input.Console, templateDir string, givenValues map[string]string) error {
+	manifest, err := templates.LoadManifest(templateDir)
+	if err != nil {
+		return err
+	}
+
+	if manifest == nil {
+		if len(givenValues) > 0 {
+			return errors.New("template parameters were given but the template does not declare any parameter")
+		}
+
+		return nil
+	}
+
+	if err := manifest.CheckValues(givenValues); err != nil {
+		return err
+	}
+
+	values := map[string]string{}
+	for _, parameter := range manifest.Parameters {
+		if value, has := givenValues[parameter.Name]; has {
+			if err := parameter.Validate(value); err != nil {
+				return err
+			}
+
+			values[parameter.Name] = value
+			continue
+		}
+
+		message := fmt.Sprintf("Please enter a value for the '%s' template parameter:", parameter.Name)
+		if parameter.Description != "" {
+			message = fmt.Sprintf("%s (%s):", parameter.Description, parameter.Name)
+		}
+
+		for {
+			value, err := console.Prompt(ctx, input.ConsoleOptions{
+				Message:      message,
+				DefaultValue: parameter.Default,
+			})
+			if err != nil {
+				return fmt.Errorf("prompting for template parameter '%s': %w", parameter.Name, err)
+			}
+
+			err = parameter.Validate(value)
+			if err == nil {
+				values[parameter.Name] = value
+				break
+			}
+
+			if i.rootOptions.NoPrompt {
+				return err
+			}
+
+			fmt.Printf("error: %v\n", err)
+		}
+	}
+
+	if err := templates.Render(templateDir, values); err != nil {
+		return err
+	}
+
+	if err := os.Remove(filepath.Join(templateDir, templates.ManifestFileName)); err != nil {
+		return fmt.Errorf("removing template manifest: %w", err)
+	}
+
+	return nil
+}
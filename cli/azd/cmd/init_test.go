@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/commands"
+	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/templates"
+	"github.com/azure/azure-dev/cli/azd/test/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+const testTemplateManifest = `
+parameters:
+  - name: appName
+    description: The name of the application
+    validation: ^[a-z]+$
+  - name: port
+    default: "3000"
+`
+
+func newTestTemplate(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, templates.ManifestFileName), []byte(testTemplateManifest), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "{{appName}}.txt"), []byte("{{appName}}:{{port}}"), 0600))
+	return dir
+}
+
+func Test_initAction_renderTemplate(t *testing.T) {
+	t.Run("Prompt", func(t *testing.T) {
+		dir := newTestTemplate(t)
+		console := mocks.NewMockConsole()
+		console.WhenPrompt(func(options input.ConsoleOptions) bool {
+			return strings.Contains(options.Message, "(appName)")
+		}).Respond("todo")
+		console.WhenPrompt(func(options input.ConsoleOptions) bool {
+			return strings.Contains(options.Message, "'port'")
+		}).Respond("8080")
+
+		action := &initAction{rootOptions: &commands.GlobalCommandOptions{}}
+		require.NoError(t, action.renderTemplate(context.Background(), console, dir, map[string]string{}))
+
+		contents, err := os.ReadFile(filepath.Join(dir, "todo.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "todo:8080", string(contents))
+
+		_, err = os.Stat(filepath.Join(dir, templates.ManifestFileName))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Params", func(t *testing.T) {
+		dir := newTestTemplate(t)
+		console := mocks.NewMockConsole()
+		console.WhenPrompt(func(options input.ConsoleOptions) bool { return true }).Respond("3000")
+
+		action := &initAction{rootOptions: &commands.GlobalCommandOptions{NoPrompt: true}}
+		require.NoError(t, action.renderTemplate(context.Background(), console, dir, map[string]string{"appName": "web"}))
+
+		contents, err := os.ReadFile(filepath.Join(dir, "web.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "web:3000", string(contents))
+	})
+
+	t.Run("InvalidParam", func(t *testing.T) {
+		action := &initAction{rootOptions: &commands.GlobalCommandOptions{NoPrompt: true}}
+
+		err := action.renderTemplate(context.Background(), mocks.NewMockConsole(), newTestTemplate(t), map[string]string{"appName": "Web"})
+		require.Error(t, err)
+
+		err = action.renderTemplate(context.Background(), mocks.NewMockConsole(), newTestTemplate(t), map[string]string{"other": "value"})
+		require.Error(t, err)
+
+		err = action.renderTemplate(context.Background(), mocks.NewMockConsole(), t.TempDir(), map[string]string{"appName": "web"})
+		require.Error(t, err)
+	})
+}
+
+func Test_isTemplateRepository(t *testing.T) {
+	for _, template := range []string{
+		"contoso/todo-nodejs-mongo",
+		"https://github.com/contoso/todo-nodejs-mongo",
+		"git@github.com:contoso/todo-nodejs-mongo.git",
+	} {
+		require.True(t, isTemplateRepository(template), template)
+	}
+
+	for _, template := range []string{"todo-nodejs-mongo", "github-actions-starter"} {
+		require.False(t, isTemplateRepository(template), template)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/azure/azure-dev/cli/azd/pkg/auth"
 	"github.com/azure/azure-dev/cli/azd/pkg/commands"
 	"github.com/azure/azure-dev/cli/azd/pkg/environment"
 	"github.com/azure/azure-dev/cli/azd/pkg/output"
@@ -27,7 +28,19 @@ func loginCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
 		rootOptions,
 		"login",
 		"Log in to Azure.",
-		"",
+		`Log in to Azure.
+
+By default, azd uses the account signed in to the Azure CLI. When the built-in authentication is enabled with
+`+withBackticks("azd config set auth.mode builtin")+`, azd signs in users with a device code and authenticates with the
+first available of:
+
+	• The service principal set by AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET or AZURE_CLIENT_CERTIFICATE_PATH.
+	• The workload identity set by AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_FEDERATED_TOKEN_FILE.
+	• The managed identity set by IDENTITY_ENDPOINT and IDENTITY_HEADER.
+	• The user signed in with `+withBackticks("azd login")+`.
+
+The built-in authentication does not require the Azure CLI: Bicep modules are compiled with the Bicep CLI, and only
+`+withBackticks("azd pipeline config")+` still needs the Azure CLI to create service principals.`,
 	)
 
 	return output.AddOutputParam(
@@ -52,8 +65,10 @@ func (la *loginAction) Run(ctx context.Context, cmd *cobra.Command, args []strin
 	}
 
 	azCli := commands.GetAzCliFromContext(ctx)
-	if err := tools.EnsureInstalled(ctx, azCli); err != nil {
-		return err
+	if !auth.IsBuiltInEnabled() {
+		if err := tools.EnsureInstalled(ctx, azCli); err != nil {
+			return err
+		}
 	}
 
 	if !la.onlyCheckStatus {
@@ -63,7 +78,7 @@ func (la *loginAction) Run(ctx context.Context, cmd *cobra.Command, args []strin
 	}
 
 	token, err := azCli.GetAccessToken(ctx)
-	if errors.Is(err, azcli.ErrAzCliNotLoggedIn) {
+	if errors.Is(err, azcli.ErrAzCliNotLoggedIn) || errors.Is(err, auth.ErrCredentialUnavailable) {
 		return azcli.ErrAzCliNotLoggedIn
 	} else if err != nil {
 		return fmt.Errorf("checking auth status: %w", err)
@@ -94,7 +109,9 @@ func (la *loginAction) SetupFlags(persistent *pflag.FlagSet, local *pflag.FlagSe
 func ensureLoggedIn(ctx context.Context) error {
 	azCli := commands.GetAzCliFromContext(ctx)
 	_, err := azCli.GetAccessToken(ctx)
-	if errors.Is(err, azcli.ErrAzCliNotLoggedIn) || errors.Is(err, azcli.ErrAzCliRefreshTokenExpired) {
+	if errors.Is(err, azcli.ErrAzCliNotLoggedIn) ||
+		errors.Is(err, azcli.ErrAzCliRefreshTokenExpired) ||
+		errors.Is(err, auth.ErrCredentialUnavailable) {
 		if err := runLogin(ctx, false); err != nil {
 			return fmt.Errorf("logging in: %w", err)
 		}
@@ -107,7 +124,18 @@ func ensureLoggedIn(ctx context.Context) error {
 
 // runLogin runs an interactive login. When running in a Codespace or Remote Container, a device code based is
 // preformed since the default browser login needs UI. A device code login can be forced with `forceDeviceCode`.
+// With the built-in authentication, users always sign in with a device code.
 func runLogin(ctx context.Context, forceDeviceCode bool) error {
+	if auth.IsBuiltInEnabled() {
+		cache, err := auth.NewUserTokenCache()
+		if err != nil {
+			return err
+		}
+
+		credential := auth.NewUserCredential(auth.CredentialOptions{Cache: cache})
+		return credential.Login(ctx, auth.ArmScope, os.Stdout)
+	}
+
 	azCli := commands.GetAzCliFromContext(ctx)
 	useDeviceCode := forceDeviceCode || os.Getenv(CodespacesEnvVarName) == "true" || os.Getenv(RemoteContainersEnvVarName) == "true"
 
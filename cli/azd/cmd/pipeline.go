@@ -5,17 +5,19 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/azure/azure-dev/cli/azd/pkg/commands"
 	"github.com/azure/azure-dev/cli/azd/pkg/environment"
-	"github.com/azure/azure-dev/cli/azd/pkg/github"
 	"github.com/azure/azure-dev/cli/azd/pkg/input"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/git"
 	githubTool "github.com/azure/azure-dev/cli/azd/pkg/tools/github"
 	"github.com/spf13/cobra"
@@ -25,11 +27,14 @@ import (
 func pipelineCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "pipeline",
-		Short: "Manage GitHub Actions pipelines.",
-		Long: `Manage GitHub Actions pipelines.
+		Short: "Manage GitHub Actions, Azure Pipelines and GitLab CI/CD pipelines.",
+		Long: `Manage GitHub Actions, Azure Pipelines and GitLab CI/CD pipelines.
 
 The Azure Developer CLI template includes a GitHub Actions pipeline configuration file (in the *.github/workflows* folder) that deploys your application whenever code is pushed to the main branch.
 
+Templates may also include an Azure Pipelines configuration file (*azure-pipelines.yml*), used when the repository is hosted in Azure Repos.
+For projects hosted on GitLab, a GitLab CI/CD configuration file (*.gitlab-ci.yml*) is created when missing.
+
 For more information, go to https://aka.ms/azure-dev/pipeline.`,
 	}
 	cmd.Flags().BoolP("help", "h", false, fmt.Sprintf("Gets help for %s.", cmd.Name()))
@@ -42,8 +47,26 @@ func pipelineConfigCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Comman
 		&pipelineConfigAction{rootOptions: rootOptions},
 		rootOptions,
 		"config",
-		"Create and configure your deployment pipeline by using GitHub Actions.",
-		`Create and configure your deployment pipeline by using GitHub Actions.
+		"Create and configure your deployment pipeline by using GitHub Actions, Azure Pipelines or GitLab CI/CD.",
+		`Create and configure your deployment pipeline by using GitHub Actions, Azure Pipelines or GitLab CI/CD.
+
+The pipeline provider is detected from the URL of the git remote: remotes hosted in Azure Repos (dev.azure.com) are
+configured to use Azure Pipelines, remotes hosted on GitLab (gitlab.com, or the host set in `+withBackticks("GITLAB_HOST")+`) are configured
+to use GitLab CI/CD, other remotes are configured to use GitHub Actions. Use `+withBackticks("--provider")+` to choose
+the provider explicitly.
+
+By default, the pipeline authenticates with the client secret of the service principal. With `+withBackticks("--auth-type federated")+`,
+GitHub Actions workflows authenticate with OpenID Connect instead: federated identity credentials are created for the branch,
+the pull requests and the environment of the repository, and only `+withBackticks("AZURE_CLIENT_ID")+`, `+withBackticks("AZURE_TENANT_ID")+` and
+`+withBackticks("AZURE_SUBSCRIPTION_ID")+` are set as repository variables.
+
+For Azure Pipelines, `+withBackticks("AZURE_DEVOPS_EXT_PAT")+` must be set to a personal access token. The command creates the
+`+withBackticks("azconnection")+` service connection, the `+withBackticks("azd")+` variable group holding the values of the environment,
+and registers `+withBackticks("azure-pipelines.yml")+` as a pipeline.
+
+For GitLab CI/CD, `+withBackticks("GITLAB_TOKEN")+` must be set to an access token with the api scope. The command creates masked
+CI/CD variables holding the values of the environment and the credentials of the service principal, and writes
+`+withBackticks(".gitlab-ci.yml")+` running `+withBackticks("azd provision")+` and `+withBackticks("azd deploy")+`.
 
 For more information, go to https://aka.ms/azure-dev/pipeline.`,
 	)
@@ -54,6 +77,8 @@ type pipelineConfigAction struct {
 	pipelineServicePrincipalName string
 	pipelineRemoteName           string
 	pipelineRoleName             string
+	pipelineProvider             string
+	pipelineAuthType             string
 	rootOptions                  *commands.GlobalCommandOptions
 }
 
@@ -64,6 +89,18 @@ func (p *pipelineConfigAction) SetupFlags(
 	local.StringVar(&p.pipelineServicePrincipalName, "principal-name", "", "The name of the service principal to use to grant access to Azure resources as part of the pipeline.")
 	local.StringVar(&p.pipelineRemoteName, "remote-name", "origin", "The name of the git remote to configure the pipeline to run on.")
 	local.StringVar(&p.pipelineRoleName, "principal-role", "Contributor", "The role to assign to the service principal.")
+	local.StringVar(
+		&p.pipelineProvider,
+		"provider",
+		"",
+		"The pipeline provider to use: 'github' for GitHub Actions, 'azdo' for Azure Pipelines or 'gitlab' for GitLab CI/CD. Detected from the git remote when not set.",
+	)
+	local.StringVar(
+		&p.pipelineAuthType,
+		"auth-type",
+		string(clientCredentialsAuthType),
+		"How the pipeline authenticates with Azure: 'client-credentials' uses a client secret, 'federated' uses OpenID Connect federated credentials.",
+	)
 }
 
 func (p *pipelineConfigAction) Run(ctx context.Context, _ *cobra.Command, args []string, azdCtx *environment.AzdContext) error {
@@ -79,14 +116,15 @@ func (p *pipelineConfigAction) Run(ctx context.Context, _ *cobra.Command, args [
 		return fmt.Errorf("loading environment: %w", err)
 	}
 
+	gitCli := git.NewGitCli()
+
+	provider, err := newPipelineProvider(ctx, p.pipelineProvider, gitCli, azdCtx, p.pipelineRemoteName, console)
 	if err != nil {
-		return fmt.Errorf("loading environment: %w", err)
+		return err
 	}
 
-	gitCli := git.NewGitCli()
-	ghCli := githubTool.NewGitHubCli()
-
-	if err := tools.EnsureInstalled(ctx, azCli, gitCli, ghCli); err != nil {
+	requiredTools := append([]tools.ExternalTool{azCli, gitCli}, provider.RequiredTools()...)
+	if err := tools.EnsureInstalled(ctx, requiredTools...); err != nil {
 		return err
 	}
 
@@ -94,113 +132,13 @@ func (p *pipelineConfigAction) Run(ctx context.Context, _ *cobra.Command, args [
 		return fmt.Errorf("failed to ensure login: %w", err)
 	}
 
-	if err := ensureGitHubLogin(ctx, ghCli, githubTool.GitHubHostName, console); err != nil {
-		return fmt.Errorf("failed to ensure login to GitHub: %w", err)
+	if err := provider.EnsureLoggedIn(ctx); err != nil {
+		return fmt.Errorf("failed to ensure login to %s: %w", provider.HostName(), err)
 	}
 
-	// This flag is used later to skip checking GitHub Actions.
-	// For new repositories, there's no need to check
-	newGitHubRepoCreated := false
-
-	getSlugOrInit := func() (string, error) {
-		for {
-			repoSlug, err := github.EnsureRemote(ctx, azdCtx.ProjectDirectory(), p.pipelineRemoteName, gitCli)
-			switch {
-			case errors.Is(err, git.ErrNotRepository):
-				// Offer the user a chance to init a new repository if one does not exist.
-				initRepo, err := console.Confirm(ctx, input.ConsoleOptions{
-					Message:      "Initialize a new git repository?",
-					DefaultValue: true,
-				})
-				if err != nil {
-					return "", fmt.Errorf("prompting for git init: %w", err)
-				}
-
-				if !initRepo {
-					return "", errors.New("confirmation declined")
-				}
-
-				if err := gitCli.InitRepo(ctx, azdCtx.ProjectDirectory()); err != nil {
-					return "", fmt.Errorf("initializing repository: %w", err)
-				}
-
-				// Recovered from this error, try again
-				continue
-			case errors.Is(err, git.ErrNoSuchRemote):
-				// Offer the user a chance to create the remote if one does not exist.
-				addRemote, err := console.Confirm(ctx, input.ConsoleOptions{
-					Message:      fmt.Sprintf("A remote named \"%s\" was not found. Would you like to configure one?", p.pipelineRemoteName),
-					DefaultValue: true,
-				})
-				if err != nil {
-					return "", fmt.Errorf("prompting for remote init: %w", err)
-				}
-
-				if !addRemote {
-					return "", errors.New("confirmation declined")
-				}
-
-				// There are a few ways to configure the remote so offer a choice to the user.
-				idx, err := console.Select(ctx, input.ConsoleOptions{
-					Message: "How would you like to configure your remote?",
-					Options: []string{
-						"Select an existing GitHub project",
-						"Create a new private GitHub repository",
-						"Enter a remote URL directly",
-					},
-					DefaultValue: "Create a new private GitHub repository",
-				})
-
-				if err != nil {
-					return "", fmt.Errorf("prompting for remote configuration type: %w", err)
-				}
-
-				var remoteUrl string
-
-				switch idx {
-				// Select from an existing GitHub project
-				case 0:
-					url, err := getRemoteUrlFromExisting(ctx, ghCli, console)
-					if err != nil {
-						return "", fmt.Errorf("getting remote from existing repository: %w", err)
-					}
-					remoteUrl = url
-				// Create a new project
-				case 1:
-					url, err := getRemoteUrlFromNewRepository(ctx, ghCli, azdCtx, console)
-					if err != nil {
-						return "", fmt.Errorf("getting remote from new repository: %w", err)
-					}
-					newGitHubRepoCreated = true
-					remoteUrl = url
-				// Enter a URL directly.
-				case 2:
-					url, err := p.getRemoteUrlFromPrompt(ctx, console)
-					if err != nil {
-						return "", fmt.Errorf("getting remote from prompt: %w", err)
-					}
-					remoteUrl = url
-				default:
-					panic(fmt.Sprintf("unexpected selection index %d", idx))
-				}
-
-				if err := gitCli.AddRemote(ctx, azdCtx.ProjectDirectory(), p.pipelineRemoteName, remoteUrl); err != nil {
-					return "", fmt.Errorf("initializing repository: %w", err)
-				}
-
-				// Recovered from this error, try again
-				continue
-			case err != nil:
-				return "", err
-			default:
-				return repoSlug, nil
-			}
-		}
-	}
-
-	repoSlug, err := getSlugOrInit()
+	repository, err := p.ensureRemote(ctx, provider, gitCli, azdCtx, console)
 	if err != nil {
-		return fmt.Errorf("ensuring github remote: %w", err)
+		return fmt.Errorf("ensuring %s remote: %w", strings.ToLower(provider.HostName()), err)
 	}
 
 	currentBranch, err := gitCli.GetCurrentBranch(ctx, azdCtx.ProjectDirectory())
@@ -214,38 +152,56 @@ func (p *pipelineConfigAction) Run(ctx context.Context, _ *cobra.Command, args [
 		p.pipelineServicePrincipalName = fmt.Sprintf("az-dev-%s", time.Now().UTC().Format("01-02-2006-15-04-05"))
 	}
 
-	fmt.Printf("Creating or updating service principal %s.\n", p.pipelineServicePrincipalName)
-
-	credentials, err := azCli.CreateOrUpdateServicePrincipal(ctx, env.GetSubscriptionId(), p.pipelineServicePrincipalName, p.pipelineRoleName)
-	if err != nil {
-		return fmt.Errorf("failed to create or update service principal: %w", err)
+	authType := pipelineAuthType(p.pipelineAuthType)
+	if authType != clientCredentialsAuthType && authType != federatedAuthType {
+		return fmt.Errorf(
+			"unknown auth type '%s', supported auth types are '%s' and '%s'",
+			p.pipelineAuthType,
+			clientCredentialsAuthType,
+			federatedAuthType,
+		)
 	}
 
-	fmt.Printf("Configuring repository %s to use credentials for %s.\n", repoSlug, p.pipelineServicePrincipalName)
+	fmt.Printf("Creating or updating service principal %s.\n", p.pipelineServicePrincipalName)
 
-	fmt.Printf("Setting AZURE_CREDENTIALS GitHub repo secret.\n")
+	var credentials azcli.AzureCredentials
+	if authType == federatedAuthType {
+		federatedCredentials, err := provider.FederatedCredentials(repository, currentBranch, &env)
+		if err != nil {
+			return err
+		}
 
-	if err := ghCli.SetSecret(ctx, repoSlug, "AZURE_CREDENTIALS", string(credentials)); err != nil {
-		return fmt.Errorf("failed setting AZURE_CREDENTIALS secret: %w", err)
-	}
+		credentials, err = azCli.CreateOrUpdateServicePrincipalWithoutSecret(ctx, env.GetSubscriptionId(), p.pipelineServicePrincipalName, p.pipelineRoleName)
+		if err != nil {
+			return fmt.Errorf("failed to create or update service principal: %w", err)
+		}
 
-	fmt.Printf("Configuring repository environment.\n")
+		for _, federatedCredential := range federatedCredentials {
+			fmt.Printf("Creating or updating federated credential %s for %s.\n", federatedCredential.Name, federatedCredential.Subject)
 
-	for _, envName := range []string{environment.EnvNameEnvVarName, environment.LocationEnvVarName, environment.SubscriptionIdEnvVarName} {
-		fmt.Printf("Setting %s GitHub repo secret.\n", envName)
+			if err := azCli.CreateOrUpdateFederatedCredential(ctx, credentials.ClientId, federatedCredential); err != nil {
+				return fmt.Errorf("failed to create or update federated credential: %w", err)
+			}
+		}
+	} else {
+		credentialsJson, err := azCli.CreateOrUpdateServicePrincipal(ctx, env.GetSubscriptionId(), p.pipelineServicePrincipalName, p.pipelineRoleName)
+		if err != nil {
+			return fmt.Errorf("failed to create or update service principal: %w", err)
+		}
 
-		if err := ghCli.SetSecret(ctx, repoSlug, envName, env.Values[envName]); err != nil {
-			return fmt.Errorf("failed setting %s secret: %w", envName, err)
+		if err := json.Unmarshal(credentialsJson, &credentials); err != nil {
+			return fmt.Errorf("could not unmarshal service principal credentials: %w", err)
 		}
 	}
 
-	fmt.Println()
-	fmt.Printf(`GitHub Action secrets are now configured. See your .github/workflows folder for details on which actions will be enabled.
-You can view the GitHub Actions here: https://github.com/%s/actions
-`, repoSlug)
+	fmt.Printf("Configuring repository %s to use credentials for %s.\n", repository.Slug, p.pipelineServicePrincipalName)
+
+	if err := provider.ConfigurePipeline(ctx, azdCtx, repository, &env, authType, credentials); err != nil {
+		return err
+	}
 
 	doPush, err := console.Confirm(ctx, input.ConsoleOptions{
-		Message:      "Would you like to commit and push your local changes to start a new GitHub Actions run?",
+		Message:      fmt.Sprintf("Would you like to commit and push your local changes to start a new %s run?", provider.Name()),
 		DefaultValue: true,
 	})
 
@@ -253,15 +209,10 @@ You can view the GitHub Actions here: https://github.com/%s/actions
 		return fmt.Errorf("prompting to push: %w", err)
 	}
 
-	// Check if GitHub actions are disabled *Only* when user requested to push changes AND this is NOT a just-created repo
-	//
-	// A repo that is just created would return zero GitHub Actions and might be confused by azd
-	// as a repo where Actions are disabled. Sadly, there's not GitHub API to fetch exact information
-	// to distinguish between disabled-after-fork v/s repo-disabled-actions v/s similar scenarios).
-	if doPush && !newGitHubRepoCreated {
-		cancelPushing, err := notifyWhenGitHubActionsAreDisabled(ctx, gitCli, ghCli, azdCtx, repoSlug, p.pipelineRemoteName, currentBranch, console)
+	if doPush {
+		cancelPushing, err := provider.BeforePush(ctx, gitCli, azdCtx, repository, p.pipelineRemoteName, currentBranch)
 		if err != nil {
-			return fmt.Errorf("ensure github actions: %w", err)
+			return err
 		}
 		// Abort doing push on user request
 		doPush = !cancelPushing
@@ -272,7 +223,7 @@ You can view the GitHub Actions here: https://github.com/%s/actions
 			return fmt.Errorf("adding files: %w", err)
 		}
 
-		if err := gitCli.Commit(ctx, azdCtx.ProjectDirectory(), "Configure GitHub Actions"); err != nil {
+		if err := gitCli.Commit(ctx, azdCtx.ProjectDirectory(), fmt.Sprintf("Configure %s", provider.Name())); err != nil {
 			return fmt.Errorf("commit changes: %w", err)
 		}
 
@@ -282,37 +233,86 @@ You can view the GitHub Actions here: https://github.com/%s/actions
 			return fmt.Errorf("pushing changes: %w", err)
 		}
 	} else {
-		fmt.Printf("To fully enable GitHub Actions you need to push this repo to GitHub using 'git push --set-upstream %s %s'.\n", p.pipelineRemoteName, currentBranch)
+		fmt.Printf(
+			"To fully enable %s you need to push this repo to %s using 'git push --set-upstream %s %s'.\n",
+			provider.Name(),
+			provider.HostName(),
+			p.pipelineRemoteName,
+			currentBranch,
+		)
 	}
 
 	return nil
 }
 
-// getRemoteUrlFromPrompt interactively prompts the user for a URL for a GitHub repository. It validates
-// that the URL is well formed and is in the correct format for a GitHub repository.
-func (p *pipelineConfigAction) getRemoteUrlFromPrompt(ctx context.Context, console input.Console) (string, error) {
-	remoteUrl := ""
+// ensureRemote ensures the project is a git repository with a remote hosted by the provider,
+// offering to initialize the repository and to configure the remote when they do not exist.
+func (p *pipelineConfigAction) ensureRemote(
+	ctx context.Context,
+	provider pipelineProvider,
+	gitCli git.GitCli,
+	azdCtx *environment.AzdContext,
+	console input.Console,
+) (pipelineRepository, error) {
+	for {
+		remoteUrl, err := gitCli.GetRemoteUrl(ctx, azdCtx.ProjectDirectory(), p.pipelineRemoteName)
+		switch {
+		case errors.Is(err, git.ErrNotRepository):
+			// Offer the user a chance to init a new repository if one does not exist.
+			initRepo, err := console.Confirm(ctx, input.ConsoleOptions{
+				Message:      "Initialize a new git repository?",
+				DefaultValue: true,
+			})
+			if err != nil {
+				return pipelineRepository{}, fmt.Errorf("prompting for git init: %w", err)
+			}
+
+			if !initRepo {
+				return pipelineRepository{}, errors.New("confirmation declined")
+			}
 
-	for remoteUrl == "" {
-		promptValue, err := console.Prompt(ctx, input.ConsoleOptions{
-			Message: fmt.Sprintf("Please enter the url to use for remote %s:", p.pipelineRemoteName),
-		})
+			if err := gitCli.InitRepo(ctx, azdCtx.ProjectDirectory()); err != nil {
+				return pipelineRepository{}, fmt.Errorf("initializing repository: %w", err)
+			}
 
-		if err != nil {
-			return "", fmt.Errorf("prompting for remote url: %w", err)
-		}
+			// Recovered from this error, try again
+			continue
+		case errors.Is(err, git.ErrNoSuchRemote):
+			// Offer the user a chance to create the remote if one does not exist.
+			addRemote, err := console.Confirm(ctx, input.ConsoleOptions{
+				Message:      fmt.Sprintf("A remote named \"%s\" was not found. Would you like to configure one?", p.pipelineRemoteName),
+				DefaultValue: true,
+			})
+			if err != nil {
+				return pipelineRepository{}, fmt.Errorf("prompting for remote init: %w", err)
+			}
 
-		remoteUrl = promptValue
+			if !addRemote {
+				return pipelineRepository{}, errors.New("confirmation declined")
+			}
 
-		if _, err := github.GetSlugForRemote(remoteUrl); errors.Is(err, github.ErrRemoteHostIsNotGitHub) {
-			fmt.Printf("error: \"%s\" is not a valid GitHub URL.\n", remoteUrl)
+			remoteUrl, err := provider.ConfigureRemote(ctx, azdCtx, p.pipelineRemoteName)
+			if err != nil {
+				return pipelineRepository{}, err
+			}
 
-			// So we retry from the loop.
-			remoteUrl = ""
+			if err := gitCli.AddRemote(ctx, azdCtx.ProjectDirectory(), p.pipelineRemoteName, remoteUrl); err != nil {
+				return pipelineRepository{}, fmt.Errorf("initializing repository: %w", err)
+			}
+
+			// Recovered from this error, try again
+			continue
+		case err != nil:
+			return pipelineRepository{}, fmt.Errorf("failed to get remote url: %w", err)
 		}
-	}
 
-	return remoteUrl, nil
+		slug, err := provider.RepositorySlug(remoteUrl)
+		if err != nil {
+			return pipelineRepository{}, fmt.Errorf("remote `%s` is not a %s repository: %w", p.pipelineRemoteName, provider.HostName(), err)
+		}
+
+		return pipelineRepository{RemoteUrl: remoteUrl, Slug: slug}, nil
+	}
 }
 
 func getRemoteUrlFromNewRepository(ctx context.Context, ghCli githubTool.GitHubCli, azdCtx *environment.AzdContext, console input.Console) (string, error) {
@@ -0,0 +1,193 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdo"
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/git"
+)
+
+const (
+	// The pipeline definition registered in Azure Pipelines, relative to the root of the repository
+	azdoPipelineFileName = "azure-pipelines.yml"
+	// The Azure Resource Manager service connection used by the pipeline to deploy the application
+	azdoServiceConnectionName = "azconnection"
+	// The variable group holding the values of the environment used by the pipeline
+	azdoVariableGroupName = "azd"
+)
+
+// azdoPipelineProvider configures Azure Pipelines to deploy the application from a repository hosted in Azure Repos
+type azdoPipelineProvider struct {
+	console             input.Console
+	personalAccessToken string
+	// newClient allows us to stub out the Azure DevOps REST API for testing
+	newClient func(organizationUrl string, personalAccessToken string) azdo.Client
+}
+
+func newAzdoPipelineProvider(console input.Console) pipelineProvider {
+	return &azdoPipelineProvider{
+		console: console,
+		newClient: func(organizationUrl string, personalAccessToken string) azdo.Client {
+			return azdo.NewClient(azdo.NewClientArgs{
+				OrganizationUrl:     organizationUrl,
+				PersonalAccessToken: personalAccessToken,
+			})
+		},
+	}
+}
+
+func (p *azdoPipelineProvider) Name() string {
+	return "Azure Pipelines"
+}
+
+func (p *azdoPipelineProvider) HostName() string {
+	return "Azure DevOps"
+}
+
+func (p *azdoPipelineProvider) RequiredTools() []tools.ExternalTool {
+	return []tools.ExternalTool{}
+}
+
+func (p *azdoPipelineProvider) EnsureLoggedIn(ctx context.Context) error {
+	p.personalAccessToken = os.Getenv(azdo.PersonalAccessTokenEnvVarName)
+	if p.personalAccessToken == "" {
+		return fmt.Errorf(
+			"%s must be set to a personal access token with the Build (read & execute), Code (read), "+
+				"Service Connections (read, query & manage) and Variable Groups (read, create & manage) scopes",
+			azdo.PersonalAccessTokenEnvVarName,
+		)
+	}
+
+	return nil
+}
+
+func (p *azdoPipelineProvider) RepositorySlug(remoteUrl string) (string, error) {
+	repository, err := azdo.GetRepositoryForRemote(remoteUrl)
+	if err != nil {
+		return "", err
+	}
+
+	return repository.Slug(), nil
+}
+
+// ConfigureRemote prompts for the URL of an existing Azure Repos repository
+func (p *azdoPipelineProvider) ConfigureRemote(ctx context.Context, azdCtx *environment.AzdContext, remoteName string) (string, error) {
+	for {
+		remoteUrl, err := p.console.Prompt(ctx, input.ConsoleOptions{
+			Message: fmt.Sprintf("Please enter the url of the Azure Repos repository to use for remote %s:", remoteName),
+		})
+		if err != nil {
+			return "", fmt.Errorf("prompting for remote url: %w", err)
+		}
+
+		if azdo.IsAzDoRemote(remoteUrl) {
+			return remoteUrl, nil
+		}
+
+		fmt.Printf("error: \"%s\" is not a valid Azure Repos URL.\n", remoteUrl)
+	}
+}
+
+func (p *azdoPipelineProvider) FederatedCredentials(
+	repository pipelineRepository,
+	branch string,
+	env *environment.Environment,
+) ([]azcli.AzCliFederatedCredential, error) {
+	return nil, fmt.Errorf(
+		"federated credentials are not supported for %s, use --auth-type %s",
+		p.Name(),
+		clientCredentialsAuthType,
+	)
+}
+
+func (p *azdoPipelineProvider) ConfigurePipeline(
+	ctx context.Context,
+	azdCtx *environment.AzdContext,
+	repository pipelineRepository,
+	env *environment.Environment,
+	authType pipelineAuthType,
+	credentials azcli.AzureCredentials,
+) error {
+	if _, err := os.Stat(filepath.Join(azdCtx.ProjectDirectory(), azdoPipelineFileName)); err != nil {
+		return fmt.Errorf("%s was not found in %s, it is required to create the pipeline: %w", azdoPipelineFileName, azdCtx.ProjectDirectory(), err)
+	}
+
+	azdoRepository, err := azdo.GetRepositoryForRemote(repository.RemoteUrl)
+	if err != nil {
+		return fmt.Errorf("remote `%s` is not an Azure Repos repository: %w", repository.RemoteUrl, err)
+	}
+
+	client := p.newClient(azdoRepository.OrganizationUrl(), p.personalAccessToken)
+
+	project, err := client.GetProject(ctx, azdoRepository.Project)
+	if err != nil {
+		return err
+	}
+
+	gitRepository, err := client.GetRepository(ctx, project, azdoRepository.Name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Creating or updating service connection %s.\n", azdoServiceConnectionName)
+
+	endpoint, err := client.CreateOrUpdateServiceConnection(ctx, project, azdoServiceConnectionName, credentials)
+	if err != nil {
+		return err
+	}
+
+	if err := client.AuthorizeAllPipelines(ctx, project, "endpoint", endpoint.Id); err != nil {
+		return err
+	}
+
+	fmt.Printf("Creating or updating variable group %s.\n", azdoVariableGroupName)
+
+	variables := map[string]azdo.Variable{}
+	for _, envName := range []string{environment.EnvNameEnvVarName, environment.LocationEnvVarName, environment.SubscriptionIdEnvVarName} {
+		variables[envName] = azdo.Variable{Value: env.Values[envName]}
+	}
+
+	group, err := client.CreateOrUpdateVariableGroup(ctx, project, azdoVariableGroupName, variables)
+	if err != nil {
+		return err
+	}
+
+	if err := client.AuthorizeAllPipelines(ctx, project, "variablegroup", fmt.Sprint(group.Id)); err != nil {
+		return err
+	}
+
+	fmt.Printf("Creating pipeline %s.\n", gitRepository.Name)
+
+	pipeline, err := client.CreateOrGetPipeline(ctx, project, gitRepository.Name, gitRepository, "/"+azdoPipelineFileName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf(`Azure Pipelines is now configured. See %s for details on the pipeline.
+You can view the pipeline here: %s
+`, azdoPipelineFileName, pipeline.WebUrl())
+
+	return nil
+}
+
+func (p *azdoPipelineProvider) BeforePush(
+	ctx context.Context,
+	gitCli git.GitCli,
+	azdCtx *environment.AzdContext,
+	repository pipelineRepository,
+	remoteName string,
+	branch string,
+) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,284 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/github"
+	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/git"
+	githubTool "github.com/azure/azure-dev/cli/azd/pkg/tools/github"
+)
+
+// gitHubPipelineProvider configures GitHub Actions to deploy the application from a repository hosted on GitHub
+type gitHubPipelineProvider struct {
+	ghCli   githubTool.GitHubCli
+	console input.Console
+	// This flag is used later to skip checking GitHub Actions.
+	// For new repositories, there's no need to check
+	newGitHubRepoCreated bool
+}
+
+func newGitHubPipelineProvider(console input.Console) pipelineProvider {
+	return &gitHubPipelineProvider{
+		ghCli:   githubTool.NewGitHubCli(),
+		console: console,
+	}
+}
+
+func (p *gitHubPipelineProvider) Name() string {
+	return "GitHub Actions"
+}
+
+func (p *gitHubPipelineProvider) HostName() string {
+	return "GitHub"
+}
+
+func (p *gitHubPipelineProvider) RequiredTools() []tools.ExternalTool {
+	return []tools.ExternalTool{p.ghCli}
+}
+
+func (p *gitHubPipelineProvider) EnsureLoggedIn(ctx context.Context) error {
+	return ensureGitHubLogin(ctx, p.ghCli, githubTool.GitHubHostName, p.console)
+}
+
+func (p *gitHubPipelineProvider) RepositorySlug(remoteUrl string) (string, error) {
+	return github.GetSlugForRemote(remoteUrl)
+}
+
+func (p *gitHubPipelineProvider) ConfigureRemote(ctx context.Context, azdCtx *environment.AzdContext, remoteName string) (string, error) {
+	// There are a few ways to configure the remote so offer a choice to the user.
+	idx, err := p.console.Select(ctx, input.ConsoleOptions{
+		Message: "How would you like to configure your remote?",
+		Options: []string{
+			"Select an existing GitHub project",
+			"Create a new private GitHub repository",
+			"Enter a remote URL directly",
+		},
+		DefaultValue: "Create a new private GitHub repository",
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("prompting for remote configuration type: %w", err)
+	}
+
+	switch idx {
+	// Select from an existing GitHub project
+	case 0:
+		url, err := getRemoteUrlFromExisting(ctx, p.ghCli, p.console)
+		if err != nil {
+			return "", fmt.Errorf("getting remote from existing repository: %w", err)
+		}
+		return url, nil
+	// Create a new project
+	case 1:
+		url, err := getRemoteUrlFromNewRepository(ctx, p.ghCli, azdCtx, p.console)
+		if err != nil {
+			return "", fmt.Errorf("getting remote from new repository: %w", err)
+		}
+		p.newGitHubRepoCreated = true
+		return url, nil
+	// Enter a URL directly.
+	case 2:
+		url, err := p.getRemoteUrlFromPrompt(ctx, remoteName)
+		if err != nil {
+			return "", fmt.Errorf("getting remote from prompt: %w", err)
+		}
+		return url, nil
+	default:
+		panic(fmt.Sprintf("unexpected selection index %d", idx))
+	}
+}
+
+// The names of federated identity credentials only allow alphanumeric characters, dashes & underscores
+var invalidFederatedCredentialNameCharacters = regexp.MustCompile(`[^a-zA-Z0-9-_]`)
+
+func (p *gitHubPipelineProvider) FederatedCredentials(
+	repository pipelineRepository,
+	branch string,
+	env *environment.Environment,
+) ([]azcli.AzCliFederatedCredential, error) {
+	newCredential := func(name string, subject string) azcli.AzCliFederatedCredential {
+		return azcli.AzCliFederatedCredential{
+			Name:        invalidFederatedCredentialNameCharacters.ReplaceAllString(name, "-"),
+			Issuer:      github.ActionsTokenIssuer,
+			Subject:     subject,
+			Description: fmt.Sprintf("Created by Azure Developer CLI for %s", repository.Slug),
+			Audiences:   []string{"api://AzureADTokenExchange"},
+		}
+	}
+
+	return []azcli.AzCliFederatedCredential{
+		newCredential(fmt.Sprintf("azd-branch-%s", branch), fmt.Sprintf("repo:%s:ref:refs/heads/%s", repository.Slug, branch)),
+		newCredential("azd-pull-request", fmt.Sprintf("repo:%s:pull_request", repository.Slug)),
+		newCredential(
+			fmt.Sprintf("azd-environment-%s", env.GetEnvName()),
+			fmt.Sprintf("repo:%s:environment:%s", repository.Slug, env.GetEnvName()),
+		),
+	}, nil
+}
+
+func (p *gitHubPipelineProvider) ConfigurePipeline(
+	ctx context.Context,
+	azdCtx *environment.AzdContext,
+	repository pipelineRepository,
+	env *environment.Environment,
+	authType pipelineAuthType,
+	credentials azcli.AzureCredentials,
+) error {
+	if authType == federatedAuthType {
+		variables := []struct{ name, value string }{
+			{"AZURE_CLIENT_ID", credentials.ClientId},
+			{"AZURE_TENANT_ID", credentials.TenantId},
+			{"AZURE_SUBSCRIPTION_ID", credentials.SubscriptionId},
+		}
+
+		for _, variable := range variables {
+			fmt.Printf("Setting %s GitHub repo variable.\n", variable.name)
+
+			if err := p.ghCli.SetVariable(ctx, repository.Slug, variable.name, variable.value); err != nil {
+				return fmt.Errorf("failed setting %s variable: %w", variable.name, err)
+			}
+		}
+
+		if err := useFederatedLoginInWorkflows(azdCtx); err != nil {
+			return err
+		}
+	} else {
+		credentialsJson, err := json.Marshal(credentials)
+		if err != nil {
+			return fmt.Errorf("couldn't build Azure Credential: %w", err)
+		}
+
+		fmt.Printf("Setting AZURE_CREDENTIALS GitHub repo secret.\n")
+
+		if err := p.ghCli.SetSecret(ctx, repository.Slug, "AZURE_CREDENTIALS", string(credentialsJson)); err != nil {
+			return fmt.Errorf("failed setting AZURE_CREDENTIALS secret: %w", err)
+		}
+	}
+
+	fmt.Printf("Configuring repository environment.\n")
+
+	secrets := []string{environment.EnvNameEnvVarName, environment.LocationEnvVarName}
+	if authType != federatedAuthType {
+		// Federated workflows log in with the AZURE_SUBSCRIPTION_ID variable instead
+		secrets = append(secrets, environment.SubscriptionIdEnvVarName)
+	}
+
+	for _, envName := range secrets {
+		fmt.Printf("Setting %s GitHub repo secret.\n", envName)
+
+		if err := p.ghCli.SetSecret(ctx, repository.Slug, envName, env.Values[envName]); err != nil {
+			return fmt.Errorf("failed setting %s secret: %w", envName, err)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf(`GitHub Action secrets are now configured. See your .github/workflows folder for details on which actions will be enabled.
+You can view the GitHub Actions here: https://github.com/%s/actions
+`, repository.Slug)
+
+	return nil
+}
+
+// useFederatedLoginInWorkflows updates the workflows of the project logging in with the AZURE_CREDENTIALS secret
+// to log in with OpenID Connect
+func useFederatedLoginInWorkflows(azdCtx *environment.AzdContext) error {
+	workflowsDirectory := filepath.Join(azdCtx.ProjectDirectory(), ".github", "workflows")
+
+	entries, err := os.ReadDir(workflowsDirectory)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("reading workflows: %w", err)
+	}
+
+	for _, entry := range entries {
+		extension := filepath.Ext(entry.Name())
+		if entry.IsDir() || (extension != ".yml" && extension != ".yaml") {
+			continue
+		}
+
+		workflowPath := filepath.Join(workflowsDirectory, entry.Name())
+		workflow, err := os.ReadFile(workflowPath)
+		if err != nil {
+			return fmt.Errorf("reading workflow %s: %w", entry.Name(), err)
+		}
+
+		updated, changed := github.UseFederatedLogin(string(workflow))
+		if !changed {
+			continue
+		}
+
+		fmt.Printf("Updating workflow %s to log in with OpenID Connect.\n", entry.Name())
+
+		if err := os.WriteFile(workflowPath, []byte(updated), 0644); err != nil {
+			return fmt.Errorf("writing workflow %s: %w", entry.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func (p *gitHubPipelineProvider) BeforePush(
+	ctx context.Context,
+	gitCli git.GitCli,
+	azdCtx *environment.AzdContext,
+	repository pipelineRepository,
+	remoteName string,
+	branch string,
+) (bool, error) {
+	// Check if GitHub actions are disabled *Only* when this is NOT a just-created repo
+	//
+	// A repo that is just created would return zero GitHub Actions and might be confused by azd
+	// as a repo where Actions are disabled. Sadly, there's not GitHub API to fetch exact information
+	// to distinguish between disabled-after-fork v/s repo-disabled-actions v/s similar scenarios).
+	if p.newGitHubRepoCreated {
+		return false, nil
+	}
+
+	cancelPushing, err := notifyWhenGitHubActionsAreDisabled(ctx, gitCli, p.ghCli, azdCtx, repository.Slug, remoteName, branch, p.console)
+	if err != nil {
+		return false, fmt.Errorf("ensure github actions: %w", err)
+	}
+
+	return cancelPushing, nil
+}
+
+// getRemoteUrlFromPrompt interactively prompts the user for a URL for a GitHub repository. It validates
+// that the URL is well formed and is in the correct format for a GitHub repository.
+func (p *gitHubPipelineProvider) getRemoteUrlFromPrompt(ctx context.Context, remoteName string) (string, error) {
+	remoteUrl := ""
+
+	for remoteUrl == "" {
+		promptValue, err := p.console.Prompt(ctx, input.ConsoleOptions{
+			Message: fmt.Sprintf("Please enter the url to use for remote %s:", remoteName),
+		})
+
+		if err != nil {
+			return "", fmt.Errorf("prompting for remote url: %w", err)
+		}
+
+		remoteUrl = promptValue
+
+		if _, err := github.GetSlugForRemote(remoteUrl); errors.Is(err, github.ErrRemoteHostIsNotGitHub) {
+			fmt.Printf("error: \"%s\" is not a valid GitHub URL.\n", remoteUrl)
+
+			// So we retry from the loop.
+			remoteUrl = ""
+		}
+	}
+
+	return remoteUrl, nil
+}
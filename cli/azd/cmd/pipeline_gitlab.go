@@ -0,0 +1,173 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/gitlab"
+	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/git"
+)
+
+// gitLabPipelineProvider configures GitLab CI/CD to deploy the application from a project hosted on GitLab
+type gitLabPipelineProvider struct {
+	console input.Console
+	token   string
+	// newClient allows us to stub out the GitLab REST API for testing
+	newClient func(baseUrl string, token string) gitlab.Client
+}
+
+func newGitLabPipelineProvider(console input.Console) pipelineProvider {
+	return &gitLabPipelineProvider{
+		console: console,
+		newClient: func(baseUrl string, token string) gitlab.Client {
+			return gitlab.NewClient(gitlab.NewClientArgs{
+				BaseUrl: baseUrl,
+				Token:   token,
+			})
+		},
+	}
+}
+
+func (p *gitLabPipelineProvider) Name() string {
+	return "GitLab CI/CD"
+}
+
+func (p *gitLabPipelineProvider) HostName() string {
+	return "GitLab"
+}
+
+func (p *gitLabPipelineProvider) RequiredTools() []tools.ExternalTool {
+	return []tools.ExternalTool{}
+}
+
+func (p *gitLabPipelineProvider) EnsureLoggedIn(ctx context.Context) error {
+	p.token = os.Getenv(gitlab.TokenEnvVarName)
+	if p.token == "" {
+		return fmt.Errorf("%s must be set to an access token with the api scope", gitlab.TokenEnvVarName)
+	}
+
+	return nil
+}
+
+func (p *gitLabPipelineProvider) RepositorySlug(remoteUrl string) (string, error) {
+	project, err := gitlab.GetProjectForRemote(remoteUrl)
+	if err != nil {
+		return "", err
+	}
+
+	return project.Path, nil
+}
+
+// ConfigureRemote prompts for the URL of an existing GitLab project
+func (p *gitLabPipelineProvider) ConfigureRemote(ctx context.Context, azdCtx *environment.AzdContext, remoteName string) (string, error) {
+	for {
+		remoteUrl, err := p.console.Prompt(ctx, input.ConsoleOptions{
+			Message: fmt.Sprintf("Please enter the url of the GitLab project to use for remote %s:", remoteName),
+		})
+		if err != nil {
+			return "", fmt.Errorf("prompting for remote url: %w", err)
+		}
+
+		if _, err := gitlab.GetProjectForRemote(remoteUrl); err == nil {
+			return remoteUrl, nil
+		}
+
+		fmt.Printf("error: \"%s\" is not a valid GitLab URL.\n", remoteUrl)
+	}
+}
+
+func (p *gitLabPipelineProvider) FederatedCredentials(
+	repository pipelineRepository,
+	branch string,
+	env *environment.Environment,
+) ([]azcli.AzCliFederatedCredential, error) {
+	return nil, fmt.Errorf(
+		"federated credentials are not supported for %s, use --auth-type %s",
+		p.Name(),
+		clientCredentialsAuthType,
+	)
+}
+
+func (p *gitLabPipelineProvider) ConfigurePipeline(
+	ctx context.Context,
+	azdCtx *environment.AzdContext,
+	repository pipelineRepository,
+	env *environment.Environment,
+	authType pipelineAuthType,
+	credentials azcli.AzureCredentials,
+) error {
+	remoteProject, err := gitlab.GetProjectForRemote(repository.RemoteUrl)
+	if err != nil {
+		return fmt.Errorf("remote `%s` is not a GitLab project: %w", repository.RemoteUrl, err)
+	}
+
+	client := p.newClient(remoteProject.BaseUrl, p.token)
+
+	project, err := client.GetProject(ctx, remoteProject.Path)
+	if err != nil {
+		return err
+	}
+
+	variables := []struct{ name, value string }{
+		{environment.EnvNameEnvVarName, env.Values[environment.EnvNameEnvVarName]},
+		{environment.LocationEnvVarName, env.Values[environment.LocationEnvVarName]},
+		{environment.SubscriptionIdEnvVarName, env.Values[environment.SubscriptionIdEnvVarName]},
+		{"AZURE_CLIENT_ID", credentials.ClientId},
+		{"AZURE_TENANT_ID", credentials.TenantId},
+		{"AZURE_CLIENT_SECRET", credentials.ClientSecret},
+	}
+
+	for _, variable := range variables {
+		masked := gitlab.CanMask(variable.value)
+
+		// The client secret must never be printed in the logs of the jobs
+		if variable.name == "AZURE_CLIENT_SECRET" && !masked {
+			return fmt.Errorf("the client secret of the service principal cannot be stored as a masked GitLab variable")
+		}
+
+		fmt.Printf("Setting %s GitLab CI/CD variable.\n", variable.name)
+
+		if err := client.CreateOrUpdateVariable(ctx, project, gitlab.Variable{
+			Key:    variable.name,
+			Value:  variable.value,
+			Masked: masked,
+		}); err != nil {
+			return fmt.Errorf("failed setting %s variable: %w", variable.name, err)
+		}
+	}
+
+	written, err := gitlab.WriteCiFile(azdCtx.ProjectDirectory())
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", gitlab.CiFileName, err)
+	}
+
+	if written {
+		fmt.Printf("Created %s running azd provision and azd deploy.\n", gitlab.CiFileName)
+	}
+
+	fmt.Println()
+	fmt.Printf(`GitLab CI/CD variables are now configured. See %s for details on the pipeline.
+You can view the pipelines here: %s/-/pipelines
+`, gitlab.CiFileName, project.WebUrl)
+
+	return nil
+}
+
+func (p *gitLabPipelineProvider) BeforePush(
+	ctx context.Context,
+	gitCli git.GitCli,
+	azdCtx *environment.AzdContext,
+	repository pipelineRepository,
+	remoteName string,
+	branch string,
+) (bool, error) {
+	return false, nil
+}
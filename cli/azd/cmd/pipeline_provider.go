@@ -0,0 +1,121 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdo"
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/gitlab"
+	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/git"
+)
+
+const (
+	gitHubPipelineProviderName = "github"
+	azdoPipelineProviderName   = "azdo"
+	gitLabPipelineProviderName = "gitlab"
+)
+
+// pipelineAuthType is how the pipeline authenticates with Azure
+type pipelineAuthType string
+
+const (
+	// The pipeline authenticates with the client secret of the service principal
+	clientCredentialsAuthType pipelineAuthType = "client-credentials"
+	// The pipeline authenticates with OpenID Connect tokens trusted by federated identity credentials of the service principal
+	federatedAuthType pipelineAuthType = "federated"
+)
+
+// pipelineProvider configures a CI/CD system to deploy the application from a git repository
+type pipelineProvider interface {
+	// Name is the name of the CI/CD system, ex) GitHub Actions
+	Name() string
+	// HostName is the name of the service hosting the repository, ex) GitHub
+	HostName() string
+	RequiredTools() []tools.ExternalTool
+	// EnsureLoggedIn ensures the user can manage the repository & the pipelines of the service
+	EnsureLoggedIn(ctx context.Context) error
+	// RepositorySlug returns the name of the repository of the remote.
+	// An error is returned when the remote is not hosted by the service.
+	RepositorySlug(remoteUrl string) (string, error)
+	// ConfigureRemote offers to select or create a repository when the git remote does not exist, and returns its URL
+	ConfigureRemote(ctx context.Context, azdCtx *environment.AzdContext, remoteName string) (string, error)
+	// FederatedCredentials returns the federated identity credentials trusting the tokens issued to the pipelines
+	// of the repository. An error is returned when the provider does not support federated credentials.
+	FederatedCredentials(repository pipelineRepository, branch string, env *environment.Environment) ([]azcli.AzCliFederatedCredential, error)
+	// ConfigurePipeline stores the credentials & the values of the environment used by the pipeline to deploy the application.
+	// With federated authentication, the credentials do not include a client secret.
+	ConfigurePipeline(
+		ctx context.Context,
+		azdCtx *environment.AzdContext,
+		repository pipelineRepository,
+		env *environment.Environment,
+		authType pipelineAuthType,
+		credentials azcli.AzureCredentials,
+	) error
+	// BeforePush is called before pushing the changes to the remote. It returns true when the push should be canceled.
+	BeforePush(
+		ctx context.Context,
+		gitCli git.GitCli,
+		azdCtx *environment.AzdContext,
+		repository pipelineRepository,
+		remoteName string,
+		branch string,
+	) (bool, error)
+}
+
+// pipelineRepository is the repository the pipeline runs on
+type pipelineRepository struct {
+	RemoteUrl string
+	Slug      string
+}
+
+// newPipelineProvider creates the provider with the given name. When no name is given, the provider is detected
+// from the URL of the git remote, defaulting to GitHub when the remote does not exist yet.
+func newPipelineProvider(
+	ctx context.Context,
+	name string,
+	gitCli git.GitCli,
+	azdCtx *environment.AzdContext,
+	remoteName string,
+	console input.Console,
+) (pipelineProvider, error) {
+	if name == "" {
+		name = gitHubPipelineProviderName
+
+		remoteUrl, err := gitCli.GetRemoteUrl(ctx, azdCtx.ProjectDirectory(), remoteName)
+		if err == nil {
+			switch {
+			case azdo.IsAzDoRemote(remoteUrl):
+				name = azdoPipelineProviderName
+			case gitlab.IsGitLabRemote(remoteUrl, os.Getenv(gitlab.HostEnvVarName)):
+				name = gitLabPipelineProviderName
+			}
+		}
+	}
+
+	switch strings.ToLower(name) {
+	case gitHubPipelineProviderName:
+		return newGitHubPipelineProvider(console), nil
+	case azdoPipelineProviderName:
+		return newAzdoPipelineProvider(console), nil
+	case gitLabPipelineProviderName:
+		return newGitLabPipelineProvider(console), nil
+	default:
+		return nil, fmt.Errorf(
+			"unknown pipeline provider '%s', supported providers are '%s', '%s' and '%s'",
+			name,
+			gitHubPipelineProviderName,
+			azdoPipelineProviderName,
+			gitLabPipelineProviderName,
+		)
+	}
+}
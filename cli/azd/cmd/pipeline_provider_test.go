@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azdo"
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/gitlab"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/git"
+	"github.com/azure/azure-dev/cli/azd/test/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRemoteGitCli struct {
+	git.GitCli
+	remoteUrl string
+	err       error
+}
+
+func (cli *fakeRemoteGitCli) GetRemoteUrl(ctx context.Context, repositoryPath string, remoteName string) (string, error) {
+	return cli.remoteUrl, cli.err
+}
+
+func Test_newPipelineProvider(t *testing.T) {
+	azdCtx := &environment.AzdContext{}
+	azdCtx.SetProjectDirectory(t.TempDir())
+	console := mocks.NewMockConsole()
+
+	cases := []struct {
+		name      string
+		provider  string
+		gitCli    git.GitCli
+		expected  string
+		expectErr bool
+	}{
+		{name: "AzDoRemote", gitCli: &fakeRemoteGitCli{remoteUrl: "https://dev.azure.com/contoso/web/_git/todo"}, expected: "Azure Pipelines"},
+		{name: "GitHubRemote", gitCli: &fakeRemoteGitCli{remoteUrl: "https://github.com/contoso/todo.git"}, expected: "GitHub Actions"},
+		{name: "GitLabRemote", gitCli: &fakeRemoteGitCli{remoteUrl: "git@gitlab.com:contoso/web/todo.git"}, expected: "GitLab CI/CD"},
+		{name: "NoRemote", gitCli: &fakeRemoteGitCli{err: git.ErrNoSuchRemote}, expected: "GitHub Actions"},
+		{name: "Explicit", provider: "azdo", gitCli: &fakeRemoteGitCli{remoteUrl: "https://github.com/contoso/todo.git"}, expected: "Azure Pipelines"},
+		{name: "ExplicitGitLab", provider: "GitLab", gitCli: &fakeRemoteGitCli{remoteUrl: "https://github.com/contoso/todo.git"}, expected: "GitLab CI/CD"},
+		{name: "Unknown", provider: "jenkins", gitCli: &fakeRemoteGitCli{}, expectErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			provider, err := newPipelineProvider(context.Background(), c.provider, c.gitCli, azdCtx, "origin", console)
+			if c.expectErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, c.expected, provider.Name())
+		})
+	}
+}
+
+// fakeAzdoClient records the resources created in Azure DevOps
+type fakeAzdoClient struct {
+	azdo.Client
+	credentials azcli.AzureCredentials
+	variables   map[string]azdo.Variable
+	authorized  []string
+	yamlPath    string
+}
+
+func (c *fakeAzdoClient) GetProject(ctx context.Context, projectName string) (azdo.Project, error) {
+	return azdo.Project{Id: "PROJECT_ID", Name: projectName}, nil
+}
+
+func (c *fakeAzdoClient) GetRepository(ctx context.Context, project azdo.Project, repositoryName string) (azdo.GitRepository, error) {
+	return azdo.GitRepository{Id: "REPOSITORY_ID", Name: repositoryName}, nil
+}
+
+func (c *fakeAzdoClient) CreateOrUpdateServiceConnection(
+	ctx context.Context,
+	project azdo.Project,
+	name string,
+	credentials azcli.AzureCredentials,
+) (azdo.ServiceEndpoint, error) {
+	c.credentials = credentials
+	return azdo.ServiceEndpoint{Id: "ENDPOINT_ID", Name: name}, nil
+}
+
+func (c *fakeAzdoClient) CreateOrUpdateVariableGroup(
+	ctx context.Context,
+	project azdo.Project,
+	name string,
+	variables map[string]azdo.Variable,
+) (azdo.VariableGroup, error) {
+	c.variables = variables
+	return azdo.VariableGroup{Id: 7, Name: name}, nil
+}
+
+func (c *fakeAzdoClient) AuthorizeAllPipelines(ctx context.Context, project azdo.Project, resourceType string, resourceId string) error {
+	c.authorized = append(c.authorized, resourceType+"/"+resourceId)
+	return nil
+}
+
+func (c *fakeAzdoClient) CreateOrGetPipeline(
+	ctx context.Context,
+	project azdo.Project,
+	name string,
+	repository azdo.GitRepository,
+	yamlPath string,
+) (azdo.Pipeline, error) {
+	c.yamlPath = yamlPath
+	return azdo.Pipeline{Id: 1, Name: name}, nil
+}
+
+func Test_azdoPipelineProvider_ConfigurePipeline(t *testing.T) {
+	projectDirectory := t.TempDir()
+	azdCtx := &environment.AzdContext{}
+	azdCtx.SetProjectDirectory(projectDirectory)
+
+	env := environment.Environment{Values: map[string]string{
+		environment.EnvNameEnvVarName:        "dev",
+		environment.LocationEnvVarName:       "westus2",
+		environment.SubscriptionIdEnvVarName: "SUBSCRIPTION_ID",
+		"OTHER_VALUE":                        "other",
+	}}
+
+	credentials := azcli.AzureCredentials{ClientId: "CLIENT_ID", ClientSecret: "SECRET", TenantId: "TENANT_ID"}
+
+	client := &fakeAzdoClient{}
+	var organizationUrl string
+	provider := &azdoPipelineProvider{
+		console:             mocks.NewMockConsole(),
+		personalAccessToken: "PAT",
+		newClient: func(url string, personalAccessToken string) azdo.Client {
+			organizationUrl = url
+			return client
+		},
+	}
+
+	repository := pipelineRepository{RemoteUrl: "https://dev.azure.com/contoso/web/_git/todo", Slug: "contoso/web/todo"}
+
+	t.Run("MissingPipelineFile", func(t *testing.T) {
+		err := provider.ConfigurePipeline(context.Background(), azdCtx, repository, &env, clientCredentialsAuthType, credentials)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), azdoPipelineFileName)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(projectDirectory, azdoPipelineFileName), []byte("trigger: [main]"), 0600))
+
+		err := provider.ConfigurePipeline(context.Background(), azdCtx, repository, &env, clientCredentialsAuthType, credentials)
+		require.NoError(t, err)
+
+		require.Equal(t, "https://dev.azure.com/contoso", organizationUrl)
+		require.Equal(t, "SECRET", client.credentials.ClientSecret)
+		require.Equal(t, map[string]azdo.Variable{
+			environment.EnvNameEnvVarName:        {Value: "dev"},
+			environment.LocationEnvVarName:       {Value: "westus2"},
+			environment.SubscriptionIdEnvVarName: {Value: "SUBSCRIPTION_ID"},
+		}, client.variables)
+		require.Equal(t, []string{"endpoint/ENDPOINT_ID", "variablegroup/7"}, client.authorized)
+		require.Equal(t, "/azure-pipelines.yml", client.yamlPath)
+	})
+}
+
+// fakeGitLabClient records the CI/CD variables created in GitLab
+type fakeGitLabClient struct {
+	variables map[string]gitlab.Variable
+}
+
+func (c *fakeGitLabClient) GetProject(ctx context.Context, path string) (gitlab.Project, error) {
+	return gitlab.Project{Id: 42, PathWithNamespace: path, WebUrl: "https://gitlab.contoso.com/" + path}, nil
+}
+
+func (c *fakeGitLabClient) CreateOrUpdateVariable(ctx context.Context, project gitlab.Project, variable gitlab.Variable) error {
+	c.variables[variable.Key] = variable
+	return nil
+}
+
+func Test_gitLabPipelineProvider_ConfigurePipeline(t *testing.T) {
+	projectDirectory := t.TempDir()
+	azdCtx := &environment.AzdContext{}
+	azdCtx.SetProjectDirectory(projectDirectory)
+
+	env := environment.Environment{Values: map[string]string{
+		environment.EnvNameEnvVarName:        "dev",
+		environment.LocationEnvVarName:       "westus2",
+		environment.SubscriptionIdEnvVarName: "00000000-0000-0000-0000-000000000001",
+	}}
+
+	repository := pipelineRepository{RemoteUrl: "https://gitlab.contoso.com/contoso/web/todo.git", Slug: "contoso/web/todo"}
+
+	newProvider := func(client *fakeGitLabClient, baseUrl *string) *gitLabPipelineProvider {
+		return &gitLabPipelineProvider{
+			console: mocks.NewMockConsole(),
+			token:   "TOKEN",
+			newClient: func(url string, token string) gitlab.Client {
+				*baseUrl = url
+				return client
+			},
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		client := &fakeGitLabClient{variables: map[string]gitlab.Variable{}}
+		var baseUrl string
+
+		credentials := azcli.AzureCredentials{
+			ClientId:     "00000000-0000-0000-0000-000000000002",
+			ClientSecret: "s3cr3t~value.with_chars",
+			TenantId:     "00000000-0000-0000-0000-000000000003",
+		}
+
+		err := newProvider(client, &baseUrl).ConfigurePipeline(
+			context.Background(), azdCtx, repository, &env, clientCredentialsAuthType, credentials)
+		require.NoError(t, err)
+
+		require.Equal(t, "https://gitlab.contoso.com", baseUrl)
+		require.Equal(t, gitlab.Variable{Key: "AZURE_CLIENT_SECRET", Value: credentials.ClientSecret, Masked: true}, client.variables["AZURE_CLIENT_SECRET"])
+		require.True(t, client.variables["AZURE_SUBSCRIPTION_ID"].Masked)
+		// Values GitLab cannot mask are stored unmasked
+		require.Equal(t, gitlab.Variable{Key: environment.EnvNameEnvVarName, Value: "dev"}, client.variables[environment.EnvNameEnvVarName])
+		require.Len(t, client.variables, 6)
+
+		ciFile, err := os.ReadFile(filepath.Join(projectDirectory, gitlab.CiFileName))
+		require.NoError(t, err)
+		require.Contains(t, string(ciFile), "azd deploy --no-prompt")
+	})
+
+	t.Run("UnmaskableClientSecret", func(t *testing.T) {
+		client := &fakeGitLabClient{variables: map[string]gitlab.Variable{}}
+		var baseUrl string
+
+		credentials := azcli.AzureCredentials{ClientId: "CLIENT_ID", ClientSecret: "not maskable", TenantId: "TENANT_ID"}
+
+		err := newProvider(client, &baseUrl).ConfigurePipeline(
+			context.Background(), azdCtx, repository, &env, clientCredentialsAuthType, credentials)
+		require.Error(t, err)
+		require.NotContains(t, client.variables, "AZURE_CLIENT_SECRET")
+	})
+}
+
+func Test_gitHubPipelineProvider_FederatedCredentials(t *testing.T) {
+	env := environment.Environment{Values: map[string]string{}}
+	env.SetEnvName("dev")
+
+	provider := newGitHubPipelineProvider(mocks.NewMockConsole())
+	repository := pipelineRepository{RemoteUrl: "https://github.com/contoso/todo.git", Slug: "contoso/todo"}
+
+	credentials, err := provider.FederatedCredentials(repository, "feature/login", &env)
+	require.NoError(t, err)
+
+	subjects := map[string]string{}
+	for _, credential := range credentials {
+		require.Equal(t, "https://token.actions.githubusercontent.com", credential.Issuer)
+		require.Equal(t, []string{"api://AzureADTokenExchange"}, credential.Audiences)
+		subjects[credential.Name] = credential.Subject
+	}
+
+	require.Equal(t, map[string]string{
+		"azd-branch-feature-login": "repo:contoso/todo:ref:refs/heads/feature/login",
+		"azd-pull-request":         "repo:contoso/todo:pull_request",
+		"azd-environment-dev":      "repo:contoso/todo:environment:dev",
+	}, subjects)
+
+	_, err = newAzdoPipelineProvider(mocks.NewMockConsole()).FederatedCredentials(repository, "main", &env)
+	require.Error(t, err)
+
+	_, err = newGitLabPipelineProvider(mocks.NewMockConsole()).FederatedCredentials(repository, "main", &env)
+	require.Error(t, err)
+}
+
+func Test_useFederatedLoginInWorkflows(t *testing.T) {
+	projectDirectory := t.TempDir()
+	azdCtx := &environment.AzdContext{}
+	azdCtx.SetProjectDirectory(projectDirectory)
+
+	// Projects without workflows are left unchanged
+	require.NoError(t, useFederatedLoginInWorkflows(azdCtx))
+
+	workflowsDirectory := filepath.Join(projectDirectory, ".github", "workflows")
+	require.NoError(t, os.MkdirAll(workflowsDirectory, 0755))
+
+	workflow := "jobs:\n  build:\n    steps:\n      - uses: azure/login@v1\n        with:\n          creds: ${{ secrets.AZURE_CREDENTIALS }}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(workflowsDirectory, "azure-dev.yml"), []byte(workflow), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(workflowsDirectory, "README.md"), []byte(workflow), 0600))
+
+	require.NoError(t, useFederatedLoginInWorkflows(azdCtx))
+
+	updated, err := os.ReadFile(filepath.Join(workflowsDirectory, "azure-dev.yml"))
+	require.NoError(t, err)
+	require.Contains(t, string(updated), "client-id: ${{ vars.AZURE_CLIENT_ID }}")
+	require.Contains(t, string(updated), "id-token: write")
+
+	unchanged, err := os.ReadFile(filepath.Join(workflowsDirectory, "README.md"))
+	require.NoError(t, err)
+	require.Equal(t, workflow, string(unchanged))
+}
@@ -7,10 +7,12 @@ import (
 )
 
 func provisionCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	action := &infraCreateAction{
+		rootOptions: rootOptions,
+	}
+
 	cmd := commands.Build(
-		&infraCreateAction{
-			rootOptions: rootOptions,
-		},
+		action,
 		rootOptions,
 		"provision",
 		"Provision the Azure resources for an application.",
@@ -24,9 +26,11 @@ The command prompts you for the following:
 Depending on what Azure resources are created, running this command might take a while. To view progress, go to the Azure portal and search for the resource group that contains your environment name.`,
 	)
 
+	action.setupPreviewFlag(cmd)
+
 	return output.AddOutputParam(
 		cmd,
-		[]output.Format{output.JsonFormat, output.NoneFormat},
+		[]output.Format{output.JsonFormat, output.TableFormat, output.NoneFormat},
 		output.NoneFormat,
 	)
 }
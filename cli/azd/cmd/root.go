@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/azure/azure-dev/cli/azd/pkg/commands"
+	"github.com/azure/azure-dev/cli/azd/pkg/config"
 	"github.com/azure/azure-dev/cli/azd/pkg/environment"
 	"github.com/spf13/cobra"
 )
@@ -77,9 +78,11 @@ For more information, visit the Azure Developer CLI Dev Hub: https://aka.ms/azur
 	cmd.PersistentFlags().BoolVar(&opts.NoPrompt, "no-prompt", false, "Accepts the default value instead of prompting, or it fails if there is no default.")
 	cmd.SetHelpTemplate(fmt.Sprintf("%s\nPlease let us know how we are doing: https://aka.ms/azure-dev/hats\n", cmd.HelpTemplate()))
 
-	// the equivalent of AZURE_CORE_COLLECT_TELEMETRY
-	opts.EnableTelemetry = os.Getenv("AZURE_DEV_COLLECT_TELEMETRY") != "no"
+	// the equivalent of AZURE_CORE_COLLECT_TELEMETRY, which can also be set in the user configuration
+	opts.EnableTelemetry = config.ResolveBool(config.CollectTelemetry, "")
+	opts.UseArmClient = os.Getenv("AZURE_DEV_USE_ARM_CLIENT") == "true"
 
+	cmd.AddCommand(configCmd(opts))
 	cmd.AddCommand(deployCmd(opts))
 	cmd.AddCommand(downCmd(opts))
 	cmd.AddCommand(envCmd(opts))
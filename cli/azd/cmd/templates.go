@@ -7,18 +7,25 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"sort"
 
 	"github.com/azure/azure-dev/cli/azd/pkg/commands"
 	"github.com/azure/azure-dev/cli/azd/pkg/environment"
 	"github.com/azure/azure-dev/cli/azd/pkg/output"
+	"github.com/azure/azure-dev/cli/azd/pkg/project"
 	"github.com/azure/azure-dev/cli/azd/pkg/templates"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	bicepTool "github.com/azure/azure-dev/cli/azd/pkg/tools/bicep"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 func templatesCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
 	root := &cobra.Command{
-		Use:   "template",
-		Short: "Manage templates.",
+		Use:     "template",
+		Aliases: []string{"templates"},
+		Short:   "Manage templates.",
 	}
 
 	root.AddCommand(output.AddOutputParam(
@@ -31,19 +38,33 @@ func templatesCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
 		[]output.Format{output.JsonFormat, output.TableFormat},
 		output.TableFormat,
 	))
+	root.AddCommand(templatesSourceCmd(rootOptions))
+	root.AddCommand(output.AddOutputParam(
+		templatesValidateCmd(rootOptions),
+		[]output.Format{output.JsonFormat, output.TableFormat},
+		output.TableFormat,
+	))
 	root.Flags().BoolP("help", "h", false, fmt.Sprintf("Gets help for %s.", root.Name()))
 
 	return root
 }
 
 func templatesListCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	var refresh bool
 	cmd := &cobra.Command{
 		Use:     "list",
 		Short:   "List templates.",
 		Aliases: []string{"ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			templateManager := templates.NewTemplateManager()
-			templateSet, err := templateManager.ListTemplates()
+
+			if refresh {
+				if err := templateManager.RefreshSources(cmd.Context()); err != nil {
+					return err
+				}
+			}
+
+			templateSet, err := templateManager.ListTemplates(cmd.Context())
 
 			if err != nil {
 				return err
@@ -54,19 +75,22 @@ func templatesListCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command
 				templateList = append(templateList, template)
 			}
 
+			sort.Slice(templateList, func(i, j int) bool { return templateList[i].Name < templateList[j].Name })
+
 			return formatTemplates(cmd, templateList...)
 		},
 	}
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Downloads the templates of the remote template sources again, ignoring the cache.")
 	cmd.Flags().BoolP("help", "h", false, fmt.Sprintf("Gets help for %s.", cmd.Name()))
 	return cmd
 }
 
 func templatesShowCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
 	action := commands.ActionFunc(
-		func(_ context.Context, cmd *cobra.Command, args []string, azdCtx *environment.AzdContext) error {
+		func(ctx context.Context, cmd *cobra.Command, args []string, azdCtx *environment.AzdContext) error {
 			templateName := args[0]
 			templateManager := templates.NewTemplateManager()
-			matchingTemplate, err := templateManager.GetTemplate(templateName)
+			matchingTemplate, err := templateManager.GetTemplate(ctx, templateName)
 
 			log.Printf("Template Name: %s\n", templateName)
 
@@ -104,6 +128,10 @@ func formatTemplates(cmd *cobra.Command, templates ...templates.Template) error
 				Heading:       "Description",
 				ValueTemplate: "{{.Description}}",
 			},
+			{
+				Heading:       "Source",
+				ValueTemplate: "{{.Source}}",
+			},
 		}
 
 		err = formatter.Format(templates, cmd.OutOrStdout(), output.TableFormatterOptions{
@@ -119,3 +147,248 @@ func formatTemplates(cmd *cobra.Command, templates ...templates.Template) error
 
 	return nil
 }
+
+func templatesSourceCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "source",
+		Short: "Manage template sources.",
+		Long: `Manage template sources.
+
+Template sources list templates in addition to the templates built into the Azure Developer CLI. The templates of
+the sources are shown by ` + withBackticks("azd template list") + ` and offered by ` + withBackticks("azd init") + `. A source is either a JSON file,
+a directory of JSON files, a git repository with a ` + withBackticks(templates.SourceFileName) + ` file at its root, or an http(s) URL, each
+holding a list of templates with a ` + withBackticks("name") + `, a ` + withBackticks("description") + ` and a ` + withBackticks("repositoryPath") + `.
+
+The templates of git repositories and URLs are cached for 24 hours. Use ` + withBackticks("azd template list --refresh") + ` to
+download them again.`,
+	}
+
+	root.AddCommand(templatesSourceAddCmd(rootOptions))
+	root.AddCommand(output.AddOutputParam(
+		templatesSourceListCmd(rootOptions),
+		[]output.Format{output.JsonFormat, output.TableFormat},
+		output.TableFormat,
+	))
+	root.AddCommand(templatesSourceRemoveCmd(rootOptions))
+	root.Flags().BoolP("help", "h", false, fmt.Sprintf("Gets help for %s.", root.Name()))
+
+	return root
+}
+
+func templatesSourceAddCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	cmd := commands.Build(
+		&templatesSourceAddAction{},
+		rootOptions,
+		"add <name> <location>",
+		"Add a template source.",
+		`Add a template source.
+
+The kind of the source is detected from the location when `+withBackticks("--kind")+` is not set: git URLs are git
+repositories, other http(s) URLs are JSON documents, existing directories are directories and other
+locations are JSON files.`,
+	)
+	cmd.Args = cobra.ExactArgs(2)
+	return cmd
+}
+
+type templatesSourceAddAction struct {
+	kind string
+}
+
+func (a *templatesSourceAddAction) SetupFlags(
+	persis *pflag.FlagSet,
+	local *pflag.FlagSet,
+) {
+	local.StringVar(&a.kind, "kind", "", "The kind of the source: 'file', 'directory', 'git' or 'url'. Detected from the location when not set.")
+}
+
+func (a *templatesSourceAddAction) Run(ctx context.Context, _ *cobra.Command, args []string, _ *environment.AzdContext) error {
+	source, err := templates.NewSource(args[0], templates.SourceKind(a.kind), args[1])
+	if err != nil {
+		return err
+	}
+
+	store, err := templates.NewSourceStore()
+	if err != nil {
+		return err
+	}
+
+	if err := store.Add(source); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added %s template source '%s' (%s).\n", source.Kind, source.Name, source.Location)
+	return nil
+}
+
+func templatesSourceListCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List template sources.",
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := templates.NewSourceStore()
+			if err != nil {
+				return err
+			}
+
+			sources, err := store.List()
+			if err != nil {
+				return err
+			}
+
+			formatter, err := output.GetFormatter(cmd)
+			if err != nil {
+				return err
+			}
+
+			if formatter.Kind() == output.TableFormat {
+				columns := []output.Column{
+					{
+						Heading:       "Name",
+						ValueTemplate: "{{.Name}}",
+					},
+					{
+						Heading:       "Kind",
+						ValueTemplate: "{{.Kind}}",
+					},
+					{
+						Heading:       "Location",
+						ValueTemplate: "{{.Location}}",
+					},
+				}
+
+				return formatter.Format(sources, cmd.OutOrStdout(), output.TableFormatterOptions{
+					Columns: columns,
+				})
+			}
+
+			return formatter.Format(sources, cmd.OutOrStdout(), nil)
+		},
+	}
+	cmd.Flags().BoolP("help", "h", false, fmt.Sprintf("Gets help for %s.", cmd.Name()))
+	return cmd
+}
+
+func templatesSourceRemoveCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	action := commands.ActionFunc(
+		func(_ context.Context, cmd *cobra.Command, args []string, _ *environment.AzdContext) error {
+			store, err := templates.NewSourceStore()
+			if err != nil {
+				return err
+			}
+
+			if err := store.Remove(args[0]); err != nil {
+				return err
+			}
+
+			fmt.Printf("Removed template source '%s'.\n", args[0])
+			return nil
+		},
+	)
+	cmd := commands.Build(
+		action,
+		rootOptions,
+		"remove <name>",
+		"Remove a template source.",
+		"",
+	)
+	cmd.Aliases = []string{"rm"}
+	cmd.Args = cobra.ExactArgs(1)
+	return cmd
+}
+
+func templatesValidateCmd(rootOptions *commands.GlobalCommandOptions) *cobra.Command {
+	action := commands.ActionFunc(
+		func(ctx context.Context, cmd *cobra.Command, args []string, azdCtx *environment.AzdContext) error {
+			projectDirectory := azdCtx.ProjectDirectory()
+			if len(args) > 0 {
+				projectDirectory = args[0]
+			}
+
+			bicepCli := bicepTool.NewBicepCli(bicepTool.NewBicepCliArgs{AzCli: commands.GetAzCliFromContext(ctx)})
+			if err := tools.EnsureInstalled(ctx, bicepCli); err != nil {
+				return err
+			}
+
+			findings, err := project.NewValidator(bicepCli).Validate(ctx, projectDirectory)
+			if err != nil {
+				return err
+			}
+
+			formatter, err := output.GetFormatter(cmd)
+			if err != nil {
+				return err
+			}
+
+			if formatter.Kind() == output.TableFormat {
+				if len(findings) == 0 {
+					fmt.Fprintln(cmd.OutOrStdout(), "No problems were found.")
+				} else {
+					columns := []output.Column{
+						{
+							Heading:       "Severity",
+							ValueTemplate: "{{.Severity}}",
+						},
+						{
+							Heading:       "Rule",
+							ValueTemplate: "{{.Rule}}",
+						},
+						{
+							Heading:       "Location",
+							ValueTemplate: "{{.Location}}",
+						},
+						{
+							Heading:       "Message",
+							ValueTemplate: "{{.Message}}",
+						},
+					}
+
+					err = formatter.Format(findings, cmd.OutOrStdout(), output.TableFormatterOptions{
+						Columns: columns,
+					})
+				}
+			} else {
+				err = formatter.Format(findings, cmd.OutOrStdout(), nil)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			// In strict mode, ex) in CI, warnings fail the validation too
+			strict := os.Getenv(project.StrictValidationEnvVarName) == "true"
+			failureCount := 0
+			for _, finding := range findings {
+				if finding.Severity == project.SeverityError || strict {
+					failureCount++
+				}
+			}
+
+			if failureCount > 0 && strict {
+				return fmt.Errorf("the template has %d error(s) or warning(s)", failureCount)
+			} else if failureCount > 0 {
+				return fmt.Errorf("the template has %d error(s)", failureCount)
+			}
+
+			return nil
+		},
+	)
+	cmd := commands.Build(
+		action,
+		rootOptions,
+		"validate [<path>]",
+		"Validate a template.",
+		`Validate a template.
+
+Checks the project in the given directory, or the current project, without deploying it: `+withBackticks("azure.yaml")+` is validated
+against its schema, every bicep module is compiled, the outputs needed by the host of each service (for example
+`+withBackticks("AZURE_CONTAINER_REGISTRY_ENDPOINT")+` for `+withBackticks("containerapp")+`) are looked up in the outputs of the infrastructure,
+and the resources of the services are looked up by their `+withBackticks("azd-service-name")+` tag.
+
+The command fails when an error is found. When `+withBackticks(project.StrictValidationEnvVarName)+` is `+withBackticks("true")+`, for example in CI,
+warnings such as unknown properties of `+withBackticks("azure.yaml")+` fail the command too.`,
+	)
+	cmd.Args = cobra.MaximumNArgs(1)
+	return cmd
+}
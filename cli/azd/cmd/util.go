@@ -14,8 +14,10 @@ import (
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/azure/azure-dev/cli/azd/pkg/azureutil"
 	"github.com/azure/azure-dev/cli/azd/pkg/commands"
+	"github.com/azure/azure-dev/cli/azd/pkg/config"
 	"github.com/azure/azure-dev/cli/azd/pkg/environment"
 	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/keyvault"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
 	"github.com/fatih/color"
 	"github.com/mgutz/ansi"
@@ -150,6 +152,10 @@ func loadOrInitEnvironment(ctx context.Context, environmentName *string, azdCtx
 		return environment.Environment{}, fmt.Errorf("initializing environment: %w", err)
 	}
 
+	if err := env.ResolveSecrets(ctx, keyvault.NewSecretResolver(commands.GetAzCliFromContext(ctx))); err != nil {
+		return environment.Environment{}, err
+	}
+
 	if isNew {
 		if err := azdCtx.SetDefaultEnvironmentName(*environmentName); err != nil {
 			return environment.Environment{}, fmt.Errorf("saving default environment name: %w", err)
@@ -161,6 +167,8 @@ func loadOrInitEnvironment(ctx context.Context, environmentName *string, azdCtx
 
 // ensureEnvironmentInitialized ensures the environment is initialized, i.e. it contains values for `AZURE_ENV_NAME`, `AZURE_LOCATION`, `AZURE_SUBSCRIPTION_ID` and `AZURE_PRINCIPAL_ID`.
 // It will use the values from the "environment spec" passed in, and prompt for any missing values as necessary.
+// The location & subscription selected by default in the prompts are resolved from the environment variables, then
+// from the defaults of the user configuration (see `azd config`).
 // Existing environment value are left unchanged, even if the "spec" has different values.
 func ensureEnvironmentInitialized(ctx context.Context, envSpec environmentSpec, env *environment.Environment, console input.Console) error {
 	if env.Values == nil {
@@ -264,10 +272,10 @@ func getSubscriptionOptions(ctx context.Context) ([]string, string, error) {
 
 	sort.Sort(azureutil.Subs(subscriptionInfos))
 
-	// If `AZURE_SUBSCRIPTION_ID` is set in the environment, use it to influence
-	// the default option in our prompt. Fall back to the what the `az` CLI is
-	// configured to use if the environment variable is unset.
-	defaultSubscriptionId := os.Getenv(environment.SubscriptionIdEnvVarName)
+	// If `AZURE_SUBSCRIPTION_ID` is set in the environment, or a default subscription in the user configuration,
+	// use it to influence the default option in our prompt. Fall back to the what the `az` CLI is
+	// configured to use if neither is set.
+	defaultSubscriptionId := config.Resolve(config.DefaultSubscription, "")
 	if defaultSubscriptionId == "" {
 		for _, info := range subscriptionInfos {
 			if info.IsDefault {
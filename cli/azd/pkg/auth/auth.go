@@ -0,0 +1,235 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+// Package auth authenticates azd to Azure without the Azure CLI: the credentials of a service principal, a workload
+// identity or a managed identity are read from the environment, and users sign in with a device code. The access tokens,
+// and the refresh tokens of the users, are cached on disk.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/config"
+	"github.com/azure/azure-dev/cli/azd/pkg/httpUtil"
+)
+
+// ArmScope is the scope of the access tokens for Azure Resource Manager
+const ArmScope = "https://management.azure.com//.default"
+
+// KeyVaultScope is the scope of the access tokens for the secrets of Key Vault
+const KeyVaultScope = "https://vault.azure.net/.default"
+
+// DefaultAuthorityHost is the Azure Active Directory authority of the Azure public cloud
+const DefaultAuthorityHost = "https://login.microsoftonline.com"
+
+// The environment variables the credentials are read from, shared with the Azure SDKs & the Azure CLI
+const (
+	TenantIdEnvVarName              = "AZURE_TENANT_ID"
+	ClientIdEnvVarName              = "AZURE_CLIENT_ID"
+	ClientSecretEnvVarName          = "AZURE_CLIENT_SECRET"
+	ClientCertificatePathEnvVarName = "AZURE_CLIENT_CERTIFICATE_PATH"
+	FederatedTokenFileEnvVarName    = "AZURE_FEDERATED_TOKEN_FILE"
+	AuthorityHostEnvVarName         = "AZURE_AUTHORITY_HOST"
+	IdentityEndpointEnvVarName      = "IDENTITY_ENDPOINT"
+	IdentityHeaderEnvVarName        = "IDENTITY_HEADER"
+)
+
+// ErrCredentialUnavailable is returned by credentials which are not configured, ex) when the user is not logged in
+var ErrCredentialUnavailable = errors.New("credential unavailable")
+
+// The amount of time before a token expires at which a new token is requested
+const tokenRefreshWindow = 2 * time.Minute
+
+type AccessToken struct {
+	Token     string
+	ExpiresOn time.Time
+}
+
+// Credential provides access tokens for a scope, ex) https://management.azure.com//.default
+type Credential interface {
+	GetToken(ctx context.Context, scope string) (AccessToken, error)
+}
+
+// AuthenticationError is an error returned by the token endpoint
+type AuthenticationError struct {
+	Code        string
+	Description string
+}
+
+func (e *AuthenticationError) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("authentication failed: %s", e.Code)
+	}
+
+	return fmt.Sprintf("authentication failed: %s: %s", e.Code, e.Description)
+}
+
+type CredentialOptions struct {
+	// AuthorityHost is the Azure Active Directory authority, defaults to AZURE_AUTHORITY_HOST,
+	// then to https://login.microsoftonline.com
+	AuthorityHost string
+	// HttpClient allows us to stub out the http requests for testing.
+	// Defaults to the client found in the context of each request.
+	HttpClient httpUtil.HttpUtil
+	// Cache stores the tokens. Tokens are not cached when nil.
+	Cache *TokenCache
+
+	now func() time.Time
+}
+
+func (o CredentialOptions) withDefaults() CredentialOptions {
+	if o.AuthorityHost == "" {
+		o.AuthorityHost = os.Getenv(AuthorityHostEnvVarName)
+	}
+
+	if o.AuthorityHost == "" {
+		o.AuthorityHost = DefaultAuthorityHost
+	}
+
+	o.AuthorityHost = strings.TrimSuffix(o.AuthorityHost, "/")
+
+	if o.now == nil {
+		o.now = time.Now
+	}
+
+	return o
+}
+
+func (o CredentialOptions) httpClient(ctx context.Context) httpUtil.HttpUtil {
+	if o.HttpClient != nil {
+		return o.HttpClient
+	}
+
+	return httpUtil.GetHttpUtilFromContext(ctx)
+}
+
+func (o CredentialOptions) tokenEndpoint(tenantId string) string {
+	return fmt.Sprintf("%s/%s/oauth2/v2.0/token", o.AuthorityHost, url.PathEscape(tenantId))
+}
+
+// cachedToken returns the cached access token of the key, unless it is about to expire
+func (o CredentialOptions) cachedToken(key string) (AccessToken, bool) {
+	if o.Cache == nil {
+		return AccessToken{}, false
+	}
+
+	entry, has := o.Cache.get(key)
+	if !has || entry.AccessToken == "" || !o.now().Add(tokenRefreshWindow).Before(entry.ExpiresOn) {
+		return AccessToken{}, false
+	}
+
+	return AccessToken{Token: entry.AccessToken, ExpiresOn: entry.ExpiresOn}, true
+}
+
+func (o CredentialOptions) cacheToken(key string, token AccessToken, refreshToken string) error {
+	if o.Cache == nil {
+		return nil
+	}
+
+	return o.Cache.set(key, cacheEntry{AccessToken: token.Token, ExpiresOn: token.ExpiresOn, RefreshToken: refreshToken})
+}
+
+// tokenResponse is the response of the token endpoints of Azure Active Directory & of the managed identity endpoint
+type tokenResponse struct {
+	AccessToken  string      `json:"access_token"`
+	RefreshToken string      `json:"refresh_token"`
+	ExpiresIn    flexibleInt `json:"expires_in"`
+	// ExpiresOn is only returned by the managed identity endpoint, in seconds since the epoch
+	ExpiresOn        flexibleInt `json:"expires_on"`
+	Error            string      `json:"error"`
+	ErrorDescription string      `json:"error_description"`
+}
+
+func (r tokenResponse) token(now time.Time) AccessToken {
+	expiresOn := now.Add(time.Duration(r.ExpiresIn) * time.Second)
+	if r.ExpiresIn == 0 && r.ExpiresOn != 0 {
+		expiresOn = time.Unix(int64(r.ExpiresOn), 0)
+	}
+
+	return AccessToken{Token: r.AccessToken, ExpiresOn: expiresOn.UTC()}
+}
+
+// flexibleInt is a number the endpoints return either as a JSON number or as a string
+type flexibleInt int64
+
+func (i *flexibleInt) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		*i = 0
+		return nil
+	}
+
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s: %w", string(data), err)
+	}
+
+	*i = flexibleInt(value)
+	return nil
+}
+
+// postForm sends a form to an endpoint of Azure Active Directory. The errors returned by the endpoint
+// are returned as *AuthenticationError.
+func (o CredentialOptions) postForm(ctx context.Context, endpoint string, form url.Values, response any) error {
+	res, err := o.httpClient(ctx).Send(&httpUtil.HttpRequestMessage{
+		Url:     endpoint,
+		Method:  http.MethodPost,
+		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		Body:    form.Encode(),
+	})
+	if err != nil {
+		return fmt.Errorf("requesting token: %w", err)
+	}
+
+	return parseResponse(res, response)
+}
+
+func parseResponse(res *httpUtil.HttpResponseMessage, response any) error {
+	if res.Status != http.StatusOK {
+		var errorResponse tokenResponse
+		if err := json.Unmarshal(res.Body, &errorResponse); err == nil && errorResponse.Error != "" {
+			return &AuthenticationError{Code: errorResponse.Error, Description: errorResponse.ErrorDescription}
+		}
+
+		return fmt.Errorf("requesting token failed with status %d: %s", res.Status, string(res.Body))
+	}
+
+	if err := json.Unmarshal(res.Body, response); err != nil {
+		return fmt.Errorf("unable to unmarshal token response: %w", err)
+	}
+
+	return nil
+}
+
+// requestToken requests a token from the token endpoint of the tenant
+func (o CredentialOptions) requestToken(ctx context.Context, tenantId string, form url.Values) (tokenResponse, error) {
+	var response tokenResponse
+	if err := o.postForm(ctx, o.tokenEndpoint(tenantId), form, &response); err != nil {
+		return tokenResponse{}, err
+	}
+
+	if response.AccessToken == "" {
+		return tokenResponse{}, errors.New("the token response has no access token")
+	}
+
+	return response, nil
+}
+
+// IsBuiltInEnabled returns true when azd authenticates with its own credentials rather than with the Azure CLI,
+// see the auth.mode setting of the user configuration
+func IsBuiltInEnabled() bool {
+	return config.Resolve(config.AuthMode, "") == config.AuthModeBuiltIn
+}
+
+func cacheKey(parts ...string) string {
+	return strings.Join(parts, "|")
+}
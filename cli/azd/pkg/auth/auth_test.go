@@ -0,0 +1,420 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/httpUtil"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer is a stand-in for the token endpoints of Azure Active Directory & of managed identities
+type tokenServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+	forms    []url.Values
+}
+
+func newTokenServer(t *testing.T, handler func(r *http.Request, form url.Values) (int, any)) *tokenServer {
+	server := &tokenServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+
+		server.mu.Lock()
+		server.requests = append(server.requests, r)
+		server.forms = append(server.forms, r.PostForm)
+		server.mu.Unlock()
+
+		status, body := handler(r, r.PostForm)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		require.NoError(t, json.NewEncoder(w).Encode(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (s *tokenServer) options(t *testing.T) CredentialOptions {
+	return CredentialOptions{
+		AuthorityHost: s.URL,
+		HttpClient:    httpUtil.NewHttpUtil(),
+		Cache:         NewTokenCache(filepath.Join(t.TempDir(), "tokens.json")),
+	}
+}
+
+func (s *tokenServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+func tokenBody(token string) map[string]any {
+	return map[string]any{"access_token": token, "expires_in": 3600, "token_type": "Bearer"}
+}
+
+func TestClientSecretCredential(t *testing.T) {
+	server := newTokenServer(t, func(r *http.Request, form url.Values) (int, any) {
+		require.Equal(t, "/tenant/oauth2/v2.0/token", r.URL.Path)
+		require.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+
+		if form.Get("client_secret") != "secret" {
+			return http.StatusUnauthorized, map[string]any{"error": "invalid_client", "error_description": "AADSTS7000215"}
+		}
+
+		return http.StatusOK, tokenBody("sp-token")
+	})
+
+	credential := NewClientSecretCredential("tenant", "client", "secret", server.options(t))
+	token, err := credential.GetToken(context.Background(), ArmScope)
+	require.NoError(t, err)
+	require.Equal(t, "sp-token", token.Token)
+	require.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresOn, time.Minute)
+
+	form := server.forms[0]
+	require.Equal(t, "client_credentials", form.Get("grant_type"))
+	require.Equal(t, "client", form.Get("client_id"))
+	require.Equal(t, ArmScope, form.Get("scope"))
+
+	// The token is cached
+	_, err = credential.GetToken(context.Background(), ArmScope)
+	require.NoError(t, err)
+	require.Equal(t, 1, server.requestCount())
+
+	_, err = NewClientSecretCredential("tenant", "client", "wrong", server.options(t)).GetToken(context.Background(), ArmScope)
+	var authErr *AuthenticationError
+	require.ErrorAs(t, err, &authErr)
+	require.Equal(t, "invalid_client", authErr.Code)
+}
+
+func TestClientCertificateCredential(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "azd-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certificateBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	certificatePath := filepath.Join(t.TempDir(), "certificate.pem")
+	contents := append(
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateBytes})...,
+	)
+	require.NoError(t, os.WriteFile(certificatePath, contents, 0600))
+
+	var server *tokenServer
+	server = newTokenServer(t, func(r *http.Request, form url.Values) (int, any) {
+		require.Equal(t, clientAssertionType, form.Get("client_assertion_type"))
+		require.Empty(t, form.Get("client_secret"))
+
+		parts := strings.Split(form.Get("client_assertion"), ".")
+		require.Len(t, parts, 3)
+
+		var header map[string]string
+		headerJson, err := base64.RawURLEncoding.DecodeString(parts[0])
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(headerJson, &header))
+		thumbprint := sha1.Sum(certificateBytes)
+		require.Equal(t, base64.RawURLEncoding.EncodeToString(thumbprint[:]), header["x5t"])
+
+		var claims map[string]any
+		claimsJson, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(claimsJson, &claims))
+		require.Equal(t, "client", claims["iss"])
+		require.Equal(t, server.URL+"/tenant/oauth2/v2.0/token", claims["aud"])
+
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+
+		return http.StatusOK, tokenBody("certificate-token")
+	})
+
+	credential, err := NewClientCertificateCredential("tenant", "client", certificatePath, server.options(t))
+	require.NoError(t, err)
+
+	token, err := credential.GetToken(context.Background(), ArmScope)
+	require.NoError(t, err)
+	require.Equal(t, "certificate-token", token.Token)
+
+	invalidPath := filepath.Join(t.TempDir(), "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateBytes}), 0600))
+	_, err = NewClientCertificateCredential("tenant", "client", invalidPath, server.options(t))
+	require.ErrorContains(t, err, "no private key found")
+}
+
+func TestWorkloadIdentityCredential(t *testing.T) {
+	server := newTokenServer(t, func(r *http.Request, form url.Values) (int, any) {
+		require.Equal(t, clientAssertionType, form.Get("client_assertion_type"))
+		return http.StatusOK, tokenBody("workload-" + form.Get("client_assertion"))
+	})
+
+	tokenFilePath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFilePath, []byte("federated-1\n"), 0600))
+
+	options := server.options(t)
+	options.Cache = nil
+	credential := NewWorkloadIdentityCredential("tenant", "client", tokenFilePath, options)
+
+	token, err := credential.GetToken(context.Background(), ArmScope)
+	require.NoError(t, err)
+	require.Equal(t, "workload-federated-1", token.Token)
+
+	// The token file is rotated by the identity provider
+	require.NoError(t, os.WriteFile(tokenFilePath, []byte("federated-2"), 0600))
+	token, err = credential.GetToken(context.Background(), ArmScope)
+	require.NoError(t, err)
+	require.Equal(t, "workload-federated-2", token.Token)
+}
+
+func TestManagedIdentityCredential(t *testing.T) {
+	expiresOn := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	server := newTokenServer(t, func(r *http.Request, _ url.Values) (int, any) {
+		require.Equal(t, http.MethodGet, r.Method)
+		if r.Header.Get("X-IDENTITY-HEADER") != "identity-secret" {
+			return http.StatusUnauthorized, map[string]any{"error": "invalid_request"}
+		}
+
+		require.Equal(t, managedIdentityApiVersion, r.URL.Query().Get("api-version"))
+		require.Equal(t, "https://management.azure.com/", r.URL.Query().Get("resource"))
+		require.Equal(t, "user-assigned", r.URL.Query().Get("client_id"))
+
+		// The managed identity endpoint returns the expiration as a string
+		return http.StatusOK, map[string]any{
+			"access_token": "identity-token",
+			"expires_on":   expiresOn.Unix(),
+			"resource":     "https://management.azure.com/",
+		}
+	})
+
+	credential := NewManagedIdentityCredential(server.URL+"/msi/token", "identity-secret", "user-assigned", server.options(t))
+	token, err := credential.GetToken(context.Background(), ArmScope)
+	require.NoError(t, err)
+	require.Equal(t, "identity-token", token.Token)
+	require.True(t, expiresOn.Equal(token.ExpiresOn))
+
+	_, err = NewManagedIdentityCredential(server.URL+"/msi/token", "wrong", "user-assigned", server.options(t)).
+		GetToken(context.Background(), ArmScope)
+	require.Error(t, err)
+}
+
+func TestDeviceCodeCredential(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	refreshes := 0
+
+	server := newTokenServer(t, func(r *http.Request, form url.Values) (int, any) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if strings.HasSuffix(r.URL.Path, "/devicecode") {
+			require.Equal(t, DefaultClientId, form.Get("client_id"))
+			require.Equal(t, ArmScope+" offline_access", form.Get("scope"))
+			return http.StatusOK, map[string]any{
+				"device_code":      "device",
+				"user_code":        "ABC123",
+				"verification_uri": "https://microsoft.com/devicelogin",
+				"expires_in":       900,
+				"interval":         0,
+				"message":          "Enter ABC123",
+			}
+		}
+
+		switch form.Get("grant_type") {
+		case deviceCodeGrantType:
+			require.Equal(t, "device", form.Get("device_code"))
+			polls++
+			if polls < 3 {
+				return http.StatusBadRequest, map[string]any{"error": "authorization_pending"}
+			}
+
+			return http.StatusOK, map[string]any{"access_token": "user-token", "refresh_token": "refresh-1", "expires_in": 3600}
+		case "refresh_token":
+			refreshes++
+			if form.Get("refresh_token") != "refresh-1" {
+				return http.StatusBadRequest, map[string]any{"error": "invalid_grant", "error_description": "AADSTS700082"}
+			}
+
+			return http.StatusOK, map[string]any{"access_token": "refreshed-token", "refresh_token": "refresh-2", "expires_in": 3600}
+		}
+
+		return http.StatusBadRequest, map[string]any{"error": "unsupported_grant_type"}
+	})
+
+	options := server.options(t)
+	credential := NewDeviceCodeCredential("", "", options)
+
+	_, err := credential.GetToken(context.Background(), ArmScope)
+	require.ErrorIs(t, err, ErrCredentialUnavailable)
+
+	var out strings.Builder
+	require.NoError(t, credential.Login(context.Background(), ArmScope, &out))
+	require.Equal(t, "Enter ABC123\n", out.String())
+	require.Equal(t, 3, polls)
+
+	token, err := credential.GetToken(context.Background(), ArmScope)
+	require.NoError(t, err)
+	require.Equal(t, "user-token", token.Token)
+
+	// A token for another scope is requested with the refresh token of the user
+	token, err = credential.GetToken(context.Background(), "https://graph.microsoft.com/.default")
+	require.NoError(t, err)
+	require.Equal(t, "refreshed-token", token.Token)
+	require.Equal(t, 1, refreshes)
+
+	// The refresh token was rotated, refresh-1 was replaced by refresh-2 which the stand-in rejects
+	_, err = credential.GetToken(context.Background(), "https://vault.azure.net/.default")
+	require.ErrorIs(t, err, ErrCredentialUnavailable)
+
+	_, err = credential.GetToken(context.Background(), "https://storage.azure.com/.default")
+	require.ErrorIs(t, err, ErrCredentialUnavailable)
+	require.Equal(t, 2, refreshes)
+
+	require.NoError(t, credential.Logout())
+	_, err = credential.GetToken(context.Background(), ArmScope)
+	require.ErrorIs(t, err, ErrCredentialUnavailable)
+}
+
+type fakeCredential struct {
+	token string
+	err   error
+	calls int
+}
+
+func (c *fakeCredential) GetToken(context.Context, string) (AccessToken, error) {
+	c.calls++
+	return AccessToken{Token: c.token}, c.err
+}
+
+func TestChainedCredential(t *testing.T) {
+	unavailable := &fakeCredential{err: ErrCredentialUnavailable}
+	available := &fakeCredential{token: "token"}
+	last := &fakeCredential{token: "last"}
+
+	chain := NewChainedCredential(unavailable, available, last)
+	for i := 0; i < 2; i++ {
+		token, err := chain.GetToken(context.Background(), ArmScope)
+		require.NoError(t, err)
+		require.Equal(t, "token", token.Token)
+	}
+
+	// The selected credential is reused
+	require.Equal(t, 1, unavailable.calls)
+	require.Equal(t, 2, available.calls)
+	require.Equal(t, 0, last.calls)
+
+	failing := &fakeCredential{err: errors.New("invalid certificate")}
+	_, err := NewChainedCredential(unavailable, failing, last).GetToken(context.Background(), ArmScope)
+	require.EqualError(t, err, "invalid certificate")
+	require.Equal(t, 0, last.calls)
+
+	_, err = NewChainedCredential(unavailable).GetToken(context.Background(), ArmScope)
+	require.ErrorIs(t, err, ErrCredentialUnavailable)
+}
+
+func TestDefaultCredentialFromEnvironment(t *testing.T) {
+	server := newTokenServer(t, func(r *http.Request, form url.Values) (int, any) {
+		if form.Get("client_assertion") != "" {
+			return http.StatusOK, tokenBody("workload-token")
+		}
+
+		return http.StatusOK, tokenBody("secret-token")
+	})
+
+	tokenFilePath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFilePath, []byte("federated"), 0600))
+
+	t.Setenv(TenantIdEnvVarName, "tenant")
+	t.Setenv(ClientIdEnvVarName, "client")
+	t.Setenv(ClientSecretEnvVarName, "")
+	t.Setenv(ClientCertificatePathEnvVarName, "")
+	t.Setenv(FederatedTokenFileEnvVarName, tokenFilePath)
+	t.Setenv(IdentityEndpointEnvVarName, "")
+	t.Setenv(IdentityHeaderEnvVarName, "")
+
+	source := NewArmTokenSource(NewDefaultCredential(server.options(t)))
+	token, err := source.GetToken(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "workload-token", token.AccessToken)
+	require.NotNil(t, token.ExpiresOn)
+
+	// The service principal of the environment comes first
+	t.Setenv(ClientSecretEnvVarName, "secret")
+	token, err = NewArmTokenSource(NewDefaultCredential(server.options(t))).GetToken(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "secret-token", token.AccessToken)
+}
+
+func TestTokenCacheExpiration(t *testing.T) {
+	now := time.Now()
+	options := CredentialOptions{Cache: NewTokenCache(filepath.Join(t.TempDir(), "tokens.json")), now: func() time.Time { return now }}
+
+	require.NoError(t, options.cacheToken("key", AccessToken{Token: "token", ExpiresOn: now.Add(time.Hour)}, ""))
+	token, has := options.cachedToken("key")
+	require.True(t, has)
+	require.Equal(t, "token", token.Token)
+
+	// Tokens about to expire are not used
+	now = now.Add(time.Hour - time.Minute)
+	_, has = options.cachedToken("key")
+	require.False(t, has)
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(options.Cache.path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+}
+
+func TestTokenCacheRestrictsExistingFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not enforced on windows")
+	}
+
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	require.NoError(t, os.WriteFile(path, []byte("{}"), 0644))
+	require.NoError(t, os.Chmod(path, 0644))
+
+	require.NoError(t, NewTokenCache(path).set("key", cacheEntry{AccessToken: "token"}))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
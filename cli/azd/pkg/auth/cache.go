@@ -0,0 +1,139 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/config"
+	"github.com/azure/azure-dev/cli/azd/pkg/osutil"
+)
+
+// The file caching the tokens, in the auth folder of the azd configuration directory
+const tokenCacheFileName = "tokens.json"
+
+// TokenCache stores access tokens & refresh tokens in a file only readable by the current user
+type TokenCache struct {
+	path string
+	mu   sync.Mutex
+}
+
+type cacheEntry struct {
+	AccessToken  string    `json:"accessToken,omitempty"`
+	ExpiresOn    time.Time `json:"expiresOn,omitempty"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+}
+
+// NewTokenCache creates a cache storing the tokens in the file at path
+func NewTokenCache(path string) *TokenCache {
+	return &TokenCache{path: path}
+}
+
+// NewUserTokenCache creates a cache storing the tokens in the azd configuration directory of the current user
+func NewUserTokenCache() (*TokenCache, error) {
+	configDir, err := config.UserDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTokenCache(filepath.Join(configDir, "auth", tokenCacheFileName)), nil
+}
+
+// Clear removes all the tokens of the cache, signing out the user
+func (c *TokenCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing token cache: %w", err)
+	}
+
+	return nil
+}
+
+func (c *TokenCache) get(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		return cacheEntry{}, false
+	}
+
+	entry, has := entries[key]
+	return entry, has
+}
+
+func (c *TokenCache) set(key string, entry cacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		// A corrupted cache is replaced
+		entries = map[string]cacheEntry{}
+	}
+
+	entries[key] = entry
+	return c.write(entries)
+}
+
+func (c *TokenCache) remove(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		return nil
+	}
+
+	delete(entries, key)
+	return c.write(entries)
+}
+
+func (c *TokenCache) read() (map[string]cacheEntry, error) {
+	contents, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]cacheEntry{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading token cache: %w", err)
+	}
+
+	entries := map[string]cacheEntry{}
+	if err := json.Unmarshal(contents, &entries); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal token cache: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (c *TokenCache) write(entries map[string]cacheEntry) error {
+	contents, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("marshalling token cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), osutil.PermissionDirectory); err != nil {
+		return fmt.Errorf("creating token cache directory: %w", err)
+	}
+
+	// The tokens are secrets, only the current user can read them. WriteFile only applies the permissions to new
+	// files, those of an existing cache are restricted as well.
+	if err := os.WriteFile(c.path, contents, 0600); err != nil {
+		return fmt.Errorf("writing token cache: %w", err)
+	}
+
+	if err := os.Chmod(c.path, 0600); err != nil {
+		return fmt.Errorf("restricting token cache permissions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+)
+
+// ChainedCredential gets tokens from the first of its credentials which is available. Once a credential
+// returned a token, it is used for all the following requests.
+type ChainedCredential struct {
+	sources []Credential
+
+	mu       sync.Mutex
+	selected Credential
+}
+
+func NewChainedCredential(sources ...Credential) *ChainedCredential {
+	return &ChainedCredential{sources: sources}
+}
+
+func (c *ChainedCredential) GetToken(ctx context.Context, scope string) (AccessToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.selected != nil {
+		return c.selected.GetToken(ctx, scope)
+	}
+
+	var unavailable []string
+	for _, source := range c.sources {
+		token, err := source.GetToken(ctx, scope)
+		if errors.Is(err, ErrCredentialUnavailable) {
+			unavailable = append(unavailable, err.Error())
+			continue
+		} else if err != nil {
+			return AccessToken{}, err
+		}
+
+		c.selected = source
+		return token, nil
+	}
+
+	return AccessToken{}, fmt.Errorf("%w: no credential is available: %s", ErrCredentialUnavailable, strings.Join(unavailable, "; "))
+}
+
+// unavailableCredential is a credential which is not configured
+type unavailableCredential struct {
+	message string
+}
+
+func (c unavailableCredential) GetToken(context.Context, string) (AccessToken, error) {
+	return AccessToken{}, fmt.Errorf("%w: %s", ErrCredentialUnavailable, c.message)
+}
+
+// invalidCredential is a credential which is configured, but can't be used, ex) the certificate is invalid
+type invalidCredential struct {
+	err error
+}
+
+func (c invalidCredential) GetToken(context.Context, string) (AccessToken, error) {
+	return AccessToken{}, c.err
+}
+
+// NewEnvironmentCredential creates a credential for the service principal configured by the environment variables
+// AZURE_TENANT_ID, AZURE_CLIENT_ID, and AZURE_CLIENT_SECRET or AZURE_CLIENT_CERTIFICATE_PATH
+func NewEnvironmentCredential(options CredentialOptions) Credential {
+	tenantId, clientId := os.Getenv(TenantIdEnvVarName), os.Getenv(ClientIdEnvVarName)
+	if tenantId == "" || clientId == "" {
+		return unavailableCredential{
+			message: fmt.Sprintf("the service principal is not configured, %s and %s are not set", TenantIdEnvVarName, ClientIdEnvVarName),
+		}
+	}
+
+	if secret := os.Getenv(ClientSecretEnvVarName); secret != "" {
+		return NewClientSecretCredential(tenantId, clientId, secret, options)
+	}
+
+	if certificatePath := os.Getenv(ClientCertificatePathEnvVarName); certificatePath != "" {
+		credential, err := NewClientCertificateCredential(tenantId, clientId, certificatePath, options)
+		if err != nil {
+			return invalidCredential{err: err}
+		}
+
+		return credential
+	}
+
+	return unavailableCredential{
+		message: fmt.Sprintf(
+			"the service principal is not configured, %s and %s are not set",
+			ClientSecretEnvVarName,
+			ClientCertificatePathEnvVarName,
+		),
+	}
+}
+
+// NewWorkloadIdentityCredentialFromEnvironment creates a workload identity credential configured by the environment
+// variables AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_FEDERATED_TOKEN_FILE
+func NewWorkloadIdentityCredentialFromEnvironment(options CredentialOptions) Credential {
+	tenantId, clientId := os.Getenv(TenantIdEnvVarName), os.Getenv(ClientIdEnvVarName)
+	tokenFilePath := os.Getenv(FederatedTokenFileEnvVarName)
+	if tenantId == "" || clientId == "" || tokenFilePath == "" {
+		return unavailableCredential{
+			message: fmt.Sprintf(
+				"the workload identity is not configured, %s, %s and %s are required",
+				TenantIdEnvVarName,
+				ClientIdEnvVarName,
+				FederatedTokenFileEnvVarName,
+			),
+		}
+	}
+
+	return NewWorkloadIdentityCredential(tenantId, clientId, tokenFilePath, options)
+}
+
+// NewManagedIdentityCredentialFromEnvironment creates a managed identity credential for the endpoint configured by
+// the environment variables IDENTITY_ENDPOINT and IDENTITY_HEADER. AZURE_CLIENT_ID selects a user-assigned identity.
+func NewManagedIdentityCredentialFromEnvironment(options CredentialOptions) Credential {
+	endpoint, secret := os.Getenv(IdentityEndpointEnvVarName), os.Getenv(IdentityHeaderEnvVarName)
+	if endpoint == "" || secret == "" {
+		return unavailableCredential{
+			message: fmt.Sprintf(
+				"no managed identity endpoint, %s and %s are not set",
+				IdentityEndpointEnvVarName,
+				IdentityHeaderEnvVarName,
+			),
+		}
+	}
+
+	return NewManagedIdentityCredential(endpoint, secret, os.Getenv(ClientIdEnvVarName), options)
+}
+
+// NewUserCredential creates the credential of the users signed in with azd login, in the tenant set by
+// AZURE_TENANT_ID, if any
+func NewUserCredential(options CredentialOptions) *DeviceCodeCredential {
+	return NewDeviceCodeCredential(os.Getenv(TenantIdEnvVarName), DefaultClientId, options)
+}
+
+// NewDefaultCredential creates the chain of the credentials azd authenticates with, in order: the service principal
+// of the environment, the workload identity, the managed identity and the user signed in with azd login.
+// The tokens are cached in the azd configuration directory of the current user.
+func NewDefaultCredential(options CredentialOptions) *ChainedCredential {
+	if options.Cache == nil {
+		cache, err := NewUserTokenCache()
+		if err != nil {
+			log.Printf("%v, tokens will not be cached", err)
+		}
+		options.Cache = cache
+	}
+
+	return NewChainedCredential(
+		NewEnvironmentCredential(options),
+		NewWorkloadIdentityCredentialFromEnvironment(options),
+		NewManagedIdentityCredentialFromEnvironment(options),
+		NewUserCredential(options),
+	)
+}
+
+// NewArmTokenSource adapts a credential to the token source of the Azure Resource Manager client
+func NewArmTokenSource(credential Credential) azcli.TokenSource {
+	return NewTokenSource(credential, ArmScope)
+}
+
+// NewTokenSource adapts a credential to a token source of the tokens for the scope, ex) KeyVaultScope
+func NewTokenSource(credential Credential, scope string) azcli.TokenSource {
+	return azcli.TokenSourceFunc(func(ctx context.Context) (azcli.AzCliAccessToken, error) {
+		token, err := credential.GetToken(ctx, scope)
+		if err != nil {
+			return azcli.AzCliAccessToken{}, err
+		}
+
+		return azcli.AzCliAccessToken{AccessToken: token.Token, ExpiresOn: &token.ExpiresOn}, nil
+	})
+}
@@ -0,0 +1,227 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// clientCredentials requests tokens of an application with the client credentials grant. The application
+// authenticates with a secret or with a signed assertion.
+type clientCredentials struct {
+	kind     string
+	tenantId string
+	clientId string
+	options  CredentialOptions
+	// authenticate adds the secret or the assertion authenticating the application to the form
+	authenticate func(form url.Values) error
+}
+
+func (c *clientCredentials) GetToken(ctx context.Context, scope string) (AccessToken, error) {
+	key := cacheKey(c.kind, c.tenantId, c.clientId, scope)
+	if token, has := c.options.cachedToken(key); has {
+		return token, nil
+	}
+
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {c.clientId},
+		"scope":      {scope},
+	}
+
+	if err := c.authenticate(form); err != nil {
+		return AccessToken{}, err
+	}
+
+	response, err := c.options.requestToken(ctx, c.tenantId, form)
+	if err != nil {
+		return AccessToken{}, err
+	}
+
+	token := response.token(c.options.now())
+	if err := c.options.cacheToken(key, token, ""); err != nil {
+		return AccessToken{}, err
+	}
+
+	return token, nil
+}
+
+// NewClientSecretCredential creates a credential authenticating a service principal with a client secret
+func NewClientSecretCredential(tenantId string, clientId string, secret string, options CredentialOptions) Credential {
+	return &clientCredentials{
+		kind:     "client-secret",
+		tenantId: tenantId,
+		clientId: clientId,
+		options:  options.withDefaults(),
+		authenticate: func(form url.Values) error {
+			form.Set("client_secret", secret)
+			return nil
+		},
+	}
+}
+
+// NewClientCertificateCredential creates a credential authenticating a service principal with a certificate.
+// The PEM file holds the certificate and its unencrypted RSA private key.
+func NewClientCertificateCredential(tenantId string, clientId string, certificatePath string, options CredentialOptions) (Credential, error) {
+	contents, err := os.ReadFile(certificatePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading client certificate: %w", err)
+	}
+
+	certificate, key, err := parseCertificate(contents)
+	if err != nil {
+		return nil, fmt.Errorf("parsing client certificate %s: %w", certificatePath, err)
+	}
+
+	options = options.withDefaults()
+	return &clientCredentials{
+		kind:     "client-certificate",
+		tenantId: tenantId,
+		clientId: clientId,
+		options:  options,
+		authenticate: func(form url.Values) error {
+			assertion, err := newClientAssertion(certificate, key, clientId, options.tokenEndpoint(tenantId), options.now())
+			if err != nil {
+				return err
+			}
+
+			form.Set("client_assertion_type", clientAssertionType)
+			form.Set("client_assertion", assertion)
+			return nil
+		},
+	}, nil
+}
+
+// NewWorkloadIdentityCredential creates a credential authenticating an application with a token of a federated
+// identity provider, ex) the service account token of a Kubernetes pod or the OIDC token of a GitHub Actions job.
+// The token file is read for every request, since the provider rotates it.
+func NewWorkloadIdentityCredential(tenantId string, clientId string, tokenFilePath string, options CredentialOptions) Credential {
+	return &clientCredentials{
+		kind:     "workload-identity",
+		tenantId: tenantId,
+		clientId: clientId,
+		options:  options.withDefaults(),
+		authenticate: func(form url.Values) error {
+			assertion, err := os.ReadFile(tokenFilePath)
+			if err != nil {
+				return fmt.Errorf("reading federated token: %w", err)
+			}
+
+			form.Set("client_assertion_type", clientAssertionType)
+			form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+			return nil
+		},
+	}
+}
+
+func parseCertificate(contents []byte) (*x509.Certificate, *rsa.PrivateKey, error) {
+	var certificate *x509.Certificate
+	var key *rsa.PrivateKey
+
+	for {
+		var block *pem.Block
+		block, contents = pem.Decode(contents)
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "CERTIFICATE":
+			// The first certificate is the certificate of the application, the others are its chain
+			if certificate == nil {
+				parsed, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					return nil, nil, err
+				}
+				certificate = parsed
+			}
+		case "RSA PRIVATE KEY":
+			parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			key = parsed
+		case "PRIVATE KEY":
+			parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			rsaKey, isRsa := parsed.(*rsa.PrivateKey)
+			if !isRsa {
+				return nil, nil, errors.New("only RSA private keys are supported")
+			}
+			key = rsaKey
+		}
+	}
+
+	if certificate == nil {
+		return nil, nil, errors.New("no certificate found")
+	}
+
+	if key == nil {
+		return nil, nil, errors.New("no private key found")
+	}
+
+	return certificate, key, nil
+}
+
+// newClientAssertion creates a JWT signed with the private key of the certificate, proving the application owns it
+func newClientAssertion(certificate *x509.Certificate, key *rsa.PrivateKey, clientId string, audience string, now time.Time) (string, error) {
+	thumbprint := sha1.Sum(certificate.Raw)
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"x5t": base64.RawURLEncoding.EncodeToString(thumbprint[:]),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]any{
+		"aud": audience,
+		"iss": clientId,
+		"sub": clientId,
+		"jti": hex.EncodeToString(jti),
+		"nbf": now.Unix(),
+		"exp": now.Add(10 * time.Minute).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing client assertion: %w", err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
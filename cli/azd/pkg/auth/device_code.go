@@ -0,0 +1,194 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+)
+
+// DefaultClientId is the public client application users sign in to, the application of the Azure CLI
+const DefaultClientId = "04b07795-8ddb-461a-bbee-02f9e1bf7b46"
+
+// DefaultTenantId lets users of any organization sign in
+const DefaultTenantId = "organizations"
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// slowDownInterval is added to the polling interval when the token endpoint asks to slow down
+const slowDownInterval = 5 * time.Second
+
+// DeviceCodeCredential signs in users with the device code flow: the user enters a code in a browser, on any device.
+// The refresh token of the user is cached, so the user stays signed in until the token expires or is revoked.
+type DeviceCodeCredential struct {
+	tenantId string
+	clientId string
+	options  CredentialOptions
+}
+
+type deviceCodeResponse struct {
+	DeviceCode      string      `json:"device_code"`
+	UserCode        string      `json:"user_code"`
+	VerificationUri string      `json:"verification_uri"`
+	ExpiresIn       flexibleInt `json:"expires_in"`
+	Interval        flexibleInt `json:"interval"`
+	Message         string      `json:"message"`
+}
+
+// NewDeviceCodeCredential creates a credential for the users of the tenant, signing in to the public client application
+func NewDeviceCodeCredential(tenantId string, clientId string, options CredentialOptions) *DeviceCodeCredential {
+	if tenantId == "" {
+		tenantId = DefaultTenantId
+	}
+
+	if clientId == "" {
+		clientId = DefaultClientId
+	}
+
+	return &DeviceCodeCredential{
+		tenantId: tenantId,
+		clientId: clientId,
+		options:  options.withDefaults(),
+	}
+}
+
+// Login signs in a user, writing the instructions of the device code flow to out, and caches the tokens of the user
+func (c *DeviceCodeCredential) Login(ctx context.Context, scope string, out io.Writer) error {
+	var deviceCode deviceCodeResponse
+	if err := c.options.postForm(
+		ctx,
+		fmt.Sprintf("%s/%s/oauth2/v2.0/devicecode", c.options.AuthorityHost, url.PathEscape(c.tenantId)),
+		url.Values{
+			"client_id": {c.clientId},
+			"scope":     {scope + " offline_access"},
+		},
+		&deviceCode,
+	); err != nil {
+		return fmt.Errorf("requesting device code: %w", err)
+	}
+
+	message := deviceCode.Message
+	if message == "" {
+		message = fmt.Sprintf(
+			"To sign in, use a web browser to open the page %s and enter the code %s to authenticate.",
+			deviceCode.VerificationUri,
+			deviceCode.UserCode,
+		)
+	}
+	fmt.Fprintln(out, message)
+
+	interval := time.Duration(deviceCode.Interval) * time.Second
+	expiresOn := c.options.now().Add(time.Duration(deviceCode.ExpiresIn) * time.Second)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		response, err := c.options.requestToken(ctx, c.tenantId, url.Values{
+			"grant_type":  {deviceCodeGrantType},
+			"client_id":   {c.clientId},
+			"device_code": {deviceCode.DeviceCode},
+		})
+
+		var authErr *AuthenticationError
+		switch {
+		case errors.As(err, &authErr) && authErr.Code == "authorization_pending":
+			if c.options.now().After(expiresOn) {
+				return errors.New("the device code expired before the user signed in")
+			}
+			continue
+		case errors.As(err, &authErr) && authErr.Code == "slow_down":
+			interval += slowDownInterval
+			continue
+		case err != nil:
+			return fmt.Errorf("signing in: %w", err)
+		}
+
+		return c.saveTokens(scope, response)
+	}
+}
+
+// Logout removes the cached tokens of the user
+func (c *DeviceCodeCredential) Logout() error {
+	if c.options.Cache == nil {
+		return nil
+	}
+
+	return c.options.Cache.Clear()
+}
+
+// GetToken returns a token of the signed in user, redeeming the cached refresh token of the user when needed.
+// ErrCredentialUnavailable is returned when no user is signed in.
+func (c *DeviceCodeCredential) GetToken(ctx context.Context, scope string) (AccessToken, error) {
+	if token, has := c.options.cachedToken(c.tokenKey(scope)); has {
+		return token, nil
+	}
+
+	var refreshToken string
+	if c.options.Cache != nil {
+		if entry, has := c.options.Cache.get(c.userKey()); has {
+			refreshToken = entry.RefreshToken
+		}
+	}
+
+	if refreshToken == "" {
+		return AccessToken{}, fmt.Errorf("%w: no user is logged in, run azd login", ErrCredentialUnavailable)
+	}
+
+	response, err := c.options.requestToken(ctx, c.tenantId, url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {c.clientId},
+		"refresh_token": {refreshToken},
+		"scope":         {scope + " offline_access"},
+	})
+
+	var authErr *AuthenticationError
+	if errors.As(err, &authErr) && authErr.Code == "invalid_grant" {
+		// The refresh token expired or was revoked, the user has to sign in again
+		if removeErr := c.options.Cache.remove(c.userKey()); removeErr != nil {
+			return AccessToken{}, removeErr
+		}
+
+		return AccessToken{}, fmt.Errorf("%w: the login expired, run azd login: %v", ErrCredentialUnavailable, err)
+	} else if err != nil {
+		return AccessToken{}, err
+	}
+
+	if err := c.saveTokens(scope, response); err != nil {
+		return AccessToken{}, err
+	}
+
+	return response.token(c.options.now()), nil
+}
+
+func (c *DeviceCodeCredential) saveTokens(scope string, response tokenResponse) error {
+	token := response.token(c.options.now())
+	if err := c.options.cacheToken(c.tokenKey(scope), token, ""); err != nil {
+		return err
+	}
+
+	// Refresh tokens are rotated, the latest one is kept
+	if response.RefreshToken != "" && c.options.Cache != nil {
+		if err := c.options.Cache.set(c.userKey(), cacheEntry{RefreshToken: response.RefreshToken}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *DeviceCodeCredential) userKey() string {
+	return cacheKey("user", c.tenantId, c.clientId)
+}
+
+func (c *DeviceCodeCredential) tokenKey(scope string) string {
+	return cacheKey("user", c.tenantId, c.clientId, scope)
+}
@@ -0,0 +1,83 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/httpUtil"
+)
+
+const managedIdentityApiVersion = "2019-08-01"
+
+type managedIdentityCredential struct {
+	endpoint string
+	secret   string
+	clientId string
+	options  CredentialOptions
+}
+
+// NewManagedIdentityCredential creates a credential requesting tokens from the managed identity endpoint of the host,
+// ex) IDENTITY_ENDPOINT & IDENTITY_HEADER in App Service, Functions & Container Apps. The client id selects a
+// user-assigned identity, the system-assigned identity is used when it is empty.
+func NewManagedIdentityCredential(endpoint string, secret string, clientId string, options CredentialOptions) Credential {
+	return &managedIdentityCredential{
+		endpoint: endpoint,
+		secret:   secret,
+		clientId: clientId,
+		options:  options.withDefaults(),
+	}
+}
+
+func (c *managedIdentityCredential) GetToken(ctx context.Context, scope string) (AccessToken, error) {
+	key := cacheKey("managed-identity", c.endpoint, c.clientId, scope)
+	if token, has := c.options.cachedToken(key); has {
+		return token, nil
+	}
+
+	query := url.Values{
+		"api-version": {managedIdentityApiVersion},
+		// The endpoint takes a resource rather than a scope
+		"resource": {strings.TrimSuffix(scope, "/.default")},
+	}
+
+	if c.clientId != "" {
+		query.Set("client_id", c.clientId)
+	}
+
+	separator := "?"
+	if strings.Contains(c.endpoint, "?") {
+		separator = "&"
+	}
+
+	res, err := c.options.httpClient(ctx).Send(&httpUtil.HttpRequestMessage{
+		Url:     c.endpoint + separator + query.Encode(),
+		Method:  http.MethodGet,
+		Headers: map[string]string{"X-IDENTITY-HEADER": c.secret},
+	})
+	if err != nil {
+		return AccessToken{}, fmt.Errorf("requesting managed identity token: %w", err)
+	}
+
+	var response tokenResponse
+	if err := parseResponse(res, &response); err != nil {
+		return AccessToken{}, fmt.Errorf("requesting managed identity token: %w", err)
+	}
+
+	if response.AccessToken == "" {
+		return AccessToken{}, errors.New("the managed identity token response has no access token")
+	}
+
+	token := response.token(c.options.now())
+	if err := c.options.cacheToken(key, token, ""); err != nil {
+		return AccessToken{}, err
+	}
+
+	return token, nil
+}
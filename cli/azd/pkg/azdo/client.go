@@ -0,0 +1,400 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azdo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/httpUtil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+)
+
+const (
+	apiVersion                     = "7.0"
+	pipelinesPermissionsApiVersion = "7.0-preview.1"
+)
+
+// PersonalAccessTokenEnvVarName is the environment variable holding the personal access token used to authenticate
+// with Azure DevOps, shared with the Azure DevOps extension of the Azure CLI.
+const PersonalAccessTokenEnvVarName = "AZURE_DEVOPS_EXT_PAT"
+
+var ErrNotFound = errors.New("azure devops resource not found")
+
+// Client manages the resources of an Azure DevOps organization used to run Azure Pipelines
+type Client interface {
+	GetProject(ctx context.Context, projectName string) (Project, error)
+	GetRepository(ctx context.Context, project Project, repositoryName string) (GitRepository, error)
+	// CreateOrUpdateServiceConnection creates an Azure Resource Manager service connection using the credentials
+	// of a service principal, or updates the credentials of the existing service connection with the same name.
+	CreateOrUpdateServiceConnection(ctx context.Context, project Project, name string, credentials azcli.AzureCredentials) (ServiceEndpoint, error)
+	// CreateOrUpdateVariableGroup creates a variable group, or replaces the variables of the existing variable group with the same name.
+	CreateOrUpdateVariableGroup(ctx context.Context, project Project, name string, variables map[string]Variable) (VariableGroup, error)
+	// AuthorizeAllPipelines allows all the pipelines of the project to use a protected resource, ex) a service connection
+	AuthorizeAllPipelines(ctx context.Context, project Project, resourceType string, resourceId string) error
+	// CreateOrGetPipeline registers a pipeline running the YAML file of the repository,
+	// or returns the existing pipeline with the same name.
+	CreateOrGetPipeline(ctx context.Context, project Project, name string, repository GitRepository, yamlPath string) (Pipeline, error)
+}
+
+type Project struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type GitRepository struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	RemoteUrl string `json:"remoteUrl"`
+	WebUrl    string `json:"webUrl"`
+}
+
+type ServiceEndpoint struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type VariableGroup struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type Variable struct {
+	Value    string `json:"value"`
+	IsSecret bool   `json:"isSecret"`
+}
+
+type Pipeline struct {
+	Id    int           `json:"id"`
+	Name  string        `json:"name"`
+	Links pipelineLinks `json:"_links"`
+}
+
+// WebUrl is the URL of the page of the pipeline in Azure DevOps
+func (p Pipeline) WebUrl() string {
+	return p.Links.Web.Href
+}
+
+type pipelineLinks struct {
+	Web struct {
+		Href string `json:"href"`
+	} `json:"web"`
+}
+
+// Resources referenced by the projects they are shared with
+type projectReference struct {
+	ProjectReference Project `json:"projectReference"`
+	Name             string  `json:"name"`
+}
+
+type serviceEndpointRequest struct {
+	Name                             string                       `json:"name"`
+	Type                             string                       `json:"type"`
+	Url                              string                       `json:"url"`
+	Authorization                    serviceEndpointAuthorization `json:"authorization"`
+	Data                             map[string]string            `json:"data"`
+	ServiceEndpointProjectReferences []projectReference           `json:"serviceEndpointProjectReferences"`
+}
+
+type serviceEndpointAuthorization struct {
+	Scheme     string            `json:"scheme"`
+	Parameters map[string]string `json:"parameters"`
+}
+
+type variableGroupRequest struct {
+	Name                           string              `json:"name"`
+	Type                           string              `json:"type"`
+	Variables                      map[string]Variable `json:"variables"`
+	VariableGroupProjectReferences []projectReference  `json:"variableGroupProjectReferences"`
+}
+
+type pipelineRequest struct {
+	Name          string                       `json:"name"`
+	Folder        string                       `json:"folder"`
+	Configuration pipelineConfigurationRequest `json:"configuration"`
+}
+
+type pipelineConfigurationRequest struct {
+	Type       string                    `json:"type"`
+	Path       string                    `json:"path"`
+	Repository pipelineRepositoryRequest `json:"repository"`
+}
+
+type pipelineRepositoryRequest struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type listResponse[T any] struct {
+	Count int `json:"count"`
+	Value []T `json:"value"`
+}
+
+type NewClientArgs struct {
+	// OrganizationUrl is the URL of the organization, ex) https://dev.azure.com/contoso
+	OrganizationUrl string
+	// PersonalAccessToken authenticates the requests
+	PersonalAccessToken string
+	// HttpClient allows us to stub out the http requests for testing.
+	// Defaults to the client found in the context of each request.
+	HttpClient httpUtil.HttpUtil
+}
+
+func NewClient(args NewClientArgs) Client {
+	return &client{
+		organizationUrl:     strings.TrimSuffix(args.OrganizationUrl, "/"),
+		personalAccessToken: args.PersonalAccessToken,
+		httpClient:          args.HttpClient,
+	}
+}
+
+type client struct {
+	organizationUrl     string
+	personalAccessToken string
+	httpClient          httpUtil.HttpUtil
+}
+
+func (c *client) GetProject(ctx context.Context, projectName string) (Project, error) {
+	var project Project
+	if err := c.get(ctx, fmt.Sprintf("/_apis/projects/%s", url.PathEscape(projectName)), &project); err != nil {
+		return Project{}, fmt.Errorf("getting project '%s': %w", projectName, err)
+	}
+
+	return project, nil
+}
+
+func (c *client) GetRepository(ctx context.Context, project Project, repositoryName string) (GitRepository, error) {
+	var repository GitRepository
+	path := fmt.Sprintf("/%s/_apis/git/repositories/%s", project.Id, url.PathEscape(repositoryName))
+	if err := c.get(ctx, path, &repository); err != nil {
+		return GitRepository{}, fmt.Errorf("getting repository '%s': %w", repositoryName, err)
+	}
+
+	return repository, nil
+}
+
+func (c *client) CreateOrUpdateServiceConnection(
+	ctx context.Context,
+	project Project,
+	name string,
+	credentials azcli.AzureCredentials,
+) (ServiceEndpoint, error) {
+	var existing listResponse[ServiceEndpoint]
+	path := fmt.Sprintf("/%s/_apis/serviceendpoint/endpoints?endpointNames=%s", project.Id, url.QueryEscape(name))
+	if err := c.get(ctx, path, &existing); err != nil {
+		return ServiceEndpoint{}, fmt.Errorf("getting service connection '%s': %w", name, err)
+	}
+
+	request := serviceEndpointRequest{
+		Name: name,
+		Type: "azurerm",
+		Url:  "https://management.azure.com/",
+		Authorization: serviceEndpointAuthorization{
+			Scheme: "ServicePrincipal",
+			Parameters: map[string]string{
+				"tenantid":            credentials.TenantId,
+				"serviceprincipalid":  credentials.ClientId,
+				"authenticationType":  "spnKey",
+				"serviceprincipalkey": credentials.ClientSecret,
+			},
+		},
+		Data: map[string]string{
+			"subscriptionId":   credentials.SubscriptionId,
+			"subscriptionName": credentials.SubscriptionId,
+			"environment":      "AzureCloud",
+			"scopeLevel":       "Subscription",
+			"creationMode":     "Manual",
+		},
+		ServiceEndpointProjectReferences: []projectReference{{ProjectReference: project, Name: name}},
+	}
+
+	var endpoint ServiceEndpoint
+	var err error
+	if len(existing.Value) > 0 {
+		err = c.send(ctx, http.MethodPut, fmt.Sprintf("/_apis/serviceendpoint/endpoints/%s", existing.Value[0].Id), request, &endpoint)
+	} else {
+		err = c.send(ctx, http.MethodPost, "/_apis/serviceendpoint/endpoints", request, &endpoint)
+	}
+
+	if err != nil {
+		return ServiceEndpoint{}, fmt.Errorf("saving service connection '%s': %w", name, err)
+	}
+
+	return endpoint, nil
+}
+
+func (c *client) CreateOrUpdateVariableGroup(
+	ctx context.Context,
+	project Project,
+	name string,
+	variables map[string]Variable,
+) (VariableGroup, error) {
+	var existing listResponse[VariableGroup]
+	path := fmt.Sprintf("/%s/_apis/distributedtask/variablegroups?groupName=%s", project.Id, url.QueryEscape(name))
+	if err := c.get(ctx, path, &existing); err != nil {
+		return VariableGroup{}, fmt.Errorf("getting variable group '%s': %w", name, err)
+	}
+
+	request := variableGroupRequest{
+		Name:                           name,
+		Type:                           "Vsts",
+		Variables:                      variables,
+		VariableGroupProjectReferences: []projectReference{{ProjectReference: project, Name: name}},
+	}
+
+	var group VariableGroup
+	var err error
+	if len(existing.Value) > 0 {
+		err = c.send(ctx, http.MethodPut, fmt.Sprintf("/_apis/distributedtask/variablegroups/%d", existing.Value[0].Id), request, &group)
+	} else {
+		err = c.send(ctx, http.MethodPost, "/_apis/distributedtask/variablegroups", request, &group)
+	}
+
+	if err != nil {
+		return VariableGroup{}, fmt.Errorf("saving variable group '%s': %w", name, err)
+	}
+
+	return group, nil
+}
+
+func (c *client) AuthorizeAllPipelines(ctx context.Context, project Project, resourceType string, resourceId string) error {
+	request := map[string]any{
+		"allPipelines": map[string]bool{"authorized": true},
+	}
+
+	path := fmt.Sprintf(
+		"/%s/_apis/pipelines/pipelinePermissions/%s/%s?api-version=%s",
+		project.Id,
+		resourceType,
+		url.PathEscape(resourceId),
+		pipelinesPermissionsApiVersion,
+	)
+	if err := c.send(ctx, http.MethodPatch, path, request, nil); err != nil {
+		return fmt.Errorf("authorizing pipelines to use %s '%s': %w", resourceType, resourceId, err)
+	}
+
+	return nil
+}
+
+func (c *client) CreateOrGetPipeline(
+	ctx context.Context,
+	project Project,
+	name string,
+	repository GitRepository,
+	yamlPath string,
+) (Pipeline, error) {
+	var existing listResponse[Pipeline]
+	if err := c.get(ctx, fmt.Sprintf("/%s/_apis/pipelines", project.Id), &existing); err != nil {
+		return Pipeline{}, fmt.Errorf("listing pipelines: %w", err)
+	}
+
+	for _, pipeline := range existing.Value {
+		if strings.EqualFold(pipeline.Name, name) {
+			return pipeline, nil
+		}
+	}
+
+	request := pipelineRequest{
+		Name:   name,
+		Folder: "\\",
+		Configuration: pipelineConfigurationRequest{
+			Type: "yaml",
+			Path: yamlPath,
+			Repository: pipelineRepositoryRequest{
+				Id:   repository.Id,
+				Name: repository.Name,
+				Type: "azureReposGit",
+			},
+		},
+	}
+
+	var pipeline Pipeline
+	if err := c.send(ctx, http.MethodPost, fmt.Sprintf("/%s/_apis/pipelines", project.Id), request, &pipeline); err != nil {
+		return Pipeline{}, fmt.Errorf("creating pipeline '%s': %w", name, err)
+	}
+
+	return pipeline, nil
+}
+
+func (c *client) get(ctx context.Context, path string, result any) error {
+	return c.send(ctx, http.MethodGet, path, nil, result)
+}
+
+// Sends an authenticated request to the REST API of the organization, unmarshalling the response into result when not nil.
+// Responses with a 404 status are returned as ErrNotFound.
+func (c *client) send(ctx context.Context, method string, path string, body any, result any) error {
+	requestUrl := c.organizationUrl + path
+	if !strings.Contains(path, "api-version=") {
+		separator := "?"
+		if strings.Contains(path, "?") {
+			separator = "&"
+		}
+
+		requestUrl = fmt.Sprintf("%s%sapi-version=%s", requestUrl, separator, apiVersion)
+	}
+
+	basicAuth := base64.StdEncoding.EncodeToString([]byte(":" + c.personalAccessToken))
+	request := &httpUtil.HttpRequestMessage{
+		Url:    requestUrl,
+		Method: method,
+		Headers: map[string]string{
+			"Authorization": fmt.Sprintf("Basic %s", basicAuth),
+		},
+	}
+
+	if body != nil {
+		bodyJson, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshalling JSON body: %w", err)
+		}
+
+		request.Body = string(bodyJson)
+	}
+
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = httpUtil.GetHttpUtilFromContext(ctx)
+	}
+
+	response, err := httpClient.Send(request)
+	if err != nil {
+		return fmt.Errorf("sending http request: %w", err)
+	}
+
+	switch {
+	case response.Status == http.StatusNotFound:
+		return ErrNotFound
+	case response.Status == http.StatusUnauthorized || response.Status == http.StatusForbidden:
+		return fmt.Errorf(
+			"the personal access token is not authorized to access %s, ensure %s is set to a valid token",
+			c.organizationUrl,
+			PersonalAccessTokenEnvVarName,
+		)
+	case response.Status >= http.StatusBadRequest:
+		var azdoError struct {
+			Message string `json:"message"`
+		}
+
+		if err := json.Unmarshal(response.Body, &azdoError); err == nil && azdoError.Message != "" {
+			return fmt.Errorf("azure devops request failed with status %d: %s", response.Status, azdoError.Message)
+		}
+
+		return fmt.Errorf("azure devops request failed with status %d: %s", response.Status, string(response.Body))
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(response.Body, result); err != nil {
+			return fmt.Errorf("could not unmarshal response %s: %w", string(response.Body), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package azdo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/httpUtil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAzDoServer is a local stand-in for the REST API of an Azure DevOps organization
+type fakeAzDoServer struct {
+	mu             sync.Mutex
+	server         *httptest.Server
+	requests       []string
+	endpoints      map[string]json.RawMessage
+	variableGroups map[string]json.RawMessage
+	pipelines      []json.RawMessage
+	authorized     []string
+}
+
+func newFakeAzDoServer(t *testing.T) *fakeAzDoServer {
+	f := &fakeAzDoServer{
+		endpoints:      map[string]json.RawMessage{},
+		variableGroups: map[string]json.RawMessage{},
+	}
+
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeAzDoServer) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+
+	if r.Header.Get("Authorization") != "Basic OlBBVA==" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if r.URL.Query().Get("api-version") == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"message":"No api-version was supplied"}`))
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+
+	write := func(value any) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(value)
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/_apis/projects/web":
+		write(Project{Id: "PROJECT_ID", Name: "web"})
+	case r.Method == http.MethodGet && r.URL.Path == "/PROJECT_ID/_apis/git/repositories/todo":
+		write(GitRepository{Id: "REPOSITORY_ID", Name: "todo"})
+	case r.Method == http.MethodGet && r.URL.Path == "/PROJECT_ID/_apis/serviceendpoint/endpoints":
+		values := []ServiceEndpoint{}
+		if _, has := f.endpoints[r.URL.Query().Get("endpointNames")]; has {
+			values = append(values, ServiceEndpoint{Id: "ENDPOINT_ID", Name: r.URL.Query().Get("endpointNames")})
+		}
+		write(listResponse[ServiceEndpoint]{Count: len(values), Value: values})
+	case (r.Method == http.MethodPost && r.URL.Path == "/_apis/serviceendpoint/endpoints") ||
+		(r.Method == http.MethodPut && r.URL.Path == "/_apis/serviceendpoint/endpoints/ENDPOINT_ID"):
+		var request serviceEndpointRequest
+		_ = json.Unmarshal(body, &request)
+		f.endpoints[request.Name] = body
+		write(ServiceEndpoint{Id: "ENDPOINT_ID", Name: request.Name})
+	case r.Method == http.MethodGet && r.URL.Path == "/PROJECT_ID/_apis/distributedtask/variablegroups":
+		values := []VariableGroup{}
+		if _, has := f.variableGroups[r.URL.Query().Get("groupName")]; has {
+			values = append(values, VariableGroup{Id: 7, Name: r.URL.Query().Get("groupName")})
+		}
+		write(listResponse[VariableGroup]{Count: len(values), Value: values})
+	case (r.Method == http.MethodPost && r.URL.Path == "/_apis/distributedtask/variablegroups") ||
+		(r.Method == http.MethodPut && r.URL.Path == "/_apis/distributedtask/variablegroups/7"):
+		var request variableGroupRequest
+		_ = json.Unmarshal(body, &request)
+		f.variableGroups[request.Name] = body
+		write(VariableGroup{Id: 7, Name: request.Name})
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/PROJECT_ID/_apis/pipelines/pipelinePermissions/"):
+		f.authorized = append(f.authorized, strings.TrimPrefix(r.URL.Path, "/PROJECT_ID/_apis/pipelines/pipelinePermissions/"))
+		write(map[string]any{})
+	case r.Method == http.MethodGet && r.URL.Path == "/PROJECT_ID/_apis/pipelines":
+		write(map[string]any{"count": len(f.pipelines), "value": f.pipelines})
+	case r.Method == http.MethodPost && r.URL.Path == "/PROJECT_ID/_apis/pipelines":
+		var request pipelineRequest
+		_ = json.Unmarshal(body, &request)
+		pipeline, _ := json.Marshal(map[string]any{
+			"id":     len(f.pipelines) + 1,
+			"name":   request.Name,
+			"_links": map[string]any{"web": map[string]string{"href": "https://dev.azure.com/contoso/web/_build/definition?definitionId=1"}},
+		})
+		f.pipelines = append(f.pipelines, pipeline)
+		_, _ = w.Write(pipeline)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestClient(server *fakeAzDoServer) Client {
+	return NewClient(NewClientArgs{
+		OrganizationUrl:     server.server.URL + "/",
+		PersonalAccessToken: "PAT",
+		HttpClient:          httpUtil.NewHttpUtil(),
+	})
+}
+
+func TestClientGetProjectAndRepository(t *testing.T) {
+	server := newFakeAzDoServer(t)
+	client := newTestClient(server)
+
+	project, err := client.GetProject(context.Background(), "web")
+	require.NoError(t, err)
+	require.Equal(t, Project{Id: "PROJECT_ID", Name: "web"}, project)
+
+	repository, err := client.GetRepository(context.Background(), project, "todo")
+	require.NoError(t, err)
+	require.Equal(t, "REPOSITORY_ID", repository.Id)
+
+	_, err = client.GetRepository(context.Background(), project, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	server := newFakeAzDoServer(t)
+	client := NewClient(NewClientArgs{
+		OrganizationUrl:     server.server.URL,
+		PersonalAccessToken: "WRONG",
+		HttpClient:          httpUtil.NewHttpUtil(),
+	})
+
+	_, err := client.GetProject(context.Background(), "web")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), PersonalAccessTokenEnvVarName)
+}
+
+func TestClientCreateOrUpdateServiceConnection(t *testing.T) {
+	server := newFakeAzDoServer(t)
+	client := newTestClient(server)
+	project := Project{Id: "PROJECT_ID", Name: "web"}
+
+	credentials := azcli.AzureCredentials{
+		ClientId:       "CLIENT_ID",
+		ClientSecret:   "CLIENT_SECRET",
+		SubscriptionId: "SUBSCRIPTION_ID",
+		TenantId:       "TENANT_ID",
+	}
+
+	endpoint, err := client.CreateOrUpdateServiceConnection(context.Background(), project, "azconnection", credentials)
+	require.NoError(t, err)
+	require.Equal(t, "ENDPOINT_ID", endpoint.Id)
+
+	var saved serviceEndpointRequest
+	require.NoError(t, json.Unmarshal(server.endpoints["azconnection"], &saved))
+	require.Equal(t, "azurerm", saved.Type)
+	require.Equal(t, "CLIENT_SECRET", saved.Authorization.Parameters["serviceprincipalkey"])
+	require.Equal(t, "SUBSCRIPTION_ID", saved.Data["subscriptionId"])
+	require.Equal(t, "PROJECT_ID", saved.ServiceEndpointProjectReferences[0].ProjectReference.Id)
+
+	// The existing service connection is updated
+	credentials.ClientSecret = "NEW_SECRET"
+	_, err = client.CreateOrUpdateServiceConnection(context.Background(), project, "azconnection", credentials)
+	require.NoError(t, err)
+	require.Contains(t, server.requests, "PUT /_apis/serviceendpoint/endpoints/ENDPOINT_ID")
+	require.NoError(t, json.Unmarshal(server.endpoints["azconnection"], &saved))
+	require.Equal(t, "NEW_SECRET", saved.Authorization.Parameters["serviceprincipalkey"])
+}
+
+func TestClientCreateOrUpdateVariableGroup(t *testing.T) {
+	server := newFakeAzDoServer(t)
+	client := newTestClient(server)
+	project := Project{Id: "PROJECT_ID", Name: "web"}
+
+	group, err := client.CreateOrUpdateVariableGroup(context.Background(), project, "azd-dev", map[string]Variable{
+		"AZURE_LOCATION": {Value: "westus2"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 7, group.Id)
+
+	_, err = client.CreateOrUpdateVariableGroup(context.Background(), project, "azd-dev", map[string]Variable{
+		"AZURE_LOCATION": {Value: "eastus"},
+	})
+	require.NoError(t, err)
+	require.Contains(t, server.requests, "PUT /_apis/distributedtask/variablegroups/7")
+
+	var saved variableGroupRequest
+	require.NoError(t, json.Unmarshal(server.variableGroups["azd-dev"], &saved))
+	require.Equal(t, "eastus", saved.Variables["AZURE_LOCATION"].Value)
+
+	require.NoError(t, client.AuthorizeAllPipelines(context.Background(), project, "variablegroup", "7"))
+	require.Equal(t, []string{"variablegroup/7"}, server.authorized)
+}
+
+func TestClientCreateOrGetPipeline(t *testing.T) {
+	server := newFakeAzDoServer(t)
+	client := newTestClient(server)
+	project := Project{Id: "PROJECT_ID", Name: "web"}
+	repository := GitRepository{Id: "REPOSITORY_ID", Name: "todo"}
+
+	pipeline, err := client.CreateOrGetPipeline(context.Background(), project, "todo", repository, "/azure-pipelines.yml")
+	require.NoError(t, err)
+	require.Equal(t, 1, pipeline.Id)
+	require.Equal(t, "https://dev.azure.com/contoso/web/_build/definition?definitionId=1", pipeline.WebUrl())
+
+	// The existing pipeline is returned
+	pipeline, err = client.CreateOrGetPipeline(context.Background(), project, "todo", repository, "/azure-pipelines.yml")
+	require.NoError(t, err)
+	require.Equal(t, 1, pipeline.Id)
+	require.Len(t, server.pipelines, 1)
+}
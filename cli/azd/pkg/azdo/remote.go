@@ -0,0 +1,71 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azdo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+var ErrRemoteHostIsNotAzDo = errors.New("not an azure devops host")
+
+// https://dev.azure.com/<organization>/<project>/_git/<repo>, optionally with a user name before the host
+var azDoRemoteHttpsUrlRegex = regexp.MustCompile(`^https://(?:[^@/]+@)?dev\.azure\.com/([^/]+)/([^/]+)/_git/([^/]+?)/?$`)
+
+// git@ssh.dev.azure.com:v3/<organization>/<project>/<repo>
+var azDoRemoteSshUrlRegex = regexp.MustCompile(`^(?:ssh://)?git@ssh\.dev\.azure\.com(?::|/)v3/([^/]+)/([^/]+)/([^/]+?)/?$`)
+
+// https://<organization>.visualstudio.com/<project>/_git/<repo>, the legacy URL of Azure DevOps organizations
+var azDoRemoteLegacyUrlRegex = regexp.MustCompile(`^https://(?:[^@/]+@)?([^./]+)\.visualstudio\.com/(?:DefaultCollection/)?([^/]+)/_git/([^/]+?)/?$`)
+
+// Repository is a git repository hosted in Azure Repos
+type Repository struct {
+	Organization string
+	Project      string
+	Name         string
+}
+
+// OrganizationUrl is the URL of the REST API of the organization of the repository
+func (r Repository) OrganizationUrl() string {
+	return fmt.Sprintf("https://dev.azure.com/%s", url.PathEscape(r.Organization))
+}
+
+// Slug returns the name of the repository in the `<organization>/<project>/<repo>` format
+func (r Repository) Slug() string {
+	return fmt.Sprintf("%s/%s/%s", r.Organization, r.Project, r.Name)
+}
+
+// GetRepositoryForRemote parses the URL of a git remote hosted in Azure Repos.
+// ErrRemoteHostIsNotAzDo is returned when the remote is not hosted in Azure Repos.
+func GetRepositoryForRemote(remoteUrl string) (Repository, error) {
+	for _, r := range []*regexp.Regexp{azDoRemoteHttpsUrlRegex, azDoRemoteSshUrlRegex, azDoRemoteLegacyUrlRegex} {
+		captures := r.FindStringSubmatch(remoteUrl)
+		if captures == nil {
+			continue
+		}
+
+		// Project & repository names containing spaces are escaped in the URL
+		parts := make([]string, 3)
+		for i, capture := range captures[1:] {
+			part, err := url.PathUnescape(capture)
+			if err != nil {
+				return Repository{}, fmt.Errorf("invalid remote url '%s': %w", remoteUrl, err)
+			}
+
+			parts[i] = part
+		}
+
+		return Repository{Organization: parts[0], Project: parts[1], Name: parts[2]}, nil
+	}
+
+	return Repository{}, ErrRemoteHostIsNotAzDo
+}
+
+// IsAzDoRemote returns true when the remote is hosted in Azure Repos
+func IsAzDoRemote(remoteUrl string) bool {
+	_, err := GetRepositoryForRemote(remoteUrl)
+	return err == nil
+}
@@ -0,0 +1,44 @@
+package azdo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRepositoryForRemote(t *testing.T) {
+	cases := []struct {
+		remote  string
+		result  Repository
+		isError bool
+	}{
+		{remote: "https://dev.azure.com/contoso/web/_git/todo", result: Repository{"contoso", "web", "todo"}},
+		{remote: "https://contoso@dev.azure.com/contoso/web/_git/todo", result: Repository{"contoso", "web", "todo"}},
+		{remote: "https://dev.azure.com/contoso/My%20Project/_git/todo", result: Repository{"contoso", "My Project", "todo"}},
+		{remote: "git@ssh.dev.azure.com:v3/contoso/web/todo", result: Repository{"contoso", "web", "todo"}},
+		{remote: "https://contoso.visualstudio.com/web/_git/todo", result: Repository{"contoso", "web", "todo"}},
+		{remote: "https://contoso.visualstudio.com/DefaultCollection/web/_git/todo", result: Repository{"contoso", "web", "todo"}},
+
+		{remote: "https://github.com/contoso/todo.git", isError: true},
+		{remote: "https://dev.azure.com/contoso/web", isError: true},
+		{remote: "git@github.com:contoso/todo.git", isError: true},
+	}
+
+	for _, c := range cases {
+		repository, err := GetRepositoryForRemote(c.remote)
+		if c.isError {
+			require.ErrorIs(t, err, ErrRemoteHostIsNotAzDo, c.remote)
+			require.False(t, IsAzDoRemote(c.remote))
+		} else {
+			require.NoError(t, err, c.remote)
+			require.Equal(t, c.result, repository, c.remote)
+			require.True(t, IsAzDoRemote(c.remote))
+		}
+	}
+}
+
+func TestRepositoryUrls(t *testing.T) {
+	repository := Repository{Organization: "contoso", Project: "web", Name: "todo"}
+	require.Equal(t, "https://dev.azure.com/contoso", repository.OrganizationUrl())
+	require.Equal(t, "contoso/web/todo", repository.Slug())
+}
@@ -37,3 +37,14 @@ func StaticWebAppRID(subscriptionId, resourceGroupName, staticSiteName string) s
 	returnValue := fmt.Sprintf("%s/providers/Microsoft.Web/staticSites/%s", ResourceGroupRID(subscriptionId, resourceGroupName), staticSiteName)
 	return returnValue
 }
+
+func ManagedClusterRID(subscriptionId, resourceGroupName, clusterName string) string {
+	returnValue := fmt.Sprintf("%s/providers/Microsoft.ContainerService/managedClusters/%s", ResourceGroupRID(subscriptionId, resourceGroupName), clusterName)
+	return returnValue
+}
+
+// Creates resource group-level deployment resource ID
+func ResourceGroupDeploymentRID(subscriptionId, resourceGroupName, deploymentId string) string {
+	returnValue := fmt.Sprintf("%s/providers/Microsoft.Resources/deployments/%s", ResourceGroupRID(subscriptionId, resourceGroupName), deploymentId)
+	return returnValue
+}
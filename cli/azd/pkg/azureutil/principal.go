@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"regexp"
 
+	"github.com/azure/azure-dev/cli/azd/pkg/auth"
 	"github.com/azure/azure-dev/cli/azd/pkg/commands"
 )
 
@@ -18,12 +19,15 @@ import (
 // principal authenticated with the CLI
 // (via ad sp signed-in-user), falling back to extracting the
 // `oid` claim from an access token a principal can not be
-// obtained in this way.
+// obtained in this way. With the built-in authentication of azd,
+// the principal is always taken from the access token.
 func GetCurrentPrincipalId(ctx context.Context) (string, error) {
 	azCli := commands.GetAzCliFromContext(ctx)
-	principalId, err := azCli.GetSignedInUserId(ctx)
-	if err == nil {
-		return principalId, nil
+	if !auth.IsBuiltInEnabled() {
+		principalId, err := azCli.GetSignedInUserId(ctx)
+		if err == nil {
+			return principalId, nil
+		}
 	}
 
 	token, err := azCli.GetAccessToken(ctx)
@@ -22,4 +22,10 @@ type GlobalCommandOptions struct {
 	// AZURE_DEV_COLLECT_TELEMETRY is set to 'no'.
 	// Defaults to true.
 	EnableTelemetry bool
+
+	// UseArmClient indicates Azure Resource Manager should be called directly over HTTP
+	// instead of through the Azure CLI. The rootCmd enables this when the environment variable
+	// AZURE_DEV_USE_ARM_CLIENT is set to 'true'.
+	// Defaults to false.
+	UseArmClient bool
 }
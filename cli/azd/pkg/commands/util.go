@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/azure/azure-dev/cli/azd/internal"
+	"github.com/azure/azure-dev/cli/azd/pkg/auth"
 	"github.com/azure/azure-dev/cli/azd/pkg/environment"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
 )
@@ -29,6 +30,21 @@ func GetAzCliFromContext(ctx context.Context) azcli.AzCli {
 		azCliArgs.EnableTelemetry = options.EnableTelemetry
 
 		azCli = azcli.NewAzCli(azCliArgs)
+
+		if auth.IsBuiltInEnabled() {
+			// Requests are authenticated by azd and the Azure CLI is not used at all
+			credential := auth.NewDefaultCredential(auth.CredentialOptions{})
+			azCli = azcli.NewArmClient(azcli.NewArmClientArgs{
+				AzCli:               azCli,
+				TokenSource:         auth.NewArmTokenSource(credential),
+				KeyVaultTokenSource: auth.NewTokenSource(credential, auth.KeyVaultScope),
+				WithoutAzCli:        true,
+			})
+		} else if options.UseArmClient {
+			azCli = azcli.NewArmClient(azcli.NewArmClientArgs{
+				AzCli: azCli,
+			})
+		}
 	}
 
 	selectedTemplate := ""
@@ -0,0 +1,427 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+// Package config manages the configuration of azd for the current user, saved in ~/.azd/config.json.
+// The values of the configuration are defaults: a value given by a flag or an environment variable takes precedence.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/osutil"
+)
+
+// The name of the folder in the user's home directory where azd writes user wide configuration data
+const azdConfigDir = ".azd"
+
+// DirectoryEnvVarName is the environment variable overriding the directory of the user configuration
+const DirectoryEnvVarName = "AZD_CONFIG_DIR"
+
+// FileName is the name of the file of the user configuration, in the azd configuration directory
+const FileName = "config.json"
+
+var ErrUnknownKey = errors.New("unknown configuration key")
+
+// UserConfig is the configuration of azd for the current user
+type UserConfig struct {
+	Auth      *AuthConfig      `json:"auth,omitempty"`
+	Defaults  *DefaultsConfig  `json:"defaults,omitempty"`
+	Output    *OutputConfig    `json:"output,omitempty"`
+	Telemetry *TelemetryConfig `json:"telemetry,omitempty"`
+}
+
+type AuthConfig struct {
+	Mode string `json:"mode,omitempty"`
+}
+
+// DefaultsConfig holds the values used when a new environment is initialized
+type DefaultsConfig struct {
+	Subscription string `json:"subscription,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+type OutputConfig struct {
+	Format string `json:"format,omitempty"`
+}
+
+type TelemetryConfig struct {
+	Collect *bool `json:"collect,omitempty"`
+}
+
+// Setting is a value of the user configuration, set with `azd config set <key> <value>`
+type Setting struct {
+	Key         string
+	Description string
+	// EnvVarName is the environment variable taking precedence over the user configuration, if any
+	EnvVarName string
+	// Default is the value used when the setting is neither set in the environment nor in the user configuration
+	Default string
+	// AllowedValues are the only values the setting accepts, when not empty
+	AllowedValues []string
+	isBool        bool
+	get           func(c *UserConfig) string
+	set           func(c *UserConfig, value string)
+}
+
+// The modes of authentication to Azure
+const (
+	// The account signed in to the Azure CLI is used
+	AuthModeAzCli = "azcli"
+	// The credentials of the environment, or of the user signed in with azd login, are used
+	AuthModeBuiltIn = "builtin"
+)
+
+var (
+	AuthMode = Setting{
+		Key:           "auth.mode",
+		Description:   "How azd authenticates to Azure: with the account of the Azure CLI, or with its built-in credentials.",
+		EnvVarName:    "AZURE_DEV_AUTH_MODE",
+		Default:       AuthModeAzCli,
+		AllowedValues: []string{AuthModeAzCli, AuthModeBuiltIn},
+		get: func(c *UserConfig) string {
+			if c.Auth == nil {
+				return ""
+			}
+			return c.Auth.Mode
+		},
+		set: func(c *UserConfig, value string) {
+			if c.Auth == nil {
+				c.Auth = &AuthConfig{}
+			}
+			c.Auth.Mode = value
+		},
+	}
+
+	DefaultSubscription = Setting{
+		Key:         "defaults.subscription",
+		Description: "The subscription selected by default when an environment is initialized.",
+		EnvVarName:  environment.SubscriptionIdEnvVarName,
+		get: func(c *UserConfig) string {
+			if c.Defaults == nil {
+				return ""
+			}
+			return c.Defaults.Subscription
+		},
+		set: func(c *UserConfig, value string) {
+			if c.Defaults == nil {
+				c.Defaults = &DefaultsConfig{}
+			}
+			c.Defaults.Subscription = value
+		},
+	}
+
+	DefaultLocation = Setting{
+		Key:         "defaults.location",
+		Description: "The location selected by default when an environment is initialized.",
+		EnvVarName:  environment.LocationEnvVarName,
+		get: func(c *UserConfig) string {
+			if c.Defaults == nil {
+				return ""
+			}
+			return c.Defaults.Location
+		},
+		set: func(c *UserConfig, value string) {
+			if c.Defaults == nil {
+				c.Defaults = &DefaultsConfig{}
+			}
+			c.Defaults.Location = value
+		},
+	}
+
+	OutputFormat = Setting{
+		Key:           "output.format",
+		Description:   "The output format of the commands supporting it, when --output is not given.",
+		EnvVarName:    "AZURE_DEV_OUTPUT_FORMAT",
+		AllowedValues: []string{"json", "table", "none"},
+		get: func(c *UserConfig) string {
+			if c.Output == nil {
+				return ""
+			}
+			return c.Output.Format
+		},
+		set: func(c *UserConfig, value string) {
+			if c.Output == nil {
+				c.Output = &OutputConfig{}
+			}
+			c.Output.Format = value
+		},
+	}
+
+	CollectTelemetry = Setting{
+		Key:         "telemetry.collect",
+		Description: "Whether usage data is collected, the equivalent of AZURE_CORE_COLLECT_TELEMETRY.",
+		EnvVarName:  "AZURE_DEV_COLLECT_TELEMETRY",
+		Default:     "true",
+		isBool:      true,
+		get: func(c *UserConfig) string {
+			if c.Telemetry == nil || c.Telemetry.Collect == nil {
+				return ""
+			}
+			return strconv.FormatBool(*c.Telemetry.Collect)
+		},
+		set: func(c *UserConfig, value string) {
+			if value == "" {
+				if c.Telemetry != nil {
+					c.Telemetry.Collect = nil
+				}
+				return
+			}
+
+			if c.Telemetry == nil {
+				c.Telemetry = &TelemetryConfig{}
+			}
+			collect, _ := parseBool(value)
+			c.Telemetry.Collect = &collect
+		},
+	}
+)
+
+// Settings are all the settings of the user configuration, sorted by key
+var Settings = []Setting{AuthMode, DefaultLocation, DefaultSubscription, OutputFormat, CollectTelemetry}
+
+// GetSetting returns the setting with the given key
+func GetSetting(key string) (Setting, error) {
+	for _, setting := range Settings {
+		if setting.Key == key {
+			return setting, nil
+		}
+	}
+
+	keys := make([]string, 0, len(Settings))
+	for _, setting := range Settings {
+		keys = append(keys, setting.Key)
+	}
+	sort.Strings(keys)
+
+	return Setting{}, fmt.Errorf("%w '%s', supported keys are %s", ErrUnknownKey, key, strings.Join(keys, ", "))
+}
+
+// Get returns the value of the setting in the user configuration, or an empty string when it is not set
+func (c *UserConfig) Get(setting Setting) string {
+	return setting.get(c)
+}
+
+// Set validates & sets the value of the setting in the user configuration
+func (c *UserConfig) Set(setting Setting, value string) error {
+	normalized, err := setting.normalize(value)
+	if err != nil {
+		return err
+	}
+
+	setting.set(c, normalized)
+	c.prune()
+	return nil
+}
+
+// Unset removes the setting from the user configuration
+func (c *UserConfig) Unset(setting Setting) {
+	setting.set(c, "")
+	c.prune()
+}
+
+// Values returns the settings set in the user configuration, by key
+func (c *UserConfig) Values() map[string]string {
+	values := map[string]string{}
+	for _, setting := range Settings {
+		if value := setting.get(c); value != "" {
+			values[setting.Key] = value
+		}
+	}
+
+	return values
+}
+
+// prune removes the empty sections, so they are not written to the configuration file
+func (c *UserConfig) prune() {
+	if c.Auth != nil && *c.Auth == (AuthConfig{}) {
+		c.Auth = nil
+	}
+
+	if c.Defaults != nil && *c.Defaults == (DefaultsConfig{}) {
+		c.Defaults = nil
+	}
+
+	if c.Output != nil && *c.Output == (OutputConfig{}) {
+		c.Output = nil
+	}
+
+	if c.Telemetry != nil && c.Telemetry.Collect == nil {
+		c.Telemetry = nil
+	}
+}
+
+func (s Setting) normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("a value is required for '%s'", s.Key)
+	}
+
+	if s.isBool {
+		parsed, err := parseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid value '%s' for '%s', the value must be true or false", value, s.Key)
+		}
+
+		return strconv.FormatBool(parsed), nil
+	}
+
+	if len(s.AllowedValues) > 0 {
+		for _, allowed := range s.AllowedValues {
+			if strings.EqualFold(allowed, value) {
+				return allowed, nil
+			}
+		}
+
+		return "", fmt.Errorf(
+			"invalid value '%s' for '%s', the allowed values are %s",
+			value,
+			s.Key,
+			strings.Join(s.AllowedValues, ", "),
+		)
+	}
+
+	return value, nil
+}
+
+// parseBool parses booleans, accepting yes & no like the AZURE_DEV_COLLECT_TELEMETRY environment variable
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes":
+		return true, nil
+	case "no":
+		return false, nil
+	default:
+		return strconv.ParseBool(value)
+	}
+}
+
+// Store persists the user configuration
+type Store struct {
+	configDir string
+}
+
+// NewStore creates a store saving the user configuration in the azd configuration directory of the current user
+func NewStore() (*Store, error) {
+	configDir, err := UserDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStoreWithDirectory(configDir), nil
+}
+
+// NewStoreWithDirectory creates a store saving the user configuration in the given configuration directory
+func NewStoreWithDirectory(configDir string) *Store {
+	return &Store{configDir: configDir}
+}
+
+// Load reads the user configuration. An empty configuration is returned when the file does not exist.
+func (s *Store) Load() (*UserConfig, error) {
+	contents, err := os.ReadFile(s.Path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return &UserConfig{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading user configuration: %w", err)
+	}
+
+	var config UserConfig
+	if err := json.Unmarshal(contents, &config); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal user configuration %s: %w", s.Path(), err)
+	}
+
+	return &config, nil
+}
+
+// Save writes the user configuration
+func (s *Store) Save(config *UserConfig) error {
+	contents, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling user configuration: %w", err)
+	}
+
+	if err := os.MkdirAll(s.configDir, osutil.PermissionDirectory); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+
+	if err := os.WriteFile(s.Path(), contents, osutil.PermissionFile); err != nil {
+		return fmt.Errorf("writing user configuration: %w", err)
+	}
+
+	return nil
+}
+
+// Path is the path of the configuration file
+func (s *Store) Path() string {
+	return filepath.Join(s.configDir, FileName)
+}
+
+// Resolve returns the value of the setting: the flag value when given, then the value of the environment variable,
+// then the value of the user configuration, then the default value. An invalid value is ignored, so that the next
+// one is used.
+func Resolve(setting Setting, flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+
+	if setting.EnvVarName != "" {
+		if value := os.Getenv(setting.EnvVarName); value != "" {
+			if normalized, err := setting.normalize(value); err == nil {
+				return normalized
+			}
+
+			log.Printf("ignoring invalid value '%s' of %s", value, setting.EnvVarName)
+		}
+	}
+
+	store, err := NewStore()
+	if err == nil {
+		var config *UserConfig
+		config, err = store.Load()
+		if err == nil {
+			if value := config.Get(setting); value != "" {
+				return value
+			}
+		}
+	}
+
+	if err != nil {
+		log.Printf("%v, ignoring the user configuration", err)
+	}
+
+	return setting.Default
+}
+
+// ResolveBool resolves the value of a boolean setting like Resolve does
+func ResolveBool(setting Setting, flagValue string) bool {
+	value, err := parseBool(Resolve(setting, flagValue))
+	if err != nil {
+		value, _ = parseBool(setting.Default)
+	}
+
+	return value
+}
+
+// UserDirectory returns the azd configuration directory of the current user, ~/.azd unless AZD_CONFIG_DIR is set
+func UserDirectory() (string, error) {
+	if configDir := os.Getenv(DirectoryEnvVarName); configDir != "" {
+		return configDir, nil
+	}
+
+	user, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("could not determine current user: %w", err)
+	}
+
+	return filepath.Join(user.HomeDir, azdConfigDir), nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	store := NewStoreWithDirectory(t.TempDir())
+
+	userConfig, err := store.Load()
+	require.NoError(t, err)
+	require.Empty(t, userConfig.Values())
+
+	require.NoError(t, userConfig.Set(DefaultLocation, "westus2"))
+	require.NoError(t, userConfig.Set(OutputFormat, "JSON"))
+	require.NoError(t, userConfig.Set(CollectTelemetry, "no"))
+	require.NoError(t, store.Save(userConfig))
+
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"defaults.location": "westus2",
+		"output.format":     "json",
+		"telemetry.collect": "false",
+	}, loaded.Values())
+
+	loaded.Unset(DefaultLocation)
+	loaded.Unset(CollectTelemetry)
+	require.Nil(t, loaded.Defaults)
+	require.Nil(t, loaded.Telemetry)
+	require.Equal(t, map[string]string{"output.format": "json"}, loaded.Values())
+}
+
+func TestSetInvalidValues(t *testing.T) {
+	userConfig := &UserConfig{}
+
+	require.Error(t, userConfig.Set(OutputFormat, "xml"))
+	require.Error(t, userConfig.Set(CollectTelemetry, "maybe"))
+	require.Error(t, userConfig.Set(DefaultSubscription, " "))
+	require.Empty(t, userConfig.Values())
+
+	_, err := GetSetting("defaults.unknown")
+	require.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestResolve(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv(DirectoryEnvVarName, configDir)
+	t.Setenv(OutputFormat.EnvVarName, "")
+	t.Setenv(CollectTelemetry.EnvVarName, "")
+
+	require.Equal(t, "", Resolve(OutputFormat, ""))
+	require.True(t, ResolveBool(CollectTelemetry, ""))
+
+	store := NewStoreWithDirectory(configDir)
+	userConfig := &UserConfig{}
+	require.NoError(t, userConfig.Set(OutputFormat, "table"))
+	require.NoError(t, userConfig.Set(CollectTelemetry, "false"))
+	require.NoError(t, store.Save(userConfig))
+
+	require.Equal(t, "table", Resolve(OutputFormat, ""))
+	require.False(t, ResolveBool(CollectTelemetry, ""))
+
+	t.Setenv(OutputFormat.EnvVarName, "json")
+	require.Equal(t, "json", Resolve(OutputFormat, ""))
+	require.Equal(t, "none", Resolve(OutputFormat, "none"))
+
+	// An invalid value of the environment variable is ignored
+	t.Setenv(OutputFormat.EnvVarName, "xml")
+	require.Equal(t, "table", Resolve(OutputFormat, ""))
+
+	t.Setenv(CollectTelemetry.EnvVarName, "yes")
+	require.True(t, ResolveBool(CollectTelemetry, ""))
+}
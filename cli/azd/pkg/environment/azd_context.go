@@ -133,6 +133,11 @@ func (c *AzdContext) GetEnvironmentWorkDirectory(name string) string {
 	return filepath.Join(c.GetEnvironmentFilePath(name), "wd")
 }
 
+// GetEnvironmentDeploymentsDirectory is the folder of the environment where the deployment history of the services is stored
+func (c *AzdContext) GetEnvironmentDeploymentsDirectory(name string) string {
+	return filepath.Join(c.EnvironmentDirectory(), name, "deployments")
+}
+
 func (c *AzdContext) GetInfrastructurePath() string {
 	return filepath.Join(c.ProjectDirectory(), InfraDirectoryName)
 }
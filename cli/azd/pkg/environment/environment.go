@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sync"
 
 	"github.com/azure/azure-dev/cli/azd/pkg/osutil"
 	"github.com/joho/godotenv"
@@ -31,6 +32,9 @@ const TenantIdEnvVarName = "AZURE_TENANT_ID"
 // ContainerRegistryEndpointEnvVarName is the name of they key used to store the endpoint of the container registry to push to.
 const ContainerRegistryEndpointEnvVarName = "AZURE_CONTAINER_REGISTRY_ENDPOINT"
 
+// AksClusterNameEnvVarName is the name of the key used to store the name of the AKS cluster services are deployed to.
+const AksClusterNameEnvVarName = "AZURE_AKS_CLUSTER_NAME"
+
 // ResourceGroupEnvVarName is the name of the azure resource group that should be used for deployments
 const ResourceGroupEnvVarName = "AZURE_RESOURCE_GROUP"
 
@@ -41,8 +45,18 @@ type Environment struct {
 	// will not be persisted when `Save` is called. This allows the zero value to be used
 	// for testing.
 	File string
+
+	// The values resolved from secrets, keyed by the name of the value
+	secrets map[string]resolvedSecret
+
+	// lock guards the values & the file of the environment, which are shared by the services deployed concurrently.
+	// Copies of the environment share the lock, the environments created without FromFile or Empty share defaultLock.
+	lock *sync.RWMutex
 }
 
+// The lock of the environments which were not created with FromFile or Empty, ex) in tests
+var defaultLock sync.RWMutex
+
 // Same restrictions as a deployment name (ref: https://docs.microsoft.com/azure/azure-resource-manager/management/resource-name-rules#microsoftresources)
 var environmentNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9-\(\)_\.]{1,64}$`)
 
@@ -57,6 +71,7 @@ func FromFile(file string) (Environment, error) {
 	env := Environment{
 		Values: make(map[string]string),
 		File:   file,
+		lock:   &sync.RWMutex{},
 	}
 
 	e, err := godotenv.Read(file)
@@ -75,7 +90,67 @@ func Empty(file string) Environment {
 	return Environment{
 		File:   file,
 		Values: make(map[string]string),
+		lock:   &sync.RWMutex{},
+	}
+}
+
+func (e *Environment) valuesLock() *sync.RWMutex {
+	if e.lock == nil {
+		return &defaultLock
 	}
+
+	return e.lock
+}
+
+// Lookup returns the value of the key and whether the environment has the key.
+// Unlike reading Values, it is safe to call while the environment is updated concurrently, ex) by parallel deployments.
+func (e *Environment) Lookup(key string) (string, bool) {
+	lock := e.valuesLock()
+	lock.RLock()
+	defer lock.RUnlock()
+
+	value, has := e.Values[key]
+	return value, has
+}
+
+// Getenv returns the value of the key, or an empty string when the environment does not have the key.
+// It is safe to call while the environment is updated concurrently.
+func (e *Environment) Getenv(key string) string {
+	value, _ := e.Lookup(key)
+	return value
+}
+
+// SetValue sets the value of the key. It is safe to call while the environment is used concurrently.
+func (e *Environment) SetValue(key string, value string) {
+	lock := e.valuesLock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	e.Values[key] = value
+}
+
+// DeleteValue removes the key from the environment. It is safe to call while the environment is used concurrently.
+func (e *Environment) DeleteValue(key string) {
+	lock := e.valuesLock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	delete(e.Values, key)
+}
+
+// CopyValues returns a copy of the values of the environment, which can be iterated while the environment is updated
+// concurrently.
+func (e *Environment) CopyValues() map[string]string {
+	lock := e.valuesLock()
+	lock.RLock()
+	defer lock.RUnlock()
+
+	values := make(map[string]string, len(e.Values))
+	for key, value := range e.Values {
+		values[key] = value
+	}
+
+	return values
 }
 
 // If `File` is set, Save writes the current contents of the environment to
@@ -85,12 +160,17 @@ func (e *Environment) Save() error {
 		return nil
 	}
 
+	// Saves are serialized, so the file is never written with the values of concurrent saves interleaved
+	lock := e.valuesLock()
+	lock.Lock()
+	defer lock.Unlock()
+
 	err := os.MkdirAll(filepath.Dir(e.File), osutil.PermissionDirectory)
 	if err != nil {
 		return fmt.Errorf("failed to create a directory: %w", err)
 	}
 
-	err = godotenv.Write(e.Values, e.File)
+	err = godotenv.Write(e.fileValues(), e.File)
 	if err != nil {
 		return fmt.Errorf("can't write '%s': %w", e.File, err)
 	}
@@ -99,29 +179,29 @@ func (e *Environment) Save() error {
 }
 
 func (e *Environment) GetEnvName() string {
-	return e.Values[EnvNameEnvVarName]
+	return e.Getenv(EnvNameEnvVarName)
 }
 
 func (e *Environment) SetEnvName(envname string) {
-	e.Values[EnvNameEnvVarName] = envname
+	e.SetValue(EnvNameEnvVarName, envname)
 }
 
 func (e *Environment) GetSubscriptionId() string {
-	return e.Values[SubscriptionIdEnvVarName]
+	return e.Getenv(SubscriptionIdEnvVarName)
 }
 
 func (e *Environment) GetTenantId() string {
-	return e.Values[TenantIdEnvVarName]
+	return e.Getenv(TenantIdEnvVarName)
 }
 
 func (e *Environment) SetSubscriptionId(id string) {
-	e.Values[SubscriptionIdEnvVarName] = id
+	e.SetValue(SubscriptionIdEnvVarName, id)
 }
 
 func (e *Environment) SetLocation(location string) {
-	e.Values[LocationEnvVarName] = location
+	e.SetValue(LocationEnvVarName, location)
 }
 
 func (e *Environment) SetPrincipalId(principalID string) {
-	e.Values[PrincipalIdEnvVarName] = principalID
+	e.SetValue(PrincipalIdEnvVarName, principalID)
 }
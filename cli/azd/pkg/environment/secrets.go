@@ -0,0 +1,159 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package environment
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// KeyVaultSecretScheme is the prefix of environment values referencing a secret stored in Azure Key Vault,
+// ex) akvs://<vault-name>/<secret-name>
+const KeyVaultSecretScheme = "akvs://"
+
+// KeyVaultNameEnvVarName is the name of the key used to store the name of the key vault where secret values are stored.
+const KeyVaultNameEnvVarName = "AZURE_KEY_VAULT_NAME"
+
+// MaskedSecretValue replaces the values of secrets when the environment values are displayed.
+const MaskedSecretValue = "********"
+
+// Key Vault secret names only allow alphanumeric characters and dashes
+var invalidSecretNameCharacters = regexp.MustCompile(`[^a-zA-Z0-9-]`)
+
+// SecretResolver reads & writes the secrets referenced by the values of an environment
+type SecretResolver interface {
+	GetSecret(ctx context.Context, reference SecretReference) (string, error)
+	SetSecret(ctx context.Context, reference SecretReference, value string) error
+}
+
+// SecretReference identifies a secret stored in Azure Key Vault
+type SecretReference struct {
+	VaultName  string
+	SecretName string
+}
+
+// String returns the value stored in the environment file for the reference
+func (r SecretReference) String() string {
+	return fmt.Sprintf("%s%s/%s", KeyVaultSecretScheme, r.VaultName, r.SecretName)
+}
+
+// IsSecretReference returns true when the value references a secret instead of holding the value itself
+func IsSecretReference(value string) bool {
+	return strings.HasPrefix(value, KeyVaultSecretScheme)
+}
+
+// ParseSecretReference parses a value in the akvs://<vault-name>/<secret-name> format
+func ParseSecretReference(value string) (SecretReference, error) {
+	if !IsSecretReference(value) {
+		return SecretReference{}, fmt.Errorf("'%s' is not a secret reference, expected %s<vault-name>/<secret-name>", value, KeyVaultSecretScheme)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, KeyVaultSecretScheme), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return SecretReference{}, fmt.Errorf("invalid secret reference '%s', expected %s<vault-name>/<secret-name>", value, KeyVaultSecretScheme)
+	}
+
+	return SecretReference{VaultName: parts[0], SecretName: parts[1]}, nil
+}
+
+// SecretNameForKey gets the name of the Key Vault secret used to store the value of the environment key. The name is
+// prefixed with the names of the project and of the environment, so that the environments and the projects sharing a
+// key vault don't overwrite the secrets of each other, ex) DB_PASSWORD of the dev environment of the todo project is
+// stored in the todo-dev-DB-PASSWORD secret
+func SecretNameForKey(projectName string, envName string, key string) string {
+	name := key
+	for _, prefix := range []string{envName, projectName} {
+		if prefix != "" {
+			name = prefix + "-" + name
+		}
+	}
+
+	return invalidSecretNameCharacters.ReplaceAllString(name, "-")
+}
+
+// A secret value resolved from the reference stored in the environment file
+type resolvedSecret struct {
+	reference string
+	value     string
+}
+
+// ResolveSecrets replaces the values of the environment referencing secrets with the values of the secrets.
+// The references are kept, so saving the environment writes the references instead of the values of the secrets.
+func (e *Environment) ResolveSecrets(ctx context.Context, resolver SecretResolver) error {
+	for key, value := range e.Values {
+		if !IsSecretReference(value) {
+			continue
+		}
+
+		reference, err := ParseSecretReference(value)
+		if err != nil {
+			return fmt.Errorf("resolving environment value '%s': %w", key, err)
+		}
+
+		secret, err := resolver.GetSecret(ctx, reference)
+		if err != nil {
+			return fmt.Errorf("resolving environment value '%s' from secret '%s': %w", key, reference, err)
+		}
+
+		e.setResolvedSecret(key, reference, secret)
+	}
+
+	return nil
+}
+
+// SetSecret stores the value in the referenced secret and sets the environment value to reference the secret
+func (e *Environment) SetSecret(ctx context.Context, resolver SecretResolver, key string, reference SecretReference, value string) error {
+	if err := resolver.SetSecret(ctx, reference, value); err != nil {
+		return fmt.Errorf("storing environment value '%s' in secret '%s': %w", key, reference, err)
+	}
+
+	e.setResolvedSecret(key, reference, value)
+	return nil
+}
+
+// IsSecret returns true when the value of the key was resolved from a secret
+func (e *Environment) IsSecret(key string) bool {
+	secret, has := e.secrets[key]
+	return has && e.Values[key] == secret.value
+}
+
+// MaskedValues returns a copy of the values of the environment where the values resolved from secrets are masked
+func (e *Environment) MaskedValues() map[string]string {
+	masked := make(map[string]string, len(e.Values))
+	for key, value := range e.Values {
+		if e.IsSecret(key) {
+			value = MaskedSecretValue
+		}
+
+		masked[key] = value
+	}
+
+	return masked
+}
+
+func (e *Environment) setResolvedSecret(key string, reference SecretReference, value string) {
+	if e.secrets == nil {
+		e.secrets = map[string]resolvedSecret{}
+	}
+
+	e.secrets[key] = resolvedSecret{reference: reference.String(), value: value}
+	e.Values[key] = value
+}
+
+// The values written to the environment file, where the values resolved from secrets are replaced by their references.
+// Values resolved from secrets which have since been changed are written as is.
+func (e *Environment) fileValues() map[string]string {
+	values := make(map[string]string, len(e.Values))
+	for key, value := range e.Values {
+		if e.IsSecret(key) {
+			value = e.secrets[key].reference
+		}
+
+		values[key] = value
+	}
+
+	return values
+}
@@ -0,0 +1,109 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package environment
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSecretResolver stores the secrets in memory, keyed by their references
+type fakeSecretResolver struct {
+	secrets map[string]string
+}
+
+func (r *fakeSecretResolver) GetSecret(ctx context.Context, reference SecretReference) (string, error) {
+	value, has := r.secrets[reference.String()]
+	if !has {
+		return "", errors.New("secret not found")
+	}
+
+	return value, nil
+}
+
+func (r *fakeSecretResolver) SetSecret(ctx context.Context, reference SecretReference, value string) error {
+	r.secrets[reference.String()] = value
+	return nil
+}
+
+func TestParseSecretReference(t *testing.T) {
+	reference, err := ParseSecretReference("akvs://my-vault/db-password")
+	require.NoError(t, err)
+	require.Equal(t, SecretReference{VaultName: "my-vault", SecretName: "db-password"}, reference)
+	require.Equal(t, "akvs://my-vault/db-password", reference.String())
+
+	for _, value := range []string{"plain value", "akvs://my-vault", "akvs:///db-password", "akvs://my-vault/db/password"} {
+		_, err := ParseSecretReference(value)
+		require.Error(t, err, value)
+	}
+}
+
+func TestSecretNameForKey(t *testing.T) {
+	require.Equal(t, "todo-dev-DB-PASSWORD", SecretNameForKey("todo", "dev", "DB_PASSWORD"))
+	require.Equal(t, "my-app-prod-eu-API-KEY-2", SecretNameForKey("my_app", "prod.eu", "API.KEY-2"))
+	require.Equal(t, "dev-DB-PASSWORD", SecretNameForKey("", "dev", "DB_PASSWORD"))
+}
+
+func TestResolveSecrets(t *testing.T) {
+	resolver := &fakeSecretResolver{secrets: map[string]string{"akvs://my-vault/DB-PASSWORD": "p@ssw0rd"}}
+
+	env := Empty(filepath.Join(t.TempDir(), ".env"))
+	env.Values["AZURE_LOCATION"] = "westus2"
+	env.Values["DB_PASSWORD"] = "akvs://my-vault/DB-PASSWORD"
+
+	require.NoError(t, env.ResolveSecrets(context.Background(), resolver))
+	require.Equal(t, "p@ssw0rd", env.Values["DB_PASSWORD"])
+	require.True(t, env.IsSecret("DB_PASSWORD"))
+	require.False(t, env.IsSecret("AZURE_LOCATION"))
+
+	require.Equal(t, map[string]string{
+		"AZURE_LOCATION": "westus2",
+		"DB_PASSWORD":    MaskedSecretValue,
+	}, env.MaskedValues())
+
+	// The reference is saved instead of the value of the secret
+	require.NoError(t, env.Save())
+	saved, err := godotenv.Read(env.File)
+	require.NoError(t, err)
+	require.Equal(t, "akvs://my-vault/DB-PASSWORD", saved["DB_PASSWORD"])
+
+	// Changing the value replaces the reference
+	env.Values["DB_PASSWORD"] = "plain"
+	require.False(t, env.IsSecret("DB_PASSWORD"))
+	require.NoError(t, env.Save())
+	saved, err = godotenv.Read(env.File)
+	require.NoError(t, err)
+	require.Equal(t, "plain", saved["DB_PASSWORD"])
+}
+
+func TestResolveSecretsMissingSecret(t *testing.T) {
+	env := Empty(filepath.Join(t.TempDir(), ".env"))
+	env.Values["DB_PASSWORD"] = "akvs://my-vault/DB-PASSWORD"
+
+	err := env.ResolveSecrets(context.Background(), &fakeSecretResolver{secrets: map[string]string{}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "DB_PASSWORD")
+}
+
+func TestSetSecret(t *testing.T) {
+	resolver := &fakeSecretResolver{secrets: map[string]string{}}
+	env := Empty(filepath.Join(t.TempDir(), ".env"))
+
+	reference := SecretReference{VaultName: "my-vault", SecretName: "API-KEY"}
+	require.NoError(t, env.SetSecret(context.Background(), resolver, "API_KEY", reference, "secret-value"))
+
+	require.Equal(t, "secret-value", resolver.secrets["akvs://my-vault/API-KEY"])
+	require.Equal(t, "secret-value", env.Values["API_KEY"])
+	require.True(t, env.IsSecret("API_KEY"))
+
+	require.NoError(t, env.Save())
+	saved, err := godotenv.Read(env.File)
+	require.NoError(t, err)
+	require.Equal(t, "akvs://my-vault/API-KEY", saved["API_KEY"])
+}
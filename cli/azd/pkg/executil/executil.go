@@ -69,6 +69,24 @@ func RunCommandList(ctx context.Context, commands []string, env []string, cwd st
 	return execCmdTree(process)
 }
 
+// RunCommandListWithCurrentStdio runs a list of commands in shell, reusing the current stdout, stderr and stdin of the
+// current process. Like RunCommandWithCurrentStdio, the output is not captured.
+func RunCommandListWithCurrentStdio(ctx context.Context, commands []string, env []string, cwd string) (RunResult, error) {
+	process, err := newCmdTree(ctx, "", commands, true)
+	if err != nil {
+		return NewRunResult(-1, "", ""), err
+	}
+
+	process.CmdTreeOptions = CmdTreeOptions{Interactive: true}
+	process.Cmd.Dir = cwd
+	process.Env = appendEnv(env)
+	process.Cmd.Stdin = os.Stdin
+	process.Cmd.Stdout = os.Stdout
+	process.Cmd.Stderr = os.Stderr
+
+	return execCmdTree(process)
+}
+
 func execCmdTree(process CmdTree) (RunResult, error) {
 	var stdOutBuf bytes.Buffer
 	var stdErrBuf bytes.Buffer
@@ -189,6 +207,9 @@ type RunArgs struct {
 	// NOTE: RunResult.Stderr will still contain stderr output.
 	Stderr io.Writer
 
+	// Stdin is read by the command when set, ex) to pass values which should not appear on the command line.
+	Stdin io.Reader
+
 	// Debug will `log.Printf` the command and it's results after it completes.
 	Debug bool
 
@@ -251,7 +272,11 @@ func RunWithResult(ctx context.Context, args RunArgs) (RunResult, error) {
 	}
 
 	cmd.Stdout = &stdout
-	cmd.Stdin = &bytes.Buffer{}
+	if args.Stdin != nil {
+		cmd.Stdin = args.Stdin
+	} else {
+		cmd.Stdin = &bytes.Buffer{}
+	}
 	cmd.Env = appendEnv(args.Env)
 
 	log.Printf("RunWithResult exec: '%s %s'", args.Cmd, strings.Join(args.Args, " "))
@@ -0,0 +1,176 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The issuer of the OpenID Connect tokens of GitHub Actions workflows
+const ActionsTokenIssuer = "https://token.actions.githubusercontent.com"
+
+// Matches the `creds` input of the azure/login action using the AZURE_CREDENTIALS secret
+var azureCredentialsLoginRegex = regexp.MustCompile(`(?m)^([ \t]*)creds:[ \t]*\$\{\{[ \t]*secrets\.AZURE_CREDENTIALS[ \t]*\}\}[ \t]*\r?$`)
+
+// Matches the references to the AZURE_SUBSCRIPTION_ID secret
+var subscriptionSecretRegex = regexp.MustCompile(`\$\{\{[ \t]*secrets\.AZURE_SUBSCRIPTION_ID[ \t]*\}\}`)
+
+var permissionsRegex = regexp.MustCompile(`(?m)^permissions:`)
+
+// Matches a line declaring permissions, capturing its indentation and the value declared on the same line
+var permissionsLineRegex = regexp.MustCompile(`^([ \t]*)permissions:[ \t]*([^\r\n#]*?)[ \t]*(?:#[^\r\n]*)?\r?\n?$`)
+var idTokenPermissionRegex = regexp.MustCompile(`(?m)^([ \t]+)id-token:[^\r\n]*$`)
+var permissionIndentRegex = regexp.MustCompile(`(?m)^([ \t]+)[^ \t\r\n#]`)
+
+// The scopes granted by the read-all permission
+var readAllScopes = []string{
+	"actions", "checks", "contents", "deployments", "discussions", "issues", "packages", "pages", "pull-requests",
+	"repository-projects", "security-events", "statuses",
+}
+var jobsRegex = regexp.MustCompile(`(?m)^jobs:`)
+
+// UseFederatedLogin updates the azure/login step of a workflow authenticating with the AZURE_CREDENTIALS secret to
+// authenticate with OpenID Connect, using the AZURE_CLIENT_ID, AZURE_TENANT_ID and AZURE_SUBSCRIPTION_ID variables.
+// References to the AZURE_SUBSCRIPTION_ID secret are replaced with the variable.
+// The workflow is granted the permission to request OpenID Connect tokens, which is merged into the permissions the
+// workflow and its jobs already declare.
+// It returns false when the workflow does not log in with the AZURE_CREDENTIALS secret.
+func UseFederatedLogin(workflow string) (string, bool) {
+	if !azureCredentialsLoginRegex.MatchString(workflow) {
+		return workflow, false
+	}
+
+	workflow = azureCredentialsLoginRegex.ReplaceAllStringFunc(workflow, func(line string) string {
+		indent := azureCredentialsLoginRegex.FindStringSubmatch(line)[1]
+
+		inputs := make([]string, 0, 3)
+		for _, input := range []string{"client-id", "tenant-id", "subscription-id"} {
+			variable := "AZURE_" + strings.ToUpper(strings.ReplaceAll(input, "-", "_"))
+			inputs = append(inputs, fmt.Sprintf("%s%s: ${{ vars.%s }}", indent, input, variable))
+		}
+
+		return strings.Join(inputs, "\n")
+	})
+
+	workflow = subscriptionSecretRegex.ReplaceAllString(workflow, "${{ vars.AZURE_SUBSCRIPTION_ID }}")
+
+	if !permissionsRegex.MatchString(workflow) {
+		if location := jobsRegex.FindStringIndex(workflow); location != nil {
+			permissions := "permissions:\n  id-token: write\n  contents: read\n\n"
+			workflow = workflow[:location[0]] + permissions + workflow[location[0]:]
+		}
+	}
+
+	return grantIdTokenWrite(workflow), true
+}
+
+// grantIdTokenWrite merges the id-token write permission into the permissions declared by a workflow, at the top level
+// and by its jobs, since the permissions of a job replace the permissions of the workflow
+func grantIdTokenWrite(workflow string) string {
+	// Ensure a permissions block at the end of the workflow ends like the other lines
+	if !strings.HasSuffix(workflow, "\n") {
+		return strings.TrimSuffix(grantIdTokenWrite(workflow+"\n"), "\n")
+	}
+
+	lines := strings.SplitAfter(workflow, "\n")
+
+	var result strings.Builder
+	for i := 0; i < len(lines); i++ {
+		match := permissionsLineRegex.FindStringSubmatch(lines[i])
+		if match == nil || !isWorkflowOrJobKey(lines, i, match[1]) {
+			result.WriteString(lines[i])
+			continue
+		}
+
+		// The block of scopes is made of the lines indented under the permissions
+		end := i + 1
+		for end < len(lines) && (strings.TrimSpace(lines[end]) == "" || len(indentOf(lines[end])) > len(match[1])) {
+			end++
+		}
+
+		result.WriteString(grantPermissions(lines[i], match[1], match[2], strings.Join(lines[i+1:end], "")))
+		i = end - 1
+	}
+
+	return result.String()
+}
+
+// grantPermissions returns the permissions declared by the line and the block of scopes that follows, with the id-token
+// write permission merged in
+func grantPermissions(line string, indent string, value string, block string) string {
+	var scopes []string
+	switch {
+	case value == "":
+		if idTokenPermissionRegex.MatchString(block) {
+			return line + idTokenPermissionRegex.ReplaceAllString(block, "${1}id-token: write")
+		}
+
+		scopeIndent := indent + "  "
+		if match := permissionIndentRegex.FindStringSubmatch(block); match != nil {
+			scopeIndent = match[1]
+		}
+
+		return line + scopeIndent + "id-token: write\n" + block
+	case value == "write-all":
+		// Already allowed to request OpenID Connect tokens
+		return line + block
+	case value == "read-all":
+		for _, scope := range readAllScopes {
+			scopes = append(scopes, scope+": read")
+		}
+	case strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}"):
+		for _, scope := range strings.Split(strings.Trim(value, "{}"), ",") {
+			scope = strings.TrimSpace(scope)
+			if scope != "" && !strings.HasPrefix(scope, "id-token:") {
+				scopes = append(scopes, scope)
+			}
+		}
+	default:
+		return line + block
+	}
+
+	scopes = append(scopes, "id-token: write")
+	scopeIndent := indent + "  "
+
+	return indent + "permissions:\n" + scopeIndent + strings.Join(scopes, "\n"+scopeIndent) + "\n" + block
+}
+
+// isWorkflowOrJobKey returns true when the key on the line with the indentation is a key of the workflow or of one of its
+// jobs, rather than for example an input of a step
+func isWorkflowOrJobKey(lines []string, index int, indent string) bool {
+	if indent == "" {
+		return true
+	}
+
+	job := parentLine(lines, index)
+	if job < 0 {
+		return false
+	}
+
+	jobs := parentLine(lines, job)
+	return jobs >= 0 && indentOf(lines[jobs]) == "" && jobsRegex.MatchString(lines[jobs])
+}
+
+// parentLine returns the index of the line declaring the key the line is nested under, or -1 for a top level line
+func parentLine(lines []string, index int) int {
+	indent := len(indentOf(lines[index]))
+	for i := index - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if len(indentOf(lines[i])) < indent {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func indentOf(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
@@ -0,0 +1,161 @@
+package github
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const credentialsWorkflow = `on:
+  push:
+    branches:
+      - main
+
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - name: Log in with Azure
+        uses: azure/login@v1
+        with:
+          creds: ${{ secrets.AZURE_CREDENTIALS }}
+`
+
+func TestUseFederatedLogin(t *testing.T) {
+	t.Run("CredentialsLogin", func(t *testing.T) {
+		updated, changed := UseFederatedLogin(credentialsWorkflow)
+		require.True(t, changed)
+		require.Equal(t, `on:
+  push:
+    branches:
+      - main
+
+permissions:
+  id-token: write
+  contents: read
+
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - name: Log in with Azure
+        uses: azure/login@v1
+        with:
+          client-id: ${{ vars.AZURE_CLIENT_ID }}
+          tenant-id: ${{ vars.AZURE_TENANT_ID }}
+          subscription-id: ${{ vars.AZURE_SUBSCRIPTION_ID }}
+`, updated)
+
+		// Updating an updated workflow changes nothing
+		_, changed = UseFederatedLogin(updated)
+		require.False(t, changed)
+	})
+
+	t.Run("ExistingPermissions", func(t *testing.T) {
+		workflow := "permissions:\n  id-token: write\n\n" + credentialsWorkflow
+
+		updated, changed := UseFederatedLogin(workflow)
+		require.True(t, changed)
+		require.Equal(t, 1, len(permissionsRegex.FindAllStringIndex(updated, -1)))
+		require.NotContains(t, updated, "AZURE_CREDENTIALS")
+	})
+
+	t.Run("SubscriptionSecret", func(t *testing.T) {
+		workflow := credentialsWorkflow + `      - name: Azure Dev Provision
+        run: azd provision --no-prompt
+        env:
+          AZURE_SUBSCRIPTION_ID: ${{ secrets.AZURE_SUBSCRIPTION_ID }}
+`
+
+		updated, changed := UseFederatedLogin(workflow)
+		require.True(t, changed)
+		require.NotContains(t, updated, "secrets.AZURE_SUBSCRIPTION_ID")
+		require.Contains(t, updated, "AZURE_SUBSCRIPTION_ID: ${{ vars.AZURE_SUBSCRIPTION_ID }}")
+	})
+
+	t.Run("MergePermissions", func(t *testing.T) {
+		tests := map[string]struct {
+			workflow string
+			expected []string
+		}{
+			"Block": {
+				"permissions:\n    contents: read\n\n" + credentialsWorkflow,
+				[]string{"permissions:\n    id-token: write\n    contents: read\n"},
+			},
+			"IdTokenRead": {
+				"permissions:\n  id-token: read\n\n" + credentialsWorkflow,
+				[]string{"permissions:\n  id-token: write\n\n"},
+			},
+			"EmptyMap": {
+				"permissions: {}\n\n" + credentialsWorkflow,
+				[]string{"permissions:\n  id-token: write\n\n"},
+			},
+			"FlowMap": {
+				"permissions: { contents: read, id-token: none }\n\n" + credentialsWorkflow,
+				[]string{"permissions:\n  contents: read\n  id-token: write\n\n"},
+			},
+			"ReadAll": {
+				"permissions: read-all\n\n" + credentialsWorkflow,
+				[]string{"permissions:\n  actions: read\n", "  contents: read\n", "  id-token: write\n\n"},
+			},
+			"WriteAll": {
+				"permissions: write-all\n\n" + credentialsWorkflow,
+				[]string{"permissions: write-all\n\n"},
+			},
+			"EndOfWorkflow": {
+				credentialsWorkflow + "\npermissions:\n  contents: read",
+				[]string{"permissions:\n  id-token: write\n  contents: read"},
+			},
+		}
+
+		for name, test := range tests {
+			test := test
+			t.Run(name, func(t *testing.T) {
+				updated, changed := UseFederatedLogin(test.workflow)
+				require.True(t, changed)
+				require.Equal(t, 1, len(permissionsRegex.FindAllStringIndex(updated, -1)))
+				require.NotContains(t, updated, "id-token: read")
+				require.NotContains(t, updated, "id-token: none")
+
+				for _, expected := range test.expected {
+					require.Contains(t, updated, expected)
+				}
+			})
+		}
+	})
+
+	t.Run("JobPermissions", func(t *testing.T) {
+		workflow := strings.Replace(credentialsWorkflow, "    runs-on: ubuntu-latest\n",
+			"    runs-on: ubuntu-latest\n    permissions:\n      contents: read\n", 1) + `  test:
+    runs-on: ubuntu-latest
+    permissions: read-all
+    steps:
+      - uses: actions/checkout@v3
+      - uses: contoso/action@v1
+        with:
+          permissions: none
+`
+
+		updated, changed := UseFederatedLogin(workflow)
+		require.True(t, changed)
+		require.Contains(t, updated, "permissions:\n  id-token: write\n  contents: read\n\njobs:")
+		require.Contains(t, updated, "    permissions:\n      id-token: write\n      contents: read\n    steps:")
+		require.Contains(t, updated, "    permissions:\n      actions: read\n")
+		require.Contains(t, updated, "      statuses: read\n      id-token: write\n    steps:")
+
+		// The inputs of the steps are not permissions
+		require.Contains(t, updated, "        with:\n          permissions: none\n")
+
+		// Updating the permissions again changes nothing
+		require.Equal(t, updated, grantIdTokenWrite(updated))
+	})
+
+	t.Run("NoCredentialsLogin", func(t *testing.T) {
+		workflow := "jobs:\n  build:\n    runs-on: ubuntu-latest\n"
+
+		updated, changed := UseFederatedLogin(workflow)
+		require.False(t, changed)
+		require.Equal(t, workflow, updated)
+	})
+}
@@ -0,0 +1,51 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package gitlab
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CiFileName is the name of the file configuring the CI/CD pipeline of a GitLab project
+const CiFileName = ".gitlab-ci.yml"
+
+// The pipeline provisioning & deploying the application on pushes to the default branch, using the variables
+// configured by `azd pipeline config`
+const ciConfiguration = `# Provisions the infrastructure & deploys the application with the Azure Developer CLI.
+# The variables used by the job are configured by running 'azd pipeline config --provider gitlab'.
+stages:
+  - deploy
+
+azure-dev:
+  stage: deploy
+  image: mcr.microsoft.com/azure-dev-cli-apps:latest
+  rules:
+    - if: $CI_COMMIT_BRANCH == $CI_DEFAULT_BRANCH
+  script:
+    - az login --service-principal --username "$AZURE_CLIENT_ID" --password "$AZURE_CLIENT_SECRET" --tenant "$AZURE_TENANT_ID"
+    - az account set --subscription "$AZURE_SUBSCRIPTION_ID"
+    - azd provision --no-prompt
+    - azd deploy --no-prompt
+`
+
+// WriteCiFile writes the pipeline running `azd provision` & `azd deploy` to the .gitlab-ci.yml file of the directory.
+// An existing file is left unchanged. It returns true when the file was written.
+func WriteCiFile(directory string) (bool, error) {
+	ciFilePath := filepath.Join(directory, CiFileName)
+
+	if _, err := os.Stat(ciFilePath); err == nil {
+		return false, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("checking for %s: %w", CiFileName, err)
+	}
+
+	if err := os.WriteFile(ciFilePath, []byte(ciConfiguration), 0644); err != nil {
+		return false, fmt.Errorf("writing %s: %w", CiFileName, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,33 @@
+package gitlab
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCiFile(t *testing.T) {
+	directory := t.TempDir()
+
+	written, err := WriteCiFile(directory)
+	require.NoError(t, err)
+	require.True(t, written)
+
+	content, err := os.ReadFile(filepath.Join(directory, CiFileName))
+	require.NoError(t, err)
+	require.Contains(t, string(content), "azd provision --no-prompt")
+	require.Contains(t, string(content), "azd deploy --no-prompt")
+
+	// An existing file is left unchanged
+	require.NoError(t, os.WriteFile(filepath.Join(directory, CiFileName), []byte("stages: []"), 0600))
+
+	written, err = WriteCiFile(directory)
+	require.NoError(t, err)
+	require.False(t, written)
+
+	content, err = os.ReadFile(filepath.Join(directory, CiFileName))
+	require.NoError(t, err)
+	require.Equal(t, "stages: []", string(content))
+}
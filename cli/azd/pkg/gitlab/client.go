@@ -0,0 +1,160 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/httpUtil"
+)
+
+// TokenEnvVarName is the environment variable holding the access token used to authenticate with GitLab,
+// shared with the GitLab CLI
+const TokenEnvVarName = "GITLAB_TOKEN"
+
+var ErrNotFound = errors.New("gitlab resource not found")
+
+// Masked variables must be at least 8 characters long, on a single line, using only the characters
+// of the Base64 alphabets & the @ : . ~ characters
+var maskableValueRegex = regexp.MustCompile(`^[a-zA-Z0-9+/=@:.~_-]{8,}$`)
+
+// Client manages the CI/CD configuration of GitLab projects using the REST API of a GitLab instance
+type Client interface {
+	GetProject(ctx context.Context, path string) (Project, error)
+	// CreateOrUpdateVariable creates a CI/CD variable of the project, or updates the existing variable with the same key
+	CreateOrUpdateVariable(ctx context.Context, project Project, variable Variable) error
+}
+
+type Project struct {
+	Id                int    `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebUrl            string `json:"web_url"`
+}
+
+type Variable struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Masked bool   `json:"masked"`
+	// Protected variables are only available to pipelines running on protected branches & tags
+	Protected bool `json:"protected"`
+}
+
+// CanMask returns true when GitLab can mask the value in the logs of jobs
+func CanMask(value string) bool {
+	return maskableValueRegex.MatchString(value)
+}
+
+type NewClientArgs struct {
+	// BaseUrl is the URL of the GitLab instance, ex) https://gitlab.com
+	BaseUrl string
+	// Token authenticates the requests
+	Token string
+	// HttpClient allows us to stub out the http requests for testing.
+	// Defaults to the client found in the context of each request.
+	HttpClient httpUtil.HttpUtil
+}
+
+func NewClient(args NewClientArgs) Client {
+	return &client{
+		baseUrl:    strings.TrimSuffix(args.BaseUrl, "/"),
+		token:      args.Token,
+		httpClient: args.HttpClient,
+	}
+}
+
+type client struct {
+	baseUrl    string
+	token      string
+	httpClient httpUtil.HttpUtil
+}
+
+func (c *client) GetProject(ctx context.Context, path string) (Project, error) {
+	var project Project
+	if err := c.send(ctx, http.MethodGet, fmt.Sprintf("/projects/%s", url.PathEscape(path)), nil, &project); err != nil {
+		return Project{}, fmt.Errorf("getting project '%s': %w", path, err)
+	}
+
+	return project, nil
+}
+
+func (c *client) CreateOrUpdateVariable(ctx context.Context, project Project, variable Variable) error {
+	variablePath := fmt.Sprintf("/projects/%d/variables/%s", project.Id, url.PathEscape(variable.Key))
+
+	err := c.send(ctx, http.MethodGet, variablePath, nil, nil)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		err = c.send(ctx, http.MethodPost, fmt.Sprintf("/projects/%d/variables", project.Id), variable, nil)
+	case err == nil:
+		err = c.send(ctx, http.MethodPut, variablePath, variable, nil)
+	}
+
+	if err != nil {
+		return fmt.Errorf("saving variable '%s': %w", variable.Key, err)
+	}
+
+	return nil
+}
+
+// Sends an authenticated request to the REST API of the GitLab instance, unmarshalling the response into result when not nil.
+// Responses with a 404 status are returned as ErrNotFound.
+func (c *client) send(ctx context.Context, method string, path string, body any, result any) error {
+	request := &httpUtil.HttpRequestMessage{
+		Url:    fmt.Sprintf("%s/api/v4%s", c.baseUrl, path),
+		Method: method,
+		Headers: map[string]string{
+			"PRIVATE-TOKEN": c.token,
+		},
+	}
+
+	if body != nil {
+		bodyJson, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshalling JSON body: %w", err)
+		}
+
+		request.Body = string(bodyJson)
+	}
+
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = httpUtil.GetHttpUtilFromContext(ctx)
+	}
+
+	response, err := httpClient.Send(request)
+	if err != nil {
+		return fmt.Errorf("sending http request: %w", err)
+	}
+
+	switch {
+	case response.Status == http.StatusNotFound:
+		return ErrNotFound
+	case response.Status == http.StatusUnauthorized || response.Status == http.StatusForbidden:
+		return fmt.Errorf("the access token is not authorized to access %s, ensure %s is set to a valid token", c.baseUrl, TokenEnvVarName)
+	case response.Status >= http.StatusBadRequest:
+		var gitLabError struct {
+			Message any `json:"message"`
+		}
+
+		if err := json.Unmarshal(response.Body, &gitLabError); err == nil && gitLabError.Message != nil {
+			return fmt.Errorf("gitlab request failed with status %d: %v", response.Status, gitLabError.Message)
+		}
+
+		return fmt.Errorf("gitlab request failed with status %d: %s", response.Status, string(response.Body))
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(response.Body, result); err != nil {
+			return fmt.Errorf("could not unmarshal response %s: %w", string(response.Body), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/httpUtil"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitLabServer is a local stand-in for the REST API of a GitLab instance
+type fakeGitLabServer struct {
+	mu        sync.Mutex
+	server    *httptest.Server
+	requests  []string
+	variables map[string]Variable
+}
+
+func newFakeGitLabServer(t *testing.T) *fakeGitLabServer {
+	f := &fakeGitLabServer{variables: map[string]Variable{}}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeGitLabServer) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := r.URL.EscapedPath()
+	f.requests = append(f.requests, fmt.Sprintf("%s %s", r.Method, path))
+
+	if r.Header.Get("PRIVATE-TOKEN") != "TOKEN" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"401 Unauthorized"}`))
+		return
+	}
+
+	write := func(status int, value any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(value)
+	}
+
+	const variablesPath = "/api/v4/projects/42/variables"
+
+	switch {
+	case r.Method == http.MethodGet && path == "/api/v4/projects/contoso%2Fweb%2Ftodo":
+		write(http.StatusOK, Project{Id: 42, PathWithNamespace: "contoso/web/todo", WebUrl: "https://gitlab.contoso.com/contoso/web/todo"})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, variablesPath+"/"):
+		variable, has := f.variables[strings.TrimPrefix(path, variablesPath+"/")]
+		if !has {
+			write(http.StatusNotFound, map[string]string{"message": "404 Variable Not Found"})
+			return
+		}
+		write(http.StatusOK, variable)
+	case r.Method == http.MethodPost && path == variablesPath:
+		var variable Variable
+		_ = json.NewDecoder(r.Body).Decode(&variable)
+		if variable.Masked && !CanMask(variable.Value) {
+			write(http.StatusBadRequest, map[string]any{"message": map[string][]string{"value": {"is invalid"}}})
+			return
+		}
+		f.variables[variable.Key] = variable
+		write(http.StatusCreated, variable)
+	case r.Method == http.MethodPut && strings.HasPrefix(path, variablesPath+"/"):
+		var variable Variable
+		_ = json.NewDecoder(r.Body).Decode(&variable)
+		f.variables[strings.TrimPrefix(path, variablesPath+"/")] = variable
+		write(http.StatusOK, variable)
+	default:
+		write(http.StatusNotFound, map[string]string{"message": "404 Not Found"})
+	}
+}
+
+func newTestClient(server *fakeGitLabServer, token string) Client {
+	return NewClient(NewClientArgs{
+		BaseUrl:    server.server.URL + "/",
+		Token:      token,
+		HttpClient: httpUtil.NewHttpUtil(),
+	})
+}
+
+func TestClientGetProject(t *testing.T) {
+	server := newFakeGitLabServer(t)
+
+	project, err := newTestClient(server, "TOKEN").GetProject(context.Background(), "contoso/web/todo")
+	require.NoError(t, err)
+	require.Equal(t, 42, project.Id)
+
+	_, err = newTestClient(server, "TOKEN").GetProject(context.Background(), "contoso/missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = newTestClient(server, "WRONG").GetProject(context.Background(), "contoso/web/todo")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), TokenEnvVarName)
+}
+
+func TestClientCreateOrUpdateVariable(t *testing.T) {
+	server := newFakeGitLabServer(t)
+	client := newTestClient(server, "TOKEN")
+	project := Project{Id: 42}
+
+	err := client.CreateOrUpdateVariable(context.Background(), project, Variable{Key: "AZURE_CLIENT_SECRET", Value: "s3cr3t~value", Masked: true})
+	require.NoError(t, err)
+	require.Contains(t, server.requests, "POST /api/v4/projects/42/variables")
+
+	err = client.CreateOrUpdateVariable(context.Background(), project, Variable{Key: "AZURE_CLIENT_SECRET", Value: "n3w~s3cr3t", Masked: true})
+	require.NoError(t, err)
+	require.Contains(t, server.requests, "PUT /api/v4/projects/42/variables/AZURE_CLIENT_SECRET")
+	require.Equal(t, Variable{Key: "AZURE_CLIENT_SECRET", Value: "n3w~s3cr3t", Masked: true}, server.variables["AZURE_CLIENT_SECRET"])
+
+	err = client.CreateOrUpdateVariable(context.Background(), project, Variable{Key: "SHORT", Value: "short", Masked: true})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is invalid")
+}
+
+func TestCanMask(t *testing.T) {
+	require.True(t, CanMask("00000000-0000-0000-0000-000000000000"))
+	require.True(t, CanMask("abc~DEF.ghi_jkl"))
+
+	require.False(t, CanMask("westus2"))
+	require.False(t, CanMask("has spaces in it"))
+	require.False(t, CanMask("multi\nline value"))
+}
@@ -0,0 +1,94 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package gitlab
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrRemoteIsNotGitLabProject = errors.New("not a gitlab project url")
+
+// The public GitLab service, other hosts are self-managed instances
+const GitLabHostName = "gitlab.com"
+
+// HostEnvVarName is the environment variable holding the host of a self-managed GitLab instance,
+// shared with the GitLab CLI
+const HostEnvVarName = "GITLAB_HOST"
+
+// http(s)://[user@]<host>[:port]/<group>[/<subgroup>...]/<project>[.git]
+var gitLabRemoteHttpsUrlRegex = regexp.MustCompile(`^(https?)://(?:[^@/]+@)?([^/]+)/(.+?)(?:\.git)?/?$`)
+
+// git@<host>:<group>[/<subgroup>...]/<project>[.git] or ssh://git@<host>[:port]/<group>[/<subgroup>...]/<project>[.git]
+var gitLabRemoteSshUrlRegex = regexp.MustCompile(`^(?:ssh://)?git@([^:/]+)(?::\d+)?[:/](.+?)(?:\.git)?$`)
+
+// RemoteProject is a project hosted by a GitLab instance
+type RemoteProject struct {
+	// BaseUrl is the URL of the GitLab instance, ex) https://gitlab.com
+	BaseUrl string
+	Host    string
+	// Path is the full path of the project, including its group & subgroups, ex) contoso/web/todo
+	Path string
+}
+
+// GetProjectForRemote parses the URL of a git remote hosted by a GitLab instance.
+// Projects may be nested in subgroups, so the path is everything after the host.
+func GetProjectForRemote(remoteUrl string) (RemoteProject, error) {
+	var project RemoteProject
+
+	if captures := gitLabRemoteHttpsUrlRegex.FindStringSubmatch(remoteUrl); captures != nil {
+		project = RemoteProject{
+			BaseUrl: fmt.Sprintf("%s://%s", captures[1], captures[2]),
+			Host:    strings.Split(captures[2], ":")[0],
+			Path:    captures[3],
+		}
+	} else if captures := gitLabRemoteSshUrlRegex.FindStringSubmatch(remoteUrl); captures != nil {
+		project = RemoteProject{
+			BaseUrl: fmt.Sprintf("https://%s", captures[1]),
+			Host:    captures[1],
+			Path:    captures[2],
+		}
+	} else {
+		return RemoteProject{}, ErrRemoteIsNotGitLabProject
+	}
+
+	// Projects always belong to a group or a user
+	segments := strings.Split(project.Path, "/")
+	if len(segments) < 2 {
+		return RemoteProject{}, ErrRemoteIsNotGitLabProject
+	}
+
+	for _, segment := range segments {
+		if segment == "" || strings.HasPrefix(segment, "_") {
+			return RemoteProject{}, ErrRemoteIsNotGitLabProject
+		}
+	}
+
+	return project, nil
+}
+
+// IsGitLabRemote returns true when the remote is hosted by gitlab.com, by a host named after GitLab, ex) gitlab.contoso.com,
+// or by one of the known self-managed hosts
+func IsGitLabRemote(remoteUrl string, knownHosts ...string) bool {
+	project, err := GetProjectForRemote(remoteUrl)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(project.Host)
+	if host == GitLabHostName || strings.Contains(host, "gitlab") {
+		return true
+	}
+
+	for _, knownHost := range knownHosts {
+		knownHost = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(knownHost), "https://"), "http://")
+		if knownHost != "" && strings.TrimSuffix(knownHost, "/") == host {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,46 @@
+package gitlab
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProjectForRemote(t *testing.T) {
+	cases := []struct {
+		remote  string
+		result  RemoteProject
+		isError bool
+	}{
+		{remote: "https://gitlab.com/contoso/todo.git", result: RemoteProject{"https://gitlab.com", "gitlab.com", "contoso/todo"}},
+		{remote: "https://gitlab.com/contoso/web/apps/todo", result: RemoteProject{"https://gitlab.com", "gitlab.com", "contoso/web/apps/todo"}},
+		{remote: "https://oauth2@git.contoso.com:8443/web/todo.git", result: RemoteProject{"https://git.contoso.com:8443", "git.contoso.com", "web/todo"}},
+		{remote: "http://localhost:8080/web/todo.git", result: RemoteProject{"http://localhost:8080", "localhost", "web/todo"}},
+		{remote: "git@gitlab.com:contoso/web/todo.git", result: RemoteProject{"https://gitlab.com", "gitlab.com", "contoso/web/todo"}},
+		{remote: "ssh://git@git.contoso.com:2222/web/todo.git", result: RemoteProject{"https://git.contoso.com", "git.contoso.com", "web/todo"}},
+
+		{remote: "https://gitlab.com/todo.git", isError: true},
+		{remote: "https://dev.azure.com/contoso/web/_git/todo", isError: true},
+		{remote: "not a url", isError: true},
+	}
+
+	for _, c := range cases {
+		project, err := GetProjectForRemote(c.remote)
+		if c.isError {
+			require.ErrorIs(t, err, ErrRemoteIsNotGitLabProject, c.remote)
+		} else {
+			require.NoError(t, err, c.remote)
+			require.Equal(t, c.result, project, c.remote)
+		}
+	}
+}
+
+func TestIsGitLabRemote(t *testing.T) {
+	require.True(t, IsGitLabRemote("git@gitlab.com:contoso/todo.git"))
+	require.True(t, IsGitLabRemote("https://gitlab.contoso.com/web/todo.git"))
+	require.True(t, IsGitLabRemote("https://git.contoso.com/web/todo.git", "https://git.contoso.com/"))
+
+	require.False(t, IsGitLabRemote("https://git.contoso.com/web/todo.git"))
+	require.False(t, IsGitLabRemote("https://github.com/contoso/todo.git"))
+	require.False(t, IsGitLabRemote("https://github.com/contoso/todo.git", ""))
+}
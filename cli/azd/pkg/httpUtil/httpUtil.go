@@ -36,33 +36,40 @@ func (hu *httpUtil) Send(req *HttpRequestMessage) (*HttpResponseMessage, error)
 
 	request, err := http.NewRequest(req.Method, req.Url, requestReader)
 	if err != nil {
-		return nil, fmt.Errorf("creating request")
+		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
 	request.Header.Add("Content-Type", "application/json")
 	request.Header.Add("Accept", "application/json")
 
+	// The headers of the request replace the default headers, ex) a form content type
 	if req.Headers != nil {
 		for k, v := range req.Headers {
-			request.Header.Add(k, v)
+			request.Header.Set(k, v)
 		}
 	}
 
 	client := &http.Client{}
 	response, err := client.Do(request)
 	if err != nil {
-		return nil, fmt.Errorf("executing http request")
+		return nil, fmt.Errorf("executing http request: %w", err)
 	}
 
 	defer response.Body.Close()
 	responseBytes, err := io.ReadAll(response.Body)
 	if err != nil {
-		return nil, fmt.Errorf("reading response")
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	responseHeaders := map[string]string{}
+	for key := range response.Header {
+		responseHeaders[key] = response.Header.Get(key)
 	}
 
 	responseMessage := &HttpResponseMessage{
-		Status: response.StatusCode,
-		Body:   responseBytes,
+		Headers: responseHeaders,
+		Status:  response.StatusCode,
+		Body:    responseBytes,
 	}
 
 	return responseMessage, nil
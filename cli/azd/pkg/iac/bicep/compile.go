@@ -33,6 +33,8 @@ func Compile(ctx context.Context, bicepCli bicep.BicepCli, bicepPath string) (Co
 type CompiledTemplate struct {
 	Parameters map[string]map[string]interface{}
 	Outputs    map[string]interface{}
+	// The resources of the template, including the templates of the nested deployments of the modules
+	Resources interface{}
 }
 
 // CanonicalizeDeploymentOutputs constructs a new map based on the value of `deploymentOutputs`, correcting the case
@@ -0,0 +1,284 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+// Package ignore matches paths against the patterns of ignore files, like the .dockerignore file of a build context
+// or the .gitignore file of a repository.
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DockerIgnoreFileName is the name of the file listing the paths excluded from a Docker build context
+const DockerIgnoreFileName = ".dockerignore"
+
+// GitIgnoreFileName is the name of the file listing the paths git does not track
+const GitIgnoreFileName = ".gitignore"
+
+// AzdIgnoreFileName is the name of the file listing the paths of a service excluded from its deployment package.
+// The file has the syntax of a .gitignore file.
+const AzdIgnoreFileName = ".azdignore"
+
+// DefaultAzdIgnorePatterns are the paths excluded from deployment packages, unless included back by the .azdignore file
+var DefaultAzdIgnorePatterns = []string{
+	".git/",
+	".azure/",
+	"__pycache__/",
+	".env",
+	AzdIgnoreFileName,
+}
+
+// Matcher tells whether paths are excluded by the patterns of an ignore file.
+// The last pattern matching a path wins, patterns starting with ! include back the paths excluded by earlier patterns.
+type Matcher struct {
+	patterns []pattern
+}
+
+type pattern struct {
+	text   string
+	negate bool
+	// Whether the pattern only matches directories, ex) the .gitignore pattern "build/"
+	directoryOnly bool
+	regexp        *regexp.Regexp
+}
+
+// NewDockerIgnore creates a matcher for .dockerignore patterns: the patterns are relative to the root of the build
+// context, * and ? do not match the path separator, ** matches any number of directories, and a path is excluded
+// when the path or one of its parent directories matches.
+func NewDockerIgnore(lines []string) (*Matcher, error) {
+	matcher := &Matcher{}
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		negate := false
+		if strings.HasPrefix(line, "!") {
+			negate = true
+			line = strings.TrimSpace(line[1:])
+		}
+
+		text := strings.TrimPrefix(path.Clean(filepath.ToSlash(line)), "/")
+		if text == "" || text == "." {
+			continue
+		}
+
+		expression, err := patternRegexp(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", line, err)
+		}
+
+		matcher.patterns = append(matcher.patterns, pattern{text: text, negate: negate, regexp: expression})
+	}
+
+	return matcher, nil
+}
+
+// NewGitIgnore creates a matcher for .gitignore patterns: a pattern without a slash matches a file or directory at any
+// depth, a pattern with a leading or middle slash is relative to the folder of the ignore file, and a pattern with a
+// trailing slash only matches directories.
+func NewGitIgnore(lines []string) (*Matcher, error) {
+	matcher := &Matcher{}
+
+	for _, line := range lines {
+		// Trailing spaces are ignored unless escaped
+		if !strings.HasSuffix(line, "\\ ") {
+			line = strings.TrimRight(line, " \t")
+		}
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		negate := false
+		if strings.HasPrefix(line, "!") {
+			negate = true
+			line = line[1:]
+		}
+
+		directoryOnly := strings.HasSuffix(line, "/")
+		text := strings.TrimSuffix(line, "/")
+		if !strings.Contains(text, "/") {
+			text = "**/" + text
+		}
+
+		text = strings.TrimPrefix(text, "/")
+		if text == "" || text == "**/" {
+			continue
+		}
+
+		expression, err := patternRegexp(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", line, err)
+		}
+
+		matcher.patterns = append(matcher.patterns, pattern{
+			text:          text,
+			negate:        negate,
+			directoryOnly: directoryOnly,
+			regexp:        expression,
+		})
+	}
+
+	return matcher, nil
+}
+
+// ReadDockerIgnore reads the .dockerignore file of the build context. A matcher excluding nothing is returned when
+// the build context has no .dockerignore file.
+func ReadDockerIgnore(contextDir string) (*Matcher, error) {
+	return readIgnoreFile(contextDir, DockerIgnoreFileName, NewDockerIgnore)
+}
+
+// ReadGitIgnore reads the .gitignore file of the folder. A matcher excluding nothing is returned when the folder has
+// no .gitignore file.
+func ReadGitIgnore(dir string) (*Matcher, error) {
+	return readIgnoreFile(dir, GitIgnoreFileName, NewGitIgnore)
+}
+
+// ReadAzdIgnore reads the .azdignore file of the folder. The patterns of the file follow DefaultAzdIgnorePatterns, the
+// defaults are used alone when the folder has no .azdignore file.
+func ReadAzdIgnore(dir string) (*Matcher, error) {
+	return readIgnoreFile(dir, AzdIgnoreFileName, func(lines []string) (*Matcher, error) {
+		return NewGitIgnore(append(append([]string{}, DefaultAzdIgnorePatterns...), lines...))
+	})
+}
+
+func readIgnoreFile(dir string, fileName string, parse func(lines []string) (*Matcher, error)) (*Matcher, error) {
+	file, err := os.Open(filepath.Join(dir, fileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return parse(nil)
+	} else if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", fileName, err)
+	}
+	defer file.Close()
+
+	lines, err := readLines(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", fileName, err)
+	}
+
+	return parse(lines)
+}
+
+// Ignores returns true when the path, relative to the root of the ignore file, is excluded
+func (m *Matcher) Ignores(relativePath string) bool {
+	return m.matches(relativePath, false)
+}
+
+// IgnoresDirectory returns true when the directory, relative to the root of the ignore file, is excluded
+func (m *Matcher) IgnoresDirectory(relativePath string) bool {
+	return m.matches(relativePath, true)
+}
+
+func (m *Matcher) matches(relativePath string, isDir bool) bool {
+	relativePath = strings.TrimPrefix(path.Clean(filepath.ToSlash(relativePath)), "/")
+
+	// The parent directories are matched as well, excluding a directory excludes its contents
+	candidates := []string{relativePath}
+	for parent := path.Dir(relativePath); parent != "." && parent != "/"; parent = path.Dir(parent) {
+		candidates = append(candidates, parent)
+	}
+
+	ignored := false
+	for _, pattern := range m.patterns {
+		if pattern.negate == !ignored {
+			// The pattern would not change the outcome
+			continue
+		}
+
+		for i, candidate := range candidates {
+			// Only the path itself may not be a directory
+			if pattern.directoryOnly && i == 0 && !isDir {
+				continue
+			}
+
+			if pattern.regexp.MatchString(candidate) {
+				ignored = !pattern.negate
+				break
+			}
+		}
+	}
+
+	return ignored
+}
+
+// HasExclusions returns true when a pattern includes back paths, in which case the contents of an ignored directory
+// can't be skipped without being matched.
+func (m *Matcher) HasExclusions() bool {
+	for _, pattern := range m.patterns {
+		if pattern.negate {
+			return true
+		}
+	}
+
+	return false
+}
+
+// patternRegexp converts a pattern to the regular expression matching the same paths
+func patternRegexp(text string) (*regexp.Regexp, error) {
+	var builder strings.Builder
+	builder.WriteString("^")
+
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '*':
+			if i+1 < len(text) && text[i+1] == '*' {
+				i++
+				if i+1 < len(text) && text[i+1] == '/' {
+					// **/ matches zero or more directories
+					i++
+					builder.WriteString("(.*/)?")
+				} else {
+					builder.WriteString(".*")
+				}
+			} else {
+				builder.WriteString("[^/]*")
+			}
+		case '?':
+			builder.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(text[i+1:], ']')
+			if end < 0 {
+				return nil, errors.New("unterminated character class")
+			}
+
+			class := text[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			builder.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(text) {
+				i++
+				builder.WriteString(regexp.QuoteMeta(string(text[i])))
+			}
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	builder.WriteString("$")
+	return regexp.Compile(builder.String())
+}
+
+func readLines(reader io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines, scanner.Err()
+}
@@ -0,0 +1,148 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerIgnore(t *testing.T) {
+	matcher, err := NewDockerIgnore([]string{
+		"# comment",
+		"",
+		"node_modules",
+		"/dist",
+		"*.log",
+		"**/*.tmp",
+		"!important.log",
+		"docs/**",
+		"!docs/README.md",
+		"src/test?",
+		"[ab].txt",
+	})
+	require.NoError(t, err)
+
+	tests := map[string]bool{
+		"node_modules":                true,
+		"node_modules/react/index.js": true,
+		"src/node_modules":            false,
+		"dist/app.js":                 true,
+		"build.log":                   true,
+		"logs/build.log":              false,
+		"important.log":               false,
+		"a/b/c.tmp":                   true,
+		"c.tmp":                       true,
+		"docs/guide/intro.md":         true,
+		"docs/README.md":              false,
+		"src/test1":                   true,
+		"src/test/x.go":               false,
+		"a.txt":                       true,
+		"c.txt":                       false,
+		"Dockerfile":                  false,
+		"./dist":                      true,
+	}
+
+	for path, ignored := range tests {
+		require.Equal(t, ignored, matcher.Ignores(path), path)
+	}
+
+	require.True(t, matcher.HasExclusions())
+}
+
+func TestReadDockerIgnore(t *testing.T) {
+	dir := t.TempDir()
+
+	matcher, err := ReadDockerIgnore(dir)
+	require.NoError(t, err)
+	require.False(t, matcher.Ignores("node_modules"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DockerIgnoreFileName), []byte("node_modules\r\n.git\n"), 0600))
+
+	matcher, err = ReadDockerIgnore(dir)
+	require.NoError(t, err)
+	require.True(t, matcher.Ignores("node_modules/react"))
+	require.True(t, matcher.Ignores(".git"))
+	require.False(t, matcher.HasExclusions())
+
+	_, err = NewDockerIgnore([]string{"[abc"})
+	require.Error(t, err)
+}
+
+func TestGitIgnore(t *testing.T) {
+	matcher, err := NewGitIgnore([]string{
+		"# comment",
+		"node_modules",
+		"/dist",
+		"*.log",
+		"!important.log",
+		"build/",
+		"docs/*.md",
+		"trailing   ",
+	})
+	require.NoError(t, err)
+
+	tests := map[string]bool{
+		"node_modules":          true,
+		"src/node_modules/a.js": true,
+		"dist/app.js":           true,
+		"src/dist/app.js":       false,
+		"logs/build.log":        true,
+		"logs/important.log":    false,
+		"build/output.js":       true,
+		"src/build/output.js":   true,
+		"docs/README.md":        true,
+		"docs/guide/README.md":  false,
+		"src/docs/README.md":    false,
+		"trailing":              true,
+		"main.go":               false,
+	}
+
+	for path, ignored := range tests {
+		require.Equal(t, ignored, matcher.Ignores(path), path)
+	}
+
+	// Patterns with a trailing slash only match directories
+	require.False(t, matcher.Ignores("build"))
+	require.True(t, matcher.IgnoresDirectory("build"))
+	require.True(t, matcher.IgnoresDirectory("src/build"))
+}
+
+func TestReadGitIgnore(t *testing.T) {
+	dir := t.TempDir()
+
+	matcher, err := ReadGitIgnore(dir)
+	require.NoError(t, err)
+	require.False(t, matcher.Ignores("bin/app"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, GitIgnoreFileName), []byte("bin/\n"), 0600))
+
+	matcher, err = ReadGitIgnore(dir)
+	require.NoError(t, err)
+	require.True(t, matcher.Ignores("bin/app"))
+	require.True(t, matcher.Ignores("src/bin/app"))
+}
+
+func TestReadAzdIgnore(t *testing.T) {
+	dir := t.TempDir()
+
+	matcher, err := ReadAzdIgnore(dir)
+	require.NoError(t, err)
+	require.True(t, matcher.IgnoresDirectory(".git"))
+	require.True(t, matcher.Ignores("src/__pycache__/app.pyc"))
+	require.True(t, matcher.Ignores(".env"))
+	require.False(t, matcher.Ignores("app.py"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, AzdIgnoreFileName), []byte("tests/\n!.env\n"), 0600))
+
+	matcher, err = ReadAzdIgnore(dir)
+	require.NoError(t, err)
+	require.True(t, matcher.Ignores("tests/fixtures/data.json"))
+	require.True(t, matcher.Ignores(AzdIgnoreFileName))
+	require.False(t, matcher.Ignores(".env"))
+	require.True(t, matcher.HasExclusions())
+}
@@ -0,0 +1,140 @@
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/async"
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+)
+
+// The name of the template module commonly used by ARM quickstart templates
+const armQuickstartModule = "azuredeploy"
+
+// ArmProvider exposes infrastructure provisioning using ARM JSON templates.
+// Deployment & destroy behave the same as Bicep, but the template is read directly without compiling a Bicep module.
+type ArmProvider struct {
+	*BicepProvider
+}
+
+// Name gets the name of the infra provider
+func (p *ArmProvider) Name() string {
+	return "ARM"
+}
+
+func (p *ArmProvider) RequiredExternalTools() []tools.ExternalTool {
+	return []tools.ExternalTool{p.azCli}
+}
+
+// Previews the infrastructure provisioning from the parameters & outputs declared within the ARM template
+func (p *ArmProvider) Preview(ctx context.Context) *async.InteractiveTaskWithProgress[*PreviewResult, *PreviewProgress] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*PreviewResult, *PreviewProgress]) {
+			asyncContext.SetProgress(&PreviewProgress{Message: "Generating ARM parameters file", Timestamp: time.Now()})
+			parametersTemplate, err := p.createParametersFile()
+			if err != nil {
+				asyncContext.SetError(fmt.Errorf("creating parameters file: %w", err))
+				return
+			}
+
+			asyncContext.SetProgress(&PreviewProgress{Message: "Reading ARM template", Timestamp: time.Now()})
+			template, err := p.readTemplate()
+			if err != nil {
+				asyncContext.SetError(fmt.Errorf("creating template: %w", err))
+				return
+			}
+
+			// Merge parameter values from template
+			for key, param := range template.Parameters {
+				if armParam, has := parametersTemplate.Parameters[key]; has {
+					param.Value = armParam.Value
+					template.Parameters[key] = param
+				}
+			}
+
+			asyncContext.SetResult(&PreviewResult{
+				Preview: *template,
+			})
+		})
+}
+
+// Provisioning the infrastructure within the specified ARM template
+func (p *ArmProvider) Deploy(ctx context.Context, preview *Preview, scope Scope) *async.InteractiveTaskWithProgress[*DeployResult, *DeployProgress] {
+	return p.deployTemplate(ctx, preview, scope, p.templatePath())
+}
+
+// Reads the outputs of the latest deployment of the environment
+func (p *ArmProvider) State(ctx context.Context) *async.InteractiveTaskWithProgress[*StateResult, *StateProgress] {
+	return p.deploymentState(ctx, p.readTemplate)
+}
+
+// Predicts the changes the deployment of the ARM template would make to the Azure resources
+func (p *ArmProvider) WhatIf(ctx context.Context, preview *Preview, scope Scope) *async.InteractiveTaskWithProgress[*PreviewResult, *PreviewProgress] {
+	return p.whatIfTemplate(ctx, preview, scope, p.templatePath())
+}
+
+// Reads the ARM template and converts it to a generic provisioning preview
+func (p *ArmProvider) readTemplate() (*Preview, error) {
+	templatePath := p.templatePath()
+	templateBytes, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading ARM template: %w", err)
+	}
+
+	var armTemplate BicepTemplate
+	if err := json.Unmarshal(templateBytes, &armTemplate); err != nil {
+		log.Printf("failed un-marshaling arm template to JSON (err: %v), template contents:\n%s", err, string(templateBytes))
+		return nil, fmt.Errorf("error un-marshaling arm template from json: %w", err)
+	}
+
+	return p.convertToPreview(armTemplate)
+}
+
+// Gets the path to the ARM template of the module
+func (p *ArmProvider) templatePath() string {
+	return filepath.Join(p.infraPath(), fmt.Sprintf("%s.json", p.options.Module))
+}
+
+// Gets the folder path to the infrastructure templates
+func (p *ArmProvider) infraPath() string {
+	infraPath := p.options.Path
+	if strings.TrimSpace(infraPath) == "" {
+		infraPath = "infra"
+	}
+
+	return filepath.Join(p.projectPath, infraPath)
+}
+
+// NewArmProvider creates a new instance of an ARM template Infra provider
+func NewArmProvider(env *environment.Environment, projectPath string, options Options, console input.Console, azCli azcli.AzCli) Provider {
+	provider := &ArmProvider{
+		BicepProvider: &BicepProvider{
+			env:         env,
+			projectPath: projectPath,
+			options:     options,
+			console:     console,
+			azCli:       azCli,
+		},
+	}
+
+	// Quickstart templates are named azuredeploy.json with an azuredeploy.parameters.json parameters file.
+	// Use them when the configured module does not exist so these templates work without any configuration.
+	if _, err := os.Stat(provider.templatePath()); errors.Is(err, os.ErrNotExist) {
+		quickstartPath := filepath.Join(provider.infraPath(), fmt.Sprintf("%s.json", armQuickstartModule))
+		if _, err := os.Stat(quickstartPath); err == nil {
+			provider.options.Module = armQuickstartModule
+		}
+	}
+
+	return provider
+}
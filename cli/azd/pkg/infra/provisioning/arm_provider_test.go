@@ -0,0 +1,175 @@
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+	"github.com/azure/azure-dev/cli/azd/test/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+// Creates a project directory containing an ARM template & parameters file for the specified module
+func createArmProject(t *testing.T, module string) string {
+	projectDir := t.TempDir()
+	infraDir := filepath.Join(projectDir, "infra")
+	require.NoError(t, os.MkdirAll(infraDir, 0755))
+
+	armTemplate := BicepTemplate{
+		Schema:         "https://schema.management.azure.com/schemas/2018-05-01/subscriptionDeploymentTemplate.json#",
+		ContentVersion: "1.0.0.0",
+		Parameters: map[string]BicepInputParameter{
+			"name":     {Type: "string"},
+			"location": {Type: "string"},
+			"sku":      {Type: "string", DefaultValue: "B1"},
+		},
+		Outputs: map[string]BicepOutputParameter{
+			"WEBSITE_URL": {Type: "string"},
+		},
+	}
+
+	armParameters := BicepTemplate{
+		Parameters: map[string]BicepInputParameter{
+			"name":     {Value: "${AZURE_ENV_NAME}"},
+			"location": {Value: "${AZURE_LOCATION}"},
+		},
+	}
+
+	templateBytes, err := json.Marshal(armTemplate)
+	require.NoError(t, err)
+	parametersBytes, err := json.Marshal(armParameters)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(infraDir, module+".json"), templateBytes, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(infraDir, module+".parameters.json"), parametersBytes, 0600))
+
+	return projectDir
+}
+
+func TestArmPreview(t *testing.T) {
+	for _, module := range []string{"main", "azuredeploy"} {
+		t.Run(module, func(t *testing.T) {
+			projectDir := createArmProject(t, module)
+			execUtil := setupExecUtilWithMocks(&BicepTemplate{}, nil)
+			azCli := azcli.NewAzCli(azcli.NewAzCliArgs{RunWithResultFn: execUtil.RunWithResult})
+
+			env := environment.Environment{Values: make(map[string]string)}
+			env.Values["AZURE_LOCATION"] = "eastus2"
+			env.SetEnvName("test-env")
+
+			options := Options{
+				Provider: Arm,
+				Path:     "infra",
+				Module:   "main",
+			}
+
+			infraProvider := NewArmProvider(&env, projectDir, options, &mocks.MockConsole{}, azCli)
+			require.Len(t, infraProvider.RequiredExternalTools(), 1)
+
+			previewTask := infraProvider.Preview(context.Background())
+
+			go func() {
+				for range previewTask.Progress() {
+				}
+			}()
+
+			previewResult, err := previewTask.Await()
+			require.Nil(t, err)
+			require.NotNil(t, previewResult.Preview)
+
+			require.Equal(t, "eastus2", previewResult.Preview.Parameters["location"].Value)
+			require.Equal(t, "test-env", previewResult.Preview.Parameters["name"].Value)
+			require.Equal(t, "B1", previewResult.Preview.Parameters["sku"].DefaultValue)
+			require.Contains(t, previewResult.Preview.Outputs, "WEBSITE_URL")
+
+			_, err = os.Stat(filepath.Join(projectDir, ".azure", "test-env", "infra", module+".parameters.json"))
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestArmDeploy(t *testing.T) {
+	expectedWebsiteUrl := "http://myapp.azurewebsites.net"
+	projectDir := createArmProject(t, "azuredeploy")
+
+	deployOutputs := make(map[string]azcli.AzCliDeploymentOutput)
+	deployOutputs["website_url"] = azcli.AzCliDeploymentOutput{Type: "string", Value: expectedWebsiteUrl}
+	azDeployment := azcli.AzCliDeployment{
+		Id:   "DEPLOYMENT_ID",
+		Name: "DEPLOYMENT_NAME",
+		Properties: azcli.AzCliDeploymentProperties{
+			Outputs: deployOutputs,
+		},
+	}
+
+	execUtil := setupExecUtilWithMocks(&BicepTemplate{}, &azDeployment)
+	// The deployment runs on the goroutine of the deploy task
+	var deployedTemplateMutex sync.Mutex
+	deployedTemplatePath := ""
+	azCli := azcli.NewAzCli(azcli.NewAzCliArgs{
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			if strings.Contains(strings.Join(args.Args, " "), "deployment sub create") {
+				for i, arg := range args.Args {
+					if arg == "--template-file" {
+						deployedTemplateMutex.Lock()
+						deployedTemplatePath = args.Args[i+1]
+						deployedTemplateMutex.Unlock()
+					}
+				}
+			}
+
+			return execUtil.RunWithResult(ctx, args)
+		},
+	})
+
+	env := environment.Environment{Values: make(map[string]string)}
+	env.Values["AZURE_LOCATION"] = "westus2"
+	env.SetEnvName("test-env")
+
+	options := Options{
+		Provider: Arm,
+		Path:     "infra",
+		Module:   "main",
+	}
+
+	ctx := context.Background()
+	scope := NewSubscriptionProvisioningScope(azCli, env.Values["AZURE_LOCATION"], env.GetSubscriptionId(), env.GetEnvName())
+	infraProvider := NewArmProvider(&env, projectDir, options, &mocks.MockConsole{}, azCli)
+
+	previewTask := infraProvider.Preview(ctx)
+	go func() {
+		for range previewTask.Progress() {
+		}
+	}()
+
+	previewResult, err := previewTask.Await()
+	require.Nil(t, err)
+
+	deployTask := infraProvider.Deploy(ctx, &previewResult.Preview, scope)
+	go func() {
+		for range deployTask.Progress() {
+		}
+	}()
+
+	go func() {
+		for range deployTask.Interactive() {
+		}
+	}()
+
+	deployResult, err := deployTask.Await()
+	require.Nil(t, err)
+	require.NotNil(t, deployResult)
+
+	// The JSON template is deployed as-is & output casing matches the template
+	deployedTemplateMutex.Lock()
+	defer deployedTemplateMutex.Unlock()
+	require.Equal(t, filepath.Join(projectDir, "infra", "azuredeploy.json"), deployedTemplatePath)
+	require.Equal(t, expectedWebsiteUrl, deployResult.Outputs["WEBSITE_URL"].Value)
+}
@@ -25,8 +25,7 @@ import (
 )
 
 type BicepTemplate struct {
-	//nolint:all
-	Schema         string                          `json:"$schema`
+	Schema         string                          `json:"$schema"`
 	ContentVersion string                          `json:"contentVersion"`
 	Parameters     map[string]BicepInputParameter  `json:"parameters"`
 	Outputs        map[string]BicepOutputParameter `json:"outputs"`
@@ -127,10 +126,13 @@ func (p *BicepProvider) UpdatePlan(ctx context.Context, preview Preview) error {
 
 // Provisioning the infrastructure within the specified template
 func (p *BicepProvider) Deploy(ctx context.Context, preview *Preview, scope Scope) *async.InteractiveTaskWithProgress[*DeployResult, *DeployProgress] {
+	return p.deployTemplate(ctx, preview, scope, p.modulePath())
+}
+
+// Deploys the template at the specified path, which is either a Bicep module or a compiled ARM template
+func (p *BicepProvider) deployTemplate(ctx context.Context, preview *Preview, scope Scope, templatePath string) *async.InteractiveTaskWithProgress[*DeployResult, *DeployProgress] {
 	return async.RunInteractiveTaskWithProgress(
 		func(asyncContext *async.InteractiveTaskContextWithProgress[*DeployResult, *DeployProgress]) {
-			isDeploymentComplete := false
-
 			err := asyncContext.Interact(func() error {
 				deploymentSlug := azure.SubscriptionDeploymentRID(p.env.GetSubscriptionId(), p.env.GetEnvName())
 				deploymentUrl := fmt.Sprintf("https://portal.azure.com/#blade/HubsExtension/DeploymentDetailsBlade/overview/id/%s\n\n", url.PathEscape(deploymentSlug))
@@ -149,14 +151,12 @@ func (p *BicepProvider) Deploy(ctx context.Context, preview *Preview, scope Scop
 			}
 
 			// Start the deployment
+			deploymentDone := make(chan struct{})
 			go func() {
-				defer func() {
-					isDeploymentComplete = true
-				}()
+				defer close(deploymentDone)
 
-				modulePath := p.modulePath()
 				parametersFilePath := p.parametersFilePath()
-				deployResult, err := p.deployModule(ctx, scope, modulePath, parametersFilePath)
+				deployResult, err := p.deployModule(ctx, scope, templatePath, parametersFilePath)
 				var outputs map[string]PreviewOutputParameter
 
 				if err != nil {
@@ -179,9 +179,11 @@ func (p *BicepProvider) Deploy(ctx context.Context, preview *Preview, scope Scop
 			// Report incremental progress
 			resourceManager := infra.NewAzureResourceManager(p.azCli)
 
-			for range time.After(10 * time.Second) {
-				if isDeploymentComplete {
-					break
+			for {
+				select {
+				case <-deploymentDone:
+					return
+				case <-time.After(10 * time.Second):
 				}
 
 				ops, err := resourceManager.GetDeploymentResourceOperations(ctx, p.env.GetSubscriptionId(), p.env.GetEnvName())
@@ -199,6 +201,71 @@ func (p *BicepProvider) Deploy(ctx context.Context, preview *Preview, scope Scop
 		})
 }
 
+// Reads the outputs of the latest deployment of the environment
+func (p *BicepProvider) State(ctx context.Context) *async.InteractiveTaskWithProgress[*StateResult, *StateProgress] {
+	return p.deploymentState(ctx, func() (*Preview, error) {
+		return p.createPreview(ctx, p.modulePath())
+	})
+}
+
+// Reads the outputs of the latest subscription deployment of the environment, the template provides the casing of the
+// output names
+func (p *BicepProvider) deploymentState(ctx context.Context, template func() (*Preview, error)) *async.InteractiveTaskWithProgress[*StateResult, *StateProgress] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*StateResult, *StateProgress]) {
+			asyncContext.SetProgress(&StateProgress{Message: "Reading deployment template", Timestamp: time.Now()})
+			preview, err := template()
+			if err != nil {
+				asyncContext.SetError(fmt.Errorf("creating template: %w", err))
+				return
+			}
+
+			asyncContext.SetProgress(&StateProgress{Message: "Fetching latest deployment", Timestamp: time.Now()})
+			scope := NewSubscriptionProvisioningScope(p.azCli, p.env.Values[environment.LocationEnvVarName], p.env.GetSubscriptionId(), p.env.GetEnvName())
+			deployment, err := scope.GetDeployment(ctx)
+			if errors.Is(err, azcli.ErrDeploymentNotFound) {
+				asyncContext.SetError(fmt.Errorf("no deployment for environment '%s' found, have you run `azd provision`?", p.env.GetEnvName()))
+				return
+			} else if err != nil {
+				asyncContext.SetError(fmt.Errorf("fetching latest deployment: %w", err))
+				return
+			}
+
+			asyncContext.SetResult(&StateResult{
+				Outputs: p.createOutputParameters(preview, deployment.Properties.Outputs),
+			})
+		})
+}
+
+// Predicts the changes the deployment of the Bicep module would make to the Azure resources
+func (p *BicepProvider) WhatIf(ctx context.Context, preview *Preview, scope Scope) *async.InteractiveTaskWithProgress[*PreviewResult, *PreviewProgress] {
+	return p.whatIfTemplate(ctx, preview, scope, p.modulePath())
+}
+
+// Runs an ARM what-if operation for the template at the specified path, which is either a Bicep module or an ARM template
+func (p *BicepProvider) whatIfTemplate(ctx context.Context, preview *Preview, scope Scope, templatePath string) *async.InteractiveTaskWithProgress[*PreviewResult, *PreviewProgress] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*PreviewResult, *PreviewProgress]) {
+			asyncContext.SetProgress(&PreviewProgress{Message: "Previewing Azure resource changes", Timestamp: time.Now()})
+
+			whatIfResult, err := scope.WhatIf(ctx, templatePath, p.parametersFilePath())
+			if err != nil {
+				asyncContext.SetError(fmt.Errorf("previewing resource changes: %w", err))
+				return
+			}
+
+			if whatIfResult.Error != nil {
+				asyncContext.SetError(fmt.Errorf("previewing resource changes: %s: %s", whatIfResult.Error.Code, whatIfResult.Error.Message))
+				return
+			}
+
+			asyncContext.SetResult(&PreviewResult{
+				Preview: *preview,
+				Changes: convertWhatIfChanges(whatIfResult),
+			})
+		})
+}
+
 func (p *BicepProvider) Destroy(ctx context.Context, preview *Preview) *async.InteractiveTaskWithProgress[*DestroyResult, *DestroyProgress] {
 	return async.RunInteractiveTaskWithProgress(
 		func(asyncContext *async.InteractiveTaskContextWithProgress[*DestroyResult, *DestroyProgress]) {
@@ -209,6 +276,7 @@ func (p *BicepProvider) Destroy(ctx context.Context, preview *Preview) *async.In
 			resourceGroups, err := resourceManager.GetResourceGroupsForDeployment(ctx, p.env.GetSubscriptionId(), p.env.GetEnvName())
 			if err != nil {
 				asyncContext.SetError(fmt.Errorf("discovering resource groups from deployment: %w", err))
+				return
 			}
 
 			var allResources []azcli.AzCliResource
@@ -218,6 +286,7 @@ func (p *BicepProvider) Destroy(ctx context.Context, preview *Preview) *async.In
 				resources, err := p.azCli.ListResourceGroupResources(ctx, p.env.GetSubscriptionId(), resourceGroup)
 				if err != nil {
 					asyncContext.SetError(fmt.Errorf("listing resource group %s: %w", resourceGroup, err))
+					return
 				}
 
 				allResources = append(allResources, resources...)
@@ -251,15 +320,18 @@ func (p *BicepProvider) Destroy(ctx context.Context, preview *Preview) *async.In
 
 				if err := p.azCli.DeleteResourceGroup(ctx, p.env.GetSubscriptionId(), resourceGroup); err != nil {
 					asyncContext.SetError(fmt.Errorf("deleting resource group %s: %w", resourceGroup, err))
+					return
 				}
 			}
 
 			asyncContext.SetProgress(&DestroyProgress{Message: "Deleting deployment", Timestamp: time.Now()})
 			if err := p.azCli.DeleteSubscriptionDeployment(ctx, p.env.GetSubscriptionId(), p.env.GetEnvName()); err != nil {
 				asyncContext.SetError(fmt.Errorf("deleting subscription deployment: %w", err))
+				return
 			}
 
 			destroyResult.Resources = allResources
+			destroyResult.Outputs = preview.Outputs
 			asyncContext.SetResult(&destroyResult)
 		})
 }
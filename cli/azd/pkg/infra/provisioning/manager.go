@@ -63,6 +63,22 @@ func (m *Manager) Deploy(ctx context.Context, preview *Preview, interactive bool
 	return deployResult, nil
 }
 
+// Predicts the changes the provisioning of the Azure infrastructure would make, without deploying anything
+func (m *Manager) WhatIf(ctx context.Context, preview *Preview, interactive bool) (*PreviewResult, error) {
+	whatIfProvider, ok := m.provider.(WhatIfProvider)
+	if !ok {
+		return nil, fmt.Errorf("the '%s' provider does not support previewing resource changes", m.provider.Name())
+	}
+
+	// Ensure that a location has been set since the what-if operation is evaluated against a deployment location
+	location, err := m.ensureLocation(ctx, preview)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.whatIf(ctx, whatIfProvider, location, preview, interactive)
+}
+
 // Destroys the Azure infrastructure for the specified project
 func (m *Manager) Destroy(ctx context.Context, preview *Preview, interactive bool) (*DestroyResult, error) {
 	// Call provisioning provider to destroy the infrastructure
@@ -85,6 +101,20 @@ func (m *Manager) Destroy(ctx context.Context, preview *Preview, interactive boo
 	return destroyResult, nil
 }
 
+// Reads the outputs of the latest provisioning of the infrastructure and updates the environment with them
+func (m *Manager) State(ctx context.Context, interactive bool) (*StateResult, error) {
+	stateResult, err := m.state(ctx, interactive)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := UpdateEnvironment(&m.env, &stateResult.Outputs); err != nil {
+		return nil, err
+	}
+
+	return stateResult, nil
+}
+
 // Previews the infrastructure provisioning and orchestrates interactive terminal operations
 func (m *Manager) preview(ctx context.Context, interactive bool) (*PreviewResult, error) {
 	var previewResult *PreviewResult
@@ -166,6 +196,46 @@ func (m *Manager) deploy(ctx context.Context, location string, preview *Preview,
 	return deployResult, nil
 }
 
+// Runs the what-if operation of the provider and orchestrates the interactive terminal operations
+func (m *Manager) whatIf(ctx context.Context, whatIfProvider WhatIfProvider, location string, preview *Preview, interactive bool) (*PreviewResult, error) {
+	var whatIfResult *PreviewResult
+
+	whatIfAndReportProgress := func(spinner *spin.Spinner) error {
+		provisioningScope := NewSubscriptionProvisioningScope(m.azCli, location, m.env.GetSubscriptionId(), m.env.GetEnvName())
+		whatIfTask := whatIfProvider.WhatIf(ctx, preview, provisioningScope)
+
+		go func() {
+			for progress := range whatIfTask.Progress() {
+				spinner.Title(fmt.Sprintf("%s...", progress.Message))
+			}
+		}()
+
+		go monitorInteraction(spinner, whatIfTask.Interactive())
+
+		result, err := whatIfTask.Await()
+		if err != nil {
+			return err
+		}
+
+		whatIfResult = result
+
+		return nil
+	}
+
+	spinner := spin.NewSpinner("Previewing Azure resource changes")
+	defer spinner.Stop()
+
+	err := whatIfAndReportProgress(spinner)
+
+	if err != nil {
+		return nil, fmt.Errorf("error previewing infrastructure changes: %w", err)
+	}
+
+	spinner.Println("Previewed Azure resource changes")
+
+	return whatIfResult, nil
+}
+
 // Destroys the specified infrastructure provisioning and orchestrates the interactive terminal operations
 func (m *Manager) destroy(ctx context.Context, preview *Preview, interactive bool) (*DestroyResult, error) {
 	var destroyResult *DestroyResult
@@ -205,6 +275,47 @@ func (m *Manager) destroy(ctx context.Context, preview *Preview, interactive boo
 	return destroyResult, nil
 }
 
+// Reads the provisioning state and orchestrates the interactive terminal operations
+func (m *Manager) state(ctx context.Context, interactive bool) (*StateResult, error) {
+	var stateResult *StateResult
+
+	stateWithProgress := func(spinner *spin.Spinner) error {
+		stateTask := m.provider.State(ctx)
+
+		go func() {
+			for stateProgress := range stateTask.Progress() {
+				if interactive {
+					spinner.Title(fmt.Sprintf("%s...", stateProgress.Message))
+				}
+			}
+		}()
+
+		go monitorInteraction(spinner, stateTask.Interactive())
+
+		result, err := stateTask.Await()
+		if err != nil {
+			return err
+		}
+
+		stateResult = result
+
+		return nil
+	}
+
+	spinner := spin.NewSpinner("Retrieving Azure deployment")
+	defer spinner.Stop()
+
+	err := stateWithProgress(spinner)
+
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving infrastructure state: %w", err)
+	}
+
+	spinner.Println("Retrieved Azure deployment")
+
+	return stateResult, nil
+}
+
 // Creates a progress message from the provisioning progress report
 func (m *Manager) showDeployProgress(progressReport DeployProgress, spinner *spin.Spinner) {
 	succeededCount := 0
@@ -232,6 +343,13 @@ func (m *Manager) ensureLocation(ctx context.Context, preview *Preview) (string,
 		}
 	}
 
+	// Providers without a plain location parameter, ex) Pulumi with azure-native:location, use the location of the
+	// environment
+	if envLocation := strings.TrimSpace(m.env.Values[environment.LocationEnvVarName]); envLocation != "" {
+		return envLocation, nil
+	}
+
+	location = ""
 	for location == "" {
 		// TODO: We will want to store this information somewhere (so we don't have to prompt the
 		// user on every deployment if they don't have a `location` parameter in their bicep file.
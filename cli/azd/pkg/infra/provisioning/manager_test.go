@@ -61,6 +61,29 @@ func TestInfraDeploy(t *testing.T) {
 	require.Nil(t, err)
 }
 
+func TestInfraState(t *testing.T) {
+	ctx := context.Background()
+	env := environment.Environment{Values: make(map[string]string)}
+	env.Values["AZURE_LOCATION"] = "eastus2"
+	env.SetEnvName("test-env")
+	options := Options{Provider: "test"}
+	interactive := false
+	execUtil := mocks.NewMockExecUtil()
+	console := mocks.NewMockConsole()
+
+	cliArgs := bicep.NewBicepCliArgs{
+		AzCli:           azcli.NewAzCli(azcli.NewAzCliArgs{RunWithResultFn: execUtil.RunWithResult}),
+		RunWithResultFn: execUtil.RunWithResult,
+	}
+
+	mgr, _ := NewManager(ctx, env, "", options, interactive, console, cliArgs)
+
+	stateResult, err := mgr.State(ctx, false)
+
+	require.NotNil(t, stateResult)
+	require.Nil(t, err)
+}
+
 func TestInfraDestroyWithPositiveConfirmation(t *testing.T) {
 	ctx := context.Background()
 	env := environment.Environment{Values: make(map[string]string)}
@@ -118,3 +141,18 @@ func TestInfraDestroyWithNegativeConfirmation(t *testing.T) {
 	require.NotNil(t, err)
 	require.Contains(t, console.Output(), "Are you sure you want to destroy?")
 }
+
+func TestInfraEnsureLocationFromEnvironment(t *testing.T) {
+	env := environment.Environment{Values: map[string]string{"AZURE_LOCATION": "eastus2"}}
+	env.SetEnvName("test-env")
+
+	// Namespaced parameters like those of pulumi are not the location, the console is not prompted either
+	mgr := &Manager{env: env, console: mocks.NewMockConsole()}
+	location, err := mgr.ensureLocation(context.Background(), &Preview{
+		Parameters: map[string]PreviewInputParameter{
+			"azure-native:location": {Value: "eastus2"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "eastus2", location)
+}
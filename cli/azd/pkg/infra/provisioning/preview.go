@@ -23,3 +23,43 @@ func (p *PreviewInputParameter) HasValue() bool {
 func (p *PreviewInputParameter) HasDefaultValue() bool {
 	return p.DefaultValue != nil
 }
+
+type ResourceChangeType string
+
+const (
+	ResourceChangeTypeCreate      ResourceChangeType = "Create"
+	ResourceChangeTypeDelete      ResourceChangeType = "Delete"
+	ResourceChangeTypeDeploy      ResourceChangeType = "Deploy"
+	ResourceChangeTypeIgnore      ResourceChangeType = "Ignore"
+	ResourceChangeTypeModify      ResourceChangeType = "Modify"
+	ResourceChangeTypeNoChange    ResourceChangeType = "NoChange"
+	ResourceChangeTypeUnsupported ResourceChangeType = "Unsupported"
+)
+
+type PropertyChangeType string
+
+const (
+	PropertyChangeTypeArray    PropertyChangeType = "Array"
+	PropertyChangeTypeCreate   PropertyChangeType = "Create"
+	PropertyChangeTypeDelete   PropertyChangeType = "Delete"
+	PropertyChangeTypeModify   PropertyChangeType = "Modify"
+	PropertyChangeTypeNoEffect PropertyChangeType = "NoEffect"
+)
+
+// PreviewResourceChange is the predicted change to an Azure resource
+type PreviewResourceChange struct {
+	ResourceId   string                  `json:"resourceId"`
+	ResourceType string                  `json:"resourceType"`
+	ResourceName string                  `json:"resourceName"`
+	ChangeType   ResourceChangeType      `json:"changeType"`
+	Delta        []PreviewPropertyChange `json:"delta,omitempty"`
+}
+
+// PreviewPropertyChange is the predicted change to a property of an Azure resource
+type PreviewPropertyChange struct {
+	Path       string                  `json:"path"`
+	ChangeType PropertyChangeType      `json:"changeType"`
+	Before     interface{}             `json:"before,omitempty"`
+	After      interface{}             `json:"after,omitempty"`
+	Children   []PreviewPropertyChange `json:"children,omitempty"`
+}
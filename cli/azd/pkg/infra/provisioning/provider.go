@@ -11,6 +11,8 @@ import (
 	"github.com/azure/azure-dev/cli/azd/pkg/tools"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/bicep"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/pulumi"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/terraform"
 )
 
 type ProviderKind string
@@ -27,10 +29,15 @@ type Options struct {
 	Provider ProviderKind `yaml:"provider"`
 	Path     string       `yaml:"path"`
 	Module   string       `yaml:"module"`
+	// Force skips the confirmation of the providers which ask before their resources are deleted, ex) Terraform.
+	// It is set by the commands, not read from azure.yaml.
+	Force bool `yaml:"-"`
 }
 
 type PreviewResult struct {
 	Preview Preview
+	// Changes holds the predicted changes to the Azure resources when the preview includes a what-if operation
+	Changes []PreviewResourceChange
 }
 
 type PreviewProgress struct {
@@ -58,6 +65,16 @@ type DestroyProgress struct {
 	Timestamp time.Time
 }
 
+// StateResult holds the outputs of the latest provisioning of the infrastructure
+type StateResult struct {
+	Outputs map[string]PreviewOutputParameter
+}
+
+type StateProgress struct {
+	Message   string
+	Timestamp time.Time
+}
+
 type Provider interface {
 	Name() string
 	RequiredExternalTools() []tools.ExternalTool
@@ -65,6 +82,12 @@ type Provider interface {
 	Preview(ctx context.Context) *async.InteractiveTaskWithProgress[*PreviewResult, *PreviewProgress]
 	Deploy(ctx context.Context, preview *Preview, scope Scope) *async.InteractiveTaskWithProgress[*DeployResult, *DeployProgress]
 	Destroy(ctx context.Context, preview *Preview) *async.InteractiveTaskWithProgress[*DestroyResult, *DestroyProgress]
+	State(ctx context.Context) *async.InteractiveTaskWithProgress[*StateResult, *StateProgress]
+}
+
+// WhatIfProvider is implemented by providers that can predict the changes a deployment would make to the Azure resources
+type WhatIfProvider interface {
+	WhatIf(ctx context.Context, preview *Preview, scope Scope) *async.InteractiveTaskWithProgress[*PreviewResult, *PreviewProgress]
 }
 
 func NewProvider(env *environment.Environment, projectPath string, options Options, console input.Console, cliArgs bicep.NewBicepCliArgs) (Provider, error) {
@@ -74,6 +97,14 @@ func NewProvider(env *environment.Environment, projectPath string, options Optio
 	case Bicep:
 		bicepArgs := bicep.NewBicepCliArgs(cliArgs)
 		provider = NewBicepProvider(env, projectPath, options, console, bicepArgs)
+	case Arm:
+		provider = NewArmProvider(env, projectPath, options, console, cliArgs.AzCli)
+	case Terraform:
+		terraformCli := terraform.NewTerraformCli(terraform.NewTerraformCliArgs{RunWithResultFn: cliArgs.RunWithResultFn})
+		provider = NewTerraformProvider(env, projectPath, options, console, terraformCli)
+	case Pulumi:
+		pulumiCli := pulumi.NewPulumiCli(pulumi.NewPulumiCliArgs{RunWithResultFn: cliArgs.RunWithResultFn})
+		provider = NewPulumiProvider(env, projectPath, options, console, pulumiCli)
 	case Test:
 		provider = NewTestProvider(env, projectPath, options, console)
 	default:
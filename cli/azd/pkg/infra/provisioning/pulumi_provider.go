@@ -0,0 +1,684 @@
+package provisioning
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/async"
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/osutil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/pulumi"
+	"gopkg.in/yaml.v3"
+)
+
+// The interval used to check the pulumi event log for new engine events while a deployment is running
+var pulumiEventPollInterval = 2 * time.Second
+
+// The type of the parameters read from secret values of the stack configuration
+const pulumiSecretParameterType = "secureString"
+
+// A configuration value as returned from `pulumi config --json`
+type pulumiConfigValue struct {
+	Value  interface{} `json:"value"`
+	Secret bool        `json:"secret"`
+}
+
+// An engine event as written to the pulumi event log, only resource step events are tracked
+type pulumiEngineEvent struct {
+	Sequence         int                  `json:"sequence"`
+	Timestamp        int64                `json:"timestamp"`
+	ResourcePreEvent *pulumiResourceEvent `json:"resourcePreEvent,omitempty"`
+	ResOutputsEvent  *pulumiResourceEvent `json:"resOutputsEvent,omitempty"`
+	ResOpFailedEvent *pulumiResourceEvent `json:"resOpFailedEvent,omitempty"`
+}
+
+type pulumiResourceEvent struct {
+	Metadata pulumiStepMetadata `json:"metadata"`
+}
+
+type pulumiStepMetadata struct {
+	Op   string `json:"op"`
+	Urn  string `json:"urn"`
+	Type string `json:"type"`
+	New  *struct {
+		Id string `json:"id"`
+	} `json:"new,omitempty"`
+}
+
+// PulumiProvider exposes infrastructure provisioning using Pulumi programs.
+// Each azd environment is mapped to a pulumi stack of the same name stored within a local file backend.
+type PulumiProvider struct {
+	env         *environment.Environment
+	projectPath string
+	options     Options
+	console     input.Console
+	cli         pulumi.PulumiCli
+}
+
+// Name gets the name of the infra provider
+func (p *PulumiProvider) Name() string {
+	return "Pulumi"
+}
+
+func (p *PulumiProvider) RequiredExternalTools() []tools.ExternalTool {
+	return []tools.ExternalTool{p.cli}
+}
+
+// Previews the infrastructure provisioning by seeding the stack configuration and running a pulumi preview
+func (p *PulumiProvider) Preview(ctx context.Context) *async.InteractiveTaskWithProgress[*PreviewResult, *PreviewProgress] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*PreviewResult, *PreviewProgress]) {
+			asyncContext.SetProgress(&PreviewProgress{Message: "Selecting pulumi stack", Timestamp: time.Now()})
+			if err := p.ensureStack(ctx); err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetProgress(&PreviewProgress{Message: "Updating pulumi stack configuration", Timestamp: time.Now()})
+			stackConfig, err := p.stackConfig()
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			if err := p.cli.SetConfig(ctx, p.stackOptions(), stackConfig); err != nil {
+				asyncContext.SetError(fmt.Errorf("updating stack configuration: %w", err))
+				return
+			}
+
+			asyncContext.SetProgress(&PreviewProgress{Message: "Previewing pulumi stack changes", Timestamp: time.Now()})
+			if _, err := p.cli.Preview(ctx, p.stackOptions()); err != nil {
+				asyncContext.SetError(fmt.Errorf("previewing stack changes: %w", err))
+				return
+			}
+
+			parameters, err := p.parameters(ctx)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			// Outputs are only known once the stack is deployed, so the outputs of the last deployment are used
+			outputs, err := p.outputs(ctx)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetResult(&PreviewResult{
+				Preview: Preview{
+					Parameters: parameters,
+					Outputs:    outputs,
+				},
+			})
+		})
+}
+
+// Writes the preview parameter values to the stack configuration
+func (p *PulumiProvider) UpdatePlan(ctx context.Context, preview Preview) error {
+	values := make(map[string]pulumi.ConfigValue, len(preview.Parameters))
+	for key, param := range preview.Parameters {
+		if param.HasValue() {
+			values[key] = pulumi.ConfigValue{
+				Value:  fmt.Sprint(param.Value),
+				Secret: param.Type == pulumiSecretParameterType,
+			}
+		}
+	}
+
+	if err := p.cli.SetConfig(ctx, p.stackOptions(), values); err != nil {
+		return fmt.Errorf("updating stack configuration: %w", err)
+	}
+
+	return nil
+}
+
+// Runs `pulumi up` for the environment stack, reporting the engine events as deployment progress
+func (p *PulumiProvider) Deploy(ctx context.Context, preview *Preview, scope Scope) *async.InteractiveTaskWithProgress[*DeployResult, *DeployProgress] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*DeployResult, *DeployProgress]) {
+			if err := p.ensureStack(ctx); err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			// The event log is appended to by pulumi, so start from a clean file for every deployment
+			eventLogPath := p.eventLogFilePath()
+			if err := os.Remove(eventLogPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				asyncContext.SetError(fmt.Errorf("removing previous event log: %w", err))
+				return
+			}
+
+			upDone := make(chan error, 1)
+			go func() {
+				_, err := p.cli.Up(ctx, p.stackOptions(), eventLogPath)
+				upDone <- err
+			}()
+
+			tracker := newPulumiProgressTracker()
+			eventLog := &pulumiEventLogReader{path: eventLogPath}
+
+			reportProgress := func() {
+				events, err := eventLog.ReadEvents()
+				if err != nil {
+					// Progress reporting is best-effort activity.
+					log.Printf("failed reading pulumi event log: %v", err)
+					return
+				}
+
+				if tracker.Apply(events) {
+					asyncContext.SetProgress(&DeployProgress{
+						Timestamp:  time.Now(),
+						Operations: tracker.Operations(),
+					})
+				}
+			}
+
+			for {
+				select {
+				case err := <-upDone:
+					reportProgress()
+
+					if err != nil {
+						asyncContext.SetError(fmt.Errorf("deploying pulumi stack: %w", err))
+						return
+					}
+
+					outputs, err := p.outputs(ctx)
+					if err != nil {
+						asyncContext.SetError(err)
+						return
+					}
+
+					asyncContext.SetResult(&DeployResult{
+						Operations: tracker.Operations(),
+						Outputs:    outputs,
+					})
+					return
+				case <-time.After(pulumiEventPollInterval):
+					reportProgress()
+				}
+			}
+		})
+}
+
+// Destroys all the resources of the environment stack
+func (p *PulumiProvider) Destroy(ctx context.Context, preview *Preview) *async.InteractiveTaskWithProgress[*DestroyResult, *DestroyProgress] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*DestroyResult, *DestroyProgress]) {
+			if err := p.ensureStack(ctx); err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			// Collect the current outputs so they can be removed from the environment once the resources are gone
+			asyncContext.SetProgress(&DestroyProgress{Message: "Fetching pulumi stack outputs", Timestamp: time.Now()})
+			outputs, err := p.outputs(ctx)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			if !p.options.Force {
+				err = asyncContext.Interact(func() error {
+					confirmDestroy, err := p.console.Confirm(ctx, input.ConsoleOptions{
+						Message: fmt.Sprintf(
+							"This will delete all resources in the pulumi stack '%s', are you sure you want to continue?\n"+
+								"You can use --force to skip this confirmation.",
+							p.env.GetEnvName()),
+						DefaultValue: false,
+					})
+
+					if err != nil {
+						return err
+					}
+
+					if !confirmDestroy {
+						return errors.New("user denied confirmation")
+					}
+
+					return nil
+				})
+
+				if err != nil {
+					asyncContext.SetError(err)
+					return
+				}
+			}
+
+			asyncContext.SetProgress(&DestroyProgress{Message: "Destroying pulumi stack resources", Timestamp: time.Now()})
+			if _, err := p.cli.Destroy(ctx, p.stackOptions()); err != nil {
+				asyncContext.SetError(fmt.Errorf("destroying pulumi stack: %w", err))
+				return
+			}
+
+			asyncContext.SetResult(&DestroyResult{
+				Outputs: outputs,
+			})
+		})
+}
+
+// Reads the outputs of the environment stack
+func (p *PulumiProvider) State(ctx context.Context) *async.InteractiveTaskWithProgress[*StateResult, *StateProgress] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*StateResult, *StateProgress]) {
+			if err := p.ensureStack(ctx); err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetProgress(&StateProgress{Message: "Fetching pulumi stack outputs", Timestamp: time.Now()})
+			outputs, err := p.outputs(ctx)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetResult(&StateResult{
+				Outputs: outputs,
+			})
+		})
+}
+
+// Selects the stack of the environment, creating the backend & stack as needed
+func (p *PulumiProvider) ensureStack(ctx context.Context) error {
+	// The file backend encrypts the secrets of the stack with the passphrase the user sets, which pulumi reads from
+	// the environment of azd. An unset passphrase is not defaulted, so secrets are never encrypted with an empty one.
+	if !isPulumiPassphraseSet() {
+		return errPulumiPassphraseNotSet
+	}
+
+	if err := os.MkdirAll(p.environmentWorkingPath(), osutil.PermissionDirectory); err != nil {
+		return fmt.Errorf("creating directory structure: %w", err)
+	}
+
+	if err := p.cli.SelectStack(ctx, p.stackOptions()); err != nil {
+		return fmt.Errorf("selecting stack: %w", err)
+	}
+
+	return nil
+}
+
+var errPulumiPassphraseNotSet = errors.New(
+	"pulumi encrypts the secrets of the stack with a passphrase: set PULUMI_CONFIG_PASSPHRASE or PULUMI_CONFIG_PASSPHRASE_FILE",
+)
+
+func isPulumiPassphraseSet() bool {
+	for _, name := range []string{"PULUMI_CONFIG_PASSPHRASE", "PULUMI_CONFIG_PASSPHRASE_FILE"} {
+		if _, has := os.LookupEnv(name); has {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The environment values passed to every stack, in addition to the values declared in the config of Pulumi.yaml
+var pulumiStackEnvVarNames = []string{
+	environment.EnvNameEnvVarName,
+	environment.LocationEnvVarName,
+	environment.SubscriptionIdEnvVarName,
+	environment.PrincipalIdEnvVarName,
+}
+
+// The settings of the Pulumi.yaml project file read by azd
+type pulumiProject struct {
+	Name   string                 `yaml:"name"`
+	Config map[string]interface{} `yaml:"config"`
+}
+
+// Gets the stack configuration seeded from the environment values the stack needs: the common azd values and the
+// values declared in the config of Pulumi.yaml. The values resolved from Key Vault secrets are set as secrets.
+func (p *PulumiProvider) stackConfig() (map[string]pulumi.ConfigValue, error) {
+	keys, err := p.declaredConfigKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	keys = append(keys, pulumiStackEnvVarNames...)
+
+	config := make(map[string]pulumi.ConfigValue, len(keys)+2)
+	for _, key := range keys {
+		if value, has := p.env.Values[key]; has {
+			config[key] = pulumi.ConfigValue{Value: value, Secret: p.env.IsSecret(key)}
+		}
+	}
+
+	// Configure the azure-native provider to target the environment subscription & location by default
+	if location := p.env.Values[environment.LocationEnvVarName]; location != "" {
+		config["azure-native:location"] = pulumi.ConfigValue{Value: location}
+	}
+
+	if subscriptionId := p.env.GetSubscriptionId(); subscriptionId != "" {
+		config["azure-native:subscriptionId"] = pulumi.ConfigValue{Value: subscriptionId}
+	}
+
+	return config, nil
+}
+
+// Gets the names of the config values declared in Pulumi.yaml, ex) API_KEY or webapp:API_KEY for the webapp project.
+// Values namespaced by other projects or providers are not read from the environment.
+func (p *PulumiProvider) declaredConfigKeys() ([]string, error) {
+	projectBytes, err := os.ReadFile(filepath.Join(p.programPath(), "Pulumi.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("reading pulumi project: %w", err)
+	}
+
+	var project pulumiProject
+	if err := yaml.Unmarshal(projectBytes, &project); err != nil {
+		return nil, fmt.Errorf("parsing pulumi project: %w", err)
+	}
+
+	keys := []string{}
+	for key := range project.Config {
+		if namespace, name, found := strings.Cut(key, ":"); !found {
+			keys = append(keys, key)
+		} else if namespace == project.Name {
+			keys = append(keys, name)
+		}
+	}
+
+	return keys, nil
+}
+
+// Reads the stack configuration as provisioning parameters
+func (p *PulumiProvider) parameters(ctx context.Context) (map[string]PreviewInputParameter, error) {
+	configJson, err := p.cli.GetConfig(ctx, p.stackOptions())
+	if err != nil {
+		return nil, fmt.Errorf("reading stack configuration: %w", err)
+	}
+
+	var config map[string]pulumiConfigValue
+	if err := json.Unmarshal([]byte(configJson), &config); err != nil {
+		return nil, fmt.Errorf("could not unmarshal output %s as pulumi configuration: %w", configJson, err)
+	}
+
+	parameters := make(map[string]PreviewInputParameter, len(config))
+	for key, configValue := range config {
+		paramType := "string"
+		if configValue.Secret {
+			paramType = pulumiSecretParameterType
+		}
+
+		parameters[key] = PreviewInputParameter{
+			Type:  paramType,
+			Value: configValue.Value,
+		}
+	}
+
+	return parameters, nil
+}
+
+// Reads the outputs of the environment stack
+func (p *PulumiProvider) outputs(ctx context.Context) (map[string]PreviewOutputParameter, error) {
+	outputJson, err := p.cli.StackOutput(ctx, p.stackOptions())
+	if err != nil {
+		return nil, fmt.Errorf("reading stack outputs: %w", err)
+	}
+
+	var stackOutputs map[string]interface{}
+	if err := json.Unmarshal([]byte(outputJson), &stackOutputs); err != nil {
+		return nil, fmt.Errorf("could not unmarshal output %s as pulumi stack outputs: %w", outputJson, err)
+	}
+
+	outputs := make(map[string]PreviewOutputParameter, len(stackOutputs))
+	for key, value := range stackOutputs {
+		outputs[key] = PreviewOutputParameter{
+			Type:  pulumiOutputType(value),
+			Value: value,
+		}
+	}
+
+	return outputs, nil
+}
+
+// Gets the environment variables passed to every pulumi command
+func (p *PulumiProvider) cliEnv() []string {
+	env := []string{
+		// The local file backend stores the stack state alongside the environment, no pulumi service login is required
+		fmt.Sprintf("PULUMI_BACKEND_URL=file://%s", filepath.ToSlash(p.environmentWorkingPath())),
+	}
+
+	if subscriptionId := p.env.GetSubscriptionId(); subscriptionId != "" {
+		env = append(env, fmt.Sprintf("ARM_SUBSCRIPTION_ID=%s", subscriptionId))
+	}
+
+	if tenantId := p.env.GetTenantId(); tenantId != "" {
+		env = append(env, fmt.Sprintf("ARM_TENANT_ID=%s", tenantId))
+	}
+
+	return env
+}
+
+func (p *PulumiProvider) stackOptions() pulumi.StackOptions {
+	return pulumi.StackOptions{
+		ProjectPath:    p.programPath(),
+		StackName:      p.env.GetEnvName(),
+		ConfigFilePath: p.configFilePath(),
+	}
+}
+
+// Gets the folder path to the pulumi program (the folder containing Pulumi.yaml)
+func (p *PulumiProvider) programPath() string {
+	infraPath := p.options.Path
+	if strings.TrimSpace(infraPath) == "" {
+		infraPath = "infra"
+	}
+
+	return filepath.Join(p.projectPath, infraPath)
+}
+
+// Gets the path to the environment specific working directory for pulumi
+func (p *PulumiProvider) environmentWorkingPath() string {
+	return filepath.Join(p.projectPath, environment.EnvironmentDirectoryName, p.env.GetEnvName(), p.options.Path)
+}
+
+// Gets the path to the stack configuration file of the environment
+func (p *PulumiProvider) configFilePath() string {
+	return filepath.Join(p.environmentWorkingPath(), fmt.Sprintf("Pulumi.%s.yaml", p.env.GetEnvName()))
+}
+
+// Gets the path to the event log written during `pulumi up`
+func (p *PulumiProvider) eventLogFilePath() string {
+	return filepath.Join(p.environmentWorkingPath(), "events.json")
+}
+
+// Gets the type name of a pulumi stack output value
+func pulumiOutputType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return ""
+	}
+}
+
+// Reads the engine events appended to the pulumi event log since the previous read
+type pulumiEventLogReader struct {
+	path    string
+	offset  int64
+	pending []byte
+}
+
+func (r *pulumiEventLogReader) ReadEvents() ([]pulumiEngineEvent, error) {
+	file, err := os.Open(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Pulumi has not written any events yet
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(r.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	contents, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	r.offset += int64(len(contents))
+
+	// Only complete lines are parsed, a partially written event is kept until the next read
+	contents = append(r.pending, contents...)
+	lastNewLine := bytes.LastIndexByte(contents, '\n')
+	if lastNewLine < 0 {
+		r.pending = contents
+		return nil, nil
+	}
+	r.pending = append([]byte{}, contents[lastNewLine+1:]...)
+
+	var events []pulumiEngineEvent
+	scanner := bufio.NewScanner(bytes.NewReader(contents[:lastNewLine]))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var event pulumiEngineEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			log.Printf("failed un-marshaling pulumi engine event (err: %v): %s", err, string(line))
+			continue
+		}
+
+		events = append(events, event)
+	}
+
+	return events, scanner.Err()
+}
+
+// Tracks the state of the resource operations reported by pulumi engine events
+type pulumiProgressTracker struct {
+	operations map[string]*azcli.AzCliResourceOperation
+	urns       []string
+}
+
+func newPulumiProgressTracker() *pulumiProgressTracker {
+	return &pulumiProgressTracker{
+		operations: map[string]*azcli.AzCliResourceOperation{},
+	}
+}
+
+// Applies the engine events to the tracked operations, returns whether any operation changed
+func (t *pulumiProgressTracker) Apply(events []pulumiEngineEvent) bool {
+	changed := false
+
+	for _, event := range events {
+		var resourceEvent *pulumiResourceEvent
+		var state string
+
+		switch {
+		case event.ResourcePreEvent != nil:
+			resourceEvent, state = event.ResourcePreEvent, "Running"
+		case event.ResOutputsEvent != nil:
+			resourceEvent, state = event.ResOutputsEvent, "Succeeded"
+		case event.ResOpFailedEvent != nil:
+			resourceEvent, state = event.ResOpFailedEvent, "Failed"
+		default:
+			continue
+		}
+
+		metadata := resourceEvent.Metadata
+
+		// The stack & provider resources are pulumi internals rather than Azure resources
+		if metadata.Type == "pulumi:pulumi:Stack" || strings.HasPrefix(metadata.Type, "pulumi:providers:") {
+			continue
+		}
+
+		operation, has := t.operations[metadata.Urn]
+		if !has {
+			operation = &azcli.AzCliResourceOperation{
+				OperationId: metadata.Urn,
+			}
+			t.operations[metadata.Urn] = operation
+			t.urns = append(t.urns, metadata.Urn)
+		}
+
+		operation.Properties.ProvisioningOperation = pulumiOperationName(metadata.Op)
+		operation.Properties.ProvisioningState = state
+		operation.Properties.Timestamp = time.Unix(event.Timestamp, 0)
+		operation.Properties.TargetResource.ResourceType = metadata.Type
+		operation.Properties.TargetResource.ResourceName = pulumiResourceName(metadata.Urn)
+		if metadata.New != nil && metadata.New.Id != "" {
+			operation.Id = metadata.New.Id
+			operation.Properties.TargetResource.Id = metadata.New.Id
+		}
+
+		changed = true
+	}
+
+	return changed
+}
+
+// Gets a snapshot of the tracked operations in the order they were first reported
+func (t *pulumiProgressTracker) Operations() []azcli.AzCliResourceOperation {
+	operations := make([]azcli.AzCliResourceOperation, 0, len(t.urns))
+	for _, urn := range t.urns {
+		operations = append(operations, *t.operations[urn])
+	}
+
+	return operations
+}
+
+// Gets the resource name from a pulumi URN, ex) urn:pulumi:<stack>::<project>::<type>::<name>
+func pulumiResourceName(urn string) string {
+	index := strings.LastIndex(urn, "::")
+	if index < 0 {
+		return urn
+	}
+
+	return urn[index+2:]
+}
+
+// Gets the provisioning operation name from a pulumi step operation, ex) "create" -> "Create"
+func pulumiOperationName(op string) string {
+	if op == "" {
+		return op
+	}
+
+	return strings.ToUpper(op[:1]) + op[1:]
+}
+
+// NewPulumiProvider creates a new instance of a Pulumi Infra provider
+func NewPulumiProvider(env *environment.Environment, projectPath string, options Options, console input.Console, cli pulumi.PulumiCli) Provider {
+	// Pulumi runs from within the program directory, so all paths passed to it must be absolute
+	if absProjectPath, err := filepath.Abs(projectPath); err == nil {
+		projectPath = absProjectPath
+	}
+
+	provider := &PulumiProvider{
+		env:         env,
+		projectPath: projectPath,
+		options:     options,
+		console:     console,
+		cli:         cli,
+	}
+
+	cli.SetEnv(provider.cliEnv())
+
+	return provider
+}
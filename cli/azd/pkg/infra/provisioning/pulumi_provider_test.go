@@ -0,0 +1,357 @@
+package provisioning
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/pulumi"
+	"github.com/azure/azure-dev/cli/azd/test/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+const pulumiConfigJson = `{
+	"webapp:AZURE_LOCATION": { "value": "westus2", "secret": false },
+	"azure-native:location": { "value": "westus2", "secret": false }
+}`
+
+const pulumiStackOutputJson = `{
+	"WEBSITE_URL": "http://myapp.azurewebsites.net",
+	"REPLICAS": 3
+}`
+
+// The engine events written to the event log by `pulumi up`, including a partially written event
+const pulumiEventLog = `{"sequence":0,"timestamp":1660000000,"preludeEvent":{"config":{}}}
+{"sequence":1,"timestamp":1660000001,"resourcePreEvent":{"metadata":{"op":"create","urn":"urn:pulumi:test-env::webapp::pulumi:pulumi:Stack::webapp-test-env","type":"pulumi:pulumi:Stack"}}}
+{"sequence":2,"timestamp":1660000002,"resourcePreEvent":{"metadata":{"op":"create","urn":"urn:pulumi:test-env::webapp::azure-native:resources:ResourceGroup::rg","type":"azure-native:resources:ResourceGroup"}}}
+{"sequence":3,"timestamp":1660000003,"resOutputsEvent":{"metadata":{"op":"create","urn":"urn:pulumi:test-env::webapp::azure-native:resources:ResourceGroup::rg","type":"azure-native:resources:ResourceGroup","new":{"id":"/subscriptions/SUBSCRIPTION_ID/resourceGroups/rg"}}}}
+{"sequence":4,"timestamp":1660000004,"resourcePreEvent":{"metadata":{"op":"create","urn":"urn:pulumi:test-env::webapp::azure-native:web:WebApp::app","type":"azure-native:web:WebApp"}}}
+{"sequence":5,"timestamp":1660000005,"resOpFailedEvent":{"metadata":{"op":"create","urn":"urn:pulumi:test-env::webapp::azure-native:web:WebApp::app","type":"azure-native:web:WebApp"}}}
+{"sequence":6,"timestamp":`
+
+// Sets up all the mocks required for the pulumi preview, deploy & destroy operations
+func setupPulumiExecUtilWithMocks() *mocks.MockExecUtil {
+	execUtil := &mocks.MockExecUtil{}
+
+	execUtil.When(func(args executil.RunArgs) bool {
+		return args.Cmd == "pulumi" && args.Args[0] == "config" && args.Args[1] == "--stack"
+	}).Respond(executil.RunResult{
+		Stdout: pulumiConfigJson,
+		Stderr: "",
+	})
+
+	execUtil.When(func(args executil.RunArgs) bool {
+		return args.Cmd == "pulumi" && args.Args[0] == "stack" && args.Args[1] == "output"
+	}).Respond(executil.RunResult{
+		Stdout: pulumiStackOutputJson,
+		Stderr: "",
+	})
+
+	execUtil.When(func(args executil.RunArgs) bool {
+		return args.Cmd == "pulumi"
+	}).Respond(executil.RunResult{
+		Stdout: "",
+		Stderr: "",
+	})
+
+	return execUtil
+}
+
+// Creates a pulumi provider for the project, returning the commands that are run through the provider
+func createPulumiProvider(t *testing.T, console input.Console) (string, *[]executil.RunArgs, Provider) {
+	t.Setenv("PULUMI_CONFIG_PASSPHRASE", "passphrase")
+
+	projectDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "infra"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "infra", "Pulumi.yaml"), []byte("name: webapp"), 0600))
+
+	execUtil := setupPulumiExecUtilWithMocks()
+	calls := []executil.RunArgs{}
+
+	env := environment.Environment{Values: make(map[string]string)}
+	env.Values["AZURE_LOCATION"] = "westus2"
+	env.Values["AZURE_SUBSCRIPTION_ID"] = "SUBSCRIPTION_ID"
+	env.SetEnvName("test-env")
+
+	options := Options{
+		Provider: Pulumi,
+		Path:     "infra",
+		Module:   "main",
+	}
+
+	pulumiCli := pulumi.NewPulumiCli(pulumi.NewPulumiCliArgs{
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			calls = append(calls, args)
+
+			// Simulate the engine events pulumi writes while the stack is deployed
+			if args.Args[0] == "up" {
+				for i, arg := range args.Args {
+					if arg == "--event-log" {
+						require.NoError(t, os.WriteFile(args.Args[i+1], []byte(pulumiEventLog), 0600))
+					}
+				}
+			}
+
+			return execUtil.RunWithResult(ctx, args)
+		},
+	})
+
+	return projectDir, &calls, NewPulumiProvider(&env, projectDir, options, console, pulumiCli)
+}
+
+func TestPulumiPreview(t *testing.T) {
+	projectDir, calls, infraProvider := createPulumiProvider(t, &mocks.MockConsole{})
+	previewTask := infraProvider.Preview(context.Background())
+
+	go func() {
+		for range previewTask.Progress() {
+		}
+	}()
+
+	previewResult, err := previewTask.Await()
+	require.Nil(t, err)
+	require.NotNil(t, previewResult.Preview)
+
+	require.Equal(t, "westus2", previewResult.Preview.Parameters["webapp:AZURE_LOCATION"].Value)
+	require.Equal(t, "http://myapp.azurewebsites.net", previewResult.Preview.Outputs["WEBSITE_URL"].Value)
+
+	// The environment stack is stored in a local file backend & seeded with the environment values
+	var setConfigArgs executil.RunArgs
+	for _, call := range *calls {
+		require.Contains(t, call.Env, "PULUMI_BACKEND_URL=file://"+filepath.ToSlash(filepath.Join(projectDir, ".azure", "test-env", "infra")))
+
+		if call.Args[0] == "config" && call.Args[1] == "set-all" {
+			setConfigArgs = call
+		}
+	}
+
+	setConfigCommand := strings.Join(setConfigArgs.Args, " ")
+	require.Contains(t, setConfigCommand, "--stack test-env")
+	require.Contains(t, setConfigCommand, "--plaintext AZURE_LOCATION=westus2")
+	require.Contains(t, setConfigCommand, "--plaintext azure-native:subscriptionId=SUBSCRIPTION_ID")
+	require.Contains(t, setConfigCommand, filepath.Join(projectDir, ".azure", "test-env", "infra", "Pulumi.test-env.yaml"))
+}
+
+// Resolves the secrets of the environment from memory
+type pulumiTestSecretResolver struct {
+	secrets map[string]string
+}
+
+func (r *pulumiTestSecretResolver) GetSecret(ctx context.Context, reference environment.SecretReference) (string, error) {
+	return r.secrets[reference.String()], nil
+}
+
+func (r *pulumiTestSecretResolver) SetSecret(ctx context.Context, reference environment.SecretReference, value string) error {
+	r.secrets[reference.String()] = value
+	return nil
+}
+
+func TestPulumiPreviewWithoutPassphrase(t *testing.T) {
+	_, calls, infraProvider := createPulumiProvider(t, mocks.NewMockConsole())
+
+	// Both variables are restored once the test completes
+	t.Setenv("PULUMI_CONFIG_PASSPHRASE_FILE", "")
+	require.NoError(t, os.Unsetenv("PULUMI_CONFIG_PASSPHRASE"))
+	require.NoError(t, os.Unsetenv("PULUMI_CONFIG_PASSPHRASE_FILE"))
+
+	previewTask := infraProvider.Preview(context.Background())
+	go func() {
+		for range previewTask.Progress() {
+		}
+	}()
+
+	_, err := previewTask.Await()
+	require.ErrorIs(t, err, errPulumiPassphraseNotSet)
+	require.Empty(t, *calls)
+}
+
+func TestPulumiPreviewStackConfig(t *testing.T) {
+	projectDir, calls, infraProvider := createPulumiProvider(t, &mocks.MockConsole{})
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "infra", "Pulumi.yaml"), []byte(`name: webapp
+config:
+  webapp:API_KEY:
+    type: string
+  WEBSITE_SKU:
+    type: string
+  azure-native:location:
+    type: string
+`), 0600))
+
+	env := infraProvider.(*PulumiProvider).env
+	env.Values["WEBSITE_SKU"] = "B1"
+	env.Values["UNRELATED_VALUE"] = "unrelated"
+
+	resolver := &pulumiTestSecretResolver{secrets: map[string]string{}}
+	reference := environment.SecretReference{VaultName: "my-vault", SecretName: "API-KEY"}
+	require.NoError(t, env.SetSecret(context.Background(), resolver, "API_KEY", reference, "s3cr3t"))
+
+	previewTask := infraProvider.Preview(context.Background())
+
+	go func() {
+		for range previewTask.Progress() {
+		}
+	}()
+
+	_, err := previewTask.Await()
+	require.Nil(t, err)
+
+	var setConfigArgs, setSecretArgs executil.RunArgs
+	for _, call := range *calls {
+		// Secrets never appear on the command line
+		require.NotContains(t, strings.Join(call.Args, " "), "s3cr3t")
+
+		if call.Args[0] == "config" && call.Args[1] == "set-all" {
+			setConfigArgs = call
+		}
+
+		if call.Args[0] == "config" && call.Args[1] == "set" {
+			setSecretArgs = call
+		}
+	}
+
+	// Only the values declared by the project and the common azd values are passed to the stack
+	setConfigCommand := strings.Join(setConfigArgs.Args, " ")
+	require.Contains(t, setConfigCommand, "--plaintext WEBSITE_SKU=B1")
+	require.Contains(t, setConfigCommand, "--plaintext AZURE_LOCATION=westus2")
+	require.NotContains(t, setConfigCommand, "UNRELATED_VALUE")
+	require.NotContains(t, setConfigCommand, "API_KEY")
+
+	require.Contains(t, setSecretArgs.Args, "--secret")
+	require.Contains(t, setSecretArgs.Args, "API_KEY")
+	require.NotNil(t, setSecretArgs.Stdin)
+
+	secret, err := io.ReadAll(setSecretArgs.Stdin)
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", string(secret))
+}
+
+func TestPulumiDeploy(t *testing.T) {
+	_, _, infraProvider := createPulumiProvider(t, &mocks.MockConsole{})
+	deployTask := infraProvider.Deploy(context.Background(), &Preview{}, nil)
+
+	progressReports := []*DeployProgress{}
+	progressDone := make(chan struct{})
+	go func() {
+		for deployProgress := range deployTask.Progress() {
+			progressReports = append(progressReports, deployProgress)
+		}
+		close(progressDone)
+	}()
+
+	deployResult, err := deployTask.Await()
+	<-progressDone
+	require.Nil(t, err)
+	require.NotNil(t, deployResult)
+
+	require.Equal(t, "http://myapp.azurewebsites.net", deployResult.Outputs["WEBSITE_URL"].Value)
+	require.Equal(t, "string", deployResult.Outputs["WEBSITE_URL"].Type)
+	require.Equal(t, "number", deployResult.Outputs["REPLICAS"].Type)
+
+	// The pulumi stack resource is excluded from the reported operations
+	require.Len(t, deployResult.Operations, 2)
+	require.Equal(t, "rg", deployResult.Operations[0].Properties.TargetResource.ResourceName)
+	require.Equal(t, "Succeeded", deployResult.Operations[0].Properties.ProvisioningState)
+	require.Equal(t, "Create", deployResult.Operations[0].Properties.ProvisioningOperation)
+	require.Equal(t, "/subscriptions/SUBSCRIPTION_ID/resourceGroups/rg", deployResult.Operations[0].Properties.TargetResource.Id)
+	require.Equal(t, "app", deployResult.Operations[1].Properties.TargetResource.ResourceName)
+	require.Equal(t, "Failed", deployResult.Operations[1].Properties.ProvisioningState)
+	require.NotEmpty(t, progressReports)
+}
+
+func TestPulumiState(t *testing.T) {
+	_, calls, infraProvider := createPulumiProvider(t, &mocks.MockConsole{})
+	stateTask := infraProvider.State(context.Background())
+
+	go func() {
+		for range stateTask.Progress() {
+		}
+	}()
+
+	stateResult, err := stateTask.Await()
+	require.Nil(t, err)
+	require.NotNil(t, stateResult)
+
+	require.Equal(t, "http://myapp.azurewebsites.net", stateResult.Outputs["WEBSITE_URL"].Value)
+	require.Equal(t, "number", stateResult.Outputs["REPLICAS"].Type)
+
+	// Reading the state does not change the stack
+	for _, call := range *calls {
+		require.NotEqual(t, "up", call.Args[0])
+	}
+}
+
+func TestPulumiDestroyWithNegativeConfirmation(t *testing.T) {
+	console := &mocks.MockConsole{}
+	console.WhenConfirm(func(options input.ConsoleOptions) bool {
+		return strings.Contains(options.Message, "are you sure you want to continue")
+	}).Respond(false)
+
+	_, calls, infraProvider := createPulumiProvider(t, console)
+	destroyTask := infraProvider.Destroy(context.Background(), &Preview{})
+
+	go func() {
+		for range destroyTask.Progress() {
+		}
+	}()
+
+	go func() {
+		for range destroyTask.Interactive() {
+		}
+	}()
+
+	destroyResult, err := destroyTask.Await()
+	require.Nil(t, destroyResult)
+	require.NotNil(t, err)
+
+	for _, call := range *calls {
+		require.NotEqual(t, "destroy", call.Args[0])
+	}
+}
+
+func TestPulumiDestroyWithForce(t *testing.T) {
+	_, _, infraProvider := createPulumiProvider(t, &mocks.MockConsole{})
+	infraProvider.(*PulumiProvider).options.Force = true
+	destroyTask := infraProvider.Destroy(context.Background(), &Preview{})
+
+	go func() {
+		for range destroyTask.Progress() {
+		}
+	}()
+
+	destroyResult, err := destroyTask.Await()
+	require.Nil(t, err)
+	require.NotNil(t, destroyResult)
+	require.Contains(t, destroyResult.Outputs, "WEBSITE_URL")
+}
+
+func TestPulumiEventLogReaderPartialLines(t *testing.T) {
+	eventLogPath := filepath.Join(t.TempDir(), "events.json")
+	reader := &pulumiEventLogReader{path: eventLogPath}
+
+	// The event log does not exist until pulumi starts the update
+	events, err := reader.ReadEvents()
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	require.NoError(t, os.WriteFile(eventLogPath, []byte(`{"sequence":0,"timestamp":1}`+"\n"+`{"sequence":1,`), 0600))
+	events, err = reader.ReadEvents()
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	file, err := os.OpenFile(eventLogPath, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`"timestamp":2}` + "\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	events, err = reader.ReadEvents()
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, 1, events[0].Sequence)
+}
@@ -11,6 +11,8 @@ type Scope interface {
 	Deploy(ctx context.Context, templatePath string, parametersPath string) error
 	// GetDeployment fetches the result of the most recent deployment.
 	GetDeployment(ctx context.Context) (azcli.AzCliDeployment, error)
+	// WhatIf previews the changes the deployment of a given template would make to the Azure resources.
+	WhatIf(ctx context.Context, templatePath string, parametersPath string) (azcli.AzCliWhatIfResult, error)
 }
 
 type ResourceGroupScope struct {
@@ -29,6 +31,10 @@ func (s *ResourceGroupScope) GetDeployment(ctx context.Context) (azcli.AzCliDepl
 	return s.azCli.GetResourceGroupDeployment(ctx, s.subscriptionId, s.resourceGroup, s.name)
 }
 
+func (s *ResourceGroupScope) WhatIf(ctx context.Context, modulePath string, parametersPath string) (azcli.AzCliWhatIfResult, error) {
+	return s.azCli.WhatIfDeployToResourceGroup(ctx, s.subscriptionId, s.resourceGroup, s.name, modulePath, parametersPath)
+}
+
 func NewResourceGroupProvisioningScope(azCli azcli.AzCli, subscriptionId string, resourceGroup string, deploymentName string) Scope {
 	return &ResourceGroupScope{
 		azCli:          azCli,
@@ -66,6 +72,10 @@ func (s *SubscriptionScope) GetDeployment(ctx context.Context) (azcli.AzCliDeplo
 	return s.azCli.GetSubscriptionDeployment(ctx, s.subscriptionId, s.name)
 }
 
+func (s *SubscriptionScope) WhatIf(ctx context.Context, bicepPath string, parametersPath string) (azcli.AzCliWhatIfResult, error) {
+	return s.azCli.WhatIfDeployToSubscription(ctx, s.subscriptionId, s.name, bicepPath, parametersPath, s.location)
+}
+
 func NewSubscriptionProvisioningScope(azCli azcli.AzCli, location string, subscriptionId string, deploymentName string) Scope {
 	return &SubscriptionScope{
 		azCli:          azCli,
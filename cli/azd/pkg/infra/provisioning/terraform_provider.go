@@ -0,0 +1,565 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/async"
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/osutil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/terraform"
+	"github.com/drone/envsubst"
+)
+
+// The subset of `terraform show -json <plan>` that is used to build a preview
+type terraformPlan struct {
+	Variables     map[string]terraformPlanVariable `json:"variables"`
+	PlannedValues struct {
+		Outputs map[string]terraformOutput `json:"outputs"`
+	} `json:"planned_values"`
+	Configuration struct {
+		RootModule struct {
+			Variables map[string]terraformConfigVariable `json:"variables"`
+			Outputs   map[string]terraformConfigOutput   `json:"outputs"`
+		} `json:"root_module"`
+	} `json:"configuration"`
+}
+
+type terraformPlanVariable struct {
+	Value interface{} `json:"value"`
+}
+
+type terraformConfigVariable struct {
+	Default     interface{} `json:"default"`
+	Description string      `json:"description"`
+}
+
+type terraformConfigOutput struct {
+	Sensitive bool `json:"sensitive"`
+}
+
+// An output value as returned from `terraform output -json` or within the planned values of a plan
+type terraformOutput struct {
+	Sensitive bool            `json:"sensitive"`
+	Type      json.RawMessage `json:"type"`
+	Value     interface{}     `json:"value"`
+}
+
+// TerraformProvider exposes infrastructure provisioning using Terraform modules
+type TerraformProvider struct {
+	env         *environment.Environment
+	projectPath string
+	options     Options
+	console     input.Console
+	cli         terraform.TerraformCli
+}
+
+// Name gets the name of the infra provider
+func (p *TerraformProvider) Name() string {
+	return "Terraform"
+}
+
+func (p *TerraformProvider) RequiredExternalTools() []tools.ExternalTool {
+	return []tools.ExternalTool{p.cli}
+}
+
+// Previews the infrastructure provisioning by running a terraform plan
+func (p *TerraformProvider) Preview(ctx context.Context) *async.InteractiveTaskWithProgress[*PreviewResult, *PreviewProgress] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*PreviewResult, *PreviewProgress]) {
+			asyncContext.SetProgress(&PreviewProgress{Message: "Generating terraform parameters", Timestamp: time.Now()})
+			if err := p.createParametersFile(); err != nil {
+				asyncContext.SetError(fmt.Errorf("creating parameters file: %w", err))
+				return
+			}
+
+			isRemoteBackend, err := p.hasRemoteBackend()
+			if err != nil {
+				asyncContext.SetError(fmt.Errorf("reading backend configuration: %w", err))
+				return
+			}
+
+			modulePath := p.modulePath()
+			asyncContext.SetProgress(&PreviewProgress{Message: "Initializing terraform", Timestamp: time.Now()})
+			if _, err := p.cli.Init(ctx, modulePath); err != nil {
+				asyncContext.SetError(fmt.Errorf("initializing terraform: %w", err))
+				return
+			}
+
+			asyncContext.SetProgress(&PreviewProgress{Message: "Validating terraform template", Timestamp: time.Now()})
+			if _, err := p.cli.Validate(ctx, modulePath); err != nil {
+				asyncContext.SetError(fmt.Errorf("validating terraform template: %w", err))
+				return
+			}
+
+			asyncContext.SetProgress(&PreviewProgress{Message: "Planning terraform deployment", Timestamp: time.Now()})
+			planArgs := append(p.stateArgs(isRemoteBackend), p.varFileArgs()...)
+			if _, err := p.cli.Plan(ctx, modulePath, p.planFilePath(), planArgs...); err != nil {
+				asyncContext.SetError(fmt.Errorf("planning terraform deployment: %w", err))
+				return
+			}
+
+			planJson, err := p.cli.Show(ctx, modulePath, p.planFilePath())
+			if err != nil {
+				asyncContext.SetError(fmt.Errorf("reading terraform plan: %w", err))
+				return
+			}
+
+			preview, err := p.convertToPreview(planJson)
+			if err != nil {
+				asyncContext.SetError(fmt.Errorf("converting terraform plan: %w", err))
+				return
+			}
+
+			asyncContext.SetResult(&PreviewResult{Preview: *preview})
+		})
+}
+
+// Writes the preview parameter values to the environment .tfvars.json file
+func (p *TerraformProvider) UpdatePlan(ctx context.Context, preview Preview) error {
+	variables := make(map[string]interface{}, len(preview.Parameters))
+	for key, param := range preview.Parameters {
+		if param.HasValue() {
+			variables[key] = param.Value
+		}
+	}
+
+	bytes, err := json.MarshalIndent(variables, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling parameters: %w", err)
+	}
+
+	parametersFilePath := p.parametersFilePath()
+	if err := os.MkdirAll(filepath.Dir(parametersFilePath), osutil.PermissionDirectory); err != nil {
+		return fmt.Errorf("creating directory structure: %w", err)
+	}
+
+	if err := os.WriteFile(parametersFilePath, bytes, osutil.PermissionFile); err != nil {
+		return fmt.Errorf("writing parameters file: %w", err)
+	}
+
+	return nil
+}
+
+// Applies the terraform plan and collects the resulting outputs
+func (p *TerraformProvider) Deploy(ctx context.Context, preview *Preview, scope Scope) *async.InteractiveTaskWithProgress[*DeployResult, *DeployProgress] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*DeployResult, *DeployProgress]) {
+			isRemoteBackend, err := p.hasRemoteBackend()
+			if err != nil {
+				asyncContext.SetError(fmt.Errorf("reading backend configuration: %w", err))
+				return
+			}
+
+			modulePath := p.modulePath()
+
+			// Prefer the plan generated during preview so the changes applied match what was previewed,
+			// but plan again when the module or its variables changed after the plan was saved.
+			if !p.isPlanCurrent(ctx, modulePath) {
+				// Keep the variables written by UpdatePlan, only staging the variables file when missing
+				if _, err := os.Stat(p.parametersFilePath()); err != nil {
+					if err := p.createParametersFile(); err != nil {
+						asyncContext.SetError(fmt.Errorf("creating parameters file: %w", err))
+						return
+					}
+				}
+
+				if err := os.MkdirAll(p.environmentWorkingPath(), osutil.PermissionDirectory); err != nil {
+					asyncContext.SetError(fmt.Errorf("creating directory structure: %w", err))
+					return
+				}
+
+				if _, err := p.cli.Init(ctx, modulePath); err != nil {
+					asyncContext.SetError(fmt.Errorf("initializing terraform: %w", err))
+					return
+				}
+
+				planArgs := append(p.stateArgs(isRemoteBackend), p.varFileArgs()...)
+				if _, err := p.cli.Plan(ctx, modulePath, p.planFilePath(), planArgs...); err != nil {
+					asyncContext.SetError(fmt.Errorf("planning terraform deployment: %w", err))
+					return
+				}
+			}
+
+			log.Printf("applying terraform module %s", modulePath)
+			applyArgs := append(p.stateArgs(isRemoteBackend), p.planFilePath())
+			if _, err := p.cli.Apply(ctx, modulePath, applyArgs...); err != nil {
+				asyncContext.SetError(fmt.Errorf("applying terraform plan: %w", err))
+				return
+			}
+
+			// An applied plan can't be applied again
+			if err := os.Remove(p.planFilePath()); err != nil {
+				log.Printf("failed removing applied terraform plan: %v", err)
+			}
+
+			outputs, err := p.outputs(ctx, isRemoteBackend)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetResult(&DeployResult{
+				Outputs: outputs,
+			})
+		})
+}
+
+// Destroys all the resources tracked within the terraform state
+func (p *TerraformProvider) Destroy(ctx context.Context, preview *Preview) *async.InteractiveTaskWithProgress[*DestroyResult, *DestroyProgress] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*DestroyResult, *DestroyProgress]) {
+			isRemoteBackend, err := p.hasRemoteBackend()
+			if err != nil {
+				asyncContext.SetError(fmt.Errorf("reading backend configuration: %w", err))
+				return
+			}
+
+			// Collect the current outputs so they can be removed from the environment once the resources are gone
+			asyncContext.SetProgress(&DestroyProgress{Message: "Fetching terraform outputs", Timestamp: time.Now()})
+			outputs, err := p.outputs(ctx, isRemoteBackend)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			if !p.options.Force {
+				err = asyncContext.Interact(func() error {
+					confirmDestroy, err := p.console.Confirm(ctx, input.ConsoleOptions{
+						Message: "This will delete all resources managed by the terraform module, are you sure you want to continue?\n" +
+							"You can use --force to skip this confirmation.",
+						DefaultValue: false,
+					})
+
+					if err != nil {
+						return err
+					}
+
+					if !confirmDestroy {
+						return errors.New("user denied confirmation")
+					}
+
+					return nil
+				})
+
+				if err != nil {
+					asyncContext.SetError(err)
+					return
+				}
+			}
+
+			asyncContext.SetProgress(&DestroyProgress{Message: "Destroying terraform resources", Timestamp: time.Now()})
+			destroyArgs := append(p.stateArgs(isRemoteBackend), p.varFileArgs()...)
+			if _, err := p.cli.Destroy(ctx, p.modulePath(), destroyArgs...); err != nil {
+				asyncContext.SetError(fmt.Errorf("destroying terraform resources: %w", err))
+				return
+			}
+
+			asyncContext.SetResult(&DestroyResult{
+				Outputs: outputs,
+			})
+		})
+}
+
+// Reads the outputs of the terraform state
+func (p *TerraformProvider) State(ctx context.Context) *async.InteractiveTaskWithProgress[*StateResult, *StateProgress] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*StateResult, *StateProgress]) {
+			isRemoteBackend, err := p.hasRemoteBackend()
+			if err != nil {
+				asyncContext.SetError(fmt.Errorf("reading backend configuration: %w", err))
+				return
+			}
+
+			asyncContext.SetProgress(&StateProgress{Message: "Fetching terraform outputs", Timestamp: time.Now()})
+			outputs, err := p.outputs(ctx, isRemoteBackend)
+			if err != nil {
+				asyncContext.SetError(err)
+				return
+			}
+
+			asyncContext.SetResult(&StateResult{
+				Outputs: outputs,
+			})
+		})
+}
+
+// Reads the outputs from the terraform state
+func (p *TerraformProvider) outputs(ctx context.Context, isRemoteBackend bool) (map[string]PreviewOutputParameter, error) {
+	outputJson, err := p.cli.Output(ctx, p.modulePath(), p.stateArgs(isRemoteBackend)...)
+	if err != nil {
+		return nil, fmt.Errorf("reading terraform outputs: %w", err)
+	}
+
+	var tfOutputs map[string]terraformOutput
+	if err := json.Unmarshal([]byte(outputJson), &tfOutputs); err != nil {
+		return nil, fmt.Errorf("could not unmarshal output %s as terraform outputs: %w", outputJson, err)
+	}
+
+	outputs := make(map[string]PreviewOutputParameter, len(tfOutputs))
+	for key, output := range tfOutputs {
+		outputs[key] = PreviewOutputParameter{
+			Type:  terraformOutputType(output.Type),
+			Value: output.Value,
+		}
+	}
+
+	return outputs, nil
+}
+
+// Checks whether the plan saved during preview can still be applied, that is the plan can be read
+// and neither the module nor its variables were modified after the plan was saved.
+func (p *TerraformProvider) isPlanCurrent(ctx context.Context, modulePath string) bool {
+	planInfo, err := os.Stat(p.planFilePath())
+	if err != nil {
+		return false
+	}
+
+	if _, err := p.cli.Show(ctx, modulePath, p.planFilePath()); err != nil {
+		log.Printf("failed reading saved terraform plan, planning again: %v", err)
+		return false
+	}
+
+	if info, err := os.Stat(p.parametersFilePath()); err == nil && info.ModTime().After(planInfo.ModTime()) {
+		return false
+	}
+
+	moduleFiles, err := os.ReadDir(modulePath)
+	if err != nil {
+		return false
+	}
+
+	for _, file := range moduleFiles {
+		info, err := file.Info()
+		if err != nil || (!file.IsDir() && info.ModTime().After(planInfo.ModTime())) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Converts the JSON representation of a terraform plan to a generic provisioning preview
+func (p *TerraformProvider) convertToPreview(planJson string) (*Preview, error) {
+	var plan terraformPlan
+	if err := json.Unmarshal([]byte(planJson), &plan); err != nil {
+		log.Printf("failed un-marshaling terraform plan to JSON (err: %v), plan contents:\n%s", err, planJson)
+		return nil, fmt.Errorf("error un-marshaling terraform plan from json: %w", err)
+	}
+
+	parameters := make(map[string]PreviewInputParameter)
+	outputs := make(map[string]PreviewOutputParameter)
+
+	for key, variable := range plan.Configuration.RootModule.Variables {
+		parameters[key] = PreviewInputParameter{
+			DefaultValue: variable.Default,
+			Value:        plan.Variables[key].Value,
+		}
+	}
+
+	for key := range plan.Configuration.RootModule.Outputs {
+		// Planned output values are only available when known before apply
+		plannedOutput := plan.PlannedValues.Outputs[key]
+		outputs[key] = PreviewOutputParameter{
+			Type:  terraformOutputType(plannedOutput.Type),
+			Value: plannedOutput.Value,
+		}
+	}
+
+	return &Preview{
+		Parameters: parameters,
+		Outputs:    outputs,
+	}, nil
+}
+
+// Copies the terraform variables file from the project template into the .azure environment folder
+func (p *TerraformProvider) createParametersFile() error {
+	parametersTemplateFilePath := p.parametersTemplateFilePath()
+	log.Printf("Reading parameters template file from: %s", parametersTemplateFilePath)
+	parametersBytes, err := os.ReadFile(parametersTemplateFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		// Variables files are optional for terraform modules
+		log.Printf("parameters template file not found, skipping")
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("reading parameter file template: %w", err)
+	}
+
+	replaced, err := envsubst.Eval(string(parametersBytes), func(name string) string {
+		if val, has := p.env.Values[name]; has {
+			return val
+		}
+		return os.Getenv(name)
+	})
+	if err != nil {
+		return fmt.Errorf("substituting parameter file: %w", err)
+	}
+
+	parametersFilePath := p.parametersFilePath()
+	if err := os.MkdirAll(filepath.Dir(parametersFilePath), osutil.PermissionDirectory); err != nil {
+		return fmt.Errorf("creating directory structure: %w", err)
+	}
+
+	log.Printf("Writing parameters file to: %s", parametersFilePath)
+	if err := os.WriteFile(parametersFilePath, []byte(replaced), osutil.PermissionFile); err != nil {
+		return fmt.Errorf("writing parameter file: %w", err)
+	}
+
+	return nil
+}
+
+var terraformBackendRegex = regexp.MustCompile(`backend\s+"([^"]+)"`)
+
+// Checks whether the terraform module declares a backend other than the default local backend.
+// When a remote backend is used the state is managed by terraform instead of being stored in the environment.
+func (p *TerraformProvider) hasRemoteBackend() (bool, error) {
+	entries, err := os.ReadDir(p.modulePath())
+	if err != nil {
+		return false, fmt.Errorf("reading module directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".tf" {
+			continue
+		}
+
+		contents, err := os.ReadFile(filepath.Join(p.modulePath(), entry.Name()))
+		if err != nil {
+			return false, fmt.Errorf("reading terraform file %s: %w", entry.Name(), err)
+		}
+
+		for _, match := range terraformBackendRegex.FindAllStringSubmatch(string(contents), -1) {
+			if match[1] != "local" {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// Gets the arguments that point terraform to the per environment local state file
+func (p *TerraformProvider) stateArgs(isRemoteBackend bool) []string {
+	if isRemoteBackend {
+		return []string{}
+	}
+
+	return []string{fmt.Sprintf("-state=%s", p.localStateFilePath())}
+}
+
+// Gets the arguments that pass the environment variables file to terraform when one exists
+func (p *TerraformProvider) varFileArgs() []string {
+	if _, err := os.Stat(p.parametersFilePath()); err != nil {
+		return []string{}
+	}
+
+	return []string{fmt.Sprintf("-var-file=%s", p.parametersFilePath())}
+}
+
+// Gets the environment variables passed to every terraform command
+func (p *TerraformProvider) cliEnv() []string {
+	env := []string{
+		fmt.Sprintf("TF_DATA_DIR=%s", p.dataDirPath()),
+	}
+
+	// The azurerm terraform provider reads the target subscription & tenant from these variables
+	if subscriptionId := p.env.GetSubscriptionId(); subscriptionId != "" {
+		env = append(env, fmt.Sprintf("ARM_SUBSCRIPTION_ID=%s", subscriptionId))
+	}
+
+	if tenantId := p.env.GetTenantId(); tenantId != "" {
+		env = append(env, fmt.Sprintf("ARM_TENANT_ID=%s", tenantId))
+	}
+
+	return env
+}
+
+// Gets the folder path to the terraform module
+func (p *TerraformProvider) modulePath() string {
+	infraPath := p.options.Path
+	if strings.TrimSpace(infraPath) == "" {
+		infraPath = "infra"
+	}
+
+	return filepath.Join(p.projectPath, infraPath)
+}
+
+// Gets the path to the project variables file template
+func (p *TerraformProvider) parametersTemplateFilePath() string {
+	parametersFilename := fmt.Sprintf("%s.tfvars.json", p.options.Module)
+	return filepath.Join(p.modulePath(), parametersFilename)
+}
+
+// Gets the path to the environment specific working directory for terraform
+func (p *TerraformProvider) environmentWorkingPath() string {
+	return filepath.Join(p.projectPath, environment.EnvironmentDirectoryName, p.env.GetEnvName(), p.options.Path)
+}
+
+// Gets the path to the staging .azure variables file
+func (p *TerraformProvider) parametersFilePath() string {
+	parametersFilename := fmt.Sprintf("%s.tfvars.json", p.options.Module)
+	return filepath.Join(p.environmentWorkingPath(), parametersFilename)
+}
+
+// Gets the path to the plan file generated during preview
+func (p *TerraformProvider) planFilePath() string {
+	return filepath.Join(p.environmentWorkingPath(), fmt.Sprintf("%s.tfplan", p.options.Module))
+}
+
+// Gets the path to the local terraform state of the environment
+func (p *TerraformProvider) localStateFilePath() string {
+	return filepath.Join(p.environmentWorkingPath(), "terraform.tfstate")
+}
+
+// Gets the path to the terraform data directory (providers & modules) of the environment
+func (p *TerraformProvider) dataDirPath() string {
+	return filepath.Join(p.environmentWorkingPath(), ".terraform")
+}
+
+// Gets the type name of a terraform output, ex) "string" or ["list", "string"]
+func terraformOutputType(rawType json.RawMessage) string {
+	var typeName string
+	if err := json.Unmarshal(rawType, &typeName); err == nil {
+		return typeName
+	}
+
+	var complexType []interface{}
+	if err := json.Unmarshal(rawType, &complexType); err == nil && len(complexType) > 0 {
+		return fmt.Sprint(complexType[0])
+	}
+
+	return ""
+}
+
+// NewTerraformProvider creates a new instance of a Terraform Infra provider
+func NewTerraformProvider(env *environment.Environment, projectPath string, options Options, console input.Console, cli terraform.TerraformCli) Provider {
+	// Terraform runs from within the module directory, so all paths passed to it must be absolute
+	if absProjectPath, err := filepath.Abs(projectPath); err == nil {
+		projectPath = absProjectPath
+	}
+
+	provider := &TerraformProvider{
+		env:         env,
+		projectPath: projectPath,
+		options:     options,
+		console:     console,
+		cli:         cli,
+	}
+
+	cli.SetEnv(provider.cliEnv())
+
+	return provider
+}
@@ -0,0 +1,334 @@
+package provisioning
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/input"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/terraform"
+	"github.com/azure/azure-dev/cli/azd/test/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+const terraformPlanJson = `{
+	"variables": {
+		"location": { "value": "westus2" },
+		"environment_name": { "value": "test-env" }
+	},
+	"planned_values": {
+		"outputs": {
+			"AZURE_LOCATION": { "sensitive": false, "type": "string", "value": "westus2" }
+		}
+	},
+	"configuration": {
+		"root_module": {
+			"variables": {
+				"location": { "description": "The location of the resources" },
+				"environment_name": { "description": "The name of the environment" },
+				"sku": { "default": "B1" }
+			},
+			"outputs": {
+				"AZURE_LOCATION": { "sensitive": false },
+				"WEBSITE_URL": { "sensitive": false }
+			}
+		}
+	}
+}`
+
+const terraformOutputJson = `{
+	"AZURE_LOCATION": { "sensitive": false, "type": "string", "value": "westus2" },
+	"WEBSITE_URL": { "sensitive": false, "type": "string", "value": "http://myapp.azurewebsites.net" },
+	"SERVICE_URLS": { "sensitive": false, "type": ["list", "string"], "value": ["http://myapp.azurewebsites.net"] }
+}`
+
+// Sets up all the mocks required for the terraform preview, deploy & destroy operations
+func setupTerraformExecUtilWithMocks() *mocks.MockExecUtil {
+	execUtil := &mocks.MockExecUtil{}
+
+	terraformCommands := map[string]string{
+		"init":     "",
+		"validate": "",
+		"plan":     "",
+		"show":     terraformPlanJson,
+		"apply":    "",
+		"output":   terraformOutputJson,
+		"destroy":  "",
+	}
+
+	for command, stdout := range terraformCommands {
+		command := command
+		execUtil.When(func(args executil.RunArgs) bool {
+			return args.Cmd == "terraform" && len(args.Args) > 1 && args.Args[1] == command
+		}).Respond(executil.RunResult{
+			Stdout: stdout,
+			Stderr: "",
+		})
+	}
+
+	return execUtil
+}
+
+// Creates a project directory with a minimal terraform module
+func createTerraformProject(t *testing.T, moduleContents string) string {
+	projectDir := t.TempDir()
+	infraDir := filepath.Join(projectDir, "infra")
+
+	require.NoError(t, os.MkdirAll(infraDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(infraDir, "main.tf"), []byte(moduleContents), 0600))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(infraDir, "main.tfvars.json"),
+		[]byte(`{ "location": "${AZURE_LOCATION}", "environment_name": "${AZURE_ENV_NAME}" }`),
+		0600,
+	))
+
+	return projectDir
+}
+
+// Creates a terraform provider for the project, returning the commands that are run through the provider
+func createTerraformProvider(projectDir string, execUtil *mocks.MockExecUtil, console input.Console) (*[]executil.RunArgs, Provider) {
+	calls := []executil.RunArgs{}
+	env := environment.Environment{Values: make(map[string]string)}
+	env.Values["AZURE_LOCATION"] = "westus2"
+	env.Values["AZURE_SUBSCRIPTION_ID"] = "SUBSCRIPTION_ID"
+	env.SetEnvName("test-env")
+
+	options := Options{
+		Provider: Terraform,
+		Path:     "infra",
+		Module:   "main",
+	}
+
+	terraformCli := terraform.NewTerraformCli(terraform.NewTerraformCliArgs{
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			calls = append(calls, args)
+			return execUtil.RunWithResult(ctx, args)
+		},
+	})
+
+	return &calls, NewTerraformProvider(&env, projectDir, options, console, terraformCli)
+}
+
+func TestTerraformPreview(t *testing.T) {
+	projectDir := createTerraformProject(t, `variable "location" {}`)
+	execUtil := setupTerraformExecUtilWithMocks()
+	calls, infraProvider := createTerraformProvider(projectDir, execUtil, &mocks.MockConsole{})
+
+	previewTask := infraProvider.Preview(context.Background())
+
+	go func() {
+		for progressReport := range previewTask.Progress() {
+			fmt.Println(progressReport.Message)
+		}
+	}()
+
+	previewResult, err := previewTask.Await()
+	require.Nil(t, err)
+	require.NotNil(t, previewResult.Preview)
+
+	require.Equal(t, "westus2", previewResult.Preview.Parameters["location"].Value)
+	require.Equal(t, "test-env", previewResult.Preview.Parameters["environment_name"].Value)
+	require.Equal(t, "B1", previewResult.Preview.Parameters["sku"].DefaultValue)
+	require.Equal(t, "westus2", previewResult.Preview.Outputs["AZURE_LOCATION"].Value)
+	require.Contains(t, previewResult.Preview.Outputs, "WEBSITE_URL")
+
+	// The variables file is staged within the environment with the environment values substituted
+	parametersBytes, err := os.ReadFile(filepath.Join(projectDir, ".azure", "test-env", "infra", "main.tfvars.json"))
+	require.NoError(t, err)
+	require.Contains(t, string(parametersBytes), `"location": "westus2"`)
+
+	// Terraform is pointed to the per environment state & the target subscription
+	var planArgs executil.RunArgs
+	for _, call := range *calls {
+		if call.Args[1] == "plan" {
+			planArgs = call
+		}
+	}
+
+	require.Contains(t, strings.Join(planArgs.Args, " "), fmt.Sprintf("-state=%s", filepath.Join(projectDir, ".azure", "test-env", "infra", "terraform.tfstate")))
+	require.Contains(t, planArgs.Env, "ARM_SUBSCRIPTION_ID=SUBSCRIPTION_ID")
+}
+
+func TestTerraformPreviewWithRemoteBackend(t *testing.T) {
+	projectDir := createTerraformProject(t, `terraform {
+  backend "azurerm" {}
+}`)
+	execUtil := setupTerraformExecUtilWithMocks()
+	calls, infraProvider := createTerraformProvider(projectDir, execUtil, &mocks.MockConsole{})
+
+	previewTask := infraProvider.Preview(context.Background())
+
+	go func() {
+		for range previewTask.Progress() {
+		}
+	}()
+
+	_, err := previewTask.Await()
+	require.Nil(t, err)
+
+	for _, call := range *calls {
+		require.NotContains(t, strings.Join(call.Args, " "), "-state=")
+	}
+}
+
+func TestTerraformDeploy(t *testing.T) {
+	projectDir := createTerraformProject(t, `variable "location" {}`)
+	execUtil := setupTerraformExecUtilWithMocks()
+	_, infraProvider := createTerraformProvider(projectDir, execUtil, &mocks.MockConsole{})
+
+	deployTask := infraProvider.Deploy(context.Background(), &Preview{}, nil)
+
+	go func() {
+		for deployProgress := range deployTask.Progress() {
+			fmt.Println(deployProgress.Timestamp)
+		}
+	}()
+
+	deployResult, err := deployTask.Await()
+	require.Nil(t, err)
+	require.NotNil(t, deployResult)
+
+	require.Equal(t, "http://myapp.azurewebsites.net", deployResult.Outputs["WEBSITE_URL"].Value)
+	require.Equal(t, "string", deployResult.Outputs["WEBSITE_URL"].Type)
+	require.Equal(t, "list", deployResult.Outputs["SERVICE_URLS"].Type)
+}
+
+func TestTerraformDeployWithSavedPlan(t *testing.T) {
+	deploy := func(t *testing.T, stale bool) []executil.RunArgs {
+		projectDir := createTerraformProject(t, `variable "location" {}`)
+		planFilePath := filepath.Join(projectDir, ".azure", "test-env", "infra", "main.tfplan")
+		require.NoError(t, os.MkdirAll(filepath.Dir(planFilePath), 0755))
+		require.NoError(t, os.WriteFile(planFilePath, []byte("plan"), 0600))
+
+		// The plan is saved after the module was written, unless the module is changed after the plan
+		planned := time.Now()
+		moduleChanged := planned.Add(-time.Hour)
+		if stale {
+			moduleChanged = planned.Add(time.Hour)
+		}
+		require.NoError(t, os.Chtimes(planFilePath, planned, planned))
+		require.NoError(t, os.Chtimes(filepath.Join(projectDir, "infra", "main.tf"), moduleChanged, moduleChanged))
+		require.NoError(t, os.Chtimes(filepath.Join(projectDir, "infra", "main.tfvars.json"), moduleChanged, moduleChanged))
+
+		execUtil := setupTerraformExecUtilWithMocks()
+		calls, infraProvider := createTerraformProvider(projectDir, execUtil, &mocks.MockConsole{})
+
+		deployTask := infraProvider.Deploy(context.Background(), &Preview{}, nil)
+		go func() {
+			for range deployTask.Progress() {
+			}
+		}()
+
+		_, err := deployTask.Await()
+		require.NoError(t, err)
+
+		// The applied plan is removed so it isn't applied again
+		require.NoFileExists(t, planFilePath)
+
+		for _, call := range *calls {
+			if call.Args[1] == "apply" {
+				require.Equal(t, planFilePath, call.Args[len(call.Args)-1])
+			}
+		}
+
+		return *calls
+	}
+
+	commands := func(calls []executil.RunArgs) []string {
+		commands := []string{}
+		for _, call := range calls {
+			commands = append(commands, call.Args[1])
+		}
+		return commands
+	}
+
+	t.Run("Current", func(t *testing.T) {
+		require.NotContains(t, commands(deploy(t, false)), "plan")
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		require.Contains(t, commands(deploy(t, true)), "plan")
+	})
+}
+
+func TestTerraformState(t *testing.T) {
+	projectDir := createTerraformProject(t, `variable "location" {}`)
+	execUtil := setupTerraformExecUtilWithMocks()
+	calls, infraProvider := createTerraformProvider(projectDir, execUtil, &mocks.MockConsole{})
+
+	stateTask := infraProvider.State(context.Background())
+
+	go func() {
+		for range stateTask.Progress() {
+		}
+	}()
+
+	stateResult, err := stateTask.Await()
+	require.Nil(t, err)
+	require.NotNil(t, stateResult)
+
+	require.Equal(t, "http://myapp.azurewebsites.net", stateResult.Outputs["WEBSITE_URL"].Value)
+	require.Equal(t, "westus2", stateResult.Outputs["AZURE_LOCATION"].Value)
+
+	// Reading the state does not change the resources
+	for _, call := range *calls {
+		require.NotEqual(t, "apply", call.Args[1])
+	}
+}
+
+func TestTerraformDestroyWithNegativeConfirmation(t *testing.T) {
+	projectDir := createTerraformProject(t, `variable "location" {}`)
+	execUtil := setupTerraformExecUtilWithMocks()
+
+	console := &mocks.MockConsole{}
+	console.WhenConfirm(func(options input.ConsoleOptions) bool {
+		return strings.Contains(options.Message, "are you sure you want to continue")
+	}).Respond(false)
+
+	calls, infraProvider := createTerraformProvider(projectDir, execUtil, console)
+	destroyTask := infraProvider.Destroy(context.Background(), &Preview{})
+
+	go func() {
+		for range destroyTask.Progress() {
+		}
+	}()
+
+	go func() {
+		for range destroyTask.Interactive() {
+		}
+	}()
+
+	destroyResult, err := destroyTask.Await()
+	require.Nil(t, destroyResult)
+	require.NotNil(t, err)
+
+	for _, call := range *calls {
+		require.NotEqual(t, "destroy", call.Args[1])
+	}
+}
+
+func TestTerraformDestroyWithForce(t *testing.T) {
+	projectDir := createTerraformProject(t, `variable "location" {}`)
+	execUtil := setupTerraformExecUtilWithMocks()
+	_, infraProvider := createTerraformProvider(projectDir, execUtil, &mocks.MockConsole{})
+
+	infraProvider.(*TerraformProvider).options.Force = true
+	destroyTask := infraProvider.Destroy(context.Background(), &Preview{})
+
+	go func() {
+		for range destroyTask.Progress() {
+		}
+	}()
+
+	destroyResult, err := destroyTask.Await()
+	require.Nil(t, err)
+	require.NotNil(t, destroyResult)
+	require.Contains(t, destroyResult.Outputs, "WEBSITE_URL")
+	require.Contains(t, destroyResult.Outputs, "AZURE_LOCATION")
+}
@@ -104,6 +104,19 @@ func (p *TestProvider) Destroy(ctx context.Context, preview *Preview) *async.Int
 		})
 }
 
+func (p *TestProvider) State(ctx context.Context) *async.InteractiveTaskWithProgress[*StateResult, *StateProgress] {
+	return async.RunInteractiveTaskWithProgress(
+		func(asyncContext *async.InteractiveTaskContextWithProgress[*StateResult, *StateProgress]) {
+			asyncContext.SetProgress(&StateProgress{Message: "Reading state", Timestamp: time.Now()})
+
+			stateResult := StateResult{
+				Outputs: make(map[string]PreviewOutputParameter),
+			}
+
+			asyncContext.SetResult(&stateResult)
+		})
+}
+
 func NewTestProvider(env *environment.Environment, projectPath string, options Options, console input.Console) Provider {
 	return &TestProvider{
 		env:         env,
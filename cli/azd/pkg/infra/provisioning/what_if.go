@@ -0,0 +1,76 @@
+package provisioning
+
+import (
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+)
+
+// Converts the result of an ARM what-if operation to the generic set of predicted resource changes
+func convertWhatIfChanges(whatIfResult azcli.AzCliWhatIfResult) []PreviewResourceChange {
+	changes := make([]PreviewResourceChange, 0, len(whatIfResult.Changes))
+
+	for _, change := range whatIfResult.Changes {
+		resourceType, resourceName := parseResourceId(change.ResourceId)
+
+		changes = append(changes, PreviewResourceChange{
+			ResourceId:   change.ResourceId,
+			ResourceType: resourceType,
+			ResourceName: resourceName,
+			ChangeType:   ResourceChangeType(change.ChangeType),
+			Delta:        convertWhatIfPropertyChanges(change.Delta),
+		})
+	}
+
+	return changes
+}
+
+func convertWhatIfPropertyChanges(delta []azcli.AzCliWhatIfPropertyChange) []PreviewPropertyChange {
+	if len(delta) == 0 {
+		return nil
+	}
+
+	propertyChanges := make([]PreviewPropertyChange, 0, len(delta))
+	for _, propertyChange := range delta {
+		propertyChanges = append(propertyChanges, PreviewPropertyChange{
+			Path:       propertyChange.Path,
+			ChangeType: PropertyChangeType(propertyChange.PropertyChangeType),
+			Before:     propertyChange.Before,
+			After:      propertyChange.After,
+			Children:   convertWhatIfPropertyChanges(propertyChange.Children),
+		})
+	}
+
+	return propertyChanges
+}
+
+// Gets the resource type & name from an Azure resource id,
+// ex) /subscriptions/{id}/resourceGroups/{rg}/providers/Microsoft.Web/sites/{site} -> Microsoft.Web/sites, {site}
+func parseResourceId(resourceId string) (string, string) {
+	const providersSegment = "/providers/"
+
+	index := strings.LastIndex(strings.ToLower(resourceId), providersSegment)
+	if index < 0 {
+		segments := strings.Split(strings.Trim(resourceId, "/"), "/")
+		if len(segments) >= 4 && strings.EqualFold(segments[2], "resourceGroups") {
+			return "Microsoft.Resources/resourceGroups", segments[3]
+		}
+
+		return "", resourceId
+	}
+
+	// The provider namespace is followed by alternating type & name segments, ex) Microsoft.Web/sites/{site}/slots/{slot}
+	segments := strings.Split(strings.Trim(resourceId[index+len(providersSegment):], "/"), "/")
+	if len(segments) < 3 {
+		return "", resourceId
+	}
+
+	types := []string{segments[0]}
+	names := []string{}
+	for i := 1; i+1 < len(segments); i += 2 {
+		types = append(types, segments[i])
+		names = append(names, segments[i+1])
+	}
+
+	return strings.Join(types, "/"), strings.Join(names, "/")
+}
@@ -0,0 +1,110 @@
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/bicep"
+	"github.com/azure/azure-dev/cli/azd/test/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResourceId(t *testing.T) {
+	tests := []struct {
+		resourceId   string
+		resourceType string
+		resourceName string
+	}{
+		{"/subscriptions/SUB/resourceGroups/rg-test", "Microsoft.Resources/resourceGroups", "rg-test"},
+		{"/subscriptions/SUB/resourceGroups/rg-test/providers/Microsoft.Web/sites/app", "Microsoft.Web/sites", "app"},
+		{"/subscriptions/SUB/resourceGroups/rg-test/providers/Microsoft.Web/sites/app/slots/staging", "Microsoft.Web/sites/slots", "app/staging"},
+		{"/subscriptions/SUB", "", "/subscriptions/SUB"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.resourceId, func(t *testing.T) {
+			resourceType, resourceName := parseResourceId(test.resourceId)
+			require.Equal(t, test.resourceType, resourceType)
+			require.Equal(t, test.resourceName, resourceName)
+		})
+	}
+}
+
+func TestBicepWhatIf(t *testing.T) {
+	whatIfResult := azcli.AzCliWhatIfResult{
+		Status: "Succeeded",
+		Changes: []azcli.AzCliWhatIfChange{
+			{
+				ResourceId: "/subscriptions/SUB/resourceGroups/rg-test",
+				ChangeType: "Create",
+			},
+			{
+				ResourceId: "/subscriptions/SUB/resourceGroups/rg-test/providers/Microsoft.Web/sites/app",
+				ChangeType: "Modify",
+				Delta: []azcli.AzCliWhatIfPropertyChange{
+					{Path: "properties.siteConfig.linuxFxVersion", PropertyChangeType: "Modify", Before: "NODE|14", After: "NODE|16"},
+				},
+			},
+		},
+	}
+	whatIfBytes, _ := json.Marshal(whatIfResult)
+
+	execUtil := setupExecUtilWithMocks(&BicepTemplate{}, nil)
+	execUtil.When(func(args executil.RunArgs) bool {
+		return args.Cmd == "az" && strings.Contains(strings.Join(args.Args, " "), "deployment sub what-if")
+	}).Respond(executil.RunResult{
+		Stdout: string(whatIfBytes),
+		Stderr: "",
+	})
+
+	azCli := azcli.NewAzCli(azcli.NewAzCliArgs{RunWithResultFn: execUtil.RunWithResult})
+	env := environment.Environment{Values: make(map[string]string)}
+	env.Values["AZURE_LOCATION"] = "westus2"
+	env.SetEnvName("test-env")
+
+	bicepArgs := bicep.NewBicepCliArgs{AzCli: azCli, RunWithResultFn: execUtil.RunWithResult}
+	infraProvider := NewBicepProvider(&env, "../../../test/samples/webapp", Options{Module: "main"}, &mocks.MockConsole{}, bicepArgs)
+	scope := NewSubscriptionProvisioningScope(azCli, env.Values["AZURE_LOCATION"], env.GetSubscriptionId(), env.GetEnvName())
+
+	whatIfProvider, ok := infraProvider.(WhatIfProvider)
+	require.True(t, ok)
+
+	preview := Preview{Parameters: map[string]PreviewInputParameter{}}
+	whatIfTask := whatIfProvider.WhatIf(context.Background(), &preview, scope)
+
+	go func() {
+		for range whatIfTask.Progress() {
+		}
+	}()
+
+	result, err := whatIfTask.Await()
+	require.NoError(t, err)
+	require.Len(t, result.Changes, 2)
+
+	require.Equal(t, ResourceChangeTypeCreate, result.Changes[0].ChangeType)
+	require.Equal(t, "Microsoft.Resources/resourceGroups", result.Changes[0].ResourceType)
+	require.Equal(t, "rg-test", result.Changes[0].ResourceName)
+
+	require.Equal(t, ResourceChangeTypeModify, result.Changes[1].ChangeType)
+	require.Equal(t, "app", result.Changes[1].ResourceName)
+	require.Len(t, result.Changes[1].Delta, 1)
+	require.Equal(t, PropertyChangeTypeModify, result.Changes[1].Delta[0].ChangeType)
+	require.Equal(t, "NODE|16", result.Changes[1].Delta[0].After)
+}
+
+func TestManagerWhatIfNotSupported(t *testing.T) {
+	env := environment.Environment{Values: make(map[string]string)}
+	env.SetEnvName("test-env")
+
+	mgr, err := NewManager(context.Background(), env, "", Options{Provider: Test}, false, &mocks.MockConsole{}, bicep.NewBicepCliArgs{})
+	require.NoError(t, err)
+
+	result, err := mgr.WhatIf(context.Background(), &Preview{}, false)
+	require.Nil(t, result)
+	require.Error(t, err)
+}
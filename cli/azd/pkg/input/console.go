@@ -5,14 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"os"
 	"sort"
 	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/azure/azure-dev/cli/azd/pkg/azureutil"
 	"github.com/azure/azure-dev/cli/azd/pkg/commands"
-	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/config"
 	"github.com/azure/azure-dev/cli/azd/pkg/templates"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
 )
@@ -106,7 +105,7 @@ func (c *AskerConsole) Confirm(ctx context.Context, options ConsoleOptions) (boo
 func (c *AskerConsole) PromptTemplate(ctx context.Context, message string) (templates.Template, error) {
 	var result templates.Template
 	templateManager := templates.NewTemplateManager()
-	templatesSet, err := templateManager.ListTemplates()
+	templatesSet, err := templateManager.ListTemplates(ctx)
 
 	if err != nil {
 		return result, fmt.Errorf("prompting for template: %w", err)
@@ -149,9 +148,9 @@ func (c *AskerConsole) PromptLocation(ctx context.Context, message string) (stri
 
 	sort.Sort(azureutil.Locs(locations))
 
-	// Allow the environment variable `AZURE_LOCATION` to control the default value for the location
-	// selection.
-	defaultLocation := os.Getenv(environment.LocationEnvVarName)
+	// Allow the environment variable `AZURE_LOCATION`, then the user configuration, to control the default value
+	// for the location selection.
+	defaultLocation := config.Resolve(config.DefaultLocation, "")
 
 	// If no location is set in the process environment, see what the CLI default is.
 	if defaultLocation == "" {
@@ -0,0 +1,580 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+// Package jsonschema validates YAML documents against JSON schemas, reporting the position of the errors in the document.
+// The keywords used by the schemas of azd are supported: type, enum, const, minLength, maxLength, properties,
+// additionalProperties, required, items, uniqueItems, $ref to local definitions, allOf, anyOf, oneOf, not and if/then/else.
+// Unknown properties and values outside of an enum which look like misspellings come with a suggestion.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is a parsed JSON schema
+type Schema struct {
+	root any
+}
+
+// ValidationError is a part of a document which does not match the schema
+type ValidationError struct {
+	// Path is the path of the value in the document, ex) services.web.host
+	Path   string
+	Line   int
+	Column int
+	// Keyword is the schema keyword the value does not satisfy, ex) additionalProperties
+	Keyword string
+	Message string
+	// Suggestion is the closest allowed property name or value, when the value looks like a misspelling of it
+	Suggestion string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// Parse parses a JSON schema
+func Parse(content []byte) (*Schema, error) {
+	var root any
+	if err := json.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal JSON schema: %w", err)
+	}
+
+	switch root.(type) {
+	case map[string]any, bool:
+	default:
+		return nil, fmt.Errorf("a JSON schema must be an object or a boolean")
+	}
+
+	return &Schema{root: root}, nil
+}
+
+// Validate validates a YAML document against the schema. The errors are sorted by position in the document.
+func (s *Schema) Validate(document *yaml.Node) []ValidationError {
+	if document.Kind == yaml.DocumentNode {
+		if len(document.Content) == 0 {
+			return nil
+		}
+
+		document = document.Content[0]
+	}
+
+	errors := s.validate(s.root, document, "")
+
+	sort.SliceStable(errors, func(i, j int) bool {
+		if errors[i].Line != errors[j].Line {
+			return errors[i].Line < errors[j].Line
+		}
+
+		return errors[i].Column < errors[j].Column
+	})
+
+	return errors
+}
+
+func (s *Schema) validate(schema any, node *yaml.Node, path string) []ValidationError {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	newError := func(keyword string, format string, args ...any) ValidationError {
+		return ValidationError{
+			Path:    path,
+			Line:    node.Line,
+			Column:  node.Column,
+			Keyword: keyword,
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+
+	switch schema := schema.(type) {
+	case bool:
+		if !schema {
+			return []ValidationError{newError("false", "is not allowed")}
+		}
+
+		return nil
+	case map[string]any:
+		var errors []ValidationError
+
+		if ref, has := schema["$ref"].(string); has {
+			resolved, err := s.resolve(ref)
+			if err != nil {
+				return []ValidationError{newError("$ref", "%v", err)}
+			}
+
+			errors = append(errors, s.validate(resolved, node, path)...)
+		}
+
+		if schemaType, has := schema["type"]; has {
+			if !matchesType(schemaType, node) {
+				// The other keywords would only report the same mismatch
+				return append(errors, newError("type", "must be of type %s, but is %s", formatTypes(schemaType), nodeType(node)))
+			}
+		}
+
+		if enum, has := schema["enum"].([]any); has {
+			value := nodeValue(node)
+			found := false
+			for _, allowed := range enum {
+				if reflect.DeepEqual(value, allowed) {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				validationError := newError("enum", "must be one of %s, but is %s", formatValues(enum), formatValue(value))
+				if text, isString := value.(string); isString {
+					var candidates []string
+					for _, allowed := range enum {
+						if allowed, isString := allowed.(string); isString {
+							candidates = append(candidates, allowed)
+						}
+					}
+
+					validationError = withSuggestion(validationError, text, candidates)
+				}
+
+				errors = append(errors, validationError)
+			}
+		}
+
+		if constValue, has := schema["const"]; has {
+			if value := nodeValue(node); !reflect.DeepEqual(value, constValue) {
+				errors = append(errors, newError("const", "must be %s, but is %s", formatValue(constValue), formatValue(value)))
+			}
+		}
+
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+			length := utf8.RuneCountInString(node.Value)
+
+			if minLength, has := schema["minLength"].(float64); has && length < int(minLength) {
+				errors = append(errors, newError("minLength", "must be at least %d characters long", int(minLength)))
+			}
+
+			if maxLength, has := schema["maxLength"].(float64); has && length > int(maxLength) {
+				errors = append(errors, newError("maxLength", "must be at most %d characters long", int(maxLength)))
+			}
+		}
+
+		if node.Kind == yaml.MappingNode {
+			errors = append(errors, s.validateObject(schema, node, path)...)
+		}
+
+		if node.Kind == yaml.SequenceNode {
+			errors = append(errors, s.validateArray(schema, node, path)...)
+		}
+
+		if allOf, has := schema["allOf"].([]any); has {
+			for _, subSchema := range allOf {
+				errors = append(errors, s.validate(subSchema, node, path)...)
+			}
+		}
+
+		if anyOf, has := schema["anyOf"].([]any); has {
+			errors = append(errors, s.validateOneOf(anyOf, node, path, false)...)
+		}
+
+		if oneOf, has := schema["oneOf"].([]any); has {
+			errors = append(errors, s.validateOneOf(oneOf, node, path, true)...)
+		}
+
+		if not, has := schema["not"]; has {
+			if len(s.validate(not, node, path)) == 0 {
+				errors = append(errors, newError("not", "is not allowed"))
+			}
+		}
+
+		if ifSchema, has := schema["if"]; has {
+			if len(s.validate(ifSchema, node, path)) == 0 {
+				if then, has := schema["then"]; has {
+					errors = append(errors, s.validate(then, node, path)...)
+				}
+			} else if elseSchema, has := schema["else"]; has {
+				errors = append(errors, s.validate(elseSchema, node, path)...)
+			}
+		}
+
+		return errors
+	default:
+		return []ValidationError{newError("", "invalid schema")}
+	}
+}
+
+func (s *Schema) validateObject(schema map[string]any, node *yaml.Node, path string) []ValidationError {
+	var errors []ValidationError
+
+	properties, _ := schema["properties"].(map[string]any)
+	present := map[string]bool{}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		present[key.Value] = true
+		propertyPath := joinPath(path, key.Value)
+
+		if propertySchema, has := properties[key.Value]; has {
+			// A property disallowed by a false schema is reported at the position of its key
+			if allowed, isBool := propertySchema.(bool); isBool && !allowed {
+				errors = append(errors, ValidationError{
+					Path:    propertyPath,
+					Line:    key.Line,
+					Column:  key.Column,
+					Keyword: "properties",
+					Message: fmt.Sprintf("property '%s' is not allowed", key.Value),
+				})
+				continue
+			}
+
+			errors = append(errors, s.validate(propertySchema, value, propertyPath)...)
+			continue
+		}
+
+		additional, has := schema["additionalProperties"]
+		if !has {
+			continue
+		}
+
+		if allowed, isBool := additional.(bool); isBool && !allowed {
+			// Only the declared properties which are not set are suggested, ex) 'hots' is a misspelling of 'host'
+			var candidates []string
+			for name, propertySchema := range properties {
+				if allowed, isBool := propertySchema.(bool); !hasKey(node, name) && !(isBool && !allowed) {
+					candidates = append(candidates, name)
+				}
+			}
+
+			errors = append(errors, withSuggestion(ValidationError{
+				Path:    propertyPath,
+				Line:    key.Line,
+				Column:  key.Column,
+				Keyword: "additionalProperties",
+				Message: fmt.Sprintf("property '%s' is not allowed", key.Value),
+			}, key.Value, candidates))
+			continue
+		}
+
+		errors = append(errors, s.validate(additional, value, propertyPath)...)
+	}
+
+	if required, has := schema["required"].([]any); has {
+		for _, name := range required {
+			if name, isString := name.(string); isString && !present[name] {
+				errors = append(errors, ValidationError{
+					Path:    path,
+					Line:    node.Line,
+					Column:  node.Column,
+					Keyword: "required",
+					Message: fmt.Sprintf("missing required property '%s'", name),
+				})
+			}
+		}
+	}
+
+	return errors
+}
+
+func (s *Schema) validateArray(schema map[string]any, node *yaml.Node, path string) []ValidationError {
+	var errors []ValidationError
+
+	if items, has := schema["items"]; has {
+		for i, item := range node.Content {
+			errors = append(errors, s.validate(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+
+	if unique, has := schema["uniqueItems"].(bool); has && unique {
+		for i := 1; i < len(node.Content); i++ {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(nodeValue(node.Content[i]), nodeValue(node.Content[j])) {
+					errors = append(errors, ValidationError{
+						Path:    fmt.Sprintf("%s[%d]", path, i),
+						Line:    node.Content[i].Line,
+						Column:  node.Content[i].Column,
+						Keyword: "uniqueItems",
+						Message: fmt.Sprintf("duplicates item %d", j),
+					})
+					break
+				}
+			}
+		}
+	}
+
+	return errors
+}
+
+// validateOneOf validates that the node matches one of the schemas, or exactly one when exclusive is true.
+// When no schema matches, the errors of the closest schema are reported: the schema with the fewest errors,
+// preferring the schemas accepting the type of the node.
+func (s *Schema) validateOneOf(schemas []any, node *yaml.Node, path string, exclusive bool) []ValidationError {
+	var closest []ValidationError
+	closestMatchesType := false
+	matches := 0
+
+	for i, subSchema := range schemas {
+		errors := s.validate(subSchema, node, path)
+		if len(errors) == 0 {
+			matches++
+			continue
+		}
+
+		subSchemaMatchesType := true
+		if object, isObject := subSchema.(map[string]any); isObject {
+			if schemaType, has := object["type"]; has {
+				subSchemaMatchesType = matchesType(schemaType, node)
+			}
+		}
+
+		if i == 0 || (subSchemaMatchesType && !closestMatchesType) ||
+			(subSchemaMatchesType == closestMatchesType && len(errors) < len(closest)) {
+			closest = errors
+			closestMatchesType = subSchemaMatchesType
+		}
+	}
+
+	switch {
+	case matches == 0:
+		return closest
+	case matches > 1 && exclusive:
+		return []ValidationError{{
+			Path:    path,
+			Line:    node.Line,
+			Column:  node.Column,
+			Keyword: "oneOf",
+			Message: "must match exactly one of the allowed forms",
+		}}
+	default:
+		return nil
+	}
+}
+
+// resolve resolves references to the definitions of the schema, ex) #/$defs/hook
+func (s *Schema) resolve(ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported schema reference '%s'", ref)
+	}
+
+	current := s.root
+	for _, segment := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if segment == "" {
+			continue
+		}
+
+		object, isObject := current.(map[string]any)
+		if !isObject {
+			return nil, fmt.Errorf("unresolved schema reference '%s'", ref)
+		}
+
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		next, has := object[segment]
+		if !has {
+			return nil, fmt.Errorf("unresolved schema reference '%s'", ref)
+		}
+
+		current = next
+	}
+
+	return current, nil
+}
+
+func matchesType(schemaType any, node *yaml.Node) bool {
+	actual := nodeType(node)
+
+	matches := func(expected string) bool {
+		return expected == actual || (expected == "number" && actual == "integer")
+	}
+
+	switch schemaType := schemaType.(type) {
+	case string:
+		return matches(schemaType)
+	case []any:
+		for _, expected := range schemaType {
+			if expected, isString := expected.(string); isString && matches(expected) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// nodeType returns the JSON schema type of the node
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+
+	switch node.Tag {
+	case "!!bool":
+		return "boolean"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!null":
+		return "null"
+	default:
+		return "string"
+	}
+}
+
+// nodeValue returns the value of the node as unmarshalled from JSON, so it can be compared with the values of the schema
+func nodeValue(node *yaml.Node) any {
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return nil
+	}
+
+	// Round trip through JSON to use the same types as the schema, ex) float64 for numbers
+	content, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var normalized any
+	if err := json.Unmarshal(content, &normalized); err != nil {
+		return value
+	}
+
+	return normalized
+}
+
+func formatTypes(schemaType any) string {
+	if types, isArray := schemaType.([]any); isArray {
+		return formatValues(types)
+	}
+
+	return fmt.Sprint(schemaType)
+}
+
+func formatValues(values []any) string {
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		formatted = append(formatted, formatValue(value))
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+func formatValue(value any) string {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(content)
+}
+
+// withSuggestion suggests the candidate closest to the value, when the value looks like a misspelling of it
+func withSuggestion(validationError ValidationError, value string, candidates []string) ValidationError {
+	// Candidates are sorted so that the suggestion does not depend on the order of the properties of the schema
+	sort.Strings(candidates)
+
+	suggestion := ""
+	bestDistance := 0
+	for _, candidate := range candidates {
+		distance := editDistance(strings.ToLower(value), strings.ToLower(candidate))
+		// Replacing every character is not a misspelling, ex) 'c' for 'a'
+		if distance > maxSuggestionDistance(candidate) || distance >= utf8.RuneCountInString(candidate) {
+			continue
+		}
+
+		if suggestion == "" || distance < bestDistance {
+			suggestion = candidate
+			bestDistance = distance
+		}
+	}
+
+	if suggestion != "" {
+		validationError.Suggestion = suggestion
+		validationError.Message = fmt.Sprintf("%s, did you mean '%s'?", validationError.Message, suggestion)
+	}
+
+	return validationError
+}
+
+// maxSuggestionDistance is the number of edits allowed for a value to be considered a misspelling of the candidate
+func maxSuggestionDistance(candidate string) int {
+	switch length := utf8.RuneCountInString(candidate); {
+	case length <= 3:
+		return 1
+	case length <= 8:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// editDistance is the number of insertions, deletions, substitutions and transpositions of adjacent characters
+// needed to transform a into b (optimal string alignment distance)
+func editDistance(a string, b string) int {
+	source, target := []rune(a), []rune(b)
+	distances := make([][]int, len(source)+1)
+	for i := range distances {
+		distances[i] = make([]int, len(target)+1)
+		distances[i][0] = i
+	}
+
+	for j := range distances[0] {
+		distances[0][j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+
+			distances[i][j] = min(distances[i-1][j]+1, distances[i][j-1]+1, distances[i-1][j-1]+cost)
+			if i > 1 && j > 1 && source[i-1] == target[j-2] && source[i-2] == target[j-1] {
+				distances[i][j] = min(distances[i][j], distances[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return distances[len(source)][len(target)]
+}
+
+func min(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+
+	return result
+}
+
+func hasKey(node *yaml.Node, name string) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
@@ -0,0 +1,161 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/azure/azure-dev/schemas"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func validate(t *testing.T, schemaJson string, document string) []ValidationError {
+	schema, err := Parse([]byte(schemaJson))
+	require.NoError(t, err)
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(document), &node))
+
+	return schema.Validate(&node)
+}
+
+func TestValidateKeywords(t *testing.T) {
+	const schema = `{
+		"type": "object",
+		"required": ["name"],
+		"additionalProperties": false,
+		"properties": {
+			"name": { "type": "string", "minLength": 2, "maxLength": 4 },
+			"kind": { "enum": ["a", "b"] },
+			"version": { "const": 1 },
+			"tags": { "type": "array", "items": { "type": "string" }, "uniqueItems": true },
+			"hook": { "$ref": "#/$defs/hook" },
+			"flag": { "type": ["boolean", "null"] }
+		},
+		"$defs": {
+			"hook": {
+				"oneOf": [
+					{ "type": "string" },
+					{ "type": "object", "required": ["run"], "properties": { "run": { "type": "string" } } }
+				]
+			}
+		}
+	}`
+
+	require.Empty(t, validate(t, schema, "name: app\nkind: a\nversion: 1\ntags: [x, y]\nhook: {run: echo}\nflag: ~\n"))
+	require.Empty(t, validate(t, schema, "name: app\nhook: echo\nflag: true\n"))
+
+	errors := validate(t, schema, `
+kind: c
+version: 2
+tags: [x, x, 3]
+hook: {other: true}
+unknown: value
+flag: 1
+`)
+
+	messages := map[string]string{}
+	for _, err := range errors {
+		messages[err.Path] = err.Message
+	}
+
+	require.Equal(t, map[string]string{
+		"":        "missing required property 'name'",
+		"kind":    `must be one of "a", "b", but is "c"`,
+		"version": "must be 1, but is 2",
+		"tags[1]": "duplicates item 0",
+		"tags[2]": "must be of type string, but is integer",
+		"hook":    "missing required property 'run'",
+		"unknown": "property 'unknown' is not allowed",
+		"flag":    `must be of type "boolean", "null", but is integer`,
+	}, messages)
+
+	errors = validate(t, schema, "name: a\n")
+	require.Len(t, errors, 1)
+	require.Equal(t, ValidationError{
+		Path:    "name",
+		Line:    1,
+		Column:  7,
+		Keyword: "minLength",
+		Message: "must be at least 2 characters long",
+	}, errors[0])
+}
+
+func TestValidateAzureYaml(t *testing.T) {
+	schema := string(schemas.AzureYamlV1)
+
+	require.Empty(t, validate(t, schema, `
+name: todo
+services:
+  api:
+    project: src/api
+    language: js
+    host: containerapp
+    docker:
+      path: ./Dockerfile
+    hooks:
+      deploying: echo deploying
+      deployed:
+        run: echo deployed
+        continueOnError: true
+`))
+
+	errors := validate(t, schema, `
+name: todo
+services:
+  api:
+    project: src/api
+    host: app-service
+    docker:
+      path: ./Dockerfile
+`)
+
+	require.Len(t, errors, 2)
+	require.Equal(t, "services.api.host", errors[0].Path)
+	require.Equal(t, 6, errors[0].Line)
+	require.Equal(t, "services.api.docker", errors[1].Path)
+	require.Equal(t, "property 'docker' is not allowed", errors[1].Message)
+}
+
+func TestValidateSuggestions(t *testing.T) {
+	schema := string(schemas.AzureYamlV1)
+
+	errors := validate(t, schema, `
+name: todo
+services:
+  api:
+    project: src/api
+    hots: containerapp
+    Language: js
+  web:
+    project: src/web
+    host: containerap
+    dependson: [api]
+    other: value
+`)
+
+	suggestions := map[string]string{}
+	for _, err := range errors {
+		suggestions[err.Path] = err.Suggestion
+	}
+
+	require.Equal(t, map[string]string{
+		"services.api.hots":      "host",
+		"services.api.Language":  "language",
+		"services.web.host":      "containerapp",
+		"services.web.dependson": "dependsOn",
+		"services.web.other":     "",
+	}, suggestions)
+
+	require.Equal(t, "additionalProperties", errors[0].Keyword)
+	require.Equal(t, "property 'hots' is not allowed, did you mean 'host'?", errors[0].Message)
+	require.Equal(t, 6, errors[0].Line)
+	require.Equal(t, 5, errors[0].Column)
+}
+
+func TestEditDistance(t *testing.T) {
+	require.Equal(t, 0, editDistance("host", "host"))
+	require.Equal(t, 1, editDistance("hots", "host"))
+	require.Equal(t, 1, editDistance("hst", "host"))
+	require.Equal(t, 3, editDistance("hosting", "host"))
+	require.Equal(t, 4, editDistance("", "host"))
+}
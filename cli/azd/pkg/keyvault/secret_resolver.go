@@ -0,0 +1,35 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package keyvault
+
+import (
+	"context"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+)
+
+// secretResolver reads & writes the secrets referenced by environment values in Azure Key Vault
+type secretResolver struct {
+	azCli azcli.AzCli
+}
+
+func (r *secretResolver) GetSecret(ctx context.Context, reference environment.SecretReference) (string, error) {
+	secret, err := r.azCli.GetKeyVaultSecret(ctx, reference.VaultName, reference.SecretName)
+	if err != nil {
+		return "", err
+	}
+
+	return secret.Value, nil
+}
+
+func (r *secretResolver) SetSecret(ctx context.Context, reference environment.SecretReference, value string) error {
+	return r.azCli.SetKeyVaultSecret(ctx, reference.VaultName, reference.SecretName, value)
+}
+
+func NewSecretResolver(azCli azcli.AzCli) environment.SecretResolver {
+	return &secretResolver{
+		azCli: azCli,
+	}
+}
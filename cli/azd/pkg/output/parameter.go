@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/azure/azure-dev/cli/azd/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +16,8 @@ const (
 	supportedFormatterAnnotation = "github.com/azure/azure-dev/cli/azd/pkg/output/supportedOutputFormatters"
 )
 
+// AddOutputParam adds the --output flag to the command. When the flag is not given, the output.format value of
+// the environment or of the user configuration is used if the command supports it, then the default format.
 func AddOutputParam(cmd *cobra.Command, supportedFormats []Format, defaultFormat Format) *cobra.Command {
 	formatNames := make([]string, len(supportedFormats))
 	for i, f := range supportedFormats {
@@ -39,18 +42,30 @@ func GetFormatter(cmd *cobra.Command) (Formatter, error) {
 	desiredFormatter := strings.ToLower(strings.TrimSpace(outputVal))
 	f := cmd.Flags().Lookup(outputFlagName)
 	supportedFormatters, hasFormatters := f.Annotations[supportedFormatterAnnotation]
-	if !hasFormatters {
-		return NewFormatter(desiredFormatter)
+
+	isSupported := func(format string) bool {
+		for _, formatter := range supportedFormatters {
+			if formatter == format {
+				return true
+			}
+		}
+
+		return !hasFormatters
 	}
 
-	supported := false
-	for _, formatter := range supportedFormatters {
-		if formatter == desiredFormatter {
-			supported = true
-			break
+	// Without --output, the format set in the environment or in the user configuration is used when the command
+	// supports it, otherwise the default format of the command
+	if !f.Changed {
+		if configured := config.Resolve(config.OutputFormat, ""); configured != "" && isSupported(configured) {
+			desiredFormatter = configured
 		}
 	}
-	if !supported {
+
+	if !hasFormatters {
+		return NewFormatter(desiredFormatter)
+	}
+
+	if !isSupported(desiredFormatter) {
 		return nil, fmt.Errorf("unsupported format '%s'", desiredFormatter)
 	}
 
@@ -0,0 +1,43 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package output
+
+import (
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFormatterResolvesConfiguredFormat(t *testing.T) {
+	t.Setenv(config.DirectoryEnvVarName, t.TempDir())
+
+	newCmd := func() *cobra.Command {
+		return AddOutputParam(&cobra.Command{}, []Format{JsonFormat, TableFormat}, TableFormat)
+	}
+
+	t.Setenv(config.OutputFormat.EnvVarName, "")
+	formatter, err := GetFormatter(newCmd())
+	require.NoError(t, err)
+	require.Equal(t, TableFormat, formatter.Kind())
+
+	t.Setenv(config.OutputFormat.EnvVarName, "json")
+	formatter, err = GetFormatter(newCmd())
+	require.NoError(t, err)
+	require.Equal(t, JsonFormat, formatter.Kind())
+
+	// The flag takes precedence
+	cmd := newCmd()
+	require.NoError(t, cmd.Flags().Set("output", "table"))
+	formatter, err = GetFormatter(cmd)
+	require.NoError(t, err)
+	require.Equal(t, TableFormat, formatter.Kind())
+
+	// A format the command does not support is ignored
+	t.Setenv(config.OutputFormat.EnvVarName, "none")
+	formatter, err = GetFormatter(newCmd())
+	require.NoError(t, err)
+	require.Equal(t, TableFormat, formatter.Kind())
+}
@@ -0,0 +1,425 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package project
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/ignore"
+	"github.com/azure/azure-dev/cli/azd/pkg/osutil"
+	"github.com/azure/azure-dev/cli/azd/pkg/project/internal"
+)
+
+// The name of the file in the deployments folder of the environment recording the deployments of the services
+const deploymentHistoryFileName = "history.json"
+
+// The name of the folder in the deployments folder of the environment where the deployed packages are stored
+const deploymentPackagesDirectoryName = "packages"
+
+// The number of the most recent deployments of a service for which the deployed package is kept
+const deploymentPackageRetention = 10
+
+// ErrDeploymentHistoryEntryNotFound is returned when the deployment history does not contain the requested deployment
+var ErrDeploymentHistoryEntryNotFound = errors.New("deployment not found in history")
+
+// DeploymentHistoryEntry is the record of a single deployment of a service
+type DeploymentHistoryEntry struct {
+	// The identifier of the deployment, unique within the environment
+	Id string `json:"id"`
+	// The name of the deployed service
+	Service string `json:"service"`
+	// The kind of target the service was deployed to
+	Kind ServiceTargetKind `json:"kind"`
+	// Related Azure resource ID
+	TargetResourceId string `json:"targetResourceId"`
+	// The time at which the deployment completed
+	Timestamp time.Time `json:"timestamp"`
+	// The commit of the project repository that was checked out when deploying, when available
+	GitCommit string `json:"gitCommit,omitempty"`
+	// The container image that was deployed, for services deployed from a container image
+	Image string `json:"image,omitempty"`
+	// The SHA256 hash of the package that was deployed, for services deployed from a zip package
+	PackageHash string `json:"packageHash,omitempty"`
+	// The endpoints of the service after the deployment
+	Endpoints []string `json:"endpoints"`
+	// The hash of the inputs of the deployment, used to skip deploying the service again when it is unchanged
+	InputHash string `json:"inputHash,omitempty"`
+	// The identifier of the deployment that was redeployed, when the deployment is a rollback
+	RollbackOf string `json:"rollbackOf,omitempty"`
+}
+
+// Artifact is the reference to what was deployed, ex) the container image or the hash of the package
+func (e *DeploymentHistoryEntry) Artifact() string {
+	if e.Image != "" {
+		return e.Image
+	}
+
+	return e.PackageHash
+}
+
+// DeploymentHistory records the deployments of the services of an environment, so that a previous deployment
+// can be redeployed. The packages of the most recent deployments of each service are stored alongside the history.
+// The history is safe for concurrent use, the services of a project are deployed at the same time.
+type DeploymentHistory struct {
+	directory string
+	// Guards the entries
+	mu      sync.Mutex
+	entries []DeploymentHistoryEntry
+}
+
+// LoadDeploymentHistory reads the deployment history stored in the specified folder.
+// An empty history is returned when no deployments have been recorded yet.
+func LoadDeploymentHistory(directory string) (*DeploymentHistory, error) {
+	history := &DeploymentHistory{
+		directory: directory,
+		entries:   []DeploymentHistoryEntry{},
+	}
+
+	bytes, err := os.ReadFile(filepath.Join(directory, deploymentHistoryFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading deployment history: %w", err)
+	}
+
+	if err := json.Unmarshal(bytes, &history.entries); err != nil {
+		return nil, fmt.Errorf("parsing deployment history: %w", err)
+	}
+
+	return history, nil
+}
+
+// NewDeploymentHistoryEntry creates the history entry for the result of deploying the service
+func NewDeploymentHistoryEntry(serviceName string, result *ServiceDeploymentResult) DeploymentHistoryEntry {
+	return DeploymentHistoryEntry{
+		Service:          serviceName,
+		Kind:             result.Kind,
+		TargetResourceId: result.TargetResourceId,
+		Timestamp:        time.Now().UTC(),
+		Image:            result.Image,
+		Endpoints:        result.Endpoints,
+	}
+}
+
+// Record adds a deployment of the service to the history. When the service was deployed from a zip package,
+// the deployed artifact folder is stored so the deployment can be redeployed later, leaving out the files excluded
+// by the .azdignore file of the service folder.
+func (h *DeploymentHistory) Record(
+	serviceName string,
+	result *ServiceDeploymentResult,
+	artifact string,
+	serviceDir string,
+	gitCommit string,
+	inputHash string,
+) (*DeploymentHistoryEntry, error) {
+	entry := NewDeploymentHistoryEntry(serviceName, result)
+	entry.GitCommit = gitCommit
+	entry.InputHash = inputHash
+
+	if deploysPackage(result) {
+		hash, err := h.SavePackage(serviceName, artifact, serviceDir)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.PackageHash = hash
+	}
+
+	return h.Add(entry)
+}
+
+// Entries returns the recorded deployments, most recent first. When serviceName is not empty, only the
+// deployments of that service are returned.
+func (h *DeploymentHistory) Entries(serviceName string) []DeploymentHistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.serviceEntries(serviceName)
+}
+
+func (h *DeploymentHistory) serviceEntries(serviceName string) []DeploymentHistoryEntry {
+	entries := []DeploymentHistoryEntry{}
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if serviceName == "" || h.entries[i].Service == serviceName {
+			entries = append(entries, h.entries[i])
+		}
+	}
+
+	return entries
+}
+
+// Latest returns the most recent deployment of the service, or nil when the service has not been deployed yet
+func (h *DeploymentHistory) Latest(serviceName string) *DeploymentHistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if h.entries[i].Service == serviceName {
+			entry := h.entries[i]
+			return &entry
+		}
+	}
+
+	return nil
+}
+
+// Get returns the deployment of the service with the specified identifier
+func (h *DeploymentHistory) Get(serviceName string, id string) (*DeploymentHistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := range h.entries {
+		if h.entries[i].Id == id && h.entries[i].Service == serviceName {
+			entry := h.entries[i]
+			return &entry, nil
+		}
+	}
+
+	return nil, fmt.Errorf("deployment '%s' of service '%s': %w", id, serviceName, ErrDeploymentHistoryEntryNotFound)
+}
+
+// ClearInputHashes forgets the inputs of the recorded deployments, so that the next deploy doesn't skip any service as
+// unchanged, ex) once the infrastructure the services were deployed to is provisioned again or deleted.
+// The deployments are kept, they can still be redeployed.
+func (h *DeploymentHistory) ClearInputHashes() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cleared := false
+	for i := range h.entries {
+		if h.entries[i].InputHash != "" {
+			h.entries[i].InputHash = ""
+			cleared = true
+		}
+	}
+
+	if !cleared {
+		return nil
+	}
+
+	return h.save()
+}
+
+// Add assigns the next identifier to the entry and appends it to the history file.
+// Packages that are no longer referenced by the most recent deployments of the service are removed.
+func (h *DeploymentHistory) Add(entry DeploymentHistoryEntry) (*DeploymentHistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	lastId := 0
+	for _, existing := range h.entries {
+		if id, err := strconv.Atoi(existing.Id); err == nil && id > lastId {
+			lastId = id
+		}
+	}
+
+	entry.Id = strconv.Itoa(lastId + 1)
+	h.entries = append(h.entries, entry)
+
+	if err := h.save(); err != nil {
+		h.entries = h.entries[:len(h.entries)-1]
+		return nil, err
+	}
+
+	if err := h.prunePackages(entry.Service); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// SavePackage zips the deployed artifact folder of the service and stores it in the history, returning the hash
+// of the package. The hash identifies the package when the deployment is redeployed.
+func (h *DeploymentHistory) SavePackage(serviceName string, artifactPath string, serviceDir string) (string, error) {
+	matcher, err := ignore.ReadAzdIgnore(serviceDir)
+	if err != nil {
+		return "", err
+	}
+
+	zipFilePath, err := internal.CreateDeployableZip(serviceName, artifactPath, matcher)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(zipFilePath)
+
+	hash, err := hashFile(zipFilePath)
+	if err != nil {
+		return "", fmt.Errorf("hashing deployment package: %w", err)
+	}
+
+	packagePath := h.packagePath(serviceName, hash)
+	if _, err := os.Stat(packagePath); err == nil {
+		return hash, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(packagePath), osutil.PermissionDirectory); err != nil {
+		return "", fmt.Errorf("creating deployment packages directory: %w", err)
+	}
+
+	if err := copyFile(zipFilePath, packagePath); err != nil {
+		return "", fmt.Errorf("storing deployment package: %w", err)
+	}
+
+	return hash, nil
+}
+
+// ExtractPackage extracts the package deployed by the entry into the target folder
+func (h *DeploymentHistory) ExtractPackage(entry *DeploymentHistoryEntry, target string) error {
+	if entry.PackageHash == "" {
+		return fmt.Errorf("deployment '%s' of service '%s' did not deploy a package", entry.Id, entry.Service)
+	}
+
+	reader, err := zip.OpenReader(h.packagePath(entry.Service, entry.PackageHash))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("the package of deployment '%s' of service '%s' is no longer available", entry.Id, entry.Service)
+	} else if err != nil {
+		return fmt.Errorf("opening deployment package: %w", err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		filePath := filepath.Join(target, filepath.FromSlash(file.Name))
+		if !strings.HasPrefix(filePath, filepath.Clean(target)+string(filepath.Separator)) {
+			return fmt.Errorf("invalid file path in deployment package: %s", file.Name)
+		}
+
+		if err := extractFile(file, filePath); err != nil {
+			return fmt.Errorf("extracting deployment package: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (h *DeploymentHistory) save() error {
+	if err := os.MkdirAll(h.directory, osutil.PermissionDirectory); err != nil {
+		return fmt.Errorf("creating deployments directory: %w", err)
+	}
+
+	bytes, err := json.MarshalIndent(h.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("serializing deployment history: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(h.directory, deploymentHistoryFileName), bytes, osutil.PermissionFile); err != nil {
+		return fmt.Errorf("writing deployment history: %w", err)
+	}
+
+	return nil
+}
+
+// Removes the stored packages of the service which are not deployed by one of its most recent deployments
+func (h *DeploymentHistory) prunePackages(serviceName string) error {
+	retained := map[string]bool{}
+	for i, entry := range h.serviceEntries(serviceName) {
+		if i == deploymentPackageRetention {
+			break
+		}
+
+		retained[entry.PackageHash] = true
+	}
+
+	packagesDirectory := filepath.Join(h.directory, deploymentPackagesDirectoryName, serviceName)
+	files, err := os.ReadDir(packagesDirectory)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("reading deployment packages: %w", err)
+	}
+
+	for _, file := range files {
+		hash := strings.TrimSuffix(file.Name(), ".zip")
+		if file.IsDir() || retained[hash] {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(packagesDirectory, file.Name())); err != nil {
+			return fmt.Errorf("removing deployment package: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Whether the artifact of the deployment is a folder which the target deploys as a zip package.
+// Static web apps are published by the SWA CLI from the output folder of the project instead.
+func deploysPackage(result *ServiceDeploymentResult) bool {
+	return result.Image == "" && (result.Kind == AppServiceTarget || result.Kind == AzureFunctionTarget)
+}
+
+func (h *DeploymentHistory) packagePath(serviceName string, hash string) string {
+	return filepath.Join(h.directory, deploymentPackagesDirectoryName, serviceName, fmt.Sprintf("%s.zip", hash))
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func copyFile(source string, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, osutil.PermissionFile)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+func extractFile(file *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), osutil.PermissionDirectory); err != nil {
+		return err
+	}
+
+	in, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, file.Mode()|0600)
+	if err != nil {
+		return err
+	}
+
+	// The packages are created by azd from the deployed artifacts, limit the copy to guard against corrupted files
+	if _, err := io.CopyN(out, in, int64(file.UncompressedSize64)); err != nil && !errors.Is(err, io.EOF) {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
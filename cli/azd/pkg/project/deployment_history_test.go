@@ -0,0 +1,191 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createDeploymentArtifact(t *testing.T, content string) string {
+	artifact := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(artifact, "static"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(artifact, "app.js"), []byte(content), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(artifact, "static", "index.html"), []byte("<html></html>"), 0600))
+
+	return artifact
+}
+
+func TestDeploymentHistoryRecord(t *testing.T) {
+	directory := t.TempDir()
+
+	history, err := LoadDeploymentHistory(directory)
+	require.NoError(t, err)
+	require.Empty(t, history.Entries(""))
+
+	apiEntry, err := history.Record("api", &ServiceDeploymentResult{
+		Kind:      AppServiceTarget,
+		Endpoints: []string{"https://api.example.com/"},
+	}, createDeploymentArtifact(t, "console.log('v1')"), t.TempDir(), "0123456789abcdef", "input-v1")
+	require.NoError(t, err)
+	require.Equal(t, "1", apiEntry.Id)
+	require.Equal(t, "0123456789abcdef", apiEntry.GitCommit)
+	require.NotEmpty(t, apiEntry.PackageHash)
+	require.FileExists(t, filepath.Join(directory, "packages", "api", apiEntry.PackageHash+".zip"))
+
+	webEntry, err := history.Record("web", &ServiceDeploymentResult{
+		Kind:  ContainerAppTarget,
+		Image: "registry.azurecr.io/web/web:azdev-deploy-1",
+	}, "sha256:1234", "", "", "")
+	require.NoError(t, err)
+	require.Equal(t, "2", webEntry.Id)
+	require.Empty(t, webEntry.PackageHash)
+	require.Equal(t, "registry.azurecr.io/web/web:azdev-deploy-1", webEntry.Artifact())
+
+	swaEntry, err := history.Record("site", &ServiceDeploymentResult{Kind: StaticWebAppTarget}, "build", "", "", "")
+	require.NoError(t, err)
+	require.Empty(t, swaEntry.Artifact())
+
+	// The history is persisted and listed most recent first
+	loaded, err := LoadDeploymentHistory(directory)
+	require.NoError(t, err)
+
+	entries := loaded.Entries("")
+	require.Len(t, entries, 3)
+	require.Equal(t, []string{"3", "2", "1"}, []string{entries[0].Id, entries[1].Id, entries[2].Id})
+
+	apiEntries := loaded.Entries("api")
+	require.Len(t, apiEntries, 1)
+	require.Equal(t, []string{"https://api.example.com/"}, apiEntries[0].Endpoints)
+	require.Equal(t, "input-v1", loaded.Latest("api").InputHash)
+	require.Equal(t, "2", loaded.Latest("web").Id)
+	require.Nil(t, loaded.Latest("worker"))
+
+	entry, err := loaded.Get("web", "2")
+	require.NoError(t, err)
+	require.Equal(t, webEntry, entry)
+
+	_, err = loaded.Get("api", "2")
+	require.True(t, errors.Is(err, ErrDeploymentHistoryEntryNotFound))
+}
+
+func TestDeploymentHistoryRecordConcurrently(t *testing.T) {
+	history, err := LoadDeploymentHistory(t.TempDir())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		i := i
+		serviceName := "svc" + strconv.Itoa(i)
+		artifact := createDeploymentArtifact(t, serviceName)
+		serviceDir := t.TempDir()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = history.Record(serviceName, &ServiceDeploymentResult{Kind: AppServiceTarget}, artifact, serviceDir, "", "")
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	// Each deployment gets its own identifier
+	ids := map[string]bool{}
+	for _, entry := range history.Entries("") {
+		ids[entry.Id] = true
+	}
+	require.Len(t, ids, 10)
+}
+
+func TestDeploymentHistoryExtractPackage(t *testing.T) {
+	history, err := LoadDeploymentHistory(t.TempDir())
+	require.NoError(t, err)
+
+	entry, err := history.Record("api", &ServiceDeploymentResult{
+		Kind: AzureFunctionTarget,
+	}, createDeploymentArtifact(t, "console.log('v1')"), t.TempDir(), "", "")
+	require.NoError(t, err)
+
+	target := t.TempDir()
+	require.NoError(t, history.ExtractPackage(entry, target))
+
+	content, err := os.ReadFile(filepath.Join(target, "app.js"))
+	require.NoError(t, err)
+	require.Equal(t, "console.log('v1')", string(content))
+	require.FileExists(t, filepath.Join(target, "static", "index.html"))
+}
+
+func TestDeploymentHistoryPackageUsesServiceAzdIgnore(t *testing.T) {
+	history, err := LoadDeploymentHistory(t.TempDir())
+	require.NoError(t, err)
+
+	// The artifact is the build output of the service, outside of the service folder holding the .azdignore file
+	serviceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(serviceDir, ".azdignore"), []byte("*.map\n"), 0600))
+
+	artifact := createDeploymentArtifact(t, "console.log('v1')")
+	require.NoError(t, os.WriteFile(filepath.Join(artifact, "app.js.map"), []byte("{}"), 0600))
+
+	entry, err := history.Record("api", &ServiceDeploymentResult{
+		Kind: AppServiceTarget,
+	}, artifact, serviceDir, "", "")
+	require.NoError(t, err)
+
+	target := t.TempDir()
+	require.NoError(t, history.ExtractPackage(entry, target))
+	require.FileExists(t, filepath.Join(target, "app.js"))
+	require.NoFileExists(t, filepath.Join(target, "app.js.map"))
+}
+
+func TestDeploymentHistoryClearInputHashes(t *testing.T) {
+	directory := t.TempDir()
+	history, err := LoadDeploymentHistory(directory)
+	require.NoError(t, err)
+
+	_, err = history.Record("web", &ServiceDeploymentResult{
+		Kind:  ContainerAppTarget,
+		Image: "registry.azurecr.io/web/web:azdev-deploy-1",
+	}, "sha256:1234", "", "", "input-v1")
+	require.NoError(t, err)
+
+	require.NoError(t, history.ClearInputHashes())
+
+	// The deployments are kept without their inputs
+	loaded, err := LoadDeploymentHistory(directory)
+	require.NoError(t, err)
+	require.Equal(t, "registry.azurecr.io/web/web:azdev-deploy-1", loaded.Latest("web").Image)
+	require.Empty(t, loaded.Latest("web").InputHash)
+}
+
+func TestDeploymentHistoryPrunesPackages(t *testing.T) {
+	directory := t.TempDir()
+	history, err := LoadDeploymentHistory(directory)
+	require.NoError(t, err)
+
+	entries := []*DeploymentHistoryEntry{}
+	for i := 0; i < deploymentPackageRetention+2; i++ {
+		entry, err := history.Record("api", &ServiceDeploymentResult{
+			Kind: AppServiceTarget,
+		}, createDeploymentArtifact(t, "version "+strconv.Itoa(i)), t.TempDir(), "", "")
+		require.NoError(t, err)
+
+		entries = append(entries, entry)
+	}
+
+	packages, err := os.ReadDir(filepath.Join(directory, "packages", "api"))
+	require.NoError(t, err)
+	require.Len(t, packages, deploymentPackageRetention)
+
+	err = history.ExtractPackage(entries[0], t.TempDir())
+	require.EqualError(t, err, "the package of deployment '1' of service 'api' is no longer available")
+
+	require.NoError(t, history.ExtractPackage(entries[len(entries)-1], t.TempDir()))
+}
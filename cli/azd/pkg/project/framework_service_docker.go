@@ -7,16 +7,47 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/ignore"
+	"github.com/azure/azure-dev/cli/azd/pkg/rtar"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/docker"
+	"github.com/drone/envsubst"
 )
 
 type DockerProjectOptions struct {
 	Path     string `json:"path"`
 	Context  string `json:"context"`
 	Platform string `json:"platform"`
+	// The stage of a multi-stage Dockerfile to build
+	Target string `yaml:"target" json:"target"`
+	// The values of the ARG instructions of the Dockerfile
+	BuildArgs map[string]string `yaml:"buildArgs" json:"buildArgs"`
+	// The BuildKit secrets mounted by the RUN instructions of the Dockerfile
+	Secrets []DockerSecret `yaml:"secrets" json:"secrets"`
+	// The metadata of the image
+	Labels map[string]string `yaml:"labels" json:"labels"`
+	// The images or the cache exports used as cache sources
+	CacheFrom []string `yaml:"cacheFrom" json:"cacheFrom"`
+	// The destinations the build cache is exported to
+	CacheTo []string `yaml:"cacheTo" json:"cacheTo"`
+	// Additional names of the built image
+	Tags []string `yaml:"tags" json:"tags"`
+	// The template of the tags of the images pushed on deploy, ex) {{envName}}-{{gitShortSha}}
+	Tag string `yaml:"tag" json:"tag"`
+	// When true, the image is built in the container registry with ACR Tasks instead of with the local Docker daemon
+	RemoteBuild bool `yaml:"remoteBuild" json:"remoteBuild"`
+}
+
+// DockerSecret is a BuildKit secret, read from a file relative to the service or from an environment variable
+type DockerSecret struct {
+	Id  string `yaml:"id" json:"id"`
+	Src string `yaml:"src" json:"src"`
+	Env string `yaml:"env" json:"env"`
 }
 
 type dockerProject struct {
@@ -27,17 +58,33 @@ type dockerProject struct {
 }
 
 func (p *dockerProject) RequiredExternalTools() []tools.ExternalTool {
+	if p.config.Docker.RemoteBuild {
+		return []tools.ExternalTool{}
+	}
+
 	return []tools.ExternalTool{p.docker}
 }
 
+// Package builds the image of the service, returning the id of the image. For remote builds, the build context is
+// packaged instead, returning the path of the tarball uploaded to the registry on deploy.
 func (p *dockerProject) Package(ctx context.Context, progress chan<- string) (string, error) {
 	dockerOptions := getDockerOptionsWithDefaults(p.config.Docker)
 
+	if dockerOptions.RemoteBuild {
+		progress <- "Packaging docker build context"
+		return packageDockerBuildContext(p.config, dockerOptions)
+	}
+
 	log.Printf("building image for service %s, cwd: %s, path: %s, context: %s)", p.config.Name, p.config.Path(), dockerOptions.Path, dockerOptions.Context)
 
+	buildOptions, err := getDockerBuildOptions(dockerOptions, p.env)
+	if err != nil {
+		return "", fmt.Errorf("building container: %s: %w", p.config.Name, err)
+	}
+
 	// Build the container
 	progress <- "Building docker image"
-	imageId, err := p.docker.Build(ctx, p.config.Path(), dockerOptions.Path, dockerOptions.Platform, dockerOptions.Context)
+	imageId, err := p.docker.Build(ctx, p.config.Path(), buildOptions)
 	if err != nil {
 		return "", fmt.Errorf("building container: %s at %s: %w", p.config.Name, dockerOptions.Context, err)
 	}
@@ -46,6 +93,168 @@ func (p *dockerProject) Package(ctx context.Context, progress chan<- string) (st
 	return imageId, nil
 }
 
+// getDockerBuildOptions returns the options of the docker build, with the references to environment variables,
+// ex) ${API_URL}, replaced by the values of the azd environment or of the process environment.
+func getDockerBuildOptions(options DockerProjectOptions, env *environment.Environment) (docker.BuildOptions, error) {
+	getEnv := func(name string) string {
+		if env != nil {
+			if value, has := env.Lookup(name); has {
+				return value
+			}
+		}
+
+		return os.Getenv(name)
+	}
+
+	var err error
+	expand := func(value string) string {
+		if err != nil {
+			return value
+		}
+
+		var replaced string
+		replaced, err = envsubst.Eval(value, getEnv)
+		return replaced
+	}
+
+	expandAll := func(values []string) []string {
+		var expanded []string
+		for _, value := range values {
+			expanded = append(expanded, expand(value))
+		}
+		return expanded
+	}
+
+	expandMap := func(values map[string]string) map[string]string {
+		if values == nil {
+			return nil
+		}
+
+		expanded := map[string]string{}
+		for key, value := range values {
+			expanded[key] = expand(value)
+		}
+		return expanded
+	}
+
+	buildOptions := docker.BuildOptions{
+		DockerfilePath: expand(options.Path),
+		BuildContext:   expand(options.Context),
+		Platform:       expand(options.Platform),
+		Target:         expand(options.Target),
+		BuildArgs:      expandMap(options.BuildArgs),
+		Labels:         expandMap(options.Labels),
+		CacheFrom:      expandAll(options.CacheFrom),
+		CacheTo:        expandAll(options.CacheTo),
+		Tags:           expandAll(options.Tags),
+	}
+
+	for _, secret := range options.Secrets {
+		if secret.Id == "" || (secret.Src == "") == (secret.Env == "") {
+			return docker.BuildOptions{}, fmt.Errorf("docker secret '%s' must have an id and either a src or an env", secret.Id)
+		}
+
+		buildOptions.Secrets = append(buildOptions.Secrets, docker.BuildSecret{
+			Id:  secret.Id,
+			Src: expand(secret.Src),
+			Env: secret.Env,
+		})
+
+		// The process environment is inherited by docker, only the values of the azd environment are added
+		if env != nil {
+			if value, has := env.Lookup(secret.Env); has && secret.Env != "" {
+				buildOptions.Env = append(buildOptions.Env, fmt.Sprintf("%s=%s", secret.Env, value))
+			}
+		}
+	}
+
+	if err != nil {
+		return docker.BuildOptions{}, fmt.Errorf("replacing environment references in docker options: %w", err)
+	}
+
+	return buildOptions, nil
+}
+
+// packageDockerBuildContext writes the build context of the service to a gzipped tarball, leaving out the files
+// excluded by the .dockerignore file of the context, and returns the path of the tarball
+func packageDockerBuildContext(config *ServiceConfig, options DockerProjectOptions) (string, error) {
+	contextDir := filepath.Join(config.Path(), options.Context)
+	dockerfilePath, err := relativeDockerfilePath(config, options)
+	if err != nil {
+		return "", err
+	}
+
+	// The options of the local docker build which can't be applied to the build run by the registry
+	unsupported := []string{}
+	if len(options.Secrets) > 0 {
+		unsupported = append(unsupported, "secrets")
+	}
+	if len(options.Labels) > 0 {
+		unsupported = append(unsupported, "labels")
+	}
+	if len(options.CacheFrom) > 0 {
+		unsupported = append(unsupported, "cache-from")
+	}
+	if len(options.CacheTo) > 0 {
+		unsupported = append(unsupported, "cache-to")
+	}
+	if len(options.Tags) > 0 {
+		unsupported = append(unsupported, "tags")
+	}
+	if len(unsupported) > 0 {
+		last := len(unsupported) - 1
+		names := unsupported[last]
+		if last > 0 {
+			names = strings.Join(unsupported[:last], ", ") + " and " + names
+		}
+
+		return "", fmt.Errorf("docker %s are not supported by remote builds", names)
+	}
+
+	matcher, err := ignore.ReadDockerIgnore(contextDir)
+	if err != nil {
+		return "", err
+	}
+
+	tarball, err := os.CreateTemp("", fmt.Sprintf("azd-%s-*.tar.gz", config.Name))
+	if err != nil {
+		return "", fmt.Errorf("creating build context tarball: %w", err)
+	}
+	defer tarball.Close()
+
+	// Like docker, the Dockerfile & the .dockerignore file are sent even when they are ignored
+	skip := func(relativePath string, isDir bool) bool {
+		relativePath = filepath.ToSlash(relativePath)
+		if relativePath == dockerfilePath || relativePath == ignore.DockerIgnoreFileName {
+			return false
+		}
+
+		return matcher.Ignores(relativePath)
+	}
+
+	if err := rtar.CreateFromDirectory(contextDir, tarball, skip, matcher.HasExclusions()); err != nil {
+		os.Remove(tarball.Name())
+		return "", fmt.Errorf("creating build context tarball: %w", err)
+	}
+
+	log.Printf("packaged build context %s of %s to %s", contextDir, config.Name, tarball.Name())
+	return tarball.Name(), nil
+}
+
+// relativeDockerfilePath returns the path of the Dockerfile relative to the build context, the Dockerfile of a remote
+// build has to be part of its build context
+func relativeDockerfilePath(config *ServiceConfig, options DockerProjectOptions) (string, error) {
+	relativePath, err := filepath.Rel(
+		filepath.Join(config.Path(), options.Context),
+		filepath.Join(config.Path(), options.Path),
+	)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("the Dockerfile %s must be in the build context %s for remote builds", options.Path, options.Context)
+	}
+
+	return filepath.ToSlash(relativePath), nil
+}
+
 func (p *dockerProject) InstallDependencies(ctx context.Context) error {
 	// When the program runs the restore actions for the underlying project (containerapp),
 	// the dependencies are installed locally
@@ -1,11 +1,19 @@
 package project
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/azure/azure-dev/cli/azd/pkg/environment"
 	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/docker"
 	"github.com/azure/azure-dev/cli/azd/test/helpers"
 	"github.com/stretchr/testify/require"
@@ -148,3 +156,301 @@ services:
 	require.Equal(t, "Building docker image", status)
 	require.Equal(t, true, ran)
 }
+
+func TestDockerBuildOptions(t *testing.T) {
+	const testProj = `
+name: test-proj
+metadata:
+  template: test-proj-template
+resourceGroup: rg-test
+services:
+  web:
+    project: src/web
+    language: js
+    host: containerapp
+    docker:
+      target: runtime
+      buildArgs:
+        API_URL: ${API_URL}
+        NODE_VERSION: 18
+      secrets:
+        - id: npmrc
+          src: .npmrc
+        - id: token
+          env: API_TOKEN
+      labels:
+        com.contoso.env: ${AZURE_ENV_NAME}
+      cacheFrom:
+        - type=registry,ref=${AZURE_CONTAINER_REGISTRY_ENDPOINT}/web:cache
+      cacheTo:
+        - type=inline
+      tags:
+        - web:latest
+`
+
+	ctx := helpers.CreateTestContext(context.Background(), gblCmdOptions, azCli, mockHttpClient)
+	env := environment.Environment{Values: map[string]string{
+		"API_URL":                           "https://api.contoso.com",
+		"API_TOKEN":                         "secret",
+		"AZURE_CONTAINER_REGISTRY_ENDPOINT": "contoso.azurecr.io",
+	}}
+	env.SetEnvName("test-env")
+
+	projectConfig, err := ParseProjectConfig(testProj, &env)
+	require.NoError(t, err)
+	prj, err := projectConfig.GetProject(ctx, &env)
+	require.NoError(t, err)
+	service := prj.Services[0]
+	ran := false
+
+	dockerArgs := docker.DockerArgs{
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			ran = true
+
+			require.Equal(t, []string{
+				"build", "-q",
+				"-f", "./Dockerfile",
+				"--platform", "amd64",
+				"--target", "runtime",
+				"--build-arg", "API_URL=https://api.contoso.com",
+				"--build-arg", "NODE_VERSION=18",
+				"--secret", "id=npmrc,src=.npmrc",
+				"--secret", "id=token,env=API_TOKEN",
+				"--label", "com.contoso.env=test-env",
+				"--cache-from", "type=registry,ref=contoso.azurecr.io/web:cache",
+				"--cache-to", "type=inline",
+				"-t", "web:latest",
+				".",
+			}, args.Args)
+			require.Equal(t, []string{"API_TOKEN=secret", "DOCKER_BUILDKIT=1"}, args.Env)
+
+			return executil.RunResult{
+				Stdout:   "imageId",
+				Stderr:   "",
+				ExitCode: 0,
+			}, nil
+		},
+	}
+
+	progress := make(chan string)
+	go func() {
+		for range progress {
+		}
+	}()
+
+	framework := NewDockerProject(service.Config, &env, docker.NewDocker(dockerArgs), NewNpmProject(service.Config, &env))
+	res, err := framework.Package(ctx, progress)
+	close(progress)
+
+	require.NoError(t, err)
+	require.Equal(t, "imageId", res)
+	require.True(t, ran)
+}
+
+func TestDockerBuildOptionsExpansion(t *testing.T) {
+	env := &environment.Environment{Values: map[string]string{}}
+
+	_, err := getDockerBuildOptions(DockerProjectOptions{
+		Secrets: []DockerSecret{{Id: "token", Src: ".token", Env: "TOKEN"}},
+	}, env)
+	require.Error(t, err)
+
+	options, err := getDockerBuildOptions(DockerProjectOptions{
+		BuildArgs: map[string]string{"VERSION": "${MISSING_VERSION=1.0}"},
+	}, env)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"VERSION": "1.0"}, options.BuildArgs)
+}
+
+// fakeAcrCli records the builds run in the registry and returns the manifest digests of the images of the registry
+type fakeAcrCli struct {
+	azcli.AzCli
+	loginServer string
+	args        azcli.AcrBuildArgs
+	files       []string
+	images      map[string]string
+}
+
+func (f *fakeAcrCli) GetAcrImageDigest(ctx context.Context, loginServer string, image string) (string, error) {
+	digest, has := f.images[loginServer+"/"+image]
+	if !has {
+		return "", azcli.ErrAcrImageNotFound
+	}
+
+	return digest, nil
+}
+
+func (f *fakeAcrCli) BuildAcrImage(ctx context.Context, subscriptionId string, loginServer string, args azcli.AcrBuildArgs, logWriter io.Writer) error {
+	f.loginServer = loginServer
+	f.args = args
+
+	file, err := os.Open(args.SourcePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		f.files = append(f.files, header.Name)
+	}
+
+	_, err = logWriter.Write([]byte("Step 1/2 : FROM node\nStep 2/2 : COPY . .\nSuccessfully built"))
+	return err
+}
+
+func TestDockerRemoteBuild(t *testing.T) {
+	const testProj = `
+name: test-proj
+services:
+  web:
+    project: src/web
+    language: js
+    host: containerapp
+    docker:
+      remoteBuild: true
+      target: runtime
+      buildArgs:
+        API_URL: ${API_URL}
+`
+
+	env := environment.Environment{Values: map[string]string{
+		environment.ContainerRegistryEndpointEnvVarName: "contoso.azurecr.io",
+		"API_URL": "https://api.contoso.com",
+	}}
+	env.SetEnvName("test-env")
+
+	projectConfig, err := ParseProjectConfig(testProj, &env)
+	require.NoError(t, err)
+	projectConfig.Path = t.TempDir()
+	serviceConfig := projectConfig.Services["web"]
+
+	files := map[string]string{
+		"Dockerfile":        "FROM node",
+		".dockerignore":     "node_modules\n*.log\nDockerfile\n",
+		"index.js":          "",
+		"src/app.js":        "",
+		"debug.log":         "",
+		"node_modules/a.js": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(serviceConfig.Path(), name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	dockerCli := docker.NewDocker(docker.DockerArgs{
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			require.Fail(t, "docker should not be run by remote builds")
+			return executil.RunResult{}, nil
+		},
+	})
+
+	framework := NewDockerProject(serviceConfig, &env, dockerCli, NewNpmProject(serviceConfig, &env))
+	require.Empty(t, framework.RequiredExternalTools())
+
+	progressMessages := []string{}
+	progress := make(chan string)
+	done := make(chan bool)
+	go func() {
+		for value := range progress {
+			progressMessages = append(progressMessages, value)
+		}
+		done <- true
+	}()
+
+	tarballPath, err := framework.Package(context.Background(), progress)
+	require.NoError(t, err)
+	require.FileExists(t, tarballPath)
+
+	acrCli := &fakeAcrCli{}
+	image, pushed, err := pushContainerImage(context.Background(), acrCli, dockerCli, &env, serviceConfig, "test-proj/web", tarballPath, progress)
+	close(progress)
+	<-done
+	require.NoError(t, err)
+	require.True(t, pushed)
+
+	require.Regexp(t, `^contoso\.azurecr\.io/test-proj/web:azdev-deploy-\d+$`, image)
+	require.Equal(t, image, env.Values[serviceImageEnvVarName(serviceConfig)])
+	require.NoFileExists(t, tarballPath)
+
+	require.Equal(t, "contoso.azurecr.io", acrCli.loginServer)
+	require.Equal(t, "Dockerfile", acrCli.args.DockerfilePath)
+	require.Equal(t, []string{strings.TrimPrefix(image, "contoso.azurecr.io/")}, acrCli.args.ImageNames)
+	require.Equal(t, "runtime", acrCli.args.Target)
+	require.Equal(t, "amd64", acrCli.args.Platform)
+	require.Equal(t, map[string]string{"API_URL": "https://api.contoso.com"}, acrCli.args.BuildArgs)
+	require.ElementsMatch(t, []string{".dockerignore", "Dockerfile", "index.js", "src/", "src/app.js"}, acrCli.files)
+
+	require.Equal(t, []string{
+		"Packaging docker build context",
+		"Building container image in the registry",
+		"Step 1/2 : FROM node",
+		"Step 2/2 : COPY . .",
+		"Successfully built",
+	}, progressMessages)
+
+	// An unchanged build context is only skipped while the image is still in the registry
+	rebuild := func() bool {
+		progress := make(chan string)
+		go func() {
+			for range progress {
+			}
+		}()
+		defer close(progress)
+
+		tarballPath, err := framework.Package(context.Background(), progress)
+		require.NoError(t, err)
+
+		_, pushed, err := pushContainerImage(context.Background(), acrCli, dockerCli, &env, serviceConfig, "test-proj/web", tarballPath, progress)
+		require.NoError(t, err)
+
+		return pushed
+	}
+
+	require.True(t, rebuild())
+
+	acrCli.images = map[string]string{env.Values[serviceImageEnvVarName(serviceConfig)]: "sha256:0123456789abcdef"}
+	require.False(t, rebuild())
+}
+
+func TestDockerRemoteBuildUnsupportedOptions(t *testing.T) {
+	serviceConfig := &ServiceConfig{
+		Name:         "web",
+		Project:      &ProjectConfig{Path: t.TempDir()},
+		RelativePath: "src/web",
+	}
+
+	_, err := packageDockerBuildContext(serviceConfig, getDockerOptionsWithDefaults(DockerProjectOptions{
+		RemoteBuild: true,
+		Secrets:     []DockerSecret{{Id: "npmrc", Src: ".npmrc"}},
+	}))
+	require.ErrorContains(t, err, "docker secrets are not supported by remote builds")
+
+	// The cache & tags options are rejected rather than silently dropped
+	_, err = packageDockerBuildContext(serviceConfig, getDockerOptionsWithDefaults(DockerProjectOptions{
+		RemoteBuild: true,
+		CacheFrom:   []string{"contoso.azurecr.io/web:cache"},
+		CacheTo:     []string{"type=inline"},
+		Tags:        []string{"web:latest"},
+	}))
+	require.ErrorContains(t, err, "docker cache-from, cache-to and tags are not supported by remote builds")
+
+	_, err = packageDockerBuildContext(serviceConfig, getDockerOptionsWithDefaults(DockerProjectOptions{
+		RemoteBuild: true,
+		Path:        "../Dockerfile",
+	}))
+	require.ErrorContains(t, err, "must be in the build context")
+}
@@ -57,7 +57,7 @@ func (dp *dotnetProject) Initialize(ctx context.Context) error {
 	}
 
 	handler := func(ctx context.Context, args ServiceLifecycleEventArgs) error {
-		bicepOutputArgs := args.Args["bicepOutput"]
+		bicepOutputArgs := args.Args[ProvisioningOutputsEventArg]
 		if bicepOutputArgs == nil {
 			log.Println("no bicep outputs set as secrets to dotnet project, map args.Args doesn't contain key \"bicepOutput\"")
 			return nil
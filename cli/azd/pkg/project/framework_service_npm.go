@@ -38,8 +38,9 @@ func (np *npmProject) Package(ctx context.Context, progress chan<- string) (stri
 	}
 
 	// Run Build, injecting env.
-	envs := make([]string, 0, len(np.env.Values)+1)
-	for k, v := range np.env.Values {
+	values := np.env.CopyValues()
+	envs := make([]string, 0, len(values)+1)
+	for k, v := range values {
 		envs = append(envs, fmt.Sprintf("%s=%s", k, v))
 	}
 	envs = append(envs, "NODE_ENV=production")
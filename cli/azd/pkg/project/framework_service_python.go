@@ -6,6 +6,7 @@ package project
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
 	"path"
 	"path/filepath"
@@ -23,10 +24,28 @@ type pythonProject struct {
 }
 
 func (pp *pythonProject) RequiredExternalTools() []tools.ExternalTool {
-	return []tools.ExternalTool{python.NewPythonCli()}
+	switch pp.dependencyManager() {
+	case python.Poetry:
+		return []tools.ExternalTool{python.NewPythonCli(), python.NewPoetryCli()}
+	case python.Pipenv:
+		return []tools.ExternalTool{python.NewPythonCli(), python.NewPipenvCli()}
+	default:
+		return []tools.ExternalTool{python.NewPythonCli()}
+	}
+}
+
+// dependencyManager returns the dependency manager of the project, pip when it can't be detected
+func (pp *pythonProject) dependencyManager() python.DependencyManager {
+	manager, err := python.DetectDependencyManager(pp.config.Path())
+	if err != nil {
+		log.Printf("detecting dependency manager of project '%s', defaulting to pip: %v", pp.config.Path(), err)
+		return python.Pip
+	}
+
+	return manager
 }
 
-func (pp *pythonProject) Package(_ context.Context, progress chan<- string) (string, error) {
+func (pp *pythonProject) Package(ctx context.Context, progress chan<- string) (string, error) {
 	publishRoot, err := os.MkdirTemp("", "azd")
 	if err != nil {
 		return "", fmt.Errorf("creating package directory for %s: %w", pp.config.Name, err)
@@ -43,16 +62,61 @@ func (pp *pythonProject) Package(_ context.Context, progress chan<- string) (str
 		return "", fmt.Errorf("publishing for %s: %w", pp.config.Name, err)
 	}
 
+	// App Service and Functions install the dependencies listed in requirements.txt
+	if err := pp.exportRequirements(ctx, filepath.Join(publishRoot, python.RequirementsFileName), progress); err != nil {
+		return "", fmt.Errorf("publishing for %s: %w", pp.config.Name, err)
+	}
+
 	return publishRoot, nil
 }
 
+// exportRequirements writes the dependencies of the project to the requirements file at outputPath, when the
+// dependencies of the project are not listed in requirements.txt
+func (pp *pythonProject) exportRequirements(ctx context.Context, outputPath string, progress chan<- string) error {
+	manager, err := python.DetectDependencyManager(pp.config.Path())
+	if err != nil {
+		return err
+	}
+
+	switch manager {
+	case python.Poetry:
+		progress <- "Exporting requirements"
+		return python.NewPoetryCli().ExportRequirements(ctx, pp.config.Path(), outputPath)
+	case python.Pipenv:
+		progress <- "Exporting requirements"
+		return python.NewPipenvCli().ExportRequirements(ctx, pp.config.Path(), outputPath)
+	case python.PyProject:
+		dependencies, err := python.ReadPyProjectDependencies(pp.config.Path())
+		if err != nil {
+			return err
+		}
+
+		return python.WriteRequirements(outputPath, dependencies)
+	default:
+		return nil
+	}
+}
+
 func (pp *pythonProject) InstallDependencies(ctx context.Context) error {
+	manager, err := python.DetectDependencyManager(pp.config.Path())
+	if err != nil {
+		return err
+	}
+
+	// Poetry and Pipenv manage the virtual environment of the project
+	switch manager {
+	case python.Poetry:
+		return python.NewPoetryCli().Install(ctx, pp.config.Path())
+	case python.Pipenv:
+		return python.NewPipenvCli().Install(ctx, pp.config.Path())
+	}
+
 	pythonCli := python.NewPythonCli()
 
 	vEnvName := pp.getVenvName()
 	vEnvPath := path.Join(pp.config.Path(), vEnvName)
 
-	_, err := os.Stat(vEnvPath)
+	_, err = os.Stat(vEnvPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			err = pythonCli.CreateVirtualEnv(ctx, pp.config.Path(), vEnvName)
@@ -64,7 +128,30 @@ func (pp *pythonProject) InstallDependencies(ctx context.Context) error {
 		}
 	}
 
-	err = pythonCli.InstallRequirements(ctx, pp.config.Path(), vEnvName, "requirements.txt")
+	requirementsFile := python.RequirementsFileName
+	if manager == python.PyProject {
+		dependencies, err := python.ReadPyProjectDependencies(pp.config.Path())
+		if err != nil {
+			return err
+		}
+
+		// The dependencies of pyproject.toml are installed from a requirements file, the project itself does not
+		// need to be an installable package
+		requirements, err := os.CreateTemp("", "azd-requirements*.txt")
+		if err != nil {
+			return fmt.Errorf("creating requirements file for project '%s': %w", pp.config.Path(), err)
+		}
+		requirements.Close()
+		defer os.Remove(requirements.Name())
+
+		if err := python.WriteRequirements(requirements.Name(), dependencies); err != nil {
+			return err
+		}
+
+		requirementsFile = requirements.Name()
+	}
+
+	err = pythonCli.InstallRequirements(ctx, pp.config.Path(), vEnvName, requirementsFile)
 	if err != nil {
 		return fmt.Errorf("requirements for project '%s' could not be installed: %w", pp.config.Path(), err)
 	}
@@ -0,0 +1,74 @@
+package project
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/python"
+	"github.com/stretchr/testify/require"
+)
+
+func createPythonTestProject(t *testing.T, files map[string]string) *pythonProject {
+	config := &ServiceConfig{
+		Name:         "api",
+		RelativePath: "src/api",
+		Language:     "python",
+		Project:      &ProjectConfig{Path: t.TempDir()},
+	}
+
+	for name, content := range files {
+		path := filepath.Join(config.Path(), name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	return NewPythonProject(config, &environment.Environment{Values: map[string]string{}}).(*pythonProject)
+}
+
+func TestPythonPackageExportsPyProjectRequirements(t *testing.T) {
+	project := createPythonTestProject(t, map[string]string{
+		"app.py":                 "print('hello')",
+		python.PyProjectFileName: "[project]\nname = \"api\"\ndependencies = [\"flask>=2.3\", \"gunicorn\"]\n",
+	})
+
+	progress := make(chan string)
+	go func() {
+		for range progress {
+		}
+	}()
+
+	publishRoot, err := project.Package(context.Background(), progress)
+	close(progress)
+	require.NoError(t, err)
+	defer os.RemoveAll(publishRoot)
+
+	require.FileExists(t, filepath.Join(publishRoot, "app.py"))
+	requirements, err := os.ReadFile(filepath.Join(publishRoot, python.RequirementsFileName))
+	require.NoError(t, err)
+	require.Equal(t, "flask>=2.3\ngunicorn\n", string(requirements))
+}
+
+func TestPythonRequiredExternalTools(t *testing.T) {
+	tests := map[string]struct {
+		files    map[string]string
+		expected []string
+	}{
+		"Pip":    {map[string]string{python.RequirementsFileName: "flask"}, []string{"Python CLI"}},
+		"Poetry": {map[string]string{python.PoetryLockFileName: ""}, []string{"Python CLI", "Poetry"}},
+		"Pipenv": {map[string]string{python.PipfileName: ""}, []string{"Python CLI", "Pipenv"}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			names := []string{}
+			for _, tool := range createPythonTestProject(t, test.files).RequiredExternalTools() {
+				names = append(names, tool.Name())
+			}
+
+			require.Equal(t, test.expected, names)
+		})
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package project
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"gopkg.in/yaml.v3"
+)
+
+// HookConfig is a shell command or script configured in azure.yaml which runs when a lifecycle event is raised
+type HookConfig struct {
+	// The shell command or the path to the script file to run, relative to the project or service folder.
+	// Each line of a multi-line value is run as a separate command.
+	Run string `yaml:"run"`
+	// When true, a failure of the hook is reported but does not fail the operation raising the event
+	ContinueOnError bool `yaml:"continueOnError,omitempty"`
+	// When true, the hook is connected to the console so it can prompt for input
+	Interactive bool `yaml:"interactive,omitempty"`
+}
+
+// UnmarshalYAML allows a hook to be declared with just the command to run, ex) `deployed: ./scripts/seed.sh`
+func (h *HookConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		h.Run = value.Value
+		return nil
+	}
+
+	type rawHookConfig HookConfig
+	return value.Decode((*rawHookConfig)(h))
+}
+
+// The events that can be hooked from the services section of azure.yaml
+var serviceHookEvents = []Event{Provisioned, Deploying, Deployed}
+
+// The events that can be hooked from the root of azure.yaml
+var projectHookEvents = []Event{Provisioning, Provisioned, Deploying, Deployed, Destroying, Destroyed}
+
+// Used to run the commands of hooks, allows us to stub out command execution for testing
+var runHookCommandList = executil.RunCommandList
+var runHookCommandListWithCurrentStdio = executil.RunCommandListWithCurrentStdio
+
+// registerProjectHooks adds an event handler for each of the hooks declared by the project
+func registerProjectHooks(pc *ProjectConfig, env *environment.Environment) error {
+	for _, name := range sortedHookEvents(pc.Hooks) {
+		name := name
+		if !isHookEvent(name, projectHookEvents) {
+			return fmt.Errorf("invalid hook '%s', supported project hooks are: %s", name, joinEvents(projectHookEvents))
+		}
+
+		hook := pc.Hooks[name]
+		if err := validateHook(name, hook); err != nil {
+			return err
+		}
+
+		handler := func(ctx context.Context, args ProjectLifecycleEventArgs) error {
+			return hook.execute(ctx, name, args.Project.Path, env, nil)
+		}
+
+		if err := pc.AddHandler(name, handler); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// registerServiceHooks adds an event handler for each of the hooks declared by the service
+func registerServiceHooks(sc *ServiceConfig, env *environment.Environment) error {
+	for _, name := range sortedHookEvents(sc.Hooks) {
+		name := name
+		if !isHookEvent(name, serviceHookEvents) {
+			return fmt.Errorf("invalid hook '%s' for service '%s', supported service hooks are: %s", name, sc.Name, joinEvents(serviceHookEvents))
+		}
+
+		hook := sc.Hooks[name]
+		if err := validateHook(name, hook); err != nil {
+			return fmt.Errorf("service '%s': %w", sc.Name, err)
+		}
+
+		handler := func(ctx context.Context, args ServiceLifecycleEventArgs) error {
+			// Provisioning raises the Deployed event with its outputs, the service itself is not deployed
+			if _, provisioned := args.Args[ProvisioningOutputsEventArg]; provisioned && name == Deployed {
+				return nil
+			}
+
+			// The hooks of a service skipped as unchanged can tell from AZD_DEPLOYMENT_SKIPPED
+			var extraEnv []string
+			if skipped, _ := args.Args[DeploymentSkippedEventArg].(bool); skipped {
+				extraEnv = append(extraEnv, fmt.Sprintf("%s=true", DeploymentSkippedEnvVarName))
+			}
+
+			return hook.execute(ctx, name, args.Service.Path(), env, extraEnv)
+		}
+
+		if err := sc.AddHandler(name, handler); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Runs the commands of the hook from the specified folder with the values of the environment and extraEnv exported
+func (h *HookConfig) execute(
+	ctx context.Context,
+	name Event,
+	cwd string,
+	env *environment.Environment,
+	extraEnv []string,
+) error {
+	commands := h.commands(cwd)
+	envVars := append(hookEnvironment(env), extraEnv...)
+
+	log.Printf("running '%s' hook: %s", name, strings.Join(commands, " && "))
+
+	var res executil.RunResult
+	var err error
+
+	if h.Interactive {
+		res, err = runHookCommandListWithCurrentStdio(ctx, commands, envVars, cwd)
+	} else {
+		res, err = runHookCommandList(ctx, commands, envVars, cwd)
+	}
+
+	if err != nil {
+		hookErr := fmt.Errorf("'%s' hook failed: %s: %w", name, res.String(), err)
+		if h.ContinueOnError {
+			log.Printf("continuing after failure, %v", hookErr)
+			fmt.Fprintf(os.Stderr, "WARNING: %v\n", hookErr)
+			return nil
+		}
+
+		return hookErr
+	}
+
+	if !h.Interactive {
+		log.Printf("'%s' hook output: %s", name, res.Stdout)
+	}
+
+	return nil
+}
+
+// Gets the commands to run for the hook. A single line referencing a script file is run with the interpreter
+// for the type of script, all other values are run as shell commands.
+func (h *HookConfig) commands(cwd string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(h.Run, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) != 1 {
+		return lines
+	}
+
+	scriptPath := lines[0]
+	if !filepath.IsAbs(scriptPath) {
+		scriptPath = filepath.Join(cwd, scriptPath)
+	}
+
+	if info, err := os.Stat(scriptPath); err != nil || info.IsDir() {
+		return lines
+	}
+
+	switch strings.ToLower(filepath.Ext(scriptPath)) {
+	case ".sh":
+		return []string{fmt.Sprintf("sh \"%s\"", scriptPath)}
+	case ".ps1":
+		return []string{fmt.Sprintf("pwsh -NoProfile -File \"%s\"", scriptPath)}
+	default:
+		return []string{fmt.Sprintf("\"%s\"", scriptPath)}
+	}
+}
+
+func validateHook(name Event, hook *HookConfig) error {
+	if hook == nil || strings.TrimSpace(hook.Run) == "" {
+		return fmt.Errorf("hook '%s' must specify the command to run", name)
+	}
+
+	return nil
+}
+
+// Exports the values of the environment to the hook commands
+func hookEnvironment(env *environment.Environment) []string {
+	if env == nil {
+		return nil
+	}
+
+	values := env.CopyValues()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	envVars := make([]string, 0, len(keys))
+	for _, key := range keys {
+		envVars = append(envVars, fmt.Sprintf("%s=%s", key, values[key]))
+	}
+
+	return envVars
+}
+
+func sortedHookEvents(hooks map[Event]*HookConfig) []Event {
+	names := make([]Event, 0, len(hooks))
+	for name := range hooks {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+func isHookEvent(name Event, events []Event) bool {
+	for _, event := range events {
+		if event == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func joinEvents(events []Event) string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = string(event)
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,269 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/stretchr/testify/require"
+)
+
+type hookRun struct {
+	commands []string
+	env      []string
+	cwd      string
+}
+
+// Replaces the execution of hook commands for the duration of the test, recording each of the runs
+func stubHookCommands(t *testing.T, err error) *[]hookRun {
+	runs := []hookRun{}
+	stub := func(ctx context.Context, commands []string, env []string, cwd string) (executil.RunResult, error) {
+		runs = append(runs, hookRun{commands: commands, env: env, cwd: cwd})
+		if err != nil {
+			return executil.NewRunResult(1, "", "hook error output"), err
+		}
+
+		return executil.NewRunResult(0, "", ""), nil
+	}
+
+	originalRun := runHookCommandList
+	originalRunWithCurrentStdio := runHookCommandListWithCurrentStdio
+	runHookCommandList = stub
+	runHookCommandListWithCurrentStdio = stub
+
+	t.Cleanup(func() {
+		runHookCommandList = originalRun
+		runHookCommandListWithCurrentStdio = originalRunWithCurrentStdio
+	})
+
+	return &runs
+}
+
+func TestProjectConfigHooks(t *testing.T) {
+	const testProj = `
+name: test-proj
+hooks:
+  provisioning: echo provisioning
+  deployed:
+    run: |
+      echo first
+      echo second
+    continueOnError: true
+    interactive: true
+services:
+  api:
+    project: src/api
+    language: js
+    hooks:
+      deploying: ./scripts/predeploy.sh
+`
+
+	e := environment.Environment{Values: make(map[string]string)}
+	e.SetEnvName("test-env")
+
+	projectConfig, err := ParseProjectConfig(testProj, &e)
+	require.NoError(t, err)
+
+	require.Equal(t, &HookConfig{Run: "echo provisioning"}, projectConfig.Hooks[Provisioning])
+	require.Equal(t, &HookConfig{
+		Run:             "echo first\necho second\n",
+		ContinueOnError: true,
+		Interactive:     true,
+	}, projectConfig.Hooks[Deployed])
+	require.Equal(t, &HookConfig{Run: "./scripts/predeploy.sh"}, projectConfig.Services["api"].Hooks[Deploying])
+}
+
+func TestProjectConfigInvalidHooks(t *testing.T) {
+	e := environment.Environment{Values: make(map[string]string)}
+	e.SetEnvName("test-env")
+
+	t.Run("UnknownProjectEvent", func(t *testing.T) {
+		const testProj = `
+name: test-proj
+hooks:
+  building: echo building
+services:
+  api:
+    project: src/api
+    language: js
+`
+		_, err := ParseProjectConfig(testProj, &e)
+		require.EqualError(
+			t,
+			err,
+			"invalid hook 'building', supported project hooks are: provisioning, provisioned, deploying, deployed, destroying, destroyed",
+		)
+	})
+
+	t.Run("UnknownServiceEvent", func(t *testing.T) {
+		const testProj = `
+name: test-proj
+services:
+  api:
+    project: src/api
+    language: js
+    hooks:
+      destroyed: echo destroyed
+`
+		_, err := ParseProjectConfig(testProj, &e)
+		require.EqualError(
+			t,
+			err,
+			"invalid hook 'destroyed' for service 'api', supported service hooks are: provisioned, deploying, deployed",
+		)
+	})
+
+	t.Run("MissingRun", func(t *testing.T) {
+		const testProj = `
+name: test-proj
+services:
+  api:
+    project: src/api
+    language: js
+    hooks:
+      deployed:
+        continueOnError: true
+`
+		_, err := ParseProjectConfig(testProj, &e)
+		require.EqualError(t, err, "service 'api': hook 'deployed' must specify the command to run")
+	})
+}
+
+func TestProjectConfigHooksRunOnEvents(t *testing.T) {
+	ctx := context.Background()
+	runs := stubHookCommands(t, nil)
+
+	const testProj = `
+name: test-proj
+hooks:
+  provisioned: |
+    echo one
+    echo two
+services:
+  api:
+    project: src/api
+    language: js
+    hooks:
+      deployed: echo deployed
+`
+
+	e := environment.Environment{Values: map[string]string{"API_URL": "https://api.example.com"}}
+	e.SetEnvName("test-env")
+
+	projectConfig, err := ParseProjectConfig(testProj, &e)
+	require.NoError(t, err)
+	projectConfig.Path = t.TempDir()
+
+	err = projectConfig.RaiseEvent(ctx, Deploying, nil)
+	require.NoError(t, err)
+	require.Len(t, *runs, 0)
+
+	err = projectConfig.RaiseEvent(ctx, Provisioned, nil)
+	require.NoError(t, err)
+	require.Len(t, *runs, 1)
+	require.Equal(t, []string{"echo one", "echo two"}, (*runs)[0].commands)
+	require.Equal(t, projectConfig.Path, (*runs)[0].cwd)
+	require.Equal(t, []string{"API_URL=https://api.example.com", "AZURE_ENV_NAME=test-env"}, (*runs)[0].env)
+
+	err = projectConfig.Services["api"].RaiseEvent(ctx, Deployed, nil)
+	require.NoError(t, err)
+	require.Len(t, *runs, 2)
+	require.Equal(t, []string{"echo deployed"}, (*runs)[1].commands)
+	require.Equal(t, filepath.Join(projectConfig.Path, "src", "api"), (*runs)[1].cwd)
+	require.NotContains(t, (*runs)[1].env, "AZD_DEPLOYMENT_SKIPPED=true")
+
+	// The hooks of a service skipped as unchanged are told about the skip
+	err = projectConfig.Services["api"].RaiseEvent(ctx, Deployed, map[string]any{DeploymentSkippedEventArg: true})
+	require.NoError(t, err)
+	require.Len(t, *runs, 3)
+	require.Contains(t, (*runs)[2].env, "AZD_DEPLOYMENT_SKIPPED=true")
+
+	// Provisioning raises the Deployed event with its outputs, which does not deploy the service
+	err = projectConfig.Services["api"].RaiseEvent(ctx, Deployed, map[string]any{ProvisioningOutputsEventArg: nil})
+	require.NoError(t, err)
+	require.Len(t, *runs, 3)
+}
+
+func TestProjectConfigHookFailure(t *testing.T) {
+	ctx := context.Background()
+
+	e := environment.Environment{Values: make(map[string]string)}
+	e.SetEnvName("test-env")
+
+	t.Run("FailsEvent", func(t *testing.T) {
+		stubHookCommands(t, errors.New("exit code: 1"))
+
+		const testProj = `
+name: test-proj
+hooks:
+  deploying: exit 1
+services:
+  api:
+    project: src/api
+    language: js
+`
+		projectConfig, err := ParseProjectConfig(testProj, &e)
+		require.NoError(t, err)
+
+		err = projectConfig.RaiseEvent(ctx, Deploying, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "'deploying' hook failed")
+		require.Contains(t, err.Error(), "hook error output")
+	})
+
+	t.Run("ContinueOnError", func(t *testing.T) {
+		runs := stubHookCommands(t, errors.New("exit code: 1"))
+
+		const testProj = `
+name: test-proj
+hooks:
+  deploying:
+    run: exit 1
+    continueOnError: true
+services:
+  api:
+    project: src/api
+    language: js
+`
+		projectConfig, err := ParseProjectConfig(testProj, &e)
+		require.NoError(t, err)
+
+		err = projectConfig.RaiseEvent(ctx, Deploying, nil)
+		require.NoError(t, err)
+		require.Len(t, *runs, 1)
+	})
+}
+
+func TestHookCommands(t *testing.T) {
+	cwd := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(cwd, "scripts"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cwd, "scripts", "seed.sh"), []byte("echo seed"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(cwd, "scripts", "seed.ps1"), []byte("Write-Host seed"), 0600))
+
+	tests := []struct {
+		name     string
+		run      string
+		expected []string
+	}{
+		{"Command", "echo hello", []string{"echo hello"}},
+		{"MultiLine", "echo hello\n\n  echo world  \n", []string{"echo hello", "echo world"}},
+		{"ShellScript", "scripts/seed.sh", []string{"sh \"" + filepath.Join(cwd, "scripts", "seed.sh") + "\""}},
+		{
+			"PowerShellScript",
+			"./scripts/seed.ps1",
+			[]string{"pwsh -NoProfile -File \"" + filepath.Join(cwd, "scripts", "seed.ps1") + "\""},
+		},
+		{"MissingScript", "scripts/missing.sh", []string{"scripts/missing.sh"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hook := &HookConfig{Run: test.run}
+			require.Equal(t, test.expected, hook.commands(cwd))
+		})
+	}
+}
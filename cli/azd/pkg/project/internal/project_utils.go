@@ -4,22 +4,38 @@
 package internal
 
 import (
+	"archive/zip"
 	"fmt"
+	"log"
 	"os"
+	"sort"
 
+	"github.com/azure/azure-dev/cli/azd/pkg/ignore"
 	"github.com/azure/azure-dev/cli/azd/pkg/rzip"
 )
 
+// The number of entries listed in the size report of a deployable zip
+const zipReportEntries = 10
+
 // CreateDeployableZip creates a zip file of a folder, recursively.
+// The paths matched by the given matcher, read from the .azdignore file of the service, are left out. The folder may be
+// the build output of the service rather than the service folder itself. The same contents always produce the same zip file.
 // Returns the path to the created zip file or an error if it fails.
-func CreateDeployableZip(appName string, path string) (string, error) {
-	// TODO: should probably avoid picking up files that weren't meant to be published (ie, local .env files, etc..)
+func CreateDeployableZip(appName string, path string, matcher *ignore.Matcher) (string, error) {
 	zipFile, err := os.CreateTemp("", "azddeploy*.zip")
 	if err != nil {
 		return "", fmt.Errorf("failed when creating zip package to deploy %s: %w", appName, err)
 	}
 
-	if err := rzip.CreateFromDirectory(path, zipFile); err != nil {
+	skip := func(relativePath string, isDir bool) bool {
+		if isDir {
+			return matcher.IgnoresDirectory(relativePath)
+		}
+
+		return matcher.Ignores(relativePath)
+	}
+
+	if err := rzip.CreateFromDirectory(path, zipFile, skip, matcher.HasExclusions()); err != nil {
 		// if we fail here just do our best to close things out and cleanup
 		zipFile.Close()
 		os.Remove(zipFile.Name())
@@ -32,5 +48,37 @@ func CreateDeployableZip(appName string, path string) (string, error) {
 		return "", err
 	}
 
+	logZipSizeReport(appName, zipFile.Name())
+
 	return zipFile.Name(), nil
 }
+
+// logZipSizeReport logs the size of the zip file and its largest entries, which helps finding the files which should
+// be excluded from the package. The report is printed with --debug.
+func logZipSizeReport(appName string, zipFilePath string) {
+	reader, err := zip.OpenReader(zipFilePath)
+	if err != nil {
+		log.Printf("reading zip package of %s: %v", appName, err)
+		return
+	}
+	defer reader.Close()
+
+	files := append([]*zip.File{}, reader.File...)
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].CompressedSize64 > files[j].CompressedSize64
+	})
+
+	var total uint64
+	for _, file := range files {
+		total += file.CompressedSize64
+	}
+
+	log.Printf("zip package of %s: %d entries, %d bytes compressed", appName, len(files), total)
+	for i, file := range files {
+		if i == zipReportEntries {
+			break
+		}
+
+		log.Printf("  %10d bytes (%d uncompressed) %s", file.CompressedSize64, file.UncompressedSize64, file.Name)
+	}
+}
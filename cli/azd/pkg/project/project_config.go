@@ -27,6 +27,7 @@ type ProjectConfig struct {
 	Metadata          *ProjectMetadata          `yaml:"metadata,omitempty"`
 	Services          map[string]*ServiceConfig `yaml:",omitempty"`
 	Infra             provisioning.Options      `yaml:"infra"`
+	Hooks             map[Event]*HookConfig     `yaml:"hooks,omitempty"`
 
 	handlers map[Event][]ProjectLifecycleEventHandlerFn
 }
@@ -242,11 +243,25 @@ func ParseProjectConfig(yamlContent string, env *environment.Environment) (*Proj
 
 	projectFile.handlers = make(map[Event][]ProjectLifecycleEventHandlerFn)
 
+	if err := registerProjectHooks(&projectFile, env); err != nil {
+		return nil, err
+	}
+
 	// If ResourceGroupName not set in azure.yaml, then look for it in the AZURE_RESOURCE_GROUP env var
 	if strings.TrimSpace(projectFile.ResourceGroupName) == "" {
 		projectFile.ResourceGroupName = environment.GetResourceGroupNameFromEnvVar(env)
 	}
 
+	// By convention, the root infrastructure module is found at `infra/main`. This may be overridden by the `infra`
+	// property of `azure.yaml`
+	if strings.TrimSpace(projectFile.Infra.Path) == "" {
+		projectFile.Infra.Path = "infra"
+	}
+
+	if strings.TrimSpace(projectFile.Infra.Module) == "" {
+		projectFile.Infra.Module = "main"
+	}
+
 	for key, svc := range projectFile.Services {
 		svc.handlers = make(map[Event][]ServiceLifecycleEventHandlerFn)
 		svc.Name = key
@@ -261,6 +276,14 @@ func ParseProjectConfig(yamlContent string, env *environment.Environment) (*Proj
 		if svc.Language == "" || svc.Language == "csharp" || svc.Language == "fsharp" {
 			svc.Language = "dotnet"
 		}
+
+		if err := registerServiceHooks(svc, env); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateServiceDependencies(projectFile.Services); err != nil {
+		return nil, err
 	}
 
 	return &projectFile, nil
@@ -289,6 +312,8 @@ func (p *ProjectConfig) Initialize(ctx context.Context, env *environment.Environ
 }
 
 // LoadProjectConfig loads the azure.yaml configuring into an viewable structure
+// The file is validated against the schema of azure.yaml first: errors fail the load, and warnings are printed
+// unless AZURE_DEV_STRICT_VALIDATION is true, in which case they fail the load too.
 // This does not evaluate any tooling
 func LoadProjectConfig(projectPath string, env *environment.Environment) (*ProjectConfig, error) {
 	log.Printf("Reading project from file '%s'\n", projectPath)
@@ -297,6 +322,26 @@ func LoadProjectConfig(projectPath string, env *environment.Environment) (*Proje
 		return nil, fmt.Errorf("reading project file: %w", err)
 	}
 
+	findings, err := ValidateProjectFile(projectPath, bytes)
+	if err != nil {
+		return nil, fmt.Errorf("validating project file: %w", err)
+	}
+
+	// Warnings are only printed, unless the validation is strict, ex) in CI
+	strict := os.Getenv(StrictValidationEnvVarName) == "true"
+	failures := []ValidationFinding{}
+	for _, finding := range findings {
+		if finding.Severity == SeverityError || strict {
+			failures = append(failures, finding)
+		} else {
+			fmt.Fprintf(os.Stderr, "WARNING: %s: %s\n", finding.Location(), finding.Message)
+		}
+	}
+
+	if len(failures) > 0 {
+		return nil, &ProjectFileError{Findings: failures}
+	}
+
 	yaml := string(bytes)
 
 	projectConfig, err := ParseProjectConfig(yaml, env)
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/azure/azure-dev/cli/azd/pkg/environment"
@@ -377,3 +379,61 @@ func TestProjectConfigRaiseEventWithArgs(t *testing.T) {
 	require.Nil(t, err)
 	require.True(t, handlerCalled)
 }
+
+func TestLoadProjectConfigValidation(t *testing.T) {
+	const testProj = `
+name: test-proj
+services:
+  api:
+    project: src/api
+    hots: containerapp
+`
+
+	projectPath := filepath.Join(t.TempDir(), "azure.yaml")
+	require.NoError(t, os.WriteFile(projectPath, []byte(testProj), 0600))
+
+	t.Run("WarningsAreIgnored", func(t *testing.T) {
+		projectConfig, err := LoadProjectConfig(projectPath, &environment.Environment{})
+		require.NoError(t, err)
+		require.True(t, projectConfig.HasService("api"))
+	})
+
+	t.Run("StrictFailsOnWarnings", func(t *testing.T) {
+		t.Setenv(StrictValidationEnvVarName, "true")
+
+		_, err := LoadProjectConfig(projectPath, &environment.Environment{})
+
+		var fileErr *ProjectFileError
+		require.ErrorAs(t, err, &fileErr)
+		require.Len(t, fileErr.Findings, 1)
+		require.Equal(t, SeverityWarning, fileErr.Findings[0].Severity)
+		require.Equal(t, 6, fileErr.Findings[0].Line)
+		require.Equal(t, 5, fileErr.Findings[0].Column)
+		require.Contains(t, err.Error(), projectPath+":6:5: warning: services.api.hots: property 'hots' is not allowed, did you mean 'host'?")
+	})
+
+	t.Run("ErrorsFail", func(t *testing.T) {
+		invalidPath := filepath.Join(t.TempDir(), "azure.yaml")
+		require.NoError(t, os.WriteFile(invalidPath, []byte("name: test-proj\nservices:\n  api:\n    host: appservice\n"), 0600))
+
+		_, err := LoadProjectConfig(invalidPath, &environment.Environment{})
+
+		var fileErr *ProjectFileError
+		require.ErrorAs(t, err, &fileErr)
+		require.Len(t, fileErr.Findings, 1)
+		require.Equal(t, SeverityError, fileErr.Findings[0].Severity)
+		require.Equal(t, "services.api: missing required property 'project'", fileErr.Findings[0].Message)
+	})
+
+	t.Run("SyntaxErrorHasLine", func(t *testing.T) {
+		invalidPath := filepath.Join(t.TempDir(), "azure.yaml")
+		require.NoError(t, os.WriteFile(invalidPath, []byte("name: test-proj\nservices:\n  api: [\n"), 0600))
+
+		_, err := LoadProjectConfig(invalidPath, &environment.Environment{})
+
+		var fileErr *ProjectFileError
+		require.ErrorAs(t, err, &fileErr)
+		require.Equal(t, RuleParse, fileErr.Findings[0].Rule)
+		require.NotZero(t, fileErr.Findings[0].Line)
+	})
+}
@@ -35,6 +35,8 @@ type Service struct {
 type ServiceDeploymentChannelResponse struct {
 	// The result of a service deploy operation
 	Result *ServiceDeploymentResult
+	// The packaged artifact that was deployed, ex) the folder with the build output or the container image
+	Artifact string
 	// The error that may have occurred during a deploy operation
 	Error error
 }
@@ -67,29 +69,48 @@ func (svc *Service) Deploy(ctx context.Context, azdCtx *environment.AzdContext)
 			return
 		}
 
-		log.Printf("deploying service %s", svc.Config.Name)
+		result <- svc.deployArtifact(ctx, azdCtx, artifact, progress)
+	}()
 
-		progress <- "Preparing for deployment"
-		res, err := svc.Target.Deploy(ctx, azdCtx, artifact, progress)
-		if err != nil {
-			result <- &ServiceDeploymentChannelResponse{
-				Error: fmt.Errorf("deploying service %s package: %w", svc.Config.Name, err),
-			}
+	return result, progress
+}
 
-			return
-		}
+// Redeploy deploys an artifact of a previous deployment of the service, ex) a package from the deployment history,
+// without building & packaging the service again
+func (svc *Service) Redeploy(ctx context.Context, azdCtx *environment.AzdContext, artifact string) (<-chan *ServiceDeploymentChannelResponse, <-chan string) {
+	result := make(chan *ServiceDeploymentChannelResponse, 1)
+	progress := make(chan string)
 
-		log.Printf("deployed service %s", svc.Config.Name)
-		progress <- "Deployment completed"
+	go func() {
+		defer close(result)
+		defer close(progress)
 
-		result <- &ServiceDeploymentChannelResponse{
-			Result: &res,
-		}
+		result <- svc.deployArtifact(ctx, azdCtx, artifact, progress)
 	}()
 
 	return result, progress
 }
 
+func (svc *Service) deployArtifact(ctx context.Context, azdCtx *environment.AzdContext, artifact string, progress chan<- string) *ServiceDeploymentChannelResponse {
+	log.Printf("deploying service %s", svc.Config.Name)
+
+	progress <- "Preparing for deployment"
+	res, err := svc.Target.Deploy(ctx, azdCtx, artifact, progress)
+	if err != nil {
+		return &ServiceDeploymentChannelResponse{
+			Error: fmt.Errorf("deploying service %s package: %w", svc.Config.Name, err),
+		}
+	}
+
+	log.Printf("deployed service %s", svc.Config.Name)
+	progress <- "Deployment completed"
+
+	return &ServiceDeploymentChannelResponse{
+		Result:   &res,
+		Artifact: artifact,
+	}
+}
+
 // GetServiceResourceName attempts to query the azure resource graph and find the resource with the 'azd-service-name' tag set to the service key
 // If not found will assume resource name conventions
 func GetServiceResourceName(ctx context.Context, resourceGroupName string, serviceName string, env *environment.Environment) (string, error) {
@@ -128,8 +149,14 @@ func GetServiceResourceName(ctx context.Context, resourceGroupName string, servi
 	// Fallback to default envName + serviceName
 	if graphQueryResults.TotalRecords != 1 {
 		log.Printf("Expecting only '1' resource match to override resource name but found '%d'", graphQueryResults.TotalRecords)
-		return fmt.Sprintf("%s%s", env.GetEnvName(), serviceName), nil
+		return defaultServiceResourceName(env, serviceName), nil
 	}
 
 	return graphQueryResults.Data[0].Name, nil
 }
+
+// defaultServiceResourceName is the name of the resource of the service when no resource is tagged with the name of the
+// service and no resource name is configured
+func defaultServiceResourceName(env *environment.Environment, serviceName string) string {
+	return fmt.Sprintf("%s%s", env.GetEnvName(), serviceName)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/azure/azure-dev/cli/azd/pkg/environment"
 	"github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/docker"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/kubectl"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/swa"
 )
 
@@ -23,7 +24,7 @@ type ServiceConfig struct {
 	ResourceName string `yaml:"resourceName"`
 	// The relative path to the project folder from the project root
 	RelativePath string `yaml:"project"`
-	// The azure hosting model to use, ex) appservice, function, containerapp
+	// The azure hosting model to use, ex) appservice, function, containerapp, aks
 	Host string `yaml:"host"`
 	// The programming language of the project
 	Language string `yaml:"language"`
@@ -33,8 +34,14 @@ type ServiceConfig struct {
 	Module string `yaml:"module"`
 	// The optional docker options
 	Docker DockerProjectOptions `yaml:"docker"`
+	// The Kubernetes deployment options, used when the service is hosted on AKS
+	K8s AksOptions `yaml:"k8s"`
 	// The infrastructure provisioning configuration
 	Infra provisioning.Options `yaml:"infra"`
+	// The names of the services that must be deployed before this service
+	DependsOn []string `yaml:"dependsOn"`
+	// The commands run when the lifecycle events of the service are raised
+	Hooks map[Event]*HookConfig `yaml:"hooks"`
 
 	handlers map[Event][]ServiceLifecycleEventHandlerFn
 }
@@ -45,6 +52,19 @@ type ServiceLifecycleEventArgs struct {
 	Args    map[string]any
 }
 
+// ProvisioningOutputsEventArg is the argument of the Deployed event of a service raised once the infrastructure is
+// provisioned, holding the outputs of the deployment. The deployed hooks of the service don't run for this event.
+const ProvisioningOutputsEventArg = "bicepOutput"
+
+// DeploymentSkippedEventArg is the argument of the Deploying & Deployed events of a service which is true when the
+// deployment of the service is skipped, because the service is unchanged since its last deployment. The events and the
+// hooks of the service are raised either way.
+const DeploymentSkippedEventArg = "deploymentSkipped"
+
+// DeploymentSkippedEnvVarName is the variable set to true for the deploying & deployed hooks of a service whose
+// deployment is skipped
+const DeploymentSkippedEnvVarName = "AZD_DEPLOYMENT_SKIPPED"
+
 // Function definition for project events
 type ServiceLifecycleEventHandlerFn func(ctx context.Context, args ServiceLifecycleEventArgs) error
 
@@ -89,6 +109,8 @@ func (sc *ServiceConfig) GetServiceTarget(ctx context.Context, env *environment.
 		target = NewFunctionAppTarget(sc, env, scope, azCli)
 	case string(StaticWebAppTarget):
 		target = NewStaticWebAppTarget(sc, env, scope, azCli, swa.NewSwaCli())
+	case string(AksTarget):
+		target = NewAksTarget(sc, env, scope, azCli, docker.NewDocker(docker.DockerArgs{}), kubectl.NewKubectlCli())
 	default:
 		return nil, fmt.Errorf("unsupported host '%s' for service '%s'", sc.Host, sc.Name)
 	}
@@ -112,7 +134,7 @@ func (sc *ServiceConfig) GetFrameworkService(ctx context.Context, env *environme
 	}
 
 	// For containerized applications we use a nested framework service
-	if sc.Host == string(ContainerAppTarget) {
+	if sc.Host == string(ContainerAppTarget) || sc.Host == string(AksTarget) {
 		sourceFramework := frameworkService
 		frameworkService = NewDockerProject(sc, env, docker.NewDocker(docker.DockerArgs{}), sourceFramework)
 	}
@@ -0,0 +1,263 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/ignore"
+	"github.com/drone/envsubst"
+	"gopkg.in/yaml.v3"
+)
+
+// InputHash computes the hash of the inputs of the deployment of the service: the files deployed from the service, ex) the
+// files of the Docker build context not excluded by its .dockerignore file, the configuration of the service in azure.yaml and the values of the environment
+// consumed by the deployment. The service is unchanged since a deployment when the hashes are equal.
+func (svc *Service) InputHash(azdCtx *environment.AzdContext, env *environment.Environment) (string, error) {
+	inputHash := sha256.New()
+
+	// The reference to the project is cleared so that only the section of the service is hashed
+	config := *svc.Config
+	config.Project = nil
+	configYaml, err := yaml.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("serializing configuration of service %s: %w", svc.Config.Name, err)
+	}
+	writeHashSection(inputHash, "config", configYaml)
+
+	if svc.Scope != nil {
+		writeHashSection(inputHash, "scope", []byte(fmt.Sprintf(
+			"%s/%s/%s", svc.Scope.SubscriptionId(), svc.Scope.ResourceGroupName(), svc.Scope.ResourceName(),
+		)))
+	}
+
+	if err := hashSources(inputHash, svc.Config); err != nil {
+		return "", fmt.Errorf("hashing sources of service %s: %w", svc.Config.Name, err)
+	}
+
+	templates := [][]byte{configYaml}
+	for _, path := range deploymentTemplatePaths(azdCtx, svc.Config) {
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("hashing deployment templates of service %s: %w", svc.Config.Name, err)
+		}
+
+		writeHashSection(inputHash, "template:"+filepath.ToSlash(path), content)
+		templates = append(templates, content)
+	}
+
+	for _, name := range consumedEnvironmentValues(svc, env, templates) {
+		writeHashSection(inputHash, "env:"+name, []byte(env.Values[name]))
+	}
+
+	return hex.EncodeToString(inputHash.Sum(nil)), nil
+}
+
+// Writes a named value to the hash, prefixed by its length so that consecutive values can't be confused
+func writeHashSection(h hash.Hash, name string, content []byte) {
+	fmt.Fprintf(h, "%s\x00%d\x00", name, len(content))
+	h.Write(content)
+}
+
+// Hashes the files the service is deployed from, skipping the files the deployment leaves out. Images are built from
+// the Docker build context without the files excluded by its .dockerignore file, along with the Dockerfile which may be
+// outside of the build context. The packages of the other targets are made from the service folder without the files
+// excluded by its .azdignore file.
+func hashSources(h hash.Hash, config *ServiceConfig) error {
+	if config.Host != string(ContainerAppTarget) && config.Host != string(AksTarget) {
+		matcher, err := ignore.ReadAzdIgnore(config.Path())
+		if err != nil {
+			return err
+		}
+
+		return hashSourceDirectory(h, config.Path(), matcher)
+	}
+
+	options := getDockerOptionsWithDefaults(config.Docker)
+	contextDir := filepath.Join(config.Path(), options.Context)
+	matcher, err := ignore.ReadDockerIgnore(contextDir)
+	if err != nil {
+		return err
+	}
+
+	if err := hashSourceDirectory(h, contextDir, matcher); err != nil {
+		return err
+	}
+
+	dockerfileHash, err := hashSourceFile(filepath.Join(config.Path(), options.Path))
+	if err != nil {
+		return err
+	}
+
+	writeHashSection(h, "dockerfile", dockerfileHash)
+	return nil
+}
+
+// The files in which the target substitutes the values of the environment when deploying the service, ex) the
+// parameters of the container app module or the Kubernetes manifests
+func deploymentTemplatePaths(azdCtx *environment.AzdContext, config *ServiceConfig) []string {
+	switch config.Host {
+	case string(ContainerAppTarget):
+		if azdCtx == nil {
+			return nil
+		}
+
+		return []string{
+			azdCtx.BicepModulePath(config.Module),
+			azdCtx.BicepParametersTemplateFilePath(config.Module),
+		}
+	case string(AksTarget):
+		deploymentPath := config.K8s.DeploymentPath
+		if strings.TrimSpace(deploymentPath) == "" {
+			deploymentPath = defaultAksDeploymentPath
+		}
+
+		paths := []string{}
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, _ := filepath.Glob(filepath.Join(config.Path(), deploymentPath, pattern))
+			paths = append(paths, matches...)
+		}
+		sort.Strings(paths)
+
+		return paths
+	default:
+		return nil
+	}
+}
+
+// Hashes the path and content of the files of the folder, in lexical order. Files excluded by the matcher are skipped,
+// as well as the git metadata and the azd environments.
+func hashSourceDirectory(h hash.Hash, dir string, matcher *ignore.Matcher) error {
+	// The root is named after the folder so that moving the sources between folders changes the hash
+	writeHashSection(h, "dir", []byte(filepath.Base(dir)))
+
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		if relative == "." {
+			return nil
+		}
+
+		if entry.IsDir() {
+			if entry.Name() == ".git" || entry.Name() == environment.EnvironmentDirectoryName ||
+				matcher.IgnoresDirectory(relative) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if matcher.Ignores(relative) {
+			return nil
+		}
+
+		name := "file:" + filepath.ToSlash(relative)
+
+		// Links are hashed by their target, the linked files are hashed where they are found in the folder
+		if entry.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			writeHashSection(h, name, []byte("link:"+filepath.ToSlash(target)))
+			return nil
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		fileHash, err := hashSourceFile(path)
+		if err != nil {
+			return err
+		}
+
+		writeHashSection(h, name, fileHash)
+		return nil
+	})
+}
+
+func hashSourceFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	fileHash := sha256.New()
+	if _, err := io.Copy(fileHash, file); err != nil {
+		return nil, err
+	}
+
+	return fileHash.Sum(nil), nil
+}
+
+// Returns the sorted names of the values of the environment consumed by the deployment of the service: the values
+// identifying where the service is deployed, the values referenced by its configuration and deployment templates, and
+// all the values for the frameworks which build the service with the environment, ex) npm.
+func consumedEnvironmentValues(svc *Service, env *environment.Environment, templates [][]byte) []string {
+	consumed := map[string]bool{
+		environment.EnvNameEnvVarName:        true,
+		environment.SubscriptionIdEnvVarName: true,
+	}
+
+	switch svc.Config.Host {
+	case string(ContainerAppTarget):
+		consumed[environment.ContainerRegistryEndpointEnvVarName] = true
+	case string(AksTarget):
+		consumed[environment.ContainerRegistryEndpointEnvVarName] = true
+		consumed[environment.AksClusterNameEnvVarName] = true
+	case string(StaticWebAppTarget):
+		consumed[environment.TenantIdEnvVarName] = true
+	}
+
+	for _, template := range templates {
+		// Templates which can't be parsed fail the deployment, their references don't need to be collected
+		_, _ = envsubst.Eval(string(template), func(name string) string {
+			consumed[name] = true
+			return ""
+		})
+	}
+
+	if _, isNpm := svc.Framework.(*npmProject); isNpm {
+		for name := range env.Values {
+			consumed[name] = true
+		}
+	}
+
+	names := []string{}
+	for name := range consumed {
+		// The images pushed by azd are recorded in the environment by the deployments, they are not inputs
+		if strings.HasPrefix(name, "SERVICE_") &&
+			(strings.HasSuffix(name, "_IMAGE_NAME") || strings.HasSuffix(name, "_IMAGE_DIGEST")) {
+			continue
+		}
+
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,170 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceInputHash(t *testing.T) {
+	const testProj = `
+name: test-proj
+services:
+  api:
+    project: src/api
+    language: py
+    host: appservice
+  web:
+    project: src/web
+    language: js
+    host: appservice
+`
+
+	env := environment.Environment{Values: map[string]string{
+		environment.SubscriptionIdEnvVarName: "SUBSCRIPTION_ID",
+		"API_BASE_URL":                       "https://api.contoso.com",
+	}}
+	env.SetEnvName("test-env")
+
+	projectConfig, err := ParseProjectConfig(testProj, &env)
+	require.NoError(t, err)
+	projectConfig.Path = t.TempDir()
+
+	apiDir := filepath.Join(projectConfig.Path, "src", "api")
+	writeFile := func(path string, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+	writeFile(filepath.Join(apiDir, "app.py"), "print('v1')")
+	writeFile(filepath.Join(apiDir, ".azdignore"), "*.log\n")
+	writeFile(filepath.Join(projectConfig.Path, "src", "web", "index.js"), "console.log('v1')")
+
+	scope := environment.NewDeploymentScope("SUBSCRIPTION_ID", "rg-test", "app-api")
+	api := &Service{
+		Config:    projectConfig.Services["api"],
+		Framework: NewPythonProject(projectConfig.Services["api"], &env),
+		Scope:     scope,
+	}
+	web := &Service{
+		Config:    projectConfig.Services["web"],
+		Framework: NewNpmProject(projectConfig.Services["web"], &env),
+		Scope:     scope,
+	}
+
+	inputHash := func(svc *Service) string {
+		hash, err := svc.InputHash(nil, &env)
+		require.NoError(t, err)
+		return hash
+	}
+
+	apiHash, webHash := inputHash(api), inputHash(web)
+	require.NotEqual(t, apiHash, webHash)
+
+	// Ignored files, git metadata and environments don't change the hash
+	writeFile(filepath.Join(apiDir, "__pycache__", "app.cpython-310.pyc"), "bytecode")
+	writeFile(filepath.Join(apiDir, ".env"), "SECRET=1")
+	writeFile(filepath.Join(apiDir, ".git", "HEAD"), "ref: refs/heads/main")
+	writeFile(filepath.Join(apiDir, ".azure", "test-env", ".env"), "AZURE_ENV_NAME=test-env")
+	writeFile(filepath.Join(apiDir, "debug.log"), "started")
+	require.Equal(t, apiHash, inputHash(api))
+
+	// The .gitignore file does not exclude files from the package of the service
+	writeFile(filepath.Join(apiDir, ".gitignore"), "settings.json\n")
+	apiHash = inputHash(api)
+	writeFile(filepath.Join(apiDir, "settings.json"), "{}")
+	require.NotEqual(t, apiHash, inputHash(api))
+	apiHash = inputHash(api)
+
+	// Values of the environment which the python service does not consume don't change its hash, npm builds
+	// consume all the values of the environment
+	env.Values["API_BASE_URL"] = "https://api.fabrikam.com"
+	require.Equal(t, apiHash, inputHash(api))
+	require.NotEqual(t, webHash, inputHash(web))
+
+	// The images recorded by the deployments are not inputs
+	webHash = inputHash(web)
+	env.Values["SERVICE_API_IMAGE_NAME"] = "registry.azurecr.io/api:azdev-deploy-1"
+	require.Equal(t, webHash, inputHash(web))
+
+	// Sources, configuration and the deployment scope are inputs
+	writeFile(filepath.Join(apiDir, "app.py"), "print('v2')")
+	changedHash := inputHash(api)
+	require.NotEqual(t, apiHash, changedHash)
+
+	api.Config.ResourceName = "app-api-2"
+	require.NotEqual(t, changedHash, inputHash(api))
+	api.Config.ResourceName = ""
+
+	api.Scope = environment.NewDeploymentScope("SUBSCRIPTION_ID", "rg-other", "app-api")
+	require.NotEqual(t, changedHash, inputHash(api))
+}
+
+func TestServiceInputHashDocker(t *testing.T) {
+	const testProj = `
+name: test-proj
+services:
+  web:
+    project: src/web
+    language: js
+    host: containerapp
+    docker:
+      path: ./Dockerfile
+      context: ../
+`
+
+	env := environment.Environment{Values: map[string]string{}}
+	env.SetEnvName("test-env")
+
+	projectConfig, err := ParseProjectConfig(testProj, &env)
+	require.NoError(t, err)
+	projectConfig.Path = t.TempDir()
+
+	writeFile := func(path string, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	// The build context is the parent of the service folder
+	contextDir := filepath.Join(projectConfig.Path, "src")
+	webDir := filepath.Join(contextDir, "web")
+	writeFile(filepath.Join(webDir, "Dockerfile"), "FROM node")
+	writeFile(filepath.Join(webDir, "index.js"), "console.log('v1')")
+	writeFile(filepath.Join(contextDir, "shared", "util.js"), "module.exports = {}")
+	writeFile(filepath.Join(contextDir, ".dockerignore"), "**/node_modules\n")
+
+	web := &Service{
+		Config:    projectConfig.Services["web"],
+		Framework: NewDockerProject(projectConfig.Services["web"], &env, nil, nil),
+	}
+
+	inputHash := func() string {
+		hash, err := web.InputHash(nil, &env)
+		require.NoError(t, err)
+		return hash
+	}
+
+	webHash := inputHash()
+
+	// Only the .dockerignore file of the build context excludes files from the image
+	writeFile(filepath.Join(webDir, "node_modules", "a.js"), "")
+	require.Equal(t, webHash, inputHash())
+
+	writeFile(filepath.Join(webDir, ".azdignore"), "*.md\n")
+	writeFile(filepath.Join(webDir, ".gitignore"), "*.md\n")
+	webHash = inputHash()
+
+	writeFile(filepath.Join(webDir, "README.md"), "# Web")
+	require.NotEqual(t, webHash, inputHash())
+	webHash = inputHash()
+
+	// Files of the build context outside of the service folder and the Dockerfile are inputs
+	writeFile(filepath.Join(contextDir, "shared", "util.js"), "module.exports = { v: 2 }")
+	require.NotEqual(t, webHash, inputHash())
+	webHash = inputHash()
+
+	writeFile(filepath.Join(webDir, "Dockerfile"), "FROM node:18")
+	require.NotEqual(t, webHash, inputHash())
+}
@@ -0,0 +1,196 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrDependencyFailed is returned for services that were not run because a service they depend on failed
+var ErrDependencyFailed = errors.New("dependency failed")
+
+// ServiceRunFn is the operation the scheduler runs for each of the scheduled services
+type ServiceRunFn func(ctx context.Context, svc *Service) error
+
+// ServiceRunResult is the outcome of running the operation for a single service
+type ServiceRunResult struct {
+	Service *Service
+	Error   error
+}
+
+// RunServices runs the operation for each of the services, running independent services concurrently.
+// A service is only run after every service it depends on completed successfully, and at most `parallelism`
+// services are run at the same time. When a service fails the services depending on it are skipped with
+// ErrDependencyFailed, while the remaining services continue to run.
+// Dependencies on services that are not part of `services` are ignored.
+// The results are returned in the same order as `services`.
+func RunServices(ctx context.Context, services []*Service, parallelism int, runFn ServiceRunFn) []ServiceRunResult {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	indexes := map[string]int{}
+	for i, svc := range services {
+		indexes[svc.Config.Name] = i
+	}
+
+	// The number of pending dependencies of each service & the services depending on each service
+	pending := make([]int, len(services))
+	dependents := make([][]int, len(services))
+	for i, svc := range services {
+		for _, dependency := range svc.Config.DependsOn {
+			if dependencyIndex, has := indexes[dependency]; has {
+				pending[i]++
+				dependents[dependencyIndex] = append(dependents[dependencyIndex], i)
+			}
+		}
+	}
+
+	ready := []int{}
+	for i := range services {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	results := make([]ServiceRunResult, len(services))
+	completed := make(chan ServiceRunResult)
+	remaining := len(services)
+	running := 0
+
+	// Skips the dependents of a failed service, including the services that transitively depend on it
+	var skipDependents func(index int)
+	skipDependents = func(index int) {
+		for _, dependent := range dependents[index] {
+			if results[dependent].Service != nil {
+				continue
+			}
+
+			results[dependent] = ServiceRunResult{
+				Service: services[dependent],
+				Error:   fmt.Errorf("skipped service '%s' because service '%s' failed: %w", services[dependent].Config.Name, services[index].Config.Name, ErrDependencyFailed),
+			}
+			remaining--
+			skipDependents(dependent)
+		}
+	}
+
+	for remaining > 0 {
+		for running < parallelism && len(ready) > 0 {
+			svc := services[ready[0]]
+			ready = ready[1:]
+			running++
+
+			go func() {
+				completed <- ServiceRunResult{
+					Service: svc,
+					Error:   runFn(ctx, svc),
+				}
+			}()
+		}
+
+		// Only possible when the dependencies contain a cycle, which is rejected when the project is parsed
+		if running == 0 {
+			for i, svc := range services {
+				if results[i].Service == nil {
+					results[i] = ServiceRunResult{
+						Service: svc,
+						Error:   fmt.Errorf("service '%s' could not be scheduled because of a dependency cycle", svc.Config.Name),
+					}
+				}
+			}
+
+			break
+		}
+
+		result := <-completed
+		running--
+		remaining--
+
+		index := indexes[result.Service.Config.Name]
+		results[index] = result
+
+		if result.Error != nil {
+			skipDependents(index)
+			continue
+		}
+
+		for _, dependent := range dependents[index] {
+			pending[dependent]--
+			if pending[dependent] == 0 && results[dependent].Service == nil {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	return results
+}
+
+// validateServiceDependencies ensures the services only depend on services declared in the project
+// and that the dependencies between the services do not contain a cycle
+func validateServiceDependencies(services map[string]*ServiceConfig) error {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, dependency := range services[name].DependsOn {
+			if _, has := services[dependency]; !has {
+				return fmt.Errorf("service '%s' depends on service '%s' which does not exist", name, dependency)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := map[string]int{}
+	path := []string{}
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			// The cycle starts at the first occurrence of the service in the current path
+			for i, pathName := range path {
+				if pathName == name {
+					cycle := append(append([]string{}, path[i:]...), name)
+					return fmt.Errorf("dependency cycle detected between services: %s", strings.Join(cycle, " -> "))
+				}
+			}
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+
+		for _, dependency := range services[name].DependsOn {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
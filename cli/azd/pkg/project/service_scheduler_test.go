@@ -0,0 +1,200 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/stretchr/testify/require"
+)
+
+func createScheduledServices(dependencies map[string][]string, names ...string) []*Service {
+	services := []*Service{}
+	for _, name := range names {
+		services = append(services, &Service{
+			Config: &ServiceConfig{
+				Name:      name,
+				DependsOn: dependencies[name],
+			},
+		})
+	}
+
+	return services
+}
+
+func TestRunServicesDependencyOrder(t *testing.T) {
+	services := createScheduledServices(map[string][]string{
+		"web":    {"api"},
+		"worker": {"api", "db"},
+	}, "api", "db", "web", "worker")
+
+	var mutex sync.Mutex
+	completed := map[string]bool{}
+
+	results := RunServices(context.Background(), services, 2, func(ctx context.Context, svc *Service) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		for _, dependency := range svc.Config.DependsOn {
+			require.True(t, completed[dependency], "%s ran before its dependency %s", svc.Config.Name, dependency)
+		}
+
+		completed[svc.Config.Name] = true
+		return nil
+	})
+
+	require.Len(t, results, 4)
+	for i, result := range results {
+		require.Equal(t, services[i], result.Service)
+		require.NoError(t, result.Error)
+	}
+
+	require.Len(t, completed, 4)
+}
+
+func TestRunServicesParallelismLimit(t *testing.T) {
+	services := createScheduledServices(nil, "api", "db", "web", "worker")
+
+	var running int32
+	var maxRunning int32
+
+	RunServices(context.Background(), services, 2, func(ctx context.Context, svc *Service) error {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	require.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+}
+
+func TestRunServicesRunsIndependentServicesConcurrently(t *testing.T) {
+	services := createScheduledServices(nil, "api", "web")
+
+	// Each service waits for the other to start, which only completes when both are run at the same time
+	var started sync.WaitGroup
+	started.Add(2)
+
+	results := RunServices(context.Background(), services, 2, func(ctx context.Context, svc *Service) error {
+		started.Done()
+		started.Wait()
+		return nil
+	})
+
+	require.NoError(t, results[0].Error)
+	require.NoError(t, results[1].Error)
+}
+
+func TestRunServicesFailureSkipsDependents(t *testing.T) {
+	services := createScheduledServices(map[string][]string{
+		"web":      {"api"},
+		"frontend": {"web"},
+	}, "api", "frontend", "web", "worker")
+
+	var mutex sync.Mutex
+	ran := []string{}
+
+	results := RunServices(context.Background(), services, 4, func(ctx context.Context, svc *Service) error {
+		mutex.Lock()
+		ran = append(ran, svc.Config.Name)
+		mutex.Unlock()
+
+		if svc.Config.Name == "api" {
+			return errors.New("api failed")
+		}
+
+		return nil
+	})
+
+	require.ElementsMatch(t, []string{"api", "worker"}, ran)
+	require.EqualError(t, results[0].Error, "api failed")
+	require.True(t, errors.Is(results[1].Error, ErrDependencyFailed))
+	require.True(t, errors.Is(results[2].Error, ErrDependencyFailed))
+	require.NoError(t, results[3].Error)
+}
+
+func TestRunServicesIgnoresUnscheduledDependencies(t *testing.T) {
+	services := createScheduledServices(map[string][]string{
+		"web": {"api"},
+	}, "web")
+
+	results := RunServices(context.Background(), services, 1, func(ctx context.Context, svc *Service) error {
+		return nil
+	})
+
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Error)
+}
+
+func TestProjectConfigServiceDependencies(t *testing.T) {
+	e := environment.Environment{Values: make(map[string]string)}
+	e.SetEnvName("test-env")
+
+	t.Run("Valid", func(t *testing.T) {
+		const testProj = `
+name: test-proj
+services:
+  web:
+    project: src/web
+    language: js
+    dependsOn:
+      - api
+  api:
+    project: src/api
+    language: js
+`
+		projectConfig, err := ParseProjectConfig(testProj, &e)
+		require.NoError(t, err)
+		require.Equal(t, []string{"api"}, projectConfig.Services["web"].DependsOn)
+	})
+
+	t.Run("UnknownService", func(t *testing.T) {
+		const testProj = `
+name: test-proj
+services:
+  web:
+    project: src/web
+    language: js
+    dependsOn:
+      - api
+`
+		_, err := ParseProjectConfig(testProj, &e)
+		require.EqualError(t, err, "service 'web' depends on service 'api' which does not exist")
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		const testProj = `
+name: test-proj
+services:
+  api:
+    project: src/api
+    language: js
+    dependsOn:
+      - worker
+  web:
+    project: src/web
+    language: js
+    dependsOn:
+      - api
+  worker:
+    project: src/worker
+    language: js
+    dependsOn:
+      - web
+`
+		_, err := ParseProjectConfig(testProj, &e)
+		require.EqualError(t, err, "dependency cycle detected between services: api -> worker -> web -> api")
+	})
+}
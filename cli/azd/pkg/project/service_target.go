@@ -18,6 +18,7 @@ const (
 	ContainerAppTarget  ServiceTargetKind = "containerapp"
 	AzureFunctionTarget ServiceTargetKind = "function"
 	StaticWebAppTarget  ServiceTargetKind = "staticwebapp"
+	AksTarget           ServiceTargetKind = "aks"
 )
 
 type ServiceDeploymentResult struct {
@@ -26,6 +27,8 @@ type ServiceDeploymentResult struct {
 	Kind             ServiceTargetKind `json:"kind"`
 	Details          interface{}       `json:"details"`
 	Endpoints        []string          `json:"endpoints"`
+	// The container image that was deployed, for the targets deploying container images
+	Image string `json:"image,omitempty"`
 }
 
 type ServiceTarget interface {
@@ -38,6 +41,12 @@ type ServiceTarget interface {
 	Endpoints(ctx context.Context) ([]string, error)
 }
 
+// DeploymentVerifier is implemented by the service targets which can confirm that the artifact of a previous deployment
+// is still the one deployed to the target resource, ex) the image run by a container app
+type DeploymentVerifier interface {
+	IsDeployed(ctx context.Context, result *ServiceDeploymentResult) bool
+}
+
 func NewServiceDeploymentResult(relatedResourceId string, kind ServiceTargetKind, rawResult string, endpoints []string) ServiceDeploymentResult {
 	returnValue := ServiceDeploymentResult{
 		TargetResourceId: relatedResourceId,
@@ -58,7 +67,43 @@ func NewServiceDeploymentResult(relatedResourceId string, kind ServiceTargetKind
 	return returnValue
 }
 
+// ServiceTargetRequirements describes what a kind of service target expects from the infrastructure of the project
+type ServiceTargetRequirements struct {
+	// Outputs are the outputs of the infrastructure the target reads from the environment
+	Outputs []string
+	// Module is true when the target deploys the infrastructure module of the service
+	Module bool
+	// Tagged is true when the target deploys to the Azure resource tagged with the name of the service in `azd-service-name`
+	Tagged bool
+}
+
+var serviceTargetRequirements = map[ServiceTargetKind]ServiceTargetRequirements{
+	AppServiceTarget: {Tagged: true},
+	ContainerAppTarget: {
+		Outputs: []string{environment.ContainerRegistryEndpointEnvVarName},
+		Module:  true,
+		Tagged:  true,
+	},
+	AzureFunctionTarget: {Tagged: true},
+	StaticWebAppTarget:  {Tagged: true},
+	AksTarget: {
+		Outputs: []string{environment.ContainerRegistryEndpointEnvVarName, environment.AksClusterNameEnvVarName},
+	},
+}
+
+// GetServiceTargetRequirements returns the requirements of the service target of a host, ex) containerapp.
+// False is returned when the host is not supported.
+func GetServiceTargetRequirements(host string) (ServiceTargetRequirements, bool) {
+	if host == "" {
+		host = string(AppServiceTarget)
+	}
+
+	requirements, has := serviceTargetRequirements[ServiceTargetKind(host)]
+	return requirements, has
+}
+
 var _ ServiceTarget = &appServiceTarget{}
 var _ ServiceTarget = &containerAppTarget{}
 var _ ServiceTarget = &functionAppTarget{}
 var _ ServiceTarget = &staticWebAppTarget{}
+var _ ServiceTarget = &aksTarget{}
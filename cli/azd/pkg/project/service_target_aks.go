@@ -0,0 +1,365 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package project
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azure"
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/docker"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/kubectl"
+	"github.com/drone/envsubst"
+)
+
+// The folder of the service containing the Kubernetes manifests, when not specified in azure.yaml
+const defaultAksDeploymentPath = "manifests"
+
+// The file names identifying a folder as a kustomize directory
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+type AksOptions struct {
+	// The path of the folder containing the Kubernetes manifests or the kustomize directory, relative to the service
+	DeploymentPath string `yaml:"deploymentPath"`
+	// The namespace of the resources which do not declare a namespace
+	Namespace string `yaml:"namespace"`
+}
+
+type aksTarget struct {
+	config  *ServiceConfig
+	env     *environment.Environment
+	scope   *environment.DeploymentScope
+	cli     azcli.AzCli
+	docker  *docker.Docker
+	kubectl kubectl.KubectlCli
+}
+
+func (t *aksTarget) RequiredExternalTools() []tools.ExternalTool {
+	if t.config.Docker.RemoteBuild {
+		return []tools.ExternalTool{t.cli, t.kubectl}
+	}
+
+	return []tools.ExternalTool{t.cli, t.docker, t.kubectl}
+}
+
+func (t *aksTarget) Deploy(ctx context.Context, azdCtx *environment.AzdContext, path string, progress chan<- string) (ServiceDeploymentResult, error) {
+	image, _, err := pushContainerImage(ctx, t.cli, t.docker, t.env, t.config, t.imageRepository(), path, progress)
+	if err != nil {
+		return ServiceDeploymentResult{}, err
+	}
+
+	progress <- "Rendering Kubernetes manifests"
+	manifestsPath, err := t.writeManifests(ctx)
+	if err != nil {
+		return ServiceDeploymentResult{}, err
+	}
+	defer os.Remove(manifestsPath)
+
+	progress <- "Getting AKS cluster credentials"
+	kubeConfigPath, err := t.writeKubeConfig(ctx)
+	if err != nil {
+		return ServiceDeploymentResult{}, err
+	}
+	defer os.Remove(kubeConfigPath)
+
+	log.Printf("applying kubernetes manifests of service %s", t.config.Name)
+
+	progress <- "Applying Kubernetes manifests"
+	if err := t.kubectl.Apply(ctx, kubeConfigPath, t.config.K8s.Namespace, manifestsPath); err != nil {
+		return ServiceDeploymentResult{}, fmt.Errorf("applying kubernetes manifests: %w", err)
+	}
+
+	progress <- "Fetching endpoints for AKS service"
+	resources, err := t.kubectl.Get(ctx, kubeConfigPath, t.config.K8s.Namespace, manifestsPath)
+	if err != nil {
+		return ServiceDeploymentResult{}, fmt.Errorf("fetching kubernetes resources: %w", err)
+	}
+
+	clusterName, err := t.clusterName()
+	if err != nil {
+		return ServiceDeploymentResult{}, err
+	}
+
+	return ServiceDeploymentResult{
+		TargetResourceId: azure.ManagedClusterRID(t.env.GetSubscriptionId(), t.scope.ResourceGroupName(), clusterName),
+		Kind:             AksTarget,
+		Details:          resources,
+		Endpoints:        kubernetesEndpoints(resources),
+		Image:            image,
+	}, nil
+}
+
+func (t *aksTarget) Endpoints(ctx context.Context) ([]string, error) {
+	manifestsPath, err := t.writeManifests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(manifestsPath)
+
+	kubeConfigPath, err := t.writeKubeConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(kubeConfigPath)
+
+	resources, err := t.kubectl.Get(ctx, kubeConfigPath, t.config.K8s.Namespace, manifestsPath)
+	if err != nil {
+		return nil, fmt.Errorf("fetching kubernetes resources: %w", err)
+	}
+
+	return kubernetesEndpoints(resources), nil
+}
+
+// The cluster is the one set as an output of the infrastructure, otherwise the resource of the service when it is tagged
+// with the name of the service or configured. The default name of the resource of the service is not a cluster.
+func (t *aksTarget) clusterName() (string, error) {
+	if clusterName := t.env.Getenv(environment.AksClusterNameEnvVarName); clusterName != "" {
+		return clusterName, nil
+	}
+
+	resourceName := t.scope.ResourceName()
+	if resourceName == "" || resourceName == defaultServiceResourceName(t.env, t.config.Name) {
+		return "", fmt.Errorf("could not determine the AKS cluster, ensure %s is set as an output of your infrastructure", environment.AksClusterNameEnvVarName)
+	}
+
+	return resourceName, nil
+}
+
+// The services of the project share the cluster, so the images of each service are pushed to a repository of the service
+func (t *aksTarget) imageRepository() string {
+	return strings.ToLower(fmt.Sprintf("%s/%s-%s", t.config.Project.Name, t.config.Name, t.env.GetEnvName()))
+}
+
+// Writes the credentials for the cluster to a temporary kubeconfig file, so the user's kubeconfig is not modified
+func (t *aksTarget) writeKubeConfig(ctx context.Context) (string, error) {
+	clusterName, err := t.clusterName()
+	if err != nil {
+		return "", err
+	}
+
+	kubeConfig, err := os.CreateTemp("", "azdkubeconfig")
+	if err != nil {
+		return "", fmt.Errorf("creating kubeconfig file: %w", err)
+	}
+	kubeConfig.Close()
+
+	err = t.cli.GetManagedClusterCredentials(ctx, t.env.GetSubscriptionId(), t.scope.ResourceGroupName(), clusterName, kubeConfig.Name())
+	if err != nil {
+		os.Remove(kubeConfig.Name())
+		return "", fmt.Errorf("getting credentials for cluster '%s': %w", clusterName, err)
+	}
+
+	return kubeConfig.Name(), nil
+}
+
+// Renders the manifests of the service to a temporary file
+func (t *aksTarget) writeManifests(ctx context.Context) (string, error) {
+	manifests, err := t.renderManifests(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	manifestsFile, err := os.CreateTemp("", "azdmanifests*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("creating manifests file: %w", err)
+	}
+	defer manifestsFile.Close()
+
+	if _, err := manifestsFile.WriteString(manifests); err != nil {
+		os.Remove(manifestsFile.Name())
+		return "", fmt.Errorf("writing manifests file: %w", err)
+	}
+
+	return manifestsFile.Name(), nil
+}
+
+// Renders the manifests of the kustomize directory or the manifest files of the deployment folder, substituting
+// the values of the environment. References to values which are not set are left unchanged.
+func (t *aksTarget) renderManifests(ctx context.Context) (string, error) {
+	deploymentPath := t.config.K8s.DeploymentPath
+	if strings.TrimSpace(deploymentPath) == "" {
+		deploymentPath = defaultAksDeploymentPath
+	}
+
+	directory := filepath.Join(t.config.Path(), deploymentPath)
+	if info, err := os.Stat(directory); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("kubernetes manifests folder '%s' for service '%s' does not exist", directory, t.config.Name)
+	}
+
+	var manifests string
+	if isKustomizeDirectory(directory) {
+		rendered, err := t.kubectl.Kustomize(ctx, directory)
+		if err != nil {
+			return "", fmt.Errorf("rendering kustomize directory: %w", err)
+		}
+
+		manifests = rendered
+	} else {
+		read, err := readManifestFiles(directory)
+		if err != nil {
+			return "", err
+		}
+
+		manifests = read
+	}
+
+	replaced, err := envsubst.Eval(manifests, func(name string) string {
+		if val, has := t.env.Lookup(name); has {
+			return val
+		}
+
+		if val, has := os.LookupEnv(name); has {
+			return val
+		}
+
+		return fmt.Sprintf("${%s}", name)
+	})
+	if err != nil {
+		return "", fmt.Errorf("substituting environment values in manifests: %w", err)
+	}
+
+	return replaced, nil
+}
+
+func isKustomizeDirectory(directory string) bool {
+	for _, name := range kustomizationFileNames {
+		if _, err := os.Stat(filepath.Join(directory, name)); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reads the YAML files of the folder in order of their names, as a single multi-document manifest
+func readManifestFiles(directory string) (string, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return "", fmt.Errorf("reading kubernetes manifests: %w", err)
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return "", fmt.Errorf("no kubernetes manifests found in '%s'", directory)
+	}
+
+	documents := make([]string, len(names))
+	for i, name := range names {
+		content, err := os.ReadFile(filepath.Join(directory, name))
+		if err != nil {
+			return "", fmt.Errorf("reading kubernetes manifest '%s': %w", name, err)
+		}
+
+		documents[i] = strings.TrimSpace(string(content))
+	}
+
+	return strings.Join(documents, "\n---\n") + "\n", nil
+}
+
+// Gets the endpoints exposed by the ingresses & the load balancer services of the resources.
+// Resources which are not assigned an address yet do not expose an endpoint.
+func kubernetesEndpoints(resources []kubectl.Resource) []string {
+	endpoints := []string{}
+
+	for _, resource := range resources {
+		switch resource.Kind {
+		case "Ingress":
+			tlsHosts := map[string]bool{}
+			for _, tls := range resource.Spec.Tls {
+				for _, host := range tls.Hosts {
+					tlsHosts[host] = true
+				}
+			}
+
+			for _, rule := range resource.Spec.Rules {
+				host := rule.Host
+				if host == "" {
+					host = loadBalancerAddress(resource.Status.LoadBalancer)
+				}
+
+				if host == "" {
+					continue
+				}
+
+				scheme := "http"
+				if tlsHosts[rule.Host] {
+					scheme = "https"
+				}
+
+				path := "/"
+				if rule.Http != nil && len(rule.Http.Paths) > 0 && rule.Http.Paths[0].Path != "" {
+					path = rule.Http.Paths[0].Path
+				}
+
+				endpoints = append(endpoints, fmt.Sprintf("%s://%s%s", scheme, host, path))
+			}
+		case "Service":
+			address := loadBalancerAddress(resource.Status.LoadBalancer)
+			if resource.Spec.Type != "LoadBalancer" || address == "" {
+				continue
+			}
+
+			for _, port := range resource.Spec.Ports {
+				switch port.Port {
+				case 80:
+					endpoints = append(endpoints, fmt.Sprintf("http://%s/", address))
+				case 443:
+					endpoints = append(endpoints, fmt.Sprintf("https://%s/", address))
+				default:
+					endpoints = append(endpoints, fmt.Sprintf("http://%s:%d/", address, port.Port))
+				}
+			}
+		}
+	}
+
+	return endpoints
+}
+
+func loadBalancerAddress(status kubectl.LoadBalancerStatus) string {
+	for _, ingress := range status.Ingress {
+		if ingress.Hostname != "" {
+			return ingress.Hostname
+		}
+
+		if ingress.Ip != "" {
+			return ingress.Ip
+		}
+	}
+
+	return ""
+}
+
+func NewAksTarget(
+	config *ServiceConfig,
+	env *environment.Environment,
+	scope *environment.DeploymentScope,
+	azCli azcli.AzCli,
+	docker *docker.Docker,
+	kubectl kubectl.KubectlCli,
+) ServiceTarget {
+	return &aksTarget{
+		config:  config,
+		env:     env,
+		scope:   scope,
+		cli:     azCli,
+		docker:  docker,
+		kubectl: kubectl,
+	}
+}
@@ -0,0 +1,246 @@
+package project
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/docker"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/kubectl"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKubectlCli records the manifests applied to the cluster and returns the configured resources
+type fakeKubectlCli struct {
+	kubectl.KubectlCli
+	kustomized       string
+	appliedManifests string
+	namespace        string
+	resources        []kubectl.Resource
+}
+
+func (f *fakeKubectlCli) Kustomize(ctx context.Context, directory string) (string, error) {
+	return f.kustomized, nil
+}
+
+func (f *fakeKubectlCli) Apply(ctx context.Context, kubeConfigPath string, namespace string, manifestsPath string) error {
+	content, err := os.ReadFile(manifestsPath)
+	if err != nil {
+		return err
+	}
+
+	f.appliedManifests = string(content)
+	f.namespace = namespace
+	return nil
+}
+
+func (f *fakeKubectlCli) Get(ctx context.Context, kubeConfigPath string, namespace string, manifestsPath string) ([]kubectl.Resource, error) {
+	return f.resources, nil
+}
+
+func createAksTestTarget(t *testing.T, kubectlCli kubectl.KubectlCli, k8s AksOptions) (ServiceTarget, *[]string) {
+	const testProj = `
+name: test-proj
+services:
+  api:
+    project: src/api
+    language: js
+    host: aks
+`
+
+	env := environment.Environment{Values: map[string]string{
+		environment.ContainerRegistryEndpointEnvVarName: "registry.azurecr.io",
+		environment.AksClusterNameEnvVarName:            "aks-cluster",
+		environment.SubscriptionIdEnvVarName:            "SUBSCRIPTION_ID",
+		"API_REPLICAS":                                  "3",
+	}}
+	env.SetEnvName("test-env")
+
+	projectConfig, err := ParseProjectConfig(testProj, &env)
+	require.NoError(t, err)
+	projectConfig.Path = t.TempDir()
+
+	serviceConfig := projectConfig.Services["api"]
+	serviceConfig.K8s = k8s
+
+	azCliCommands := []string{}
+	azCli := azcli.NewAzCli(azcli.NewAzCliArgs{
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			azCliCommands = append(azCliCommands, strings.Join(args.Args[:2], " "))
+			return executil.NewRunResult(0, "", ""), nil
+		},
+	})
+
+	dockerCli := docker.NewDocker(docker.DockerArgs{
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			return executil.NewRunResult(0, "", ""), nil
+		},
+	})
+
+	scope := environment.NewDeploymentScope("SUBSCRIPTION_ID", "rg-test", "test-envapi")
+	return NewAksTarget(serviceConfig, &env, scope, azCli, dockerCli, kubectlCli), &azCliCommands
+}
+
+func writeManifest(t *testing.T, directory string, name string, content string) {
+	require.NoError(t, os.MkdirAll(directory, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, name), []byte(content), 0600))
+}
+
+func TestAksTargetDeploy(t *testing.T) {
+	kubectlCli := &fakeKubectlCli{
+		resources: []kubectl.Resource{
+			{
+				Kind: "Service",
+				Spec: kubectl.ResourceSpec{
+					Type:  "LoadBalancer",
+					Ports: []kubectl.ServicePort{{Port: 80}},
+				},
+				Status: kubectl.ResourceStatus{
+					LoadBalancer: kubectl.LoadBalancerStatus{
+						Ingress: []kubectl.LoadBalancerIngress{{Ip: "20.1.2.3"}},
+					},
+				},
+			},
+		},
+	}
+
+	target, azCliCommands := createAksTestTarget(t, kubectlCli, AksOptions{Namespace: "apps"})
+	aksTarget := target.(*aksTarget)
+
+	manifestsPath := filepath.Join(aksTarget.config.Path(), "manifests")
+	writeManifest(t, manifestsPath, "deployment.yaml", "kind: Deployment\nspec:\n  replicas: ${API_REPLICAS}\n  image: ${SERVICE_API_IMAGE_NAME}\n")
+	writeManifest(t, manifestsPath, "service.yml", "kind: Service\nmetadata:\n  name: ${UNKNOWN_VALUE}\n")
+	writeManifest(t, manifestsPath, "README.md", "not a manifest")
+
+	progress := make(chan string)
+	go func() {
+		for range progress {
+		}
+	}()
+
+	result, err := target.Deploy(context.Background(), nil, "imageId", progress)
+	close(progress)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"acr login", "aks get-credentials"}, *azCliCommands)
+	require.Equal(t, AksTarget, result.Kind)
+	require.Equal(t, []string{"http://20.1.2.3/"}, result.Endpoints)
+	require.True(t, strings.HasPrefix(result.Image, "registry.azurecr.io/test-proj/api-test-env:azdev-deploy-"))
+	require.Equal(
+		t,
+		"/subscriptions/SUBSCRIPTION_ID/resourceGroups/rg-test/providers/Microsoft.ContainerService/managedClusters/aks-cluster",
+		result.TargetResourceId,
+	)
+
+	require.Equal(t, "apps", kubectlCli.namespace)
+	require.Equal(
+		t,
+		"kind: Deployment\nspec:\n  replicas: 3\n  image: "+result.Image+"\n---\nkind: Service\nmetadata:\n  name: ${UNKNOWN_VALUE}\n",
+		kubectlCli.appliedManifests,
+	)
+}
+
+func TestAksTargetKustomize(t *testing.T) {
+	kubectlCli := &fakeKubectlCli{
+		kustomized: "kind: Deployment\nspec:\n  replicas: ${API_REPLICAS}\n",
+	}
+
+	target, _ := createAksTestTarget(t, kubectlCli, AksOptions{DeploymentPath: "k8s/overlays/dev"})
+	aksTarget := target.(*aksTarget)
+
+	writeManifest(t, filepath.Join(aksTarget.config.Path(), "k8s", "overlays", "dev"), "kustomization.yaml", "resources: []")
+
+	manifests, err := aksTarget.renderManifests(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "kind: Deployment\nspec:\n  replicas: 3\n", manifests)
+}
+
+func TestAksTargetMissingManifests(t *testing.T) {
+	target, _ := createAksTestTarget(t, &fakeKubectlCli{}, AksOptions{})
+
+	_, err := target.Endpoints(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "kubernetes manifests folder")
+}
+
+func TestAksTargetClusterName(t *testing.T) {
+	target, _ := createAksTestTarget(t, &fakeKubectlCli{}, AksOptions{})
+	aksTarget := target.(*aksTarget)
+
+	clusterName, err := aksTarget.clusterName()
+	require.NoError(t, err)
+	require.Equal(t, "aks-cluster", clusterName)
+
+	// Without the output of the infrastructure, the resource of the service is the cluster when it was found
+	delete(aksTarget.env.Values, environment.AksClusterNameEnvVarName)
+	aksTarget.scope = environment.NewDeploymentScope("SUBSCRIPTION_ID", "rg-test", "aks-tagged")
+
+	clusterName, err = aksTarget.clusterName()
+	require.NoError(t, err)
+	require.Equal(t, "aks-tagged", clusterName)
+
+	// The default name of the resource of the service does not name a cluster
+	aksTarget.scope = environment.NewDeploymentScope("SUBSCRIPTION_ID", "rg-test", "test-envapi")
+
+	_, err = aksTarget.clusterName()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), environment.AksClusterNameEnvVarName)
+}
+
+func TestKubernetesEndpoints(t *testing.T) {
+	resources := []kubectl.Resource{
+		{
+			Kind: "Ingress",
+			Spec: kubectl.ResourceSpec{
+				Rules: []kubectl.IngressRule{
+					{Host: "api.contoso.com", Http: &kubectl.IngressRuleHttp{Paths: []kubectl.IngressPath{{Path: "/api"}}}},
+					{Host: "www.contoso.com"},
+					{},
+				},
+				Tls: []kubectl.IngressTls{{Hosts: []string{"api.contoso.com"}}},
+			},
+			Status: kubectl.ResourceStatus{
+				LoadBalancer: kubectl.LoadBalancerStatus{
+					Ingress: []kubectl.LoadBalancerIngress{{Ip: "20.1.2.3"}},
+				},
+			},
+		},
+		{
+			Kind: "Service",
+			Spec: kubectl.ResourceSpec{
+				Type:  "LoadBalancer",
+				Ports: []kubectl.ServicePort{{Port: 443}, {Port: 8080}},
+			},
+			Status: kubectl.ResourceStatus{
+				LoadBalancer: kubectl.LoadBalancerStatus{
+					Ingress: []kubectl.LoadBalancerIngress{{Hostname: "api.westus.cloudapp.azure.com"}},
+				},
+			},
+		},
+		{
+			// Pending load balancer
+			Kind: "Service",
+			Spec: kubectl.ResourceSpec{Type: "LoadBalancer", Ports: []kubectl.ServicePort{{Port: 80}}},
+		},
+		{
+			Kind: "Service",
+			Spec: kubectl.ResourceSpec{Type: "ClusterIP", Ports: []kubectl.ServicePort{{Port: 80}}},
+		},
+		{
+			Kind: "Deployment",
+		},
+	}
+
+	require.Equal(t, []string{
+		"https://api.contoso.com/api",
+		"http://www.contoso.com/",
+		"http://20.1.2.3/",
+		"https://api.westus.cloudapp.azure.com/",
+		"http://api.westus.cloudapp.azure.com:8080/",
+	}, kubernetesEndpoints(resources))
+}
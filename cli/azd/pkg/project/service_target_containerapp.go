@@ -5,10 +5,14 @@ package project
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -20,67 +24,55 @@ import (
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
 	bicepTool "github.com/azure/azure-dev/cli/azd/pkg/tools/bicep"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/docker"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/git"
 	"github.com/drone/envsubst"
 )
 
 type containerAppTarget struct {
-	config *ServiceConfig
-	env    *environment.Environment
-	scope  *environment.DeploymentScope
-	cli    azcli.AzCli
-	docker *docker.Docker
+	config   *ServiceConfig
+	env      *environment.Environment
+	scope    *environment.DeploymentScope
+	cli      azcli.AzCli
+	bicepCli bicepTool.BicepCli
+	docker   *docker.Docker
 }
 
 func (at *containerAppTarget) RequiredExternalTools() []tools.ExternalTool {
+	if at.config.Docker.RemoteBuild {
+		return []tools.ExternalTool{at.cli}
+	}
+
 	return []tools.ExternalTool{at.cli, at.docker}
 }
 
 func (at *containerAppTarget) Deploy(ctx context.Context, azdCtx *environment.AzdContext, path string, progress chan<- string) (ServiceDeploymentResult, error) {
-	bicepPath := azdCtx.BicepModulePath(at.config.Module)
-
-	progress <- "Creating deployment template"
-	template, err := bicep.Compile(ctx, bicepTool.NewBicepCli(bicepTool.NewBicepCliArgs{AzCli: at.cli}), bicepPath)
+	image, pushed, err := pushContainerImage(ctx, at.cli, at.docker, at.env, at.config, at.imageRepository(), path, progress)
 	if err != nil {
 		return ServiceDeploymentResult{}, err
 	}
 
-	// Login to container registry.
-	loginServer, has := at.env.Values[environment.ContainerRegistryEndpointEnvVarName]
-	if !has {
-		return ServiceDeploymentResult{}, fmt.Errorf("could not determine container registry endpoint, ensure %s is set as an output of your infrastructure", environment.ContainerRegistryEndpointEnvVarName)
-	}
-
-	log.Printf("logging into registry %s", loginServer)
-
-	progress <- "Logging into container registry"
-	if err := at.cli.LoginAcr(ctx, at.env.GetSubscriptionId(), loginServer); err != nil {
-		return ServiceDeploymentResult{}, fmt.Errorf("logging into registry '%s': %w", loginServer, err)
-	}
-
-	fullTag := fmt.Sprintf("%s/%s/%s:azdev-deploy-%d", loginServer, at.scope.ResourceName(), at.scope.ResourceName(), time.Now().Unix())
-
-	// Tag image.
-	log.Printf("tagging image %s as %s", path, fullTag)
-	progress <- "Tagging image"
-	if err := at.docker.Tag(ctx, at.config.Path(), path, fullTag); err != nil {
-		return ServiceDeploymentResult{}, fmt.Errorf("tagging image: %w", err)
-	}
-
-	log.Printf("pushing %s to registry", fullTag)
+	// The revision is not updated when the container app already runs the image
+	if !pushed && at.isRunning(ctx, image) {
+		progress <- "Container app is up to date, skipping revision update"
+		endpoints, err := at.Endpoints(ctx)
+		if err != nil {
+			return ServiceDeploymentResult{}, err
+		}
 
-	// Push image.
-	progress <- "Pushing container image"
-	if err := at.docker.Push(ctx, at.config.Path(), fullTag); err != nil {
-		return ServiceDeploymentResult{}, fmt.Errorf("pushing image: %w", err)
+		return ServiceDeploymentResult{
+			TargetResourceId: azure.ContainerAppRID(at.env.GetSubscriptionId(), at.scope.ResourceGroupName(), at.scope.ResourceName()),
+			Kind:             ContainerAppTarget,
+			Endpoints:        endpoints,
+			Image:            image,
+		}, nil
 	}
 
-	log.Printf("writing image name to environment")
-
-	// Save the name of the image we pushed into the environment with a well known key.
-	at.env.Values[fmt.Sprintf("SERVICE_%s_IMAGE_NAME", strings.ToUpper(at.config.Name))] = fullTag
+	bicepPath := azdCtx.BicepModulePath(at.config.Module)
 
-	if err := at.env.Save(); err != nil {
-		return ServiceDeploymentResult{}, fmt.Errorf("saving image name to environment: %w", err)
+	progress <- "Creating deployment template"
+	template, err := bicep.Compile(ctx, at.bicepCli, bicepPath)
+	if err != nil {
+		return ServiceDeploymentResult{}, err
 	}
 
 	log.Print("generating deployment parameters file")
@@ -93,7 +85,7 @@ func (at *containerAppTarget) Deploy(ctx context.Context, azdCtx *environment.Az
 	}
 
 	replaced, err := envsubst.Eval(string(templateBytes), func(name string) string {
-		if val, has := at.env.Values[name]; has {
+		if val, has := at.env.Lookup(name); has {
 			return val
 		}
 		return os.Getenv(name)
@@ -132,7 +124,7 @@ func (at *containerAppTarget) Deploy(ctx context.Context, azdCtx *environment.Az
 		template.CanonicalizeDeploymentOutputs(&res.Properties.Outputs)
 
 		for name, o := range res.Properties.Outputs {
-			at.env.Values[name] = fmt.Sprintf("%v", o.Value)
+			at.env.SetValue(name, fmt.Sprintf("%v", o.Value))
 		}
 
 		if err := at.env.Save(); err != nil {
@@ -151,6 +143,7 @@ func (at *containerAppTarget) Deploy(ctx context.Context, azdCtx *environment.Az
 		Kind:             ContainerAppTarget,
 		Details:          res,
 		Endpoints:        endpoints,
+		Image:            image,
 	}, nil
 }
 
@@ -163,12 +156,376 @@ func (at *containerAppTarget) Endpoints(ctx context.Context) ([]string, error) {
 	return []string{fmt.Sprintf("https://%s/", containerAppProperties.Properties.Configuration.Ingress.Fqdn)}, nil
 }
 
+// IsDeployed returns true when the container app still runs the image of the deployment
+func (at *containerAppTarget) IsDeployed(ctx context.Context, result *ServiceDeploymentResult) bool {
+	return result.Image != "" && at.isRunning(ctx, result.Image)
+}
+
+// isRunning returns true when a container of the container app runs the image
+func (at *containerAppTarget) isRunning(ctx context.Context, image string) bool {
+	containerAppProperties, err := at.cli.GetContainerAppProperties(ctx, at.env.GetSubscriptionId(), at.scope.ResourceGroupName(), at.scope.ResourceName())
+	if err != nil {
+		log.Printf("failed getting the image of the container app, updating the revision: %v", err)
+		return false
+	}
+
+	for _, container := range containerAppProperties.Properties.Template.Containers {
+		if container.Image == image {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The repository of the registry where the images of the container app are pushed
+func (at *containerAppTarget) imageRepository() string {
+	return fmt.Sprintf("%s/%s", at.scope.ResourceName(), at.scope.ResourceName())
+}
+
+// The name of the environment value where the name of the image pushed for the service is saved
+func serviceImageEnvVarName(config *ServiceConfig) string {
+	return fmt.Sprintf("SERVICE_%s_IMAGE_NAME", strings.ToUpper(config.Name))
+}
+
+// The name of the environment value where the digest of the image pushed for the service is saved
+func serviceImageDigestEnvVarName(config *ServiceConfig) string {
+	return fmt.Sprintf("SERVICE_%s_IMAGE_DIGEST", strings.ToUpper(config.Name))
+}
+
+// Pushes the image of the service to the repository in the container registry of the environment, returning the name
+// of the pushed image and whether an image was pushed.
+// The name & the digest of the image are saved in the environment, so it can be referenced by the deployment templates.
+// When the digest of the image matches the digest of the last image pushed for the service, nothing is pushed and the
+// name of the last image is returned.
+func pushContainerImage(
+	ctx context.Context,
+	cli azcli.AzCli,
+	docker *docker.Docker,
+	env *environment.Environment,
+	config *ServiceConfig,
+	repository string,
+	path string,
+	progress chan<- string,
+) (string, bool, error) {
+	// Login to container registry.
+	loginServer, has := env.Lookup(environment.ContainerRegistryEndpointEnvVarName)
+	if !has {
+		return "", false, fmt.Errorf("could not determine container registry endpoint, ensure %s is set as an output of your infrastructure", environment.ContainerRegistryEndpointEnvVarName)
+	}
+
+	if config.Docker.RemoteBuild {
+		return buildContainerImageInRegistry(ctx, cli, env, config, loginServer, repository, path, progress)
+	}
+
+	log.Printf("logging into registry %s", loginServer)
+
+	progress <- "Logging into container registry"
+	if err := cli.LoginAcr(ctx, env.GetSubscriptionId(), loginServer); err != nil {
+		return "", false, fmt.Errorf("logging into registry '%s': %w", loginServer, err)
+	}
+
+	// When redeploying an image from the registry, ex) when rolling back to a previous deployment,
+	// the image is pulled so it can be tagged for this deployment.
+	if strings.HasPrefix(path, loginServer+"/") {
+		log.Printf("pulling image %s", path)
+		progress <- "Pulling container image"
+		if err := docker.Pull(ctx, config.Path(), path); err != nil {
+			return "", false, fmt.Errorf("pulling image: %w", err)
+		}
+	}
+
+	digest, err := docker.ImageId(ctx, config.Path(), path)
+	if err != nil {
+		return "", false, err
+	}
+
+	if image, unchanged := unchangedServiceImage(ctx, cli, docker, env, config, loginServer, digest, path); unchanged {
+		log.Printf("image %s of %s is unchanged, skipping push", image, config.Name)
+		progress <- "Container image is unchanged, skipping push"
+		return image, false, nil
+	}
+
+	tag, err := renderImageTag(ctx, config, env, digest)
+	if err != nil {
+		return "", false, err
+	}
+
+	fullTag := fmt.Sprintf("%s/%s:%s", loginServer, repository, tag)
+
+	// Tag image.
+	log.Printf("tagging image %s as %s", path, fullTag)
+	progress <- "Tagging image"
+	if err := docker.Tag(ctx, config.Path(), path, fullTag); err != nil {
+		return "", false, fmt.Errorf("tagging image: %w", err)
+	}
+
+	log.Printf("pushing %s to registry", fullTag)
+
+	// Push image.
+	progress <- "Pushing container image"
+	if err := docker.Push(ctx, config.Path(), fullTag); err != nil {
+		return "", false, fmt.Errorf("pushing image: %w", err)
+	}
+
+	return fullTag, true, saveServiceImage(env, config, fullTag, digest)
+}
+
+// Builds the image of the service in the container registry from the tarball of the build context, streaming the log
+// of the build as progress, and returns the name of the built image and whether an image was built.
+// The digest of a remote build is the digest of its build context.
+func buildContainerImageInRegistry(
+	ctx context.Context,
+	cli azcli.AzCli,
+	env *environment.Environment,
+	config *ServiceConfig,
+	loginServer string,
+	repository string,
+	path string,
+	progress chan<- string,
+) (string, bool, error) {
+	// An image from the registry, ex) when rolling back to a previous deployment, is deployed as is
+	if strings.HasPrefix(path, loginServer+"/") {
+		return path, true, saveServiceImage(env, config, path, "")
+	}
+	defer os.Remove(path)
+
+	digest, err := fileDigest(path)
+	if err != nil {
+		return "", false, err
+	}
+
+	if image, unchanged := unchangedServiceImage(ctx, cli, nil, env, config, loginServer, digest, ""); unchanged {
+		log.Printf("build context of %s is unchanged, skipping build of %s", config.Name, image)
+		progress <- "Build context is unchanged, skipping image build"
+		return image, false, nil
+	}
+
+	dockerOptions := getDockerOptionsWithDefaults(config.Docker)
+	buildOptions, err := getDockerBuildOptions(dockerOptions, env)
+	if err != nil {
+		return "", false, fmt.Errorf("building container: %s: %w", config.Name, err)
+	}
+
+	dockerfilePath, err := relativeDockerfilePath(config, dockerOptions)
+	if err != nil {
+		return "", false, err
+	}
+
+	tag, err := renderImageTag(ctx, config, env, digest)
+	if err != nil {
+		return "", false, err
+	}
+
+	image := fmt.Sprintf("%s:%s", repository, tag)
+	log.Printf("building image %s in registry %s", image, loginServer)
+
+	progress <- "Building container image in the registry"
+	logWriter := &progressWriter{progress: progress}
+	err = cli.BuildAcrImage(ctx, env.GetSubscriptionId(), loginServer, azcli.AcrBuildArgs{
+		SourcePath:     path,
+		DockerfilePath: dockerfilePath,
+		ImageNames:     []string{image},
+		Target:         buildOptions.Target,
+		BuildArgs:      buildOptions.BuildArgs,
+		Platform:       buildOptions.Platform,
+	}, logWriter)
+	logWriter.Flush()
+	if err != nil {
+		return "", false, fmt.Errorf("building image in registry '%s': %w", loginServer, err)
+	}
+
+	fullTag := fmt.Sprintf("%s/%s", loginServer, image)
+	return fullTag, true, saveServiceImage(env, config, fullTag, digest)
+}
+
+// DefaultImageTagTemplate is the template of the tags of the images pushed on deploy when the service has no tag template
+const DefaultImageTagTemplate = "azdev-deploy-{{timestamp}}"
+
+// imageTagPlaceholderRegex matches the placeholders of image tag templates, ex) {{gitSha}}
+var imageTagPlaceholderRegex = regexp.MustCompile(`{{\s*([A-Za-z]+)\s*}}`)
+
+// imageTagRegex matches the valid tags of Docker images
+var imageTagRegex = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+
+// renderImageTag returns the tag of the image pushed for the service, rendering the tag template of the service.
+// The placeholders of the template are:
+//   - {{envName}}: the name of the environment
+//   - {{gitSha}} & {{gitShortSha}}: the full & the abbreviated id of the current commit of the project
+//   - {{timestamp}}: the current time as a unix timestamp
+//   - {{digest}}: the first 12 characters of the digest of the image
+func renderImageTag(ctx context.Context, config *ServiceConfig, env *environment.Environment, digest string) (string, error) {
+	template := config.Docker.Tag
+	if template == "" {
+		template = DefaultImageTagTemplate
+	}
+
+	var renderErr error
+	tag := imageTagPlaceholderRegex.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := imageTagPlaceholderRegex.FindStringSubmatch(placeholder)[1]
+
+		switch name {
+		case "envName":
+			return env.GetEnvName()
+		case "timestamp":
+			return fmt.Sprintf("%d", time.Now().Unix())
+		case "digest":
+			hash := strings.TrimPrefix(digest, "sha256:")
+			if len(hash) > 12 {
+				hash = hash[:12]
+			}
+			return hash
+		case "gitSha", "gitShortSha":
+			commit, err := git.NewGitCli().GetCurrentCommit(ctx, config.Project.Path)
+			if err != nil {
+				renderErr = fmt.Errorf("the %s placeholder of the image tag requires a git repository: %w", placeholder, err)
+				return placeholder
+			}
+
+			if name == "gitShortSha" && len(commit) > 7 {
+				commit = commit[:7]
+			}
+			return commit
+		}
+
+		renderErr = fmt.Errorf("unknown placeholder %s in the image tag template '%s'", placeholder, template)
+		return placeholder
+	})
+
+	if renderErr != nil {
+		return "", renderErr
+	}
+
+	if !imageTagRegex.MatchString(tag) {
+		return "", fmt.Errorf("'%s' rendered from the image tag template '%s' is not a valid image tag", tag, template)
+	}
+
+	return tag, nil
+}
+
+// unchangedServiceImage returns the name of the last image pushed for the service, when its digest is the same and the
+// image is still in the registry, ex) the registry may have been recreated since. When the image is built locally, the
+// image in the registry must also be the one pushed from the local image, so that an overwritten tag is pushed again.
+func unchangedServiceImage(
+	ctx context.Context,
+	cli azcli.AzCli,
+	docker *docker.Docker,
+	env *environment.Environment,
+	config *ServiceConfig,
+	loginServer string,
+	digest string,
+	localImage string,
+) (string, bool) {
+	image := env.Getenv(serviceImageEnvVarName(config))
+	if digest == "" || image == "" || env.Getenv(serviceImageDigestEnvVarName(config)) != digest {
+		return "", false
+	}
+
+	if !strings.HasPrefix(image, loginServer+"/") {
+		log.Printf("image %s of %s was pushed to another registry than %s", image, config.Name, loginServer)
+		return "", false
+	}
+
+	manifestDigest, err := cli.GetAcrImageDigest(ctx, loginServer, strings.TrimPrefix(image, loginServer+"/"))
+	if errors.Is(err, azcli.ErrAcrImageNotFound) {
+		log.Printf("image %s of %s is no longer in the registry", image, config.Name)
+		return "", false
+	} else if err != nil {
+		log.Printf("checking image %s of %s in the registry: %v", image, config.Name, err)
+		return "", false
+	}
+
+	if localImage == "" {
+		return image, true
+	}
+
+	repoDigests, err := docker.RepoDigests(ctx, config.Path(), localImage)
+	if err != nil {
+		log.Printf("reading the repo digests of image %s: %v", localImage, err)
+		return "", false
+	}
+
+	repository := image[:strings.LastIndex(image, ":")]
+	for _, repoDigest := range repoDigests {
+		if repoDigest == repository+"@"+manifestDigest {
+			return image, true
+		}
+	}
+
+	log.Printf("image %s in the registry was not pushed from the local image of %s", image, config.Name)
+	return "", false
+}
+
+// fileDigest returns the sha256 digest of the contents of a file, ex) sha256:e3b0c442...
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("computing digest of %s: %w", path, err)
+	}
+
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
+
+// Saves the name & the digest of the image deployed for the service into the environment with well known keys.
+func saveServiceImage(env *environment.Environment, config *ServiceConfig, image string, digest string) error {
+	log.Printf("writing image name to environment")
+
+	env.SetValue(serviceImageEnvVarName(config), image)
+	if digest != "" {
+		env.SetValue(serviceImageDigestEnvVarName(config), digest)
+	} else {
+		env.DeleteValue(serviceImageDigestEnvVarName(config))
+	}
+
+	if err := env.Save(); err != nil {
+		return fmt.Errorf("saving image name to environment: %w", err)
+	}
+
+	return nil
+}
+
+// progressWriter reports each line written to it as progress
+type progressWriter struct {
+	progress chan<- string
+	line     []byte
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b == '\n' {
+			w.Flush()
+			continue
+		}
+
+		w.line = append(w.line, b)
+	}
+
+	return len(p), nil
+}
+
+// Flush reports the line written so far, if any
+func (w *progressWriter) Flush() {
+	line := strings.TrimSpace(string(w.line))
+	w.line = w.line[:0]
+
+	if line != "" {
+		w.progress <- line
+	}
+}
+
 func NewContainerAppTarget(config *ServiceConfig, env *environment.Environment, scope *environment.DeploymentScope, azCli azcli.AzCli, docker *docker.Docker) ServiceTarget {
 	return &containerAppTarget{
-		config: config,
-		env:    env,
-		scope:  scope,
-		cli:    azCli,
-		docker: docker,
+		config:   config,
+		env:      env,
+		scope:    scope,
+		cli:      azCli,
+		bicepCli: bicepTool.NewBicepCli(bicepTool.NewBicepCliArgs{AzCli: azCli}),
+		docker:   docker,
 	}
 }
@@ -0,0 +1,314 @@
+package project
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
+	bicepTool "github.com/azure/azure-dev/cli/azd/pkg/tools/bicep"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/docker"
+	"github.com/stretchr/testify/require"
+)
+
+// The digest of the manifest the local image was pushed as
+const testManifestDigest = "sha256:f0e1d2c3b4a5968778695a4b3c2d1e0f"
+
+func createContainerAppTestTarget(t *testing.T, imageId string, runningImage string) (*containerAppTarget, *fakeAcrCli, *[]string) {
+	const testProj = `
+name: test-proj
+services:
+  api:
+    project: src/api
+    language: js
+    host: containerapp
+    docker:
+      tag: "{{envName}}-{{digest}}"
+`
+
+	env := environment.Environment{Values: map[string]string{
+		environment.ContainerRegistryEndpointEnvVarName: "registry.azurecr.io",
+		environment.SubscriptionIdEnvVarName:            "SUBSCRIPTION_ID",
+	}}
+	env.SetEnvName("test-env")
+
+	projectConfig, err := ParseProjectConfig(testProj, &env)
+	require.NoError(t, err)
+	projectConfig.Path = t.TempDir()
+
+	commands := []string{}
+	azCli := azcli.NewAzCli(azcli.NewAzCliArgs{
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			commands = append(commands, "az "+strings.Join(args.Args[:2], " "))
+			if args.Args[0] == "resource" {
+				return executil.NewRunResult(0, `{"properties":{"template":{"containers":[{"name":"main","image":"`+runningImage+`"}]},"configuration":{"ingress":{"fqdn":"api.contoso.com"}}}}`, ""), nil
+			}
+
+			return executil.NewRunResult(0, "", ""), nil
+		},
+	})
+
+	dockerCli := docker.NewDocker(docker.DockerArgs{
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			commands = append(commands, "docker "+args.Args[0])
+			if args.Args[0] == "image" && args.Args[3] == "{{json .RepoDigests}}" {
+				return executil.NewRunResult(0, `["registry.azurecr.io/ca-api/ca-api@`+testManifestDigest+`"]`, ""), nil
+			} else if args.Args[0] == "image" {
+				return executil.NewRunResult(0, imageId+"\n", ""), nil
+			}
+
+			return executil.NewRunResult(0, "", ""), nil
+		},
+	})
+
+	scope := environment.NewDeploymentScope("SUBSCRIPTION_ID", "rg-test", "ca-api")
+	registry := &fakeAcrCli{AzCli: azCli, images: map[string]string{}}
+	target := NewContainerAppTarget(projectConfig.Services["api"], &env, scope, registry, dockerCli)
+	return target.(*containerAppTarget), registry, &commands
+}
+
+func TestContainerAppTargetUnchangedImage(t *testing.T) {
+	const imageId = "sha256:8a9d2b7c61f0e4d3c2b1a09876543210fedcba9876543210fedcba9876543210"
+	const image = "registry.azurecr.io/ca-api/ca-api:test-env-8a9d2b7c61f0"
+
+	target, registry, commands := createContainerAppTestTarget(t, imageId, image)
+	target.env.Values["SERVICE_API_IMAGE_NAME"] = image
+	target.env.Values["SERVICE_API_IMAGE_DIGEST"] = imageId
+	registry.images[image] = testManifestDigest
+
+	progress := make(chan string)
+	progressMessages := []string{}
+	done := make(chan bool)
+	go func() {
+		for value := range progress {
+			progressMessages = append(progressMessages, value)
+		}
+		done <- true
+	}()
+
+	result, err := target.Deploy(context.Background(), nil, imageId, progress)
+	close(progress)
+	<-done
+	require.NoError(t, err)
+
+	require.Equal(t, image, result.Image)
+	require.Equal(t, []string{"https://api.contoso.com/"}, result.Endpoints)
+	require.Equal(t, []string{"az acr login", "docker image", "docker image", "az resource show", "az resource show"}, *commands)
+	require.Contains(t, progressMessages, "Container image is unchanged, skipping push")
+	require.Contains(t, progressMessages, "Container app is up to date, skipping revision update")
+
+	// The deployment is confirmed while the container app runs its image
+	require.True(t, target.IsDeployed(context.Background(), &ServiceDeploymentResult{Image: image}))
+	require.False(t, target.IsDeployed(context.Background(), &ServiceDeploymentResult{Image: image + "-other"}))
+}
+
+func TestPushContainerImageDigest(t *testing.T) {
+	const imageId = "sha256:8a9d2b7c61f0e4d3c2b1a09876543210fedcba9876543210fedcba9876543210"
+
+	target, _, commands := createContainerAppTestTarget(t, imageId, "")
+	// The last image pushed was built from other sources
+	target.env.Values["SERVICE_API_IMAGE_NAME"] = "registry.azurecr.io/ca-api/ca-api:test-env-0123456789ab"
+	target.env.Values["SERVICE_API_IMAGE_DIGEST"] = "sha256:0123456789abcdef"
+
+	progress := make(chan string)
+	go func() {
+		for range progress {
+		}
+	}()
+
+	image, pushed, err := pushContainerImage(
+		context.Background(), target.cli, target.docker, target.env, target.config, target.imageRepository(), imageId, progress,
+	)
+	close(progress)
+	require.NoError(t, err)
+
+	require.True(t, pushed)
+	require.Equal(t, "registry.azurecr.io/ca-api/ca-api:test-env-8a9d2b7c61f0", image)
+	require.Equal(t, []string{"az acr login", "docker image", "docker tag", "docker push"}, *commands)
+	require.Equal(t, image, target.env.Values["SERVICE_API_IMAGE_NAME"])
+	require.Equal(t, imageId, target.env.Values["SERVICE_API_IMAGE_DIGEST"])
+}
+
+func TestPushContainerImageNotInRegistry(t *testing.T) {
+	const imageId = "sha256:8a9d2b7c61f0e4d3c2b1a09876543210fedcba9876543210fedcba9876543210"
+	const image = "registry.azurecr.io/ca-api/ca-api:test-env-8a9d2b7c61f0"
+
+	push := func(target *containerAppTarget) bool {
+		progress := make(chan string)
+		go func() {
+			for range progress {
+			}
+		}()
+		defer close(progress)
+
+		_, pushed, err := pushContainerImage(
+			context.Background(), target.cli, target.docker, target.env, target.config, target.imageRepository(), imageId, progress,
+		)
+		require.NoError(t, err)
+
+		return pushed
+	}
+
+	t.Run("Missing", func(t *testing.T) {
+		// The image was recorded as pushed, but the registry was recreated since
+		target, _, commands := createContainerAppTestTarget(t, imageId, "")
+		target.env.Values["SERVICE_API_IMAGE_NAME"] = image
+		target.env.Values["SERVICE_API_IMAGE_DIGEST"] = imageId
+
+		require.True(t, push(target))
+		require.Contains(t, *commands, "docker push")
+	})
+
+	t.Run("Overwritten", func(t *testing.T) {
+		// The tag was overwritten in the registry with an image that was not pushed from the local image
+		target, registry, commands := createContainerAppTestTarget(t, imageId, "")
+		target.env.Values["SERVICE_API_IMAGE_NAME"] = image
+		target.env.Values["SERVICE_API_IMAGE_DIGEST"] = imageId
+		registry.images[image] = "sha256:0123456789abcdef"
+
+		require.True(t, push(target))
+		require.Contains(t, *commands, "docker push")
+	})
+}
+
+func TestContainerAppTargetParallelDeploy(t *testing.T) {
+	const testProj = `
+name: test-proj
+services:
+  api:
+    project: src/api
+    language: js
+    host: containerapp
+  web:
+    project: src/web
+    language: js
+    host: containerapp
+`
+
+	projectDir := t.TempDir()
+	azdCtx := &environment.AzdContext{}
+	azdCtx.SetProjectDirectory(projectDir)
+
+	env := environment.Empty(azdCtx.GetEnvironmentFilePath("test-env"))
+	env.Values[environment.ContainerRegistryEndpointEnvVarName] = "registry.azurecr.io"
+	env.Values[environment.SubscriptionIdEnvVarName] = "SUBSCRIPTION_ID"
+	env.SetEnvName("test-env")
+
+	projectConfig, err := ParseProjectConfig(testProj, &env)
+	require.NoError(t, err)
+	projectConfig.Path = projectDir
+
+	for _, module := range []string{"api", "web"} {
+		require.NoError(t, os.MkdirAll(azdCtx.InfrastructureDirectory(), 0755))
+		require.NoError(t, os.WriteFile(azdCtx.BicepModulePath(module), []byte(""), 0600))
+		require.NoError(t, os.WriteFile(
+			azdCtx.BicepParametersTemplateFilePath(module),
+			[]byte(`{"parameters":{"image":{"value":"${SERVICE_`+strings.ToUpper(module)+`_IMAGE_NAME}"}}}`),
+			0600,
+		))
+	}
+
+	// Each deployment outputs the url of its service
+	azCli := azcli.NewAzCli(azcli.NewAzCliArgs{
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			command := strings.Join(args.Args, " ")
+			switch {
+			case strings.HasPrefix(command, "resource show"):
+				return executil.NewRunResult(0, `{"properties":{"configuration":{"ingress":{"fqdn":"app.contoso.com"}}}}`, ""), nil
+			case strings.HasPrefix(command, "deployment group show"):
+				for i, arg := range args.Args {
+					if arg == "--name" {
+						name := strings.ToUpper(strings.TrimPrefix(args.Args[i+1], "ca-"))
+						return executil.NewRunResult(0, `{"properties":{"outputs":{"`+name+`_URL":{"type":"String","value":"https://`+args.Args[i+1]+`.contoso.com"}}}}`, ""), nil
+					}
+				}
+			}
+
+			return executil.NewRunResult(0, "{}", ""), nil
+		},
+	})
+
+	bicepCli := bicepTool.NewBicepCli(bicepTool.NewBicepCliArgs{
+		AzCli: azCli,
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			return executil.NewRunResult(0, `{"outputs":{"API_URL":{"type":"string"},"WEB_URL":{"type":"string"}}}`, ""), nil
+		},
+	})
+
+	dockerCli := docker.NewDocker(docker.DockerArgs{
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			if args.Args[0] == "image" {
+				return executil.NewRunResult(0, "sha256:"+strings.Repeat(args.Args[len(args.Args)-1][:1], 64)+"\n", ""), nil
+			}
+
+			return executil.NewRunResult(0, "", ""), nil
+		},
+	})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, name := range []string{"api", "web"} {
+		i, name := i, name
+		scope := environment.NewDeploymentScope("SUBSCRIPTION_ID", "rg-test", "ca-"+name)
+		target := NewContainerAppTarget(projectConfig.Services[name], &env, scope, azCli, dockerCli).(*containerAppTarget)
+		target.bicepCli = bicepCli
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			progress := make(chan string)
+			go func() {
+				for range progress {
+				}
+			}()
+
+			_, errs[i] = target.Deploy(context.Background(), azdCtx, name+"-image", progress)
+			close(progress)
+		}()
+	}
+
+	wg.Wait()
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+
+	// The values written by both deployments are saved
+	saved, err := environment.FromFile(env.File)
+	require.NoError(t, err)
+	require.Equal(t, "https://ca-api.contoso.com", saved.Values["API_URL"])
+	require.Equal(t, "https://ca-web.contoso.com", saved.Values["WEB_URL"])
+	require.Regexp(t, `^registry.azurecr.io/ca-api/ca-api:azdev-deploy-\d+$`, saved.Values["SERVICE_API_IMAGE_NAME"])
+	require.Regexp(t, `^registry.azurecr.io/ca-web/ca-web:azdev-deploy-\d+$`, saved.Values["SERVICE_WEB_IMAGE_NAME"])
+}
+
+func TestRenderImageTag(t *testing.T) {
+	env := &environment.Environment{Values: map[string]string{}}
+	env.SetEnvName("dev")
+
+	config := &ServiceConfig{Name: "api", Project: &ProjectConfig{Path: t.TempDir()}}
+	render := func(template string) (string, error) {
+		config.Docker.Tag = template
+		return renderImageTag(context.Background(), config, env, "sha256:8a9d2b7c61f0e4d3c2b1")
+	}
+
+	tag, err := render("")
+	require.NoError(t, err)
+	require.Regexp(t, `^azdev-deploy-\d+$`, tag)
+
+	tag, err = render("{{envName}}-{{ digest }}")
+	require.NoError(t, err)
+	require.Equal(t, "dev-8a9d2b7c61f0", tag)
+
+	_, err = render("{{branch}}")
+	require.ErrorContains(t, err, "unknown placeholder {{branch}}")
+
+	_, err = render("{{gitSha}}")
+	require.ErrorContains(t, err, "requires a git repository")
+
+	_, err = render("release/{{envName}}")
+	require.ErrorContains(t, err, "is not a valid image tag")
+}
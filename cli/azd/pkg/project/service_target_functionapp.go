@@ -10,6 +10,7 @@ import (
 
 	"github.com/azure/azure-dev/cli/azd/pkg/azure"
 	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/ignore"
 	"github.com/azure/azure-dev/cli/azd/pkg/project/internal"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools"
 	"github.com/azure/azure-dev/cli/azd/pkg/tools/azcli"
@@ -30,7 +31,12 @@ func (f *functionAppTarget) RequiredExternalTools() []tools.ExternalTool {
 
 func (f *functionAppTarget) Deploy(ctx context.Context, _ *environment.AzdContext, path string, progress chan<- string) (ServiceDeploymentResult, error) {
 	progress <- "Compressing deployment artifacts"
-	zipFilePath, err := internal.CreateDeployableZip(f.config.Name, path)
+	matcher, err := ignore.ReadAzdIgnore(f.config.Path())
+	if err != nil {
+		return ServiceDeploymentResult{}, fmt.Errorf("deploying service %s: %w", f.config.Name, err)
+	}
+
+	zipFilePath, err := internal.CreateDeployableZip(f.config.Name, path, matcher)
 
 	if err != nil {
 		return ServiceDeploymentResult{}, err
@@ -0,0 +1,417 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/environment"
+	"github.com/azure/azure-dev/cli/azd/pkg/iac/bicep"
+	"github.com/azure/azure-dev/cli/azd/pkg/infra/provisioning"
+	"github.com/azure/azure-dev/cli/azd/pkg/jsonschema"
+	bicepTool "github.com/azure/azure-dev/cli/azd/pkg/tools/bicep"
+	"github.com/azure/azure-dev/schemas"
+	"gopkg.in/yaml.v3"
+)
+
+type ValidationSeverity string
+
+const (
+	// The project can't be provisioned or deployed
+	SeverityError ValidationSeverity = "error"
+	// The project may not work as expected
+	SeverityWarning ValidationSeverity = "warning"
+)
+
+// The rules checked by the validator
+const (
+	RuleSchema         = "schema"
+	RuleParse          = "parse"
+	RuleInfraProvider  = "infra-provider"
+	RuleInfraModule    = "infra-module"
+	RuleServiceProject = "service-project"
+	RuleServiceModule  = "service-module"
+	RuleServiceOutput  = "service-output"
+	RuleServiceTag     = "service-tag"
+)
+
+// StrictValidationEnvVarName is the environment variable which, when set to true, fails the commands loading a project
+// file with warnings, ex) unknown properties, instead of only printing them
+const StrictValidationEnvVarName = "AZURE_DEV_STRICT_VALIDATION"
+
+// ServiceNameTagName is the tag identifying the Azure resource a service is deployed to
+const ServiceNameTagName = "azd-service-name"
+
+// ValidationFinding is a problem found in a project by the validator
+type ValidationFinding struct {
+	Severity ValidationSeverity `json:"severity"`
+	Rule     string             `json:"rule"`
+	// File is the path of the file the finding is about, relative to the project directory
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Service string `json:"service,omitempty"`
+	Message string `json:"message"`
+}
+
+// Location returns the file and the position of the finding, ex) azure.yaml:4:5
+func (f ValidationFinding) Location() string {
+	if f.Line == 0 {
+		return f.File
+	}
+
+	return fmt.Sprintf("%s:%d:%d", f.File, f.Line, f.Column)
+}
+
+// ProjectFileError is returned when a project file does not match the schema of azure.yaml
+type ProjectFileError struct {
+	Findings []ValidationFinding
+}
+
+func (e *ProjectFileError) Error() string {
+	lines := make([]string, 0, len(e.Findings)+1)
+	lines = append(lines, fmt.Sprintf("%s is invalid:", environment.ProjectFileName))
+
+	for _, finding := range e.Findings {
+		lines = append(lines, fmt.Sprintf("  %s: %s: %s", finding.Location(), finding.Severity, finding.Message))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+var yamlErrorLineRegex = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// ValidateProjectFile validates the content of a project file against the schema of azure.yaml. The properties
+// which are ignored when the project is loaded, ex) unknown properties, are reported as warnings, with the closest
+// known property as a suggestion when they look like a misspelling. The findings are sorted by position.
+func ValidateProjectFile(file string, content []byte) ([]ValidationFinding, error) {
+	findings := []ValidationFinding{}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		finding := ValidationFinding{Severity: SeverityError, Rule: RuleParse, File: file, Message: err.Error()}
+		if match := yamlErrorLineRegex.FindStringSubmatch(err.Error()); match != nil {
+			finding.Line, _ = strconv.Atoi(match[1])
+			finding.Message = match[2]
+		}
+
+		return append(findings, finding), nil
+	}
+
+	schema, err := jsonschema.Parse(schemas.AzureYamlV1)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, schemaError := range schema.Validate(&document) {
+		severity := SeverityError
+		if schemaError.Keyword == "additionalProperties" || schemaError.Keyword == "properties" {
+			severity = SeverityWarning
+		}
+
+		message := schemaError.Message
+		if schemaError.Path != "" {
+			message = fmt.Sprintf("%s: %s", schemaError.Path, schemaError.Message)
+		}
+
+		findings = append(findings, ValidationFinding{
+			Severity: severity,
+			Rule:     RuleSchema,
+			File:     file,
+			Line:     schemaError.Line,
+			Column:   schemaError.Column,
+			Message:  message,
+		})
+	}
+
+	return findings, nil
+}
+
+// Validator checks a project before it is provisioned & deployed: azure.yaml is validated against its schema,
+// the bicep modules are compiled, and the outputs & the resources needed by the targets of the services are
+// looked up in the compiled templates.
+type Validator struct {
+	bicepCli bicepTool.BicepCli
+}
+
+func NewValidator(bicepCli bicepTool.BicepCli) *Validator {
+	return &Validator{bicepCli: bicepCli}
+}
+
+// Validate validates the project in the directory. The findings are sorted by file and position.
+func (v *Validator) Validate(ctx context.Context, projectDirectory string) ([]ValidationFinding, error) {
+	content, err := os.ReadFile(filepath.Join(projectDirectory, environment.ProjectFileName))
+	if err != nil {
+		return nil, fmt.Errorf("reading project file: %w", err)
+	}
+
+	findings, err := ValidateProjectFile(environment.ProjectFileName, content)
+	if err != nil {
+		return nil, err
+	}
+
+	addFinding := func(finding ValidationFinding) {
+		if finding.File == "" {
+			finding.File = environment.ProjectFileName
+		}
+
+		findings = append(findings, finding)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		// The syntax error is reported by ValidateProjectFile
+		return findings, nil
+	}
+
+	projectConfig, err := ParseProjectConfig(string(content), &environment.Environment{Values: map[string]string{}})
+	if err != nil {
+		addFinding(ValidationFinding{Severity: SeverityError, Rule: RuleParse, Message: err.Error()})
+		return sortFindings(findings), nil
+	}
+
+	nodes := serviceNodes(&document)
+	addServiceFinding := func(service *ServiceConfig, finding ValidationFinding) {
+		finding.Service = service.Name
+		if node, has := nodes[service.Name]; has && finding.File == "" {
+			finding.Line = node.key.Line
+			finding.Column = node.key.Column
+		}
+
+		addFinding(finding)
+	}
+
+	services := make([]*ServiceConfig, 0, len(projectConfig.Services))
+	for _, service := range projectConfig.Services {
+		services = append(services, service)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+
+	for _, service := range services {
+		if _, err := os.Stat(filepath.Join(projectDirectory, service.RelativePath)); err != nil {
+			addServiceFinding(service, ValidationFinding{
+				Severity: SeverityError,
+				Rule:     RuleServiceProject,
+				Message:  fmt.Sprintf("the project directory '%s' of service '%s' does not exist", service.RelativePath, service.Name),
+			})
+		}
+	}
+
+	if projectConfig.Infra.Provider != "" && projectConfig.Infra.Provider != provisioning.Bicep {
+		addFinding(ValidationFinding{
+			Severity: SeverityWarning,
+			Rule:     RuleInfraProvider,
+			Message: fmt.Sprintf(
+				"the infrastructure of provider '%s' is not compiled, the outputs and the resources needed by the services were not checked",
+				projectConfig.Infra.Provider,
+			),
+		})
+
+		return sortFindings(findings), nil
+	}
+
+	infraDirectory := filepath.Join(projectDirectory, projectConfig.Infra.Path)
+	relativeModulePath := func(module string) string {
+		return filepath.ToSlash(filepath.Join(projectConfig.Infra.Path, module+".bicep"))
+	}
+
+	mainTemplate, err := v.compile(ctx, infraDirectory, projectConfig.Infra.Module)
+	if err != nil {
+		addFinding(ValidationFinding{
+			Severity: SeverityError,
+			Rule:     RuleInfraModule,
+			File:     relativeModulePath(projectConfig.Infra.Module),
+			Message:  err.Error(),
+		})
+
+		return sortFindings(findings), nil
+	}
+
+	outputs := map[string]bool{}
+	for name := range mainTemplate.Outputs {
+		outputs[strings.ToLower(name)] = true
+	}
+
+	taggedServices := map[string]bool{}
+	collectServiceTags(mainTemplate.Resources, taggedServices)
+
+	for _, service := range services {
+		requirements, supported := GetServiceTargetRequirements(service.Host)
+		if !supported {
+			// Unsupported hosts are reported by the schema validation
+			continue
+		}
+
+		// The module of the service is only deployed by some targets, but a module set explicitly must exist
+		explicitModule := nodes[service.Name].value != nil && mappingValue(nodes[service.Name].value, "module") != nil
+		if requirements.Module || explicitModule {
+			moduleTemplate, err := v.compile(ctx, infraDirectory, service.Module)
+			if err != nil {
+				addServiceFinding(service, ValidationFinding{
+					Severity: SeverityError,
+					Rule:     RuleServiceModule,
+					File:     relativeModulePath(service.Module),
+					Message:  fmt.Sprintf("module '%s' of service '%s': %v", service.Module, service.Name, err),
+				})
+			} else {
+				collectServiceTags(moduleTemplate.Resources, taggedServices)
+			}
+
+			parametersPath := filepath.Join(infraDirectory, service.Module+".parameters.json")
+			if _, err := os.Stat(parametersPath); requirements.Module && err != nil {
+				addServiceFinding(service, ValidationFinding{
+					Severity: SeverityError,
+					Rule:     RuleServiceModule,
+					File:     filepath.ToSlash(filepath.Join(projectConfig.Infra.Path, service.Module+".parameters.json")),
+					Message: fmt.Sprintf(
+						"service '%s' hosted on '%s' deploys module '%s', which has no parameters file",
+						service.Name,
+						service.Host,
+						service.Module,
+					),
+				})
+			}
+		}
+
+		for _, output := range requirements.Outputs {
+			if !outputs[strings.ToLower(output)] {
+				addServiceFinding(service, ValidationFinding{
+					Severity: SeverityError,
+					Rule:     RuleServiceOutput,
+					File:     relativeModulePath(projectConfig.Infra.Module),
+					Message: fmt.Sprintf(
+						"service '%s' hosted on '%s' needs the '%s' output, which is not an output of the infrastructure",
+						service.Name,
+						service.Host,
+						output,
+					),
+				})
+			}
+		}
+	}
+
+	for _, service := range services {
+		requirements, supported := GetServiceTargetRequirements(service.Host)
+		if !supported || !requirements.Tagged || service.ResourceName != "" || taggedServices[service.Name] {
+			continue
+		}
+
+		addServiceFinding(service, ValidationFinding{
+			Severity: SeverityError,
+			Rule:     RuleServiceTag,
+			File:     relativeModulePath(projectConfig.Infra.Module),
+			Message: fmt.Sprintf(
+				"no resource of the infrastructure is tagged with '%s: %s', the resource of service '%s' can't be found",
+				ServiceNameTagName,
+				service.Name,
+				service.Name,
+			),
+		})
+	}
+
+	return sortFindings(findings), nil
+}
+
+func (v *Validator) compile(ctx context.Context, infraDirectory string, module string) (bicep.CompiledTemplate, error) {
+	modulePath := filepath.Join(infraDirectory, module+".bicep")
+	if _, err := os.Stat(modulePath); errors.Is(err, os.ErrNotExist) {
+		return bicep.CompiledTemplate{}, fmt.Errorf("the bicep file %s does not exist", module+".bicep")
+	}
+
+	return bicep.Compile(ctx, v.bicepCli, modulePath)
+}
+
+// serviceNode is a service in azure.yaml
+type serviceNode struct {
+	key   *yaml.Node
+	value *yaml.Node
+}
+
+// serviceNodes returns the nodes of the services in azure.yaml, by name of service
+func serviceNodes(document *yaml.Node) map[string]serviceNode {
+	nodes := map[string]serviceNode{}
+
+	services := mappingValue(document, "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return nodes
+	}
+
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		nodes[services.Content[i].Value] = serviceNode{key: services.Content[i], value: services.Content[i+1]}
+	}
+
+	return nodes
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+
+		node = node.Content[0]
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+var serviceNameTagExpressionRegex = regexp.MustCompile(`'` + ServiceNameTagName + `',\s*'([^']*)'`)
+
+// collectServiceTags looks up the values of the azd-service-name tags in the resources of a compiled template,
+// both as literal tags and in template expressions, ex) [union(parameters('tags'), createObject('azd-service-name', 'web'))]
+func collectServiceTags(value any, services map[string]bool) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, child := range value {
+			if name, isString := child.(string); isString && key == ServiceNameTagName {
+				services[name] = true
+				continue
+			}
+
+			collectServiceTags(child, services)
+		}
+	case []any:
+		for _, child := range value {
+			collectServiceTags(child, services)
+		}
+	case string:
+		for _, match := range serviceNameTagExpressionRegex.FindAllStringSubmatch(value, -1) {
+			services[match[1]] = true
+		}
+	}
+}
+
+func sortFindings(findings []ValidationFinding) []ValidationFinding {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+
+		return findings[i].Column < findings[j].Column
+	})
+
+	return findings
+}
@@ -0,0 +1,282 @@
+package project
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/bicep"
+	"github.com/azure/azure-dev/schemas"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBicepCli returns the compiled templates configured by name of bicep file
+type fakeBicepCli struct {
+	bicep.BicepCli
+	compiled map[string]string
+}
+
+func (f *fakeBicepCli) Build(ctx context.Context, file string) (string, error) {
+	compiled, has := f.compiled[filepath.Base(file)]
+	if !has {
+		return "", errors.New("compilation failed")
+	}
+
+	return compiled, nil
+}
+
+func writeProjectFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for path, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0600))
+	}
+
+	return dir
+}
+
+const validAzureYaml = `name: todo
+services:
+  web:
+    project: src/web
+    host: appservice
+  api:
+    project: src/api
+    host: containerapp
+    language: js
+`
+
+// The templates compiled from bicep, with tags set as literal objects & as template expressions
+const (
+	mainCompiled = `{
+		"outputs": { "AZURE_CONTAINER_REGISTRY_ENDPOINT": { "type": "string" } },
+		"resources": [{
+			"type": "Microsoft.Resources/deployments",
+			"properties": { "template": { "resources": [{
+				"type": "Microsoft.Web/sites",
+				"tags": "[union(parameters('tags'), createObject('azd-service-name', 'web'))]"
+			}] } }
+		}]
+	}`
+	apiCompiled = `{
+		"resources": [{ "type": "Microsoft.App/containerApps", "tags": { "azd-service-name": "api" } }]
+	}`
+)
+
+func TestValidatorValid(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		"azure.yaml":                validAzureYaml,
+		"src/web/index.js":          "",
+		"src/api/index.js":          "",
+		"infra/main.bicep":          "",
+		"infra/api.bicep":           "",
+		"infra/api.parameters.json": "{}",
+	})
+
+	validator := NewValidator(&fakeBicepCli{compiled: map[string]string{"main.bicep": mainCompiled, "api.bicep": apiCompiled}})
+	findings, err := validator.Validate(context.Background(), dir)
+	require.NoError(t, err)
+	require.Empty(t, findings)
+}
+
+func TestValidatorFindings(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		"azure.yaml": `name: todo
+services:
+  web:
+    project: src/web
+    host: appservice
+    module: app/web
+  api:
+    project: src/api
+    host: containerapp
+    language: js
+  worker:
+    project: src/worker
+    host: function
+    resourceName: worker-func
+`,
+		"src/web/index.js":    "",
+		"src/worker/index.js": "",
+		"infra/main.bicep":    "",
+		"infra/api.bicep":     "",
+	})
+
+	validator := NewValidator(&fakeBicepCli{compiled: map[string]string{
+		"main.bicep": `{ "outputs": {}, "resources": [] }`,
+		"api.bicep":  apiCompiled,
+	}})
+
+	findings, err := validator.Validate(context.Background(), dir)
+	require.NoError(t, err)
+
+	rules := map[string][]string{}
+	for _, finding := range findings {
+		require.Equal(t, SeverityError, finding.Severity)
+		rules[finding.Service] = append(rules[finding.Service], finding.Rule)
+	}
+
+	require.ElementsMatch(t, []string{RuleServiceProject, RuleServiceModule, RuleServiceOutput}, rules["api"])
+	require.ElementsMatch(t, []string{RuleServiceModule, RuleServiceTag}, rules["web"])
+	// Services with an explicit resource name are not looked up by tag
+	require.Empty(t, rules["worker"])
+
+	for _, finding := range findings {
+		if finding.Rule == RuleServiceProject {
+			require.Equal(t, "azure.yaml:7:3", finding.Location())
+		}
+	}
+}
+
+func TestValidatorSchema(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		"azure.yaml": `name: todo
+services:
+  web:
+    project: src/web
+    host: app-service
+    docker:
+      path: ./Dockerfile
+`,
+		"src/web/index.js": "",
+	})
+
+	findings, err := NewValidator(&fakeBicepCli{}).Validate(context.Background(), dir)
+	require.NoError(t, err)
+
+	require.Equal(t, RuleSchema, findings[0].Rule)
+	require.Equal(t, "azure.yaml:5:11", findings[0].Location())
+	require.Equal(t, RuleSchema, findings[1].Rule)
+	require.Equal(t, "azure.yaml:6:5", findings[1].Location())
+
+	// The infrastructure is compiled even when azure.yaml does not match the schema
+	require.Equal(t, RuleInfraModule, findings[2].Rule)
+	require.Equal(t, "infra/main.bicep", findings[2].Location())
+}
+
+func TestValidateProjectFileDockerOptions(t *testing.T) {
+	const valid = `name: todo
+services:
+  web:
+    project: src/web
+    host: containerapp
+    docker:
+      target: runtime
+      buildArgs:
+        API_URL: ${API_URL}
+        NODE_VERSION: 18
+      secrets:
+        - id: npmrc
+          src: .npmrc
+      labels:
+        com.contoso.team: web
+      cacheFrom: [contoso.azurecr.io/web:cache]
+      cacheTo: [type=inline]
+      tags: [web:latest]
+`
+
+	findings, err := ValidateProjectFile("azure.yaml", []byte(valid))
+	require.NoError(t, err)
+	require.Empty(t, findings)
+
+	const invalid = `name: todo
+services:
+  web:
+    project: src/web
+    host: containerapp
+    docker:
+      buildArgs:
+        PACKAGES: [curl]
+      secrets:
+        - id: token
+          src: .token
+          env: TOKEN
+`
+
+	findings, err = ValidateProjectFile("azure.yaml", []byte(invalid))
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	require.Equal(t, "azure.yaml:8:19", findings[0].Location())
+	require.Equal(t, SeverityError, findings[0].Severity)
+	require.Equal(t, "azure.yaml:10:11", findings[1].Location())
+	require.Equal(t, SeverityError, findings[1].Severity)
+}
+
+// The fields which are set when the project is loaded, they are not read from azure.yaml
+var loadedFields = map[reflect.Type][]string{
+	reflect.TypeOf(ProjectConfig{}): {"Path"},
+	reflect.TypeOf(ServiceConfig{}): {"Project", "Name"},
+}
+
+// yamlKey returns the key of the field in yaml, the name in the tag or the lowercased name of the field
+func yamlKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+
+	return name
+}
+
+// requireSchemaProperties checks that the fields of the struct are declared by the properties of the schema, and the
+// fields of the nested structs by the schemas of the properties
+func requireSchemaProperties(t *testing.T, structType reflect.Type, schema map[string]any, path string) {
+	properties, _ := schema["properties"].(map[string]any)
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() || containsString(loadedFields[structType], field.Name) {
+			continue
+		}
+
+		key := yamlKey(field)
+		if key == "-" {
+			continue
+		}
+
+		property, has := properties[key].(map[string]any)
+		require.Truef(t, has, "%s.%s is not declared by the schema of azure.yaml", path, key)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Map {
+			// Maps with arbitrary keys, ex) services, declare the schema of their values with additionalProperties, the
+			// schemas of maps with known keys, ex) hooks, declare the keys instead of the fields of the values
+			values, ok := property["additionalProperties"].(map[string]any)
+			if !ok {
+				continue
+			}
+			property = values
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if _, has := property["properties"]; has && fieldType.Kind() == reflect.Struct {
+			requireSchemaProperties(t, fieldType, property, path+"."+key)
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestSchemaDeclaresProjectFields(t *testing.T) {
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(schemas.AzureYamlV1, &schema))
+
+	requireSchemaProperties(t, reflect.TypeOf(ProjectConfig{}), schema, "azure.yaml")
+}
@@ -0,0 +1,95 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package rtar
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// CreateFromDirectory writes a gzipped tarball of the contents of the source directory to buf. Paths for which skip
+// returns true are left out, the contents of a skipped directory are still walked when walkSkippedDirectories is true.
+func CreateFromDirectory(
+	source string,
+	buf io.Writer,
+	skip func(relativePath string, isDir bool) bool,
+	walkSkippedDirectories bool,
+) error {
+	gz := gzip.NewWriter(buf)
+	w := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(source, func(path string, info fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+
+		if relativePath == "." {
+			return nil
+		}
+
+		if skip != nil && skip(relativePath, info.IsDir()) {
+			if info.IsDir() && !walkSkippedDirectories {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		fileInfo, err := info.Info()
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if fileInfo.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(fileInfo, link)
+		if err != nil {
+			return err
+		}
+
+		header.Name = filepath.ToSlash(relativePath)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := w.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !fileInfo.Mode().IsRegular() {
+			return nil
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		_, err = io.Copy(w, in)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
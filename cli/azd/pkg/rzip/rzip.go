@@ -5,54 +5,192 @@ package rzip
 
 import (
 	"archive/zip"
+	"fmt"
 	"io"
 	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
-func CreateFromDirectory(source string, buf *os.File) error {
+// The modification time of all the entries, the archives of the same contents are identical whenever they are created.
+// This is the earliest time which can be represented in a zip file.
+var entryModified = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+type entry struct {
+	// The slash separated path of the entry in the archive
+	name string
+	// The file with the contents of the entry
+	path string
+	mode fs.FileMode
+}
+
+// CreateFromDirectory writes a zip archive of the contents of the source directory to buf. The entries are sorted by
+// name and have a fixed modification time, so that the same contents always produce the same archive.
+// Paths for which skip returns true are left out, the contents of a skipped directory are still walked when
+// walkSkippedDirectories is true.
+// Symbolic links are archived as the files and directories they link to, the hosts the archives are deployed to don't
+// all restore links. Broken links are left out.
+func CreateFromDirectory(
+	source string,
+	buf io.Writer,
+	skip func(relativePath string, isDir bool) bool,
+	walkSkippedDirectories bool,
+) error {
+	root, err := filepath.EvalSymlinks(source)
+	if err != nil {
+		return err
+	}
+
+	c := collector{
+		root:                   root,
+		skip:                   skip,
+		walkSkippedDirectories: walkSkippedDirectories,
+		visited:                map[string]bool{root: true},
+	}
+
+	if err := c.collect(root, ""); err != nil {
+		return err
+	}
+
+	sort.Slice(c.entries, func(i, j int) bool {
+		return c.entries[i].name < c.entries[j].name
+	})
+
 	w := zip.NewWriter(buf)
-	err := filepath.WalkDir(source, func(path string, info fs.DirEntry, err error) error {
-		if err != nil {
+	for _, entry := range c.entries {
+		if err := writeEntry(w, entry); err != nil {
 			return err
 		}
+	}
 
-		if info.IsDir() {
-			return nil
+	return w.Close()
+}
+
+type collector struct {
+	root                   string
+	skip                   func(relativePath string, isDir bool) bool
+	walkSkippedDirectories bool
+	// The directories which are being walked, to guard against cycles of links
+	visited map[string]bool
+	entries []entry
+}
+
+// collect adds the entries for the contents of the directory, named after prefix in the archive
+func (c *collector) collect(dir string, prefix string) error {
+	return filepath.WalkDir(dir, func(path string, info fs.DirEntry, err error) error {
+		if err != nil {
+			return err
 		}
-		fileInfo, err := info.Info()
+
+		relativePath, err := filepath.Rel(dir, path)
 		if err != nil {
 			return err
 		}
 
-		header := &zip.FileHeader{
-			Name: strings.Replace(
-				strings.TrimPrefix(
-					strings.TrimPrefix(path, source),
-					string(filepath.Separator)), "\\", "/", -1),
-			Modified: fileInfo.ModTime(),
-			Method:   zip.Deflate,
+		if relativePath == "." {
+			return nil
 		}
 
-		f, err := w.CreateHeader(header)
-		if err != nil {
-			return err
+		relativePath = filepath.Join(prefix, relativePath)
+		name := filepath.ToSlash(relativePath)
+
+		if info.Type()&fs.ModeSymlink != 0 {
+			return c.collectLink(path, relativePath)
 		}
-		in, err := os.Open(path)
-		if err != nil {
-			return err
+
+		if c.skip != nil && c.skip(relativePath, info.IsDir()) {
+			if info.IsDir() && !c.walkSkippedDirectories {
+				return filepath.SkipDir
+			}
+			return nil
 		}
-		_, err = io.Copy(f, in)
+
+		if info.IsDir() || !info.Type().IsRegular() {
+			return nil
+		}
+
+		fileInfo, err := info.Info()
 		if err != nil {
 			return err
 		}
+
+		c.entries = append(c.entries, entry{name: name, path: path, mode: fileInfo.Mode()})
 		return nil
 	})
+}
+
+func (c *collector) collectLink(path string, relativePath string) error {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		log.Printf("skipping broken link %s: %v", path, err)
+		return nil
+	}
+
+	targetInfo, err := os.Stat(target)
 	if err != nil {
 		return err
 	}
 
-	return w.Close()
+	if c.skip != nil && c.skip(relativePath, targetInfo.IsDir()) {
+		return nil
+	}
+
+	if !targetInfo.IsDir() {
+		c.entries = append(c.entries, entry{name: filepath.ToSlash(relativePath), path: target, mode: targetInfo.Mode()})
+		return nil
+	}
+
+	if c.visitedAncestor(target) {
+		return fmt.Errorf("link %s creates a cycle", path)
+	}
+
+	c.visited[target] = true
+	defer delete(c.visited, target)
+
+	return c.collect(target, relativePath)
+}
+
+func writeEntry(w *zip.Writer, entry entry) error {
+	header := &zip.FileHeader{
+		Name:     entry.name,
+		Modified: entryModified,
+		Method:   zip.Deflate,
+	}
+	header.SetMode(entry.mode)
+
+	f, err := w.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(entry.path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	_, err = io.Copy(f, in)
+	return err
+}
+
+// Whether the directory is one of the directories being walked or one of their ancestors, following a link to it
+// would walk the link again
+func (c *collector) visitedAncestor(dir string) bool {
+	for visited := range c.visited {
+		if within(dir, visited) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Whether the path is the directory or one of its descendants
+func within(dir string, path string) bool {
+	relative, err := filepath.Rel(dir, path)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
 }
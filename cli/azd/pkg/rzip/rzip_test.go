@@ -0,0 +1,127 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package rzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func readZip(t *testing.T, content []byte) map[string]*zip.File {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+
+	files := map[string]*zip.File{}
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	return files
+}
+
+func readEntry(t *testing.T, file *zip.File) string {
+	in, err := file.Open()
+	require.NoError(t, err)
+	defer in.Close()
+
+	content, err := io.ReadAll(in)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestCreateFromDirectory(t *testing.T) {
+	source := t.TempDir()
+	writeFile(t, filepath.Join(source, "app.py"), "print('hello')")
+	writeFile(t, filepath.Join(source, "static", "index.html"), "<html></html>")
+	writeFile(t, filepath.Join(source, "tests", "test_app.py"), "assert True")
+	writeFile(t, filepath.Join(source, "tests", "keep.py"), "keep")
+
+	skip := func(relativePath string, isDir bool) bool {
+		return strings.HasPrefix(filepath.ToSlash(relativePath), "tests")
+	}
+
+	var first bytes.Buffer
+	require.NoError(t, CreateFromDirectory(source, &first, skip, false))
+
+	files := readZip(t, first.Bytes())
+	require.Len(t, files, 2)
+	require.Equal(t, "print('hello')", readEntry(t, files["app.py"]))
+	require.Equal(t, "<html></html>", readEntry(t, files["static/index.html"]))
+	require.True(t, files["app.py"].Modified.Equal(time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)))
+
+	// The archive does not depend on when the files were written
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(source, "app.py"), later, later))
+
+	var second bytes.Buffer
+	require.NoError(t, CreateFromDirectory(source, &second, skip, false))
+	require.Equal(t, first.Bytes(), second.Bytes())
+
+	// The contents of the skipped directories are walked when requested
+	var walked bytes.Buffer
+	require.NoError(t, CreateFromDirectory(source, &walked, func(relativePath string, isDir bool) bool {
+		return skip(relativePath, isDir) && filepath.Base(relativePath) != "keep.py"
+	}, true))
+	require.Contains(t, readZip(t, walked.Bytes()), "tests/keep.py")
+}
+
+func TestCreateFromDirectoryLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links requires privileges on windows")
+	}
+
+	source := t.TempDir()
+	outside := t.TempDir()
+	writeFile(t, filepath.Join(source, "lib", "util.py"), "util")
+	writeFile(t, filepath.Join(outside, "shared", "common.py"), "common")
+	writeFile(t, filepath.Join(outside, "config.json"), "{}")
+	require.NoError(t, os.MkdirAll(filepath.Join(source, "app"), 0755))
+
+	require.NoError(t, os.Symlink(filepath.Join("..", "lib", "util.py"), filepath.Join(source, "app", "util.py")))
+	require.NoError(t, os.Symlink(filepath.Join("..", "lib"), filepath.Join(source, "app", "lib")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "shared"), filepath.Join(source, "shared")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "config.json"), filepath.Join(source, "config.json")))
+	require.NoError(t, os.Symlink(filepath.Join(source, "missing"), filepath.Join(source, "broken")))
+
+	var buf bytes.Buffer
+	require.NoError(t, CreateFromDirectory(source, &buf, nil, false))
+
+	files := readZip(t, buf.Bytes())
+	require.Len(t, files, 5)
+
+	// Links are archived as their targets, wherever the targets are
+	require.Zero(t, files["app/util.py"].Mode()&os.ModeSymlink)
+	require.Equal(t, "util", readEntry(t, files["app/util.py"]))
+	require.Equal(t, "util", readEntry(t, files["app/lib/util.py"]))
+	require.Equal(t, "common", readEntry(t, files["shared/common.py"]))
+	require.Equal(t, "{}", readEntry(t, files["config.json"]))
+	require.NotContains(t, files, "broken")
+}
+
+func TestCreateFromDirectoryLinkCycle(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links requires privileges on windows")
+	}
+
+	source := t.TempDir()
+	writeFile(t, filepath.Join(source, "app", "app.py"), "app")
+	require.NoError(t, os.Symlink("..", filepath.Join(source, "app", "parent")))
+
+	var buf bytes.Buffer
+	require.ErrorContains(t, CreateFromDirectory(source, &buf, nil, false), "creates a cycle")
+}
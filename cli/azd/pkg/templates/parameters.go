@@ -0,0 +1,238 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFileName is the name of the file declaring the parameters of a template, at the root of the template
+const ManifestFileName = "azd-template.yaml"
+
+var parameterNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Manifest declares the parameters of a template. Placeholders referencing the parameters, ex) {{appName}},
+// are replaced by the values of the parameters in the contents and the names of the files of the template.
+type Manifest struct {
+	Parameters []Parameter `yaml:"parameters"`
+}
+
+type Parameter struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	// Default is used when no value is given. Parameters without a default value are required.
+	Default string `yaml:"default,omitempty"`
+	// Validation is a regular expression the whole value must match
+	Validation string `yaml:"validation,omitempty"`
+}
+
+// Validate returns an error when the value is empty or does not match the validation regular expression of the parameter
+func (p Parameter) Validate(value string) error {
+	if value == "" {
+		return fmt.Errorf("a value is required for template parameter '%s'", p.Name)
+	}
+
+	if p.Validation == "" {
+		return nil
+	}
+
+	validation, err := compileValidation(p.Validation)
+	if err != nil {
+		return fmt.Errorf("invalid validation of template parameter '%s': %w", p.Name, err)
+	}
+
+	if !validation.MatchString(value) {
+		return fmt.Errorf("value '%s' of template parameter '%s' does not match '%s'", value, p.Name, p.Validation)
+	}
+
+	return nil
+}
+
+// compileValidation compiles the validation of a parameter, anchored so that the whole value must match, ex) [a-z]+
+// rejects ABCx
+func compileValidation(validation string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + validation + `)$`)
+}
+
+// LoadManifest reads the manifest at the root of the template directory. A nil manifest is returned when the
+// template does not have a manifest.
+func LoadManifest(templateDir string) (*Manifest, error) {
+	contents, err := os.ReadFile(filepath.Join(templateDir, ManifestFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading template manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(contents, &manifest); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", ManifestFileName, err)
+	}
+
+	if err := manifest.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ManifestFileName, err)
+	}
+
+	return &manifest, nil
+}
+
+func (m *Manifest) validate() error {
+	names := map[string]bool{}
+
+	for _, parameter := range m.Parameters {
+		if !parameterNameRegex.MatchString(parameter.Name) {
+			return fmt.Errorf(
+				"invalid parameter name '%s', names must start with a letter or '_' and only contain letters, digits and '_'",
+				parameter.Name,
+			)
+		}
+
+		if names[parameter.Name] {
+			return fmt.Errorf("parameter '%s' is declared more than once", parameter.Name)
+		}
+		names[parameter.Name] = true
+
+		if _, err := compileValidation(parameter.Validation); err != nil {
+			return fmt.Errorf("invalid validation of parameter '%s': %w", parameter.Name, err)
+		}
+
+		if parameter.Default != "" {
+			if err := parameter.Validate(parameter.Default); err != nil {
+				return fmt.Errorf("invalid default value: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckValues returns an error when a value is given for a parameter the manifest does not declare
+func (m *Manifest) CheckValues(values map[string]string) error {
+	for name := range values {
+		found := false
+		for _, parameter := range m.Parameters {
+			if parameter.Name == name {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("the template does not have a parameter named '%s'", name)
+		}
+	}
+
+	return nil
+}
+
+// ParseParameterValues parses values given as key=value pairs
+func ParseParameterValues(pairs []string) (map[string]string, error) {
+	values := map[string]string{}
+
+	for _, pair := range pairs {
+		name, value, found := strings.Cut(pair, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid template parameter '%s', parameters must be given as <name>=<value>", pair)
+		}
+
+		values[name] = value
+	}
+
+	return values, nil
+}
+
+// Render replaces the placeholders of the parameters in the contents and the names of the files of the template
+// directory. The .git folder is left unchanged, as are binary files.
+func Render(templateDir string, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+
+	placeholderRegex := regexp.MustCompile(`\{\{\s*(` + strings.Join(names, "|") + `)\s*\}\}`)
+	replace := func(text string) string {
+		return placeholderRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+			return values[placeholderRegex.FindStringSubmatch(placeholder)[1]]
+		})
+	}
+
+	var paths []string
+	if err := filepath.WalkDir(templateDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		if path != templateDir {
+			paths = append(paths, path)
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+
+		if bytes.IndexByte(contents, 0) != -1 {
+			return nil
+		}
+
+		rendered := replace(string(contents))
+		if rendered == string(contents) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(path, []byte(rendered), info.Mode().Perm()); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("rendering template: %w", err)
+	}
+
+	// Files & folders are renamed from the deepest, so the paths of their parents are still valid
+	for i := len(paths) - 1; i >= 0; i-- {
+		path := paths[i]
+		renamed := replace(filepath.Base(path))
+		if renamed == filepath.Base(path) {
+			continue
+		}
+
+		target := filepath.Join(filepath.Dir(path), renamed)
+		if _, err := os.Stat(target); err == nil {
+			return fmt.Errorf("renaming %s: %s already exists", path, target)
+		}
+
+		if err := os.Rename(path, target); err != nil {
+			return fmt.Errorf("renaming %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
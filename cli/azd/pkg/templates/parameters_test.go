@@ -0,0 +1,117 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadManifest(t *testing.T) {
+	t.Run("Missing", func(t *testing.T) {
+		manifest, err := LoadManifest(t.TempDir())
+		require.NoError(t, err)
+		require.Nil(t, manifest)
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestFileName), []byte(`
+parameters:
+  - name: appName
+    description: The name of the application
+    default: todo
+    validation: ^[a-z][a-z0-9-]*$
+  - name: port
+`), 0600))
+
+		manifest, err := LoadManifest(dir)
+		require.NoError(t, err)
+		require.Equal(t, []Parameter{
+			{Name: "appName", Description: "The name of the application", Default: "todo", Validation: "^[a-z][a-z0-9-]*$"},
+			{Name: "port"},
+		}, manifest.Parameters)
+
+		require.NoError(t, manifest.CheckValues(map[string]string{"port": "8080"}))
+		require.Error(t, manifest.CheckValues(map[string]string{"other": "value"}))
+	})
+
+	invalid := map[string]string{
+		"InvalidName":       "parameters:\n  - name: app-name\n",
+		"Duplicate":         "parameters:\n  - name: app\n  - name: app\n",
+		"InvalidValidation": "parameters:\n  - name: app\n    validation: '['\n",
+		"InvalidDefault":    "parameters:\n  - name: app\n    default: Todo\n    validation: ^[a-z]+$\n",
+	}
+
+	for name, contents := range invalid {
+		contents := contents
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestFileName), []byte(contents), 0600))
+
+			_, err := LoadManifest(dir)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParameterValidate(t *testing.T) {
+	parameter := Parameter{Name: "appName", Validation: "^[a-z]+$"}
+
+	require.NoError(t, parameter.Validate("todo"))
+	require.Error(t, parameter.Validate("Todo"))
+	require.Error(t, parameter.Validate(""))
+
+	// The whole value must match the validation, anchored or not
+	partial := Parameter{Name: "appName", Validation: "[a-z]+"}
+	require.NoError(t, partial.Validate("todo"))
+	require.Error(t, partial.Validate("ABCx"))
+	require.Error(t, partial.Validate("todo app"))
+
+	alternatives := Parameter{Name: "size", Validation: "small|large"}
+	require.NoError(t, alternatives.Validate("large"))
+	require.Error(t, alternatives.Validate("smaller"))
+}
+
+func TestParseParameterValues(t *testing.T) {
+	values, err := ParseParameterValues([]string{"appName=todo", "connection=a=b", "empty="})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"appName": "todo", "connection": "a=b", "empty": ""}, values)
+
+	_, err = ParseParameterValues([]string{"appName"})
+	require.Error(t, err)
+
+	_, err = ParseParameterValues([]string{"=todo"})
+	require.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(path string, contents string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(contents), 0600))
+	}
+
+	read := func(path string) string {
+		contents, err := os.ReadFile(filepath.Join(dir, path))
+		require.NoError(t, err)
+		return string(contents)
+	}
+
+	write("README.md", "# {{appName}}\nListening on port {{ port }}, {{unknown}} and ${{ secrets.TOKEN }} are unchanged.")
+	write("src/{{appName}}/{{appName}}.go", "package {{appName}}")
+	write(".git/config", "{{appName}}")
+	write("logo.png", "{{appName}}\x00")
+
+	require.NoError(t, Render(dir, map[string]string{"appName": "todo", "port": "8080"}))
+
+	require.Equal(t, "# todo\nListening on port 8080, {{unknown}} and ${{ secrets.TOKEN }} are unchanged.", read("README.md"))
+	require.Equal(t, "package todo", read("src/todo/todo.go"))
+	require.Equal(t, "{{appName}}", read(".git/config"))
+	require.Equal(t, "{{appName}}\x00", read("logo.png"))
+
+	_, err := os.Stat(filepath.Join(dir, "src", "{{appName}}"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,226 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/config"
+	"github.com/azure/azure-dev/cli/azd/pkg/osutil"
+)
+
+// SourceKind is the kind of location the templates of a source are read from
+type SourceKind string
+
+const (
+	// A JSON file holding a list of templates
+	SourceKindFile SourceKind = "file"
+	// A directory of JSON files, each holding a list of templates
+	SourceKindDirectory SourceKind = "directory"
+	// A git repository with a templates.json file holding a list of templates at its root
+	SourceKindGit SourceKind = "git"
+	// An HTTP(S) URL returning a list of templates
+	SourceKindUrl SourceKind = "url"
+)
+
+// BuiltInSourceName is the name of the source of the templates embedded in azd
+const BuiltInSourceName = "default"
+
+// SourceFileName is the name of the file holding the templates at the root of a git repository source
+const SourceFileName = "templates.json"
+
+// The file listing the template sources configured by the user, in the azd configuration directory
+const sourcesFileName = "template-sources.json"
+
+var ErrSourceNotFound = errors.New("template source not found")
+
+// Names of sources are used as file names in the cache
+var sourceNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-_.]*$`)
+
+// Source is a user configured location templates are read from, in addition to the built-in templates
+type Source struct {
+	Name     string     `json:"name"`
+	Kind     SourceKind `json:"kind"`
+	Location string     `json:"location"`
+}
+
+// IsRemote returns true when the templates of the source are downloaded, and so cached on disk
+func (s Source) IsRemote() bool {
+	return s.Kind == SourceKindGit || s.Kind == SourceKindUrl
+}
+
+// NewSource creates a source of templates. When kind is empty, the kind is detected from the location:
+// git URLs are git repositories, other http(s) URLs are JSON documents, existing directories are directories
+// and other locations are JSON files. The paths of local sources are made absolute.
+func NewSource(name string, kind SourceKind, location string) (Source, error) {
+	if !sourceNameRegex.MatchString(name) {
+		return Source{}, fmt.Errorf(
+			"invalid template source name '%s', names must start with a letter or digit and only contain letters, digits, '-', '_' and '.'",
+			name,
+		)
+	}
+
+	if strings.EqualFold(name, BuiltInSourceName) {
+		return Source{}, fmt.Errorf("the template source name '%s' is reserved for the built-in templates", name)
+	}
+
+	if location == "" {
+		return Source{}, errors.New("the location of the template source is required")
+	}
+
+	if kind == "" {
+		kind = detectSourceKind(location)
+	}
+
+	switch kind {
+	case SourceKindFile, SourceKindDirectory:
+		absolutePath, err := filepath.Abs(location)
+		if err != nil {
+			return Source{}, fmt.Errorf("resolving path '%s': %w", location, err)
+		}
+		location = absolutePath
+	case SourceKindGit:
+	case SourceKindUrl:
+		if !strings.HasPrefix(location, "https://") && !strings.HasPrefix(location, "http://") {
+			return Source{}, fmt.Errorf("the location of a '%s' template source must be an http(s) URL", SourceKindUrl)
+		}
+	default:
+		return Source{}, fmt.Errorf(
+			"unknown template source kind '%s', supported kinds are '%s', '%s', '%s' and '%s'",
+			kind,
+			SourceKindFile,
+			SourceKindDirectory,
+			SourceKindGit,
+			SourceKindUrl,
+		)
+	}
+
+	return Source{Name: name, Kind: kind, Location: location}, nil
+}
+
+func detectSourceKind(location string) SourceKind {
+	switch {
+	case strings.HasPrefix(location, "git@") || strings.HasPrefix(location, "ssh://") || strings.HasSuffix(location, ".git"):
+		return SourceKindGit
+	case strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://"):
+		return SourceKindUrl
+	}
+
+	if info, err := os.Stat(location); err == nil && info.IsDir() {
+		return SourceKindDirectory
+	}
+
+	return SourceKindFile
+}
+
+// SourceStore persists the template sources configured by the user
+type SourceStore struct {
+	configDir string
+}
+
+// NewSourceStore creates a store saving the sources in the azd configuration directory of the user (~/.azd)
+func NewSourceStore() (*SourceStore, error) {
+	configDir, err := config.UserDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSourceStoreWithDirectory(configDir), nil
+}
+
+// NewSourceStoreWithDirectory creates a store saving the sources in the given configuration directory
+func NewSourceStoreWithDirectory(configDir string) *SourceStore {
+	return &SourceStore{configDir: configDir}
+}
+
+// List returns the configured sources, in the order they were added
+func (s *SourceStore) List() ([]Source, error) {
+	contents, err := os.ReadFile(filepath.Join(s.configDir, sourcesFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Source{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading template sources: %w", err)
+	}
+
+	var sources []Source
+	if err := json.Unmarshal(contents, &sources); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal template sources JSON: %w", err)
+	}
+
+	return sources, nil
+}
+
+// Add saves a new source, failing when a source with the same name already exists
+func (s *SourceStore) Add(source Source) error {
+	sources, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range sources {
+		if strings.EqualFold(existing.Name, source.Name) {
+			return fmt.Errorf("template source '%s' already exists", source.Name)
+		}
+	}
+
+	return s.save(append(sources, source))
+}
+
+// Remove deletes the source with the given name and its cached templates
+func (s *SourceStore) Remove(name string) error {
+	sources, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	remaining := make([]Source, 0, len(sources))
+	for _, source := range sources {
+		if !strings.EqualFold(source.Name, name) {
+			remaining = append(remaining, source)
+		}
+	}
+
+	if len(remaining) == len(sources) {
+		return fmt.Errorf("template source '%s': %w", name, ErrSourceNotFound)
+	}
+
+	if err := s.save(remaining); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.cachePath(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing cached templates: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SourceStore) save(sources []Source) error {
+	contents, err := json.MarshalIndent(sources, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling template sources: %w", err)
+	}
+
+	if err := os.MkdirAll(s.configDir, osutil.PermissionDirectory); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(s.configDir, sourcesFileName), contents, osutil.PermissionFile); err != nil {
+		return fmt.Errorf("writing template sources: %w", err)
+	}
+
+	return nil
+}
+
+// cachePath is the path of the file caching the templates of a remote source
+func (s *SourceStore) cachePath(name string) string {
+	return filepath.Join(s.configDir, "templates", strings.ToLower(name)+".json")
+}
@@ -0,0 +1,77 @@
+package templates
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSource(t *testing.T) {
+	directory := t.TempDir()
+
+	cases := []struct {
+		name     string
+		location string
+		kind     SourceKind
+		expected SourceKind
+	}{
+		{name: "GitHttps", location: "https://github.com/contoso/templates.git", expected: SourceKindGit},
+		{name: "GitSsh", location: "git@github.com:contoso/templates", expected: SourceKindGit},
+		{name: "Url", location: "https://contoso.com/templates.json", expected: SourceKindUrl},
+		{name: "Directory", location: directory, expected: SourceKindDirectory},
+		{name: "File", location: filepath.Join(directory, "templates.json"), expected: SourceKindFile},
+		{name: "Explicit", location: "https://contoso.com/templates", kind: SourceKindGit, expected: SourceKindGit},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			source, err := NewSource("contoso", c.kind, c.location)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, source.Kind)
+		})
+	}
+
+	t.Run("RelativePath", func(t *testing.T) {
+		source, err := NewSource("contoso", SourceKindFile, "templates.json")
+		require.NoError(t, err)
+		require.True(t, filepath.IsAbs(source.Location))
+	})
+
+	for _, name := range []string{"", "default", "has space", "../escape"} {
+		_, err := NewSource(name, "", "templates.json")
+		require.Error(t, err, "name %q", name)
+	}
+
+	_, err := NewSource("contoso", "svn", "templates.json")
+	require.Error(t, err)
+
+	_, err = NewSource("contoso", SourceKindUrl, directory)
+	require.Error(t, err)
+}
+
+func TestSourceStore(t *testing.T) {
+	store := NewSourceStoreWithDirectory(filepath.Join(t.TempDir(), ".azd"))
+
+	sources, err := store.List()
+	require.NoError(t, err)
+	require.Empty(t, sources)
+
+	first := Source{Name: "first", Kind: SourceKindUrl, Location: "https://contoso.com/first.json"}
+	second := Source{Name: "second", Kind: SourceKindFile, Location: "/templates/second.json"}
+
+	require.NoError(t, store.Add(first))
+	require.NoError(t, store.Add(second))
+	require.Error(t, store.Add(Source{Name: "FIRST", Kind: SourceKindFile, Location: "/other.json"}))
+
+	sources, err = store.List()
+	require.NoError(t, err)
+	require.Equal(t, []Source{first, second}, sources)
+
+	require.NoError(t, store.Remove("first"))
+	require.ErrorIs(t, store.Remove("first"), ErrSourceNotFound)
+
+	sources, err = store.List()
+	require.NoError(t, err)
+	require.Equal(t, []Source{second}, sources)
+}
@@ -4,4 +4,6 @@ type Template struct {
 	Name           string `json:"name"`
 	Description    string `json:"description"`
 	RepositoryPath string `json:"repositoryPath"`
+	// Source is the name of the template source the template was read from
+	Source string `json:"source,omitempty"`
 }
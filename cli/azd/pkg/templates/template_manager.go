@@ -1,17 +1,54 @@
 package templates
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/azure/azure-dev/cli/azd/pkg/httpUtil"
+	"github.com/azure/azure-dev/cli/azd/pkg/osutil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools/git"
 	"github.com/azure/azure-dev/cli/azd/resources"
 )
 
+// DefaultRefreshInterval is how long the templates downloaded from remote sources are cached before being downloaded again
+const DefaultRefreshInterval = 24 * time.Hour
+
 type TemplateManager struct {
+	// sources is nil when the configuration directory of the user can't be determined,
+	// in which case only the built-in templates are listed
+	sources         *SourceStore
+	refreshInterval time.Duration
+	// httpClient allows us to stub out the http requests for testing.
+	// Defaults to the client found in the context.
+	httpClient httpUtil.HttpUtil
+	gitCli     git.GitCli
+	// warnings receives the warnings about the sources that can't be read. Defaults to stderr.
+	warnings io.Writer
+	// warnedSources tracks the sources already reported as failing, so that each source is only warned about once
+	warnedSources map[string]bool
+}
+
+// cachedTemplates is the content of the file caching the templates of a remote source
+type cachedTemplates struct {
+	ExpiresOn string     `json:"expiresOn"`
+	Templates []Template `json:"templates"`
 }
 
-// Get a set of templates where each key is the name of the template
-func (tm *TemplateManager) ListTemplates() (map[string]Template, error) {
+// Get a set of templates where each key is the name of the template. The built-in templates are merged with the templates
+// of the sources configured by the user, a template of a source replacing any built-in or previous template with the same name.
+// Sources that can't be read are skipped with a warning, so that the templates of the other sources are still listed.
+func (tm *TemplateManager) ListTemplates(ctx context.Context) (map[string]Template, error) {
 	result := make(map[string]Template)
 	var templates []Template
 	err := json.Unmarshal(resources.TemplatesJson, &templates)
@@ -20,14 +57,62 @@ func (tm *TemplateManager) ListTemplates() (map[string]Template, error) {
 	}
 
 	for _, template := range templates {
+		template.Source = BuiltInSourceName
 		result[template.Name] = template
 	}
 
+	if tm.sources == nil {
+		return result, nil
+	}
+
+	sources, err := tm.sources.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, source := range sources {
+		sourceTemplates, err := tm.sourceTemplates(ctx, source, false)
+		if err != nil {
+			tm.warnSourceFailed(source, err)
+			continue
+		}
+
+		for _, template := range sourceTemplates {
+			if existing, has := result[template.Name]; has {
+				log.Printf("template '%s' of source '%s' replaces the template of source '%s'", template.Name, source.Name, existing.Source)
+			}
+
+			template.Source = source.Name
+			result[template.Name] = template
+		}
+	}
+
 	return result, nil
 }
 
-func (tm *TemplateManager) GetTemplate(templateName string) (Template, error) {
-	templates, err := tm.ListTemplates()
+// warnSourceFailed reports that the templates of a source could not be read, warning the user the first time only
+func (tm *TemplateManager) warnSourceFailed(source Source, err error) {
+	log.Printf("skipping templates of source '%s': %v", source.Name, err)
+
+	if tm.warnedSources[source.Name] {
+		return
+	}
+
+	if tm.warnedSources == nil {
+		tm.warnedSources = map[string]bool{}
+	}
+	tm.warnedSources[source.Name] = true
+
+	warnings := tm.warnings
+	if warnings == nil {
+		warnings = os.Stderr
+	}
+
+	fmt.Fprintf(warnings, "warning: skipping templates of source '%s': %v\n", source.Name, err)
+}
+
+func (tm *TemplateManager) GetTemplate(ctx context.Context, templateName string) (Template, error) {
+	templates, err := tm.ListTemplates(ctx)
 
 	if err != nil {
 		return Template{}, fmt.Errorf("unable to list templates: %w", err)
@@ -40,6 +125,199 @@ func (tm *TemplateManager) GetTemplate(templateName string) (Template, error) {
 	return Template{}, fmt.Errorf("template with name '%s' was not found", templateName)
 }
 
+// RefreshSources downloads the templates of all the remote sources again, regardless of the age of the cached templates
+func (tm *TemplateManager) RefreshSources(ctx context.Context) error {
+	if tm.sources == nil {
+		return nil
+	}
+
+	sources, err := tm.sources.List()
+	if err != nil {
+		return err
+	}
+
+	for _, source := range sources {
+		if !source.IsRemote() {
+			continue
+		}
+
+		if _, err := tm.sourceTemplates(ctx, source, true); err != nil {
+			return fmt.Errorf("refreshing templates of source '%s': %w", source.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// sourceTemplates reads the templates of a source. The templates of remote sources are read from the cache until
+// it expires, and the expired cache is used when the templates can't be downloaded.
+func (tm *TemplateManager) sourceTemplates(ctx context.Context, source Source, forceRefresh bool) ([]Template, error) {
+	if !source.IsRemote() {
+		return readSource(source)
+	}
+
+	cachePath := tm.sources.cachePath(source.Name)
+	cache, cacheErr := readCache(cachePath)
+	if cacheErr != nil && !errors.Is(cacheErr, fs.ErrNotExist) {
+		log.Printf("ignoring cached templates of source '%s': %v", source.Name, cacheErr)
+	}
+
+	if cacheErr == nil && !forceRefresh {
+		if expiresOn, err := time.Parse(time.RFC3339, cache.ExpiresOn); err == nil && time.Now().UTC().Before(expiresOn) {
+			return cache.Templates, nil
+		}
+	}
+
+	templates, err := tm.downloadSource(ctx, source)
+	if err != nil {
+		if cacheErr == nil && !forceRefresh {
+			log.Printf("failed to download templates of source '%s': %v, using the expired cache", source.Name, err)
+			return cache.Templates, nil
+		}
+
+		return nil, err
+	}
+
+	if err := writeCache(cachePath, cachedTemplates{
+		ExpiresOn: time.Now().UTC().Add(tm.refreshInterval).Format(time.RFC3339),
+		Templates: templates,
+	}); err != nil {
+		log.Printf("failed to cache templates of source '%s': %v", source.Name, err)
+	}
+
+	return templates, nil
+}
+
+func (tm *TemplateManager) downloadSource(ctx context.Context, source Source) ([]Template, error) {
+	switch source.Kind {
+	case SourceKindUrl:
+		httpClient := tm.httpClient
+		if httpClient == nil {
+			httpClient = httpUtil.GetHttpUtilFromContext(ctx)
+		}
+
+		response, err := httpClient.Send(&httpUtil.HttpRequestMessage{Url: source.Location, Method: http.MethodGet})
+		if err != nil {
+			return nil, fmt.Errorf("downloading templates: %w", err)
+		}
+
+		if response.Status != http.StatusOK {
+			return nil, fmt.Errorf("downloading templates from %s failed with status %d", source.Location, response.Status)
+		}
+
+		return parseTemplates(response.Body, source.Location)
+	case SourceKindGit:
+		cloneDir, err := os.MkdirTemp("", "azd-template-source")
+		if err != nil {
+			return nil, fmt.Errorf("creating temp folder: %w", err)
+		}
+		defer os.RemoveAll(cloneDir)
+
+		// git clones into a folder that does not exist yet
+		repositoryDir := filepath.Join(cloneDir, "repository")
+		if err := tm.gitCli.FetchCode(ctx, source.Location, "", repositoryDir); err != nil {
+			return nil, fmt.Errorf("cloning %s: %w", source.Location, err)
+		}
+
+		return readTemplatesFile(filepath.Join(repositoryDir, SourceFileName))
+	default:
+		return nil, fmt.Errorf("template sources of kind '%s' can't be downloaded", source.Kind)
+	}
+}
+
+// readSource reads the templates of a local source
+func readSource(source Source) ([]Template, error) {
+	if source.Kind == SourceKindFile {
+		return readTemplatesFile(source.Location)
+	}
+
+	entries, err := os.ReadDir(source.Location)
+	if err != nil {
+		return nil, fmt.Errorf("reading directory: %w", err)
+	}
+
+	// Files are read in name order so that templates are overridden deterministically
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	templates := []Template{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".json") {
+			continue
+		}
+
+		fileTemplates, err := readTemplatesFile(filepath.Join(source.Location, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		templates = append(templates, fileTemplates...)
+	}
+
+	return templates, nil
+}
+
+func readTemplatesFile(path string) ([]Template, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading templates: %w", err)
+	}
+
+	return parseTemplates(contents, path)
+}
+
+func parseTemplates(contents []byte, location string) ([]Template, error) {
+	var templates []Template
+	if err := json.Unmarshal(contents, &templates); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal templates JSON of %s: %w", location, err)
+	}
+
+	for i, template := range templates {
+		if template.Name == "" {
+			return nil, fmt.Errorf("template %d of %s has no name", i, location)
+		}
+	}
+
+	return templates, nil
+}
+
+func readCache(path string) (cachedTemplates, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return cachedTemplates{}, err
+	}
+
+	var cache cachedTemplates
+	if err := json.Unmarshal(contents, &cache); err != nil {
+		return cachedTemplates{}, fmt.Errorf("could not unmarshal cache file: %w", err)
+	}
+
+	return cache, nil
+}
+
+func writeCache(path string, cache cachedTemplates) error {
+	contents, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), osutil.PermissionDirectory); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, contents, osutil.PermissionFile)
+}
+
+// NewTemplateManager creates a template manager listing the built-in templates and the templates of the sources
+// configured by the user
 func NewTemplateManager() *TemplateManager {
-	return &TemplateManager{}
+	sources, err := NewSourceStore()
+	if err != nil {
+		log.Printf("%v, only listing the built-in templates", err)
+	}
+
+	return &TemplateManager{
+		sources:         sources,
+		refreshInterval: DefaultRefreshInterval,
+		gitCli:          git.NewGitCli(),
+	}
 }
@@ -1,9 +1,19 @@
 package templates
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/azure/azure-dev/cli/azd/pkg/httpUtil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -15,7 +25,7 @@ func TestNewTemplateManager(t *testing.T) {
 
 func TestListTemplates(t *testing.T) {
 	templateManager := NewTemplateManager()
-	templates, err := templateManager.ListTemplates()
+	templates, err := templateManager.ListTemplates(context.Background())
 
 	require.Greater(t, len(templates), 0)
 	require.Nil(t, err)
@@ -24,7 +34,7 @@ func TestListTemplates(t *testing.T) {
 func TestGetTemplateWithValidName(t *testing.T) {
 	templateName := "Azure-Samples/todo-nodejs-mongo"
 	templateManager := NewTemplateManager()
-	template, err := templateManager.GetTemplate(templateName)
+	template, err := templateManager.GetTemplate(context.Background(), templateName)
 
 	require.NotNil(t, template)
 	require.Equal(t, template.Name, templateName)
@@ -34,9 +44,147 @@ func TestGetTemplateWithValidName(t *testing.T) {
 func TestGetTemplateWithInvalidName(t *testing.T) {
 	templateName := "not-a-valid-template-name"
 	templateManager := NewTemplateManager()
-	template, err := templateManager.GetTemplate(templateName)
+	template, err := templateManager.GetTemplate(context.Background(), templateName)
 
 	require.Equal(t, template, Template{})
 	require.NotNil(t, err)
 	require.Equal(t, err.Error(), fmt.Sprintf("template with name '%s' was not found", templateName))
 }
+
+// newTestTemplateManager creates a template manager reading the sources configured in the given directory
+func newTestTemplateManager(configDir string, httpClient httpUtil.HttpUtil) *TemplateManager {
+	return &TemplateManager{
+		sources:         NewSourceStoreWithDirectory(configDir),
+		refreshInterval: DefaultRefreshInterval,
+		httpClient:      httpClient,
+	}
+}
+
+func writeTemplates(t *testing.T, path string, templates ...Template) {
+	contents, err := json.Marshal(templates)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, contents, 0600))
+}
+
+func TestListTemplatesWithLocalSources(t *testing.T) {
+	configDir := t.TempDir()
+	sourcesDir := t.TempDir()
+
+	filePath := filepath.Join(sourcesDir, "contoso.json")
+	writeTemplates(t, filePath,
+		Template{Name: "contoso/starter", Description: "Contoso starter", RepositoryPath: "https://contoso.com/starter.git"},
+		// Replaces the built-in template
+		Template{Name: "Azure-Samples/todo-nodejs-mongo", Description: "Contoso todo", RepositoryPath: "contoso/todo"},
+	)
+
+	directoryPath := filepath.Join(sourcesDir, "team")
+	require.NoError(t, os.Mkdir(directoryPath, 0755))
+	writeTemplates(t, filepath.Join(directoryPath, "a.json"), Template{Name: "team/api", RepositoryPath: "team/api"})
+	writeTemplates(t, filepath.Join(directoryPath, "b.json"), Template{Name: "team/web", RepositoryPath: "team/web"})
+	require.NoError(t, os.WriteFile(filepath.Join(directoryPath, "README.md"), []byte("# Templates"), 0600))
+
+	store := NewSourceStoreWithDirectory(configDir)
+	for _, location := range []string{filePath, directoryPath} {
+		source, err := NewSource(filepath.Base(location), "", location)
+		require.NoError(t, err)
+		require.NoError(t, store.Add(source))
+	}
+
+	templates, err := newTestTemplateManager(configDir, nil).ListTemplates(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, "contoso.json", templates["contoso/starter"].Source)
+	require.Equal(t, "Contoso todo", templates["Azure-Samples/todo-nodejs-mongo"].Description)
+	require.Equal(t, "team", templates["team/api"].Source)
+	require.Contains(t, templates, "team/web")
+	require.Equal(t, BuiltInSourceName, templates["Azure-Samples/todo-python-mongo"].Source)
+}
+
+func TestListTemplatesWithUrlSource(t *testing.T) {
+	configDir := t.TempDir()
+
+	requests := 0
+	available := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode([]Template{{Name: "contoso/remote", RepositoryPath: "contoso/remote"}})
+	}))
+	defer server.Close()
+
+	source, err := NewSource("remote", "", server.URL+"/templates.json")
+	require.NoError(t, err)
+	require.Equal(t, SourceKindUrl, source.Kind)
+	require.NoError(t, NewSourceStoreWithDirectory(configDir).Add(source))
+
+	templateManager := newTestTemplateManager(configDir, httpUtil.NewHttpUtil())
+
+	t.Run("Download", func(t *testing.T) {
+		template, err := templateManager.GetTemplate(context.Background(), "contoso/remote")
+		require.NoError(t, err)
+		require.Equal(t, "remote", template.Source)
+		require.Equal(t, 1, requests)
+	})
+
+	t.Run("Cached", func(t *testing.T) {
+		_, err := templateManager.GetTemplate(context.Background(), "contoso/remote")
+		require.NoError(t, err)
+		require.Equal(t, 1, requests)
+	})
+
+	t.Run("Refresh", func(t *testing.T) {
+		require.NoError(t, templateManager.RefreshSources(context.Background()))
+		require.Equal(t, 2, requests)
+	})
+
+	t.Run("ExpiredCacheUsedWhenUnavailable", func(t *testing.T) {
+		// Cache templates which are already expired
+		templateManager.refreshInterval = -time.Hour
+		require.NoError(t, templateManager.RefreshSources(context.Background()))
+		require.Equal(t, 3, requests)
+
+		available = false
+		_, err := templateManager.GetTemplate(context.Background(), "contoso/remote")
+		require.NoError(t, err)
+		require.Equal(t, 4, requests)
+
+		require.Error(t, templateManager.RefreshSources(context.Background()))
+	})
+}
+
+func TestListTemplatesWithFailingSource(t *testing.T) {
+	configDir := t.TempDir()
+	sourcesDir := t.TempDir()
+
+	validPath := filepath.Join(sourcesDir, "valid.json")
+	writeTemplates(t, validPath, Template{Name: "contoso/starter", RepositoryPath: "contoso/starter"})
+
+	invalidPath := filepath.Join(sourcesDir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("not json"), 0600))
+
+	store := NewSourceStoreWithDirectory(configDir)
+	for _, location := range []string{invalidPath, validPath} {
+		source, err := NewSource(filepath.Base(location), "", location)
+		require.NoError(t, err)
+		require.NoError(t, store.Add(source))
+	}
+
+	warnings := &bytes.Buffer{}
+	templateManager := newTestTemplateManager(configDir, nil)
+	templateManager.warnings = warnings
+
+	// The templates of the other sources are still listed
+	templates, err := templateManager.ListTemplates(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "valid.json", templates["contoso/starter"].Source)
+	require.Contains(t, templates, "Azure-Samples/todo-nodejs-mongo")
+
+	// The failing source is only warned about once
+	_, err = templateManager.GetTemplate(context.Background(), "contoso/starter")
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(warnings.String(), "warning: skipping templates of source 'invalid.json'"))
+}
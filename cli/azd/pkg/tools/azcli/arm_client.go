@@ -0,0 +1,1076 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azcli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azure"
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/httpUtil"
+)
+
+const (
+	defaultArmEndpoint     = "https://management.azure.com"
+	defaultArmPollInterval = 5 * time.Second
+
+	armResourcesApiVersion     = "2021-04-01"
+	armSubscriptionsApiVersion = "2020-01-01"
+	armWebApiVersion           = "2022-03-01"
+	armContainerAppsApiVersion = "2022-03-01"
+	armKeyVaultApiVersion      = "2022-07-01"
+	armResourceGraphApiVersion = "2021-03-01"
+	armRegistryApiVersion      = "2019-05-01"
+	armRegistryTasksApiVersion = "2019-04-01"
+)
+
+type NewArmClientArgs struct {
+	// AzCli handles the operations that have no Azure Resource Manager equivalent,
+	// like signing in, zip deployments & deploying Bicep modules, unless WithoutAzCli is set.
+	AzCli AzCli
+	// TokenSource provides the tokens used to authenticate requests.
+	// Defaults to the access tokens of the account signed in to the Azure CLI.
+	TokenSource TokenSource
+	// HttpClient allows us to stub out the http requests for testing.
+	// Defaults to the client found in the context of each request.
+	HttpClient httpUtil.HttpUtil
+	// Endpoint is the Azure Resource Manager endpoint, defaults to https://management.azure.com
+	Endpoint string
+	// PollInterval is the time to wait between checks on the status of long running operations
+	PollInterval time.Duration
+	// WithoutAzCli makes the client independent of the Azure CLI. The operations otherwise handled by AzCli are sent
+	// as REST requests, or fail with ErrAzCliRequired when there is no REST equivalent.
+	WithoutAzCli bool
+	// KeyVaultTokenSource provides the tokens used to authenticate requests to Key Vault when WithoutAzCli is set.
+	KeyVaultTokenSource TokenSource
+	// RunWithResultFn allows us to stub out the commands run without the Azure CLI, like `docker login` & `bicep build`
+	RunWithResultFn func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error)
+}
+
+// NewArmClient creates an AzCli that calls the Azure Resource Manager REST API directly instead of running `az` commands.
+func NewArmClient(args NewArmClientArgs) AzCli {
+	if args.AzCli == nil {
+		args.AzCli = NewAzCli(NewAzCliArgs{})
+	}
+
+	if args.TokenSource == nil {
+		args.TokenSource = NewAzCliTokenSource(args.AzCli)
+	}
+
+	if args.Endpoint == "" {
+		args.Endpoint = defaultArmEndpoint
+	}
+
+	if args.PollInterval == 0 {
+		args.PollInterval = defaultArmPollInterval
+	}
+
+	if args.RunWithResultFn == nil {
+		args.RunWithResultFn = executil.RunWithResult
+	}
+
+	cli := &armClient{
+		AzCli:           args.AzCli,
+		tokenSource:     NewCachingTokenSource(args.TokenSource),
+		httpClient:      args.HttpClient,
+		endpoint:        strings.TrimSuffix(args.Endpoint, "/"),
+		pollInterval:    args.PollInterval,
+		withoutAzCli:    args.WithoutAzCli,
+		runWithResultFn: args.RunWithResultFn,
+	}
+
+	if args.KeyVaultTokenSource != nil {
+		cli.keyVaultTokenSource = NewCachingTokenSource(args.KeyVaultTokenSource)
+	}
+
+	return cli
+}
+
+// armClient implements the AzCli operations with Azure Resource Manager requests.
+// Operations without a REST equivalent are handled by the embedded AzCli, unless the client runs without the Azure CLI.
+type armClient struct {
+	AzCli
+
+	tokenSource         TokenSource
+	keyVaultTokenSource TokenSource
+	httpClient          httpUtil.HttpUtil
+	endpoint            string
+	pollInterval        time.Duration
+	withoutAzCli        bool
+	runWithResultFn     func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error)
+}
+
+func (cli *armClient) GetAccessToken(ctx context.Context) (AzCliAccessToken, error) {
+	return cli.tokenSource.GetToken(ctx)
+}
+
+func (cli *armClient) GetSubscriptionTenant(ctx context.Context, subscriptionId string) (string, error) {
+	var subscription struct {
+		TenantId string `json:"tenantId"`
+	}
+
+	if err := cli.get(ctx, armPath(azure.SubscriptionRID(subscriptionId), armSubscriptionsApiVersion), &subscription); err != nil {
+		return "", fmt.Errorf("failed getting subscription: %w", err)
+	}
+
+	return subscription.TenantId, nil
+}
+
+func (cli *armClient) GetSubscriptionDeployment(ctx context.Context, subscriptionId string, deploymentName string) (AzCliDeployment, error) {
+	return cli.getDeployment(ctx, azure.SubscriptionDeploymentRID(subscriptionId, deploymentName))
+}
+
+func (cli *armClient) GetResourceGroupDeployment(ctx context.Context, subscriptionId string, resourceGroupName string, deploymentName string) (AzCliDeployment, error) {
+	return cli.getDeployment(ctx, azure.ResourceGroupDeploymentRID(subscriptionId, resourceGroupName, deploymentName))
+}
+
+func (cli *armClient) GetResource(ctx context.Context, subscriptionId string, resourceId string) (AzCliResourceExtended, error) {
+	apiVersion, err := cli.resourceApiVersion(ctx, subscriptionId, resourceId)
+	if err != nil {
+		return AzCliResourceExtended{}, err
+	}
+
+	var resource AzCliResourceExtended
+	if err := cli.get(ctx, armPath(resourceId, apiVersion), &resource); err != nil {
+		return AzCliResourceExtended{}, fmt.Errorf("failed getting resource: %w", err)
+	}
+
+	return resource, nil
+}
+
+func (cli *armClient) GetKeyVault(ctx context.Context, subscriptionId string, vaultName string) (AzCliKeyVault, error) {
+	vaultsPath := fmt.Sprintf("%s/providers/Microsoft.KeyVault/vaults", azure.SubscriptionRID(subscriptionId))
+	vaults, err := armList[AzCliKeyVault](ctx, cli, armPath(vaultsPath, armKeyVaultApiVersion))
+	if err != nil {
+		return AzCliKeyVault{}, fmt.Errorf("failed listing key vaults: %w", err)
+	}
+
+	for _, vault := range vaults {
+		if strings.EqualFold(vault.Name, vaultName) {
+			return vault, nil
+		}
+	}
+
+	return AzCliKeyVault{}, fmt.Errorf("key vault '%s' was not found", vaultName)
+}
+
+func (cli *armClient) PurgeKeyVault(ctx context.Context, subscriptionId string, vaultName string) error {
+	deletedVaultsPath := fmt.Sprintf("%s/providers/Microsoft.KeyVault/deletedVaults", azure.SubscriptionRID(subscriptionId))
+	deletedVaults, err := armList[AzCliResource](ctx, cli, armPath(deletedVaultsPath, armKeyVaultApiVersion))
+	if err != nil {
+		return fmt.Errorf("failed listing deleted key vaults: %w", err)
+	}
+
+	for _, deletedVault := range deletedVaults {
+		if !strings.EqualFold(deletedVault.Name, vaultName) {
+			continue
+		}
+
+		// The id of a deleted vault includes the location the vault was deleted from
+		response, err := cli.send(ctx, http.MethodPost, armPath(deletedVault.Id+"/purge", armKeyVaultApiVersion), nil)
+		if err != nil {
+			return fmt.Errorf("failed purging key vault: %w", err)
+		}
+
+		if _, err := cli.waitForOperation(ctx, response); err != nil {
+			return fmt.Errorf("failed purging key vault: %w", err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("deleted key vault '%s' was not found", vaultName)
+}
+
+type acrBuildSourceUpload struct {
+	UploadUrl    string `json:"uploadUrl"`
+	RelativePath string `json:"relativePath"`
+}
+
+type acrRun struct {
+	Id         string `json:"id"`
+	Properties struct {
+		RunId  string `json:"runId"`
+		Status string `json:"status"`
+	} `json:"properties"`
+}
+
+type acrDockerBuildRequest struct {
+	Type           string                `json:"type"`
+	IsPushEnabled  bool                  `json:"isPushEnabled"`
+	ImageNames     []string              `json:"imageNames"`
+	SourceLocation string                `json:"sourceLocation"`
+	DockerFilePath string                `json:"dockerFilePath"`
+	Target         string                `json:"target,omitempty"`
+	Arguments      []acrBuildArgument    `json:"arguments"`
+	Platform       acrPlatformProperties `json:"platform"`
+}
+
+type acrBuildArgument struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	IsSecret bool   `json:"isSecret"`
+}
+
+type acrPlatformProperties struct {
+	Os           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (cli *armClient) BuildAcrImage(ctx context.Context, subscriptionId string, loginServer string, args AcrBuildArgs, logWriter io.Writer) error {
+	registryId, err := cli.findRegistry(ctx, subscriptionId, loginServer)
+	if err != nil {
+		return err
+	}
+
+	// The build context is uploaded to the storage of the registry
+	var upload acrBuildSourceUpload
+	response, err := cli.send(ctx, http.MethodPost, armPath(registryId+"/listBuildSourceUploadUrl", armRegistryTasksApiVersion), nil)
+	if err != nil {
+		return fmt.Errorf("failed getting build source upload url: %w", err)
+	}
+
+	if err := json.Unmarshal(response.Body, &upload); err != nil {
+		return fmt.Errorf("could not unmarshal response %s: %w", string(response.Body), err)
+	}
+
+	source, err := os.ReadFile(args.SourcePath)
+	if err != nil {
+		return fmt.Errorf("reading build context: %w", err)
+	}
+
+	uploadResponse, err := cli.client(ctx).Send(&httpUtil.HttpRequestMessage{
+		Url:    upload.UploadUrl,
+		Method: http.MethodPut,
+		Headers: map[string]string{
+			"Content-Type":   "application/octet-stream",
+			"x-ms-blob-type": "BlockBlob",
+		},
+		Body: string(source),
+	})
+	if err != nil {
+		return fmt.Errorf("uploading build context: %w", err)
+	} else if uploadResponse.Status >= http.StatusBadRequest {
+		return fmt.Errorf("uploading build context: %d: %s", uploadResponse.Status, string(uploadResponse.Body))
+	}
+
+	request := acrDockerBuildRequest{
+		Type:           "DockerBuildRequest",
+		IsPushEnabled:  true,
+		ImageNames:     args.ImageNames,
+		SourceLocation: upload.RelativePath,
+		DockerFilePath: args.DockerfilePath,
+		Target:         args.Target,
+		Arguments:      []acrBuildArgument{},
+		Platform:       parseAcrPlatform(args.Platform),
+	}
+
+	buildArgNames := []string{}
+	for name := range args.BuildArgs {
+		buildArgNames = append(buildArgNames, name)
+	}
+	sort.Strings(buildArgNames)
+
+	for _, name := range buildArgNames {
+		request.Arguments = append(request.Arguments, acrBuildArgument{Name: name, Value: args.BuildArgs[name]})
+	}
+
+	response, err = cli.send(ctx, http.MethodPost, armPath(registryId+"/scheduleRun", armRegistryTasksApiVersion), request)
+	if err != nil {
+		return fmt.Errorf("failed scheduling build: %w", err)
+	}
+
+	response, err = cli.waitForOperation(ctx, response)
+	if err != nil {
+		return fmt.Errorf("failed scheduling build: %w", err)
+	}
+
+	var run acrRun
+	if err := json.Unmarshal(response.Body, &run); err != nil {
+		return fmt.Errorf("could not unmarshal response %s: %w", string(response.Body), err)
+	}
+
+	return cli.waitForAcrRun(ctx, registryId, run.Properties.RunId, logWriter)
+}
+
+// Waits for the run of the registry to complete, copying the log of the run to logWriter as it is written
+func (cli *armClient) waitForAcrRun(ctx context.Context, registryId string, runId string, logWriter io.Writer) error {
+	runPath := fmt.Sprintf("%s/runs/%s", registryId, runId)
+
+	var logLink struct {
+		LogLink string `json:"logLink"`
+	}
+
+	response, err := cli.send(ctx, http.MethodPost, armPath(runPath+"/listLogSasUrl", armRegistryTasksApiVersion), nil)
+	if err != nil {
+		return fmt.Errorf("failed getting build log url: %w", err)
+	}
+
+	if err := json.Unmarshal(response.Body, &logLink); err != nil {
+		return fmt.Errorf("could not unmarshal response %s: %w", string(response.Body), err)
+	}
+
+	logOffset := 0
+	for {
+		var run acrRun
+		if err := cli.get(ctx, armPath(runPath, armRegistryTasksApiVersion), &run); err != nil {
+			return fmt.Errorf("failed getting build status: %w", err)
+		}
+
+		// The status is read before the log, so the log is complete once the run is done
+		if logOffset, err = cli.copyAcrLog(ctx, logLink.LogLink, logOffset, logWriter); err != nil {
+			return err
+		}
+
+		switch run.Properties.Status {
+		case "Succeeded":
+			return nil
+		case "Failed", "Canceled", "Error", "Timeout":
+			return fmt.Errorf("build %s in the registry did not succeed, status: %s", runId, run.Properties.Status)
+		}
+
+		if err := cli.wait(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// Copies the content of the log blob written after the offset to the writer, returning the new offset
+func (cli *armClient) copyAcrLog(ctx context.Context, logLink string, offset int, logWriter io.Writer) (int, error) {
+	response, err := cli.client(ctx).Send(&httpUtil.HttpRequestMessage{
+		Url:     logLink,
+		Method:  http.MethodGet,
+		Headers: map[string]string{"Range": fmt.Sprintf("bytes=%d-", offset)},
+	})
+	if err != nil {
+		return offset, fmt.Errorf("reading build log: %w", err)
+	}
+
+	switch response.Status {
+	case http.StatusPartialContent:
+		// The range starts at the offset
+	case http.StatusOK:
+		// The range was ignored, the whole log is returned
+		if offset >= len(response.Body) {
+			return offset, nil
+		}
+		response.Body = response.Body[offset:]
+	case http.StatusNotFound, http.StatusRequestedRangeNotSatisfiable:
+		// Nothing was written yet
+		return offset, nil
+	default:
+		return offset, fmt.Errorf("reading build log: %d: %s", response.Status, string(response.Body))
+	}
+
+	if _, err := logWriter.Write(response.Body); err != nil {
+		return offset, err
+	}
+
+	return offset + len(response.Body), nil
+}
+
+// Finds the id of the container registry with the login server in the subscription
+func (cli *armClient) findRegistry(ctx context.Context, subscriptionId string, loginServer string) (string, error) {
+	type registry struct {
+		Id         string `json:"id"`
+		Properties struct {
+			LoginServer string `json:"loginServer"`
+		} `json:"properties"`
+	}
+
+	registriesPath := fmt.Sprintf("%s/providers/Microsoft.ContainerRegistry/registries", azure.SubscriptionRID(subscriptionId))
+	registries, err := armList[registry](ctx, cli, armPath(registriesPath, armRegistryApiVersion))
+	if err != nil {
+		return "", fmt.Errorf("failed listing container registries: %w", err)
+	}
+
+	for _, registry := range registries {
+		if strings.EqualFold(registry.Properties.LoginServer, loginServer) {
+			return registry.Id, nil
+		}
+	}
+
+	return "", fmt.Errorf("container registry '%s' was not found in subscription '%s'", loginServer, subscriptionId)
+}
+
+// The media types of the image manifests accepted from container registries
+var acrManifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+func (cli *armClient) GetAcrImageDigest(ctx context.Context, loginServer string, image string) (string, error) {
+	repository, reference := image, "latest"
+	if index := strings.LastIndex(image, ":"); index > strings.LastIndex(image, "/") {
+		repository, reference = image[:index], image[index+1:]
+	}
+
+	token, err := cli.acrAccessToken(ctx, loginServer, fmt.Sprintf("repository:%s:pull", repository))
+	if err != nil {
+		return "", err
+	}
+
+	response, err := cli.client(ctx).Send(&httpUtil.HttpRequestMessage{
+		Url:    fmt.Sprintf("https://%s/v2/%s/manifests/%s", loginServer, repository, reference),
+		Method: http.MethodHead,
+		Headers: map[string]string{
+			"Authorization": fmt.Sprintf("Bearer %s", token),
+			"Accept":        strings.Join(acrManifestMediaTypes, ", "),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("getting manifest of image %s: %w", image, err)
+	}
+
+	switch response.Status {
+	case http.StatusOK:
+		return response.Headers["Docker-Content-Digest"], nil
+	case http.StatusNotFound:
+		return "", ErrAcrImageNotFound
+	default:
+		return "", fmt.Errorf("getting manifest of image %s: %d: %s", image, response.Status, string(response.Body))
+	}
+}
+
+// Exchanges the Azure access token for an access token of the container registry limited to the scope,
+// ex) repository:app/web:pull
+func (cli *armClient) acrAccessToken(ctx context.Context, loginServer string, scope string) (string, error) {
+	refreshToken, err := cli.acrRefreshToken(ctx, loginServer)
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := cli.acrTokenRequest(ctx, loginServer, "token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"service":       {loginServer},
+		"scope":         {scope},
+		"refresh_token": {refreshToken},
+	}, &token); err != nil {
+		return "", err
+	}
+
+	return token.AccessToken, nil
+}
+
+// Exchanges the Azure access token for a refresh token of the container registry
+func (cli *armClient) acrRefreshToken(ctx context.Context, loginServer string) (string, error) {
+	accessToken, err := cli.tokenSource.GetToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("getting access token: %w", err)
+	}
+
+	var token struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := cli.acrTokenRequest(ctx, loginServer, "exchange", url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {loginServer},
+		"access_token": {accessToken.AccessToken},
+	}, &token); err != nil {
+		return "", err
+	}
+
+	return token.RefreshToken, nil
+}
+
+func (cli *armClient) acrTokenRequest(ctx context.Context, loginServer string, endpoint string, form url.Values, result interface{}) error {
+	response, err := cli.client(ctx).Send(&httpUtil.HttpRequestMessage{
+		Url:     fmt.Sprintf("https://%s/oauth2/%s", loginServer, endpoint),
+		Method:  http.MethodPost,
+		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		Body:    form.Encode(),
+	})
+	if err != nil {
+		return fmt.Errorf("getting token of registry '%s': %w", loginServer, err)
+	} else if response.Status != http.StatusOK {
+		return fmt.Errorf("getting token of registry '%s': %d: %s", loginServer, response.Status, string(response.Body))
+	}
+
+	if err := json.Unmarshal(response.Body, result); err != nil {
+		return fmt.Errorf("could not unmarshal response %s: %w", string(response.Body), err)
+	}
+
+	return nil
+}
+
+// Parses a Docker platform, ex) amd64, linux/amd64 or linux/arm64/v8. The OS defaults to linux.
+func parseAcrPlatform(platform string) acrPlatformProperties {
+	parts := strings.Split(platform, "/")
+	if len(parts) == 1 {
+		parts = []string{"linux", parts[0]}
+	}
+
+	properties := acrPlatformProperties{Os: parts[0], Architecture: parts[1]}
+	if properties.Architecture == "" {
+		properties.Architecture = "amd64"
+	}
+
+	if len(parts) > 2 {
+		properties.Variant = parts[2]
+	}
+
+	return properties
+}
+
+func (cli *armClient) GetAppServiceProperties(ctx context.Context, subscriptionId string, resourceGroup string, appName string) (AzCliAppServiceProperties, error) {
+	var site struct {
+		Properties AzCliAppServiceProperties `json:"properties"`
+	}
+
+	if err := cli.get(ctx, armPath(azure.WebsiteRID(subscriptionId, resourceGroup, appName), armWebApiVersion), &site); err != nil {
+		return AzCliAppServiceProperties{}, fmt.Errorf("failed getting webapp properties: %w", err)
+	}
+
+	return site.Properties, nil
+}
+
+func (cli *armClient) GetContainerAppProperties(ctx context.Context, subscriptionId, resourceGroup, appName string) (AzCliContainerAppProperties, error) {
+	var containerApp AzCliContainerAppProperties
+	if err := cli.get(ctx, armPath(azure.ContainerAppRID(subscriptionId, resourceGroup, appName), armContainerAppsApiVersion), &containerApp); err != nil {
+		return AzCliContainerAppProperties{}, fmt.Errorf("failed getting containerapp properties: %w", err)
+	}
+
+	return containerApp, nil
+}
+
+func (cli *armClient) GetFunctionAppProperties(ctx context.Context, subscriptionID string, resourceGroup string, funcName string) (AzCliFunctionAppProperties, error) {
+	var site struct {
+		Properties AzCliFunctionAppProperties `json:"properties"`
+	}
+
+	if err := cli.get(ctx, armPath(azure.WebsiteRID(subscriptionID, resourceGroup, funcName), armWebApiVersion), &site); err != nil {
+		return AzCliFunctionAppProperties{}, fmt.Errorf("failed getting functionapp properties: %w", err)
+	}
+
+	return site.Properties, nil
+}
+
+func (cli *armClient) GetStaticWebAppProperties(ctx context.Context, subscriptionID string, resourceGroup string, appName string) (AzCliStaticWebAppProperties, error) {
+	var staticSite struct {
+		Properties AzCliStaticWebAppProperties `json:"properties"`
+	}
+
+	if err := cli.get(ctx, armPath(azure.StaticWebAppRID(subscriptionID, resourceGroup, appName), armWebApiVersion), &staticSite); err != nil {
+		return AzCliStaticWebAppProperties{}, fmt.Errorf("failed getting staticwebapp properties: %w", err)
+	}
+
+	return staticSite.Properties, nil
+}
+
+func (cli *armClient) GetStaticWebAppEnvironmentProperties(ctx context.Context, subscriptionID string, resourceGroup string, appName string, environmentName string) (AzCliStaticWebAppEnvironmentProperties, error) {
+	var build struct {
+		Properties AzCliStaticWebAppEnvironmentProperties `json:"properties"`
+	}
+
+	buildPath := fmt.Sprintf("%s/builds/%s", azure.StaticWebAppRID(subscriptionID, resourceGroup, appName), environmentName)
+	if err := cli.get(ctx, armPath(buildPath, armWebApiVersion), &build); err != nil {
+		return AzCliStaticWebAppEnvironmentProperties{}, fmt.Errorf("failed getting staticwebapp environment properties: %w", err)
+	}
+
+	return build.Properties, nil
+}
+
+func (cli *armClient) GetStaticWebAppApiKey(ctx context.Context, subscriptionID string, resourceGroup string, appName string) (string, error) {
+	secretsPath := fmt.Sprintf("%s/listSecrets", azure.StaticWebAppRID(subscriptionID, resourceGroup, appName))
+	response, err := cli.send(ctx, http.MethodPost, armPath(secretsPath, armWebApiVersion), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed getting staticwebapp api key: %w", err)
+	}
+
+	var secrets struct {
+		Properties struct {
+			ApiKey string `json:"apiKey"`
+		} `json:"properties"`
+	}
+
+	if err := json.Unmarshal(response.Body, &secrets); err != nil {
+		return "", fmt.Errorf("could not unmarshal response %s as staticwebapp secrets: %w", string(response.Body), err)
+	}
+
+	return secrets.Properties.ApiKey, nil
+}
+
+func (cli *armClient) DeployToSubscription(ctx context.Context, subscriptionId string, deploymentName string, templatePath string, parametersPath string, location string) (AzCliDeploymentResult, error) {
+	// Bicep modules must be compiled before they can be deployed, which is left to the Azure CLI when it is used
+	if isBicepModule(templatePath) && !cli.withoutAzCli {
+		return cli.AzCli.DeployToSubscription(ctx, subscriptionId, deploymentName, templatePath, parametersPath, location)
+	}
+
+	return cli.deploy(ctx, azure.SubscriptionDeploymentRID(subscriptionId, deploymentName), location, templatePath, parametersPath)
+}
+
+func (cli *armClient) DeployToResourceGroup(ctx context.Context, subscriptionId string, resourceGroup string, deploymentName string, templatePath string, parametersPath string) (AzCliDeploymentResult, error) {
+	if isBicepModule(templatePath) && !cli.withoutAzCli {
+		return cli.AzCli.DeployToResourceGroup(ctx, subscriptionId, resourceGroup, deploymentName, templatePath, parametersPath)
+	}
+
+	return cli.deploy(ctx, azure.ResourceGroupDeploymentRID(subscriptionId, resourceGroup, deploymentName), "", templatePath, parametersPath)
+}
+
+func (cli *armClient) WhatIfDeployToSubscription(ctx context.Context, subscriptionId string, deploymentName string, templatePath string, parametersPath string, location string) (AzCliWhatIfResult, error) {
+	if isBicepModule(templatePath) && !cli.withoutAzCli {
+		return cli.AzCli.WhatIfDeployToSubscription(ctx, subscriptionId, deploymentName, templatePath, parametersPath, location)
+	}
+
+	return cli.whatIf(ctx, azure.SubscriptionDeploymentRID(subscriptionId, deploymentName), location, templatePath, parametersPath)
+}
+
+func (cli *armClient) WhatIfDeployToResourceGroup(ctx context.Context, subscriptionId string, resourceGroup string, deploymentName string, templatePath string, parametersPath string) (AzCliWhatIfResult, error) {
+	if isBicepModule(templatePath) && !cli.withoutAzCli {
+		return cli.AzCli.WhatIfDeployToResourceGroup(ctx, subscriptionId, resourceGroup, deploymentName, templatePath, parametersPath)
+	}
+
+	return cli.whatIf(ctx, azure.ResourceGroupDeploymentRID(subscriptionId, resourceGroup, deploymentName), "", templatePath, parametersPath)
+}
+
+func (cli *armClient) DeleteSubscriptionDeployment(ctx context.Context, subscriptionId string, deploymentName string) error {
+	if err := cli.delete(ctx, armPath(azure.SubscriptionDeploymentRID(subscriptionId, deploymentName), armResourcesApiVersion)); err != nil {
+		return fmt.Errorf("failed deleting subscription deployment: %w", err)
+	}
+
+	return nil
+}
+
+func (cli *armClient) DeleteResourceGroup(ctx context.Context, subscriptionId string, resourceGroupName string) error {
+	if err := cli.delete(ctx, armPath(azure.ResourceGroupRID(subscriptionId, resourceGroupName), armResourcesApiVersion)); err != nil {
+		return fmt.Errorf("failed deleting resource group: %w", err)
+	}
+
+	return nil
+}
+
+func (cli *armClient) ListResourceGroupResources(ctx context.Context, subscriptionId string, resourceGroupName string) ([]AzCliResource, error) {
+	resourcesPath := fmt.Sprintf("%s/resources", azure.ResourceGroupRID(subscriptionId, resourceGroupName))
+	resources, err := armList[AzCliResource](ctx, cli, armPath(resourcesPath, armResourcesApiVersion))
+	if err != nil {
+		return nil, fmt.Errorf("failed listing resource group resources: %w", err)
+	}
+
+	return resources, nil
+}
+
+func (cli *armClient) ListSubscriptionDeploymentOperations(ctx context.Context, subscriptionId string, deploymentName string) ([]AzCliResourceOperation, error) {
+	return cli.listDeploymentOperations(ctx, azure.SubscriptionDeploymentRID(subscriptionId, deploymentName))
+}
+
+func (cli *armClient) ListResourceGroupDeploymentOperations(ctx context.Context, subscriptionId string, resourceGroupName string, deploymentName string) ([]AzCliResourceOperation, error) {
+	return cli.listDeploymentOperations(ctx, azure.ResourceGroupDeploymentRID(subscriptionId, resourceGroupName, deploymentName))
+}
+
+func (cli *armClient) GraphQuery(ctx context.Context, query string, subscriptions []string) (*AzCliGraphQuery, error) {
+	requestBody := GraphQueryRequest{
+		Subscriptions: subscriptions,
+		Query:         query,
+	}
+
+	response, err := cli.send(ctx, http.MethodPost, armPath("/providers/Microsoft.ResourceGraph/resources", armResourceGraphApiVersion), requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed running graph query: %w", err)
+	}
+
+	var graphQueryResult AzCliGraphQuery
+	if err := json.Unmarshal(response.Body, &graphQueryResult); err != nil {
+		return nil, fmt.Errorf("could not unmarshal response %s as an AzCliGraphQuery: %w", string(response.Body), err)
+	}
+
+	return &graphQueryResult, nil
+}
+
+func (cli *armClient) getDeployment(ctx context.Context, deploymentId string) (AzCliDeployment, error) {
+	var deployment AzCliDeployment
+	if err := cli.get(ctx, armPath(deploymentId, armResourcesApiVersion), &deployment); err != nil {
+		return AzCliDeployment{}, fmt.Errorf("failed getting deployment: %w", err)
+	}
+
+	return deployment, nil
+}
+
+func (cli *armClient) listDeploymentOperations(ctx context.Context, deploymentId string) ([]AzCliResourceOperation, error) {
+	operations, err := armList[AzCliResourceOperation](ctx, cli, armPath(deploymentId+"/operations", armResourcesApiVersion))
+	if err != nil {
+		return nil, fmt.Errorf("failed listing deployment operations: %w", err)
+	}
+
+	return operations, nil
+}
+
+type armDeploymentRequest struct {
+	Location   string                         `json:"location,omitempty"`
+	Properties armDeploymentRequestProperties `json:"properties"`
+}
+
+type armDeploymentRequestProperties struct {
+	Mode       string          `json:"mode"`
+	Template   json.RawMessage `json:"template"`
+	Parameters json.RawMessage `json:"parameters"`
+}
+
+// Starts the deployment of an ARM template & waits for the deployment to complete
+func (cli *armClient) deploy(ctx context.Context, deploymentId string, location string, templatePath string, parametersPath string) (AzCliDeploymentResult, error) {
+	template, err := cli.readTemplate(ctx, templatePath)
+	if err != nil {
+		return AzCliDeploymentResult{}, err
+	}
+
+	request, err := newArmDeploymentRequest(location, template, parametersPath)
+	if err != nil {
+		return AzCliDeploymentResult{}, err
+	}
+
+	if _, err := cli.send(ctx, http.MethodPut, armPath(deploymentId, armResourcesApiVersion), request); err != nil {
+		return AzCliDeploymentResult{}, fmt.Errorf("failed starting deployment: %w", err)
+	}
+
+	for {
+		var deployment struct {
+			Properties struct {
+				ProvisioningState string                           `json:"provisioningState"`
+				Error             *AzCliDeploymentErrorResponse    `json:"error"`
+				Outputs           map[string]AzCliDeploymentOutput `json:"outputs"`
+			} `json:"properties"`
+		}
+
+		if err := cli.get(ctx, armPath(deploymentId, armResourcesApiVersion), &deployment); err != nil {
+			return AzCliDeploymentResult{}, fmt.Errorf("failed getting deployment status: %w", err)
+		}
+
+		switch deployment.Properties.ProvisioningState {
+		case "Succeeded":
+			return AzCliDeploymentResult{
+				Properties: AzCliDeploymentResultProperties{
+					Outputs: deployment.Properties.Outputs,
+				},
+			}, nil
+		case "Failed", "Canceled":
+			if deployment.Properties.Error != nil {
+				return AzCliDeploymentResult{}, fmt.Errorf("deployment %s: %w", strings.ToLower(deployment.Properties.ProvisioningState), newArmError(0, *deployment.Properties.Error))
+			}
+
+			return AzCliDeploymentResult{}, fmt.Errorf("deployment %s", strings.ToLower(deployment.Properties.ProvisioningState))
+		}
+
+		if err := cli.wait(ctx); err != nil {
+			return AzCliDeploymentResult{}, err
+		}
+	}
+}
+
+// Previews the changes the deployment of an ARM template would make
+func (cli *armClient) whatIf(ctx context.Context, deploymentId string, location string, templatePath string, parametersPath string) (AzCliWhatIfResult, error) {
+	template, err := cli.readTemplate(ctx, templatePath)
+	if err != nil {
+		return AzCliWhatIfResult{}, err
+	}
+
+	request, err := newArmDeploymentRequest(location, template, parametersPath)
+	if err != nil {
+		return AzCliWhatIfResult{}, err
+	}
+
+	response, err := cli.send(ctx, http.MethodPost, armPath(deploymentId+"/whatIf", armResourcesApiVersion), request)
+	if err != nil {
+		return AzCliWhatIfResult{}, fmt.Errorf("failed starting what-if operation: %w", err)
+	}
+
+	response, err = cli.waitForOperation(ctx, response)
+	if err != nil {
+		return AzCliWhatIfResult{}, fmt.Errorf("failed running what-if operation: %w", err)
+	}
+
+	// Unlike the Azure CLI output, the changes are nested within the properties of the REST response
+	var operationResult struct {
+		Status     string `json:"status"`
+		Properties struct {
+			Changes []AzCliWhatIfChange `json:"changes"`
+		} `json:"properties"`
+		Error *AzCliDeploymentErrorResponse `json:"error"`
+	}
+
+	if err := json.Unmarshal(response.Body, &operationResult); err != nil {
+		return AzCliWhatIfResult{}, fmt.Errorf("could not unmarshal response %s as an AzCliWhatIfResult: %w", string(response.Body), err)
+	}
+
+	return AzCliWhatIfResult{
+		Status:  operationResult.Status,
+		Changes: operationResult.Properties.Changes,
+		Error:   operationResult.Error,
+	}, nil
+}
+
+func (cli *armClient) delete(ctx context.Context, path string) error {
+	response, err := cli.send(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = cli.waitForOperation(ctx, response)
+	return err
+}
+
+// Gets the latest API version of the resource provider for the type of the resource
+func (cli *armClient) resourceApiVersion(ctx context.Context, subscriptionId string, resourceId string) (string, error) {
+	namespace, resourceType, err := parseResourceType(resourceId)
+	if err != nil {
+		return "", err
+	}
+
+	var provider struct {
+		ResourceTypes []struct {
+			ResourceType string   `json:"resourceType"`
+			ApiVersions  []string `json:"apiVersions"`
+		} `json:"resourceTypes"`
+	}
+
+	providerPath := fmt.Sprintf("%s/providers/%s", azure.SubscriptionRID(subscriptionId), namespace)
+	if err := cli.get(ctx, armPath(providerPath, armResourcesApiVersion), &provider); err != nil {
+		return "", fmt.Errorf("failed getting resource provider '%s': %w", namespace, err)
+	}
+
+	for _, providerResourceType := range provider.ResourceTypes {
+		if !strings.EqualFold(providerResourceType.ResourceType, resourceType) || len(providerResourceType.ApiVersions) == 0 {
+			continue
+		}
+
+		// API versions are ordered newest first, prefer stable versions over previews
+		for _, apiVersion := range providerResourceType.ApiVersions {
+			if !strings.HasSuffix(apiVersion, "-preview") {
+				return apiVersion, nil
+			}
+		}
+
+		return providerResourceType.ApiVersions[0], nil
+	}
+
+	return "", fmt.Errorf("resource type '%s/%s' was not found", namespace, resourceType)
+}
+
+// Waits for a long running operation to complete, returning the final response of the operation
+func (cli *armClient) waitForOperation(ctx context.Context, response *httpUtil.HttpResponseMessage) (*httpUtil.HttpResponseMessage, error) {
+	for response.Status == http.StatusAccepted {
+		location := headerValue(response.Headers, "Location")
+		if location == "" {
+			return response, nil
+		}
+
+		if err := cli.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		var err error
+		response, err = cli.send(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+func (cli *armClient) wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(cli.pollInterval):
+		return nil
+	}
+}
+
+func (cli *armClient) get(ctx context.Context, path string, result interface{}) error {
+	response, err := cli.send(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(response.Body, result); err != nil {
+		return fmt.Errorf("could not unmarshal response %s: %w", string(response.Body), err)
+	}
+
+	return nil
+}
+
+// Sends an authenticated request to Azure Resource Manager.
+// Responses with an error status are returned as an *ArmError.
+func (cli *armClient) send(ctx context.Context, method string, path string, body interface{}) (*httpUtil.HttpResponseMessage, error) {
+	url := path
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		url = cli.endpoint + path
+	}
+
+	requestBody := ""
+	if body != nil {
+		bodyJson, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling JSON body: %w", err)
+		}
+
+		requestBody = string(bodyJson)
+	}
+
+	return cli.sendWithToken(ctx, cli.tokenSource, method, url, nil, requestBody)
+}
+
+// Sends a request authenticated with a token of tokenSource, the body is sent as is.
+// Responses with an error status are returned as an *ArmError.
+func (cli *armClient) sendWithToken(ctx context.Context, tokenSource TokenSource, method string, url string, headers map[string]string, body string) (*httpUtil.HttpResponseMessage, error) {
+	token, err := tokenSource.GetToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting access token: %w", err)
+	}
+
+	request := &httpUtil.HttpRequestMessage{
+		Url:    url,
+		Method: method,
+		Headers: map[string]string{
+			"Authorization": fmt.Sprintf("Bearer %s", token.AccessToken),
+			"User-Agent":    cli.UserAgent(),
+		},
+		Body: body,
+	}
+
+	for name, value := range headers {
+		request.Headers[name] = value
+	}
+
+	response, err := cli.client(ctx).Send(request)
+	if err != nil {
+		return nil, fmt.Errorf("sending http request: %w", err)
+	}
+
+	if response.Status >= http.StatusBadRequest {
+		return nil, newArmErrorFromResponse(response.Status, response.Body)
+	}
+
+	return response, nil
+}
+
+func (cli *armClient) client(ctx context.Context) httpUtil.HttpUtil {
+	if cli.httpClient != nil {
+		return cli.httpClient
+	}
+
+	return httpUtil.GetHttpUtilFromContext(ctx)
+}
+
+// Lists all the values of a paged ARM collection
+func armList[T any](ctx context.Context, cli *armClient, path string) ([]T, error) {
+	values := []T{}
+
+	for path != "" {
+		var page struct {
+			Value    []T    `json:"value"`
+			NextLink string `json:"nextLink"`
+		}
+
+		if err := cli.get(ctx, path, &page); err != nil {
+			return nil, err
+		}
+
+		values = append(values, page.Value...)
+		path = page.NextLink
+	}
+
+	return values, nil
+}
+
+// Reads an ARM template, Bicep modules are compiled to an ARM template with the Bicep CLI
+func (cli *armClient) readTemplate(ctx context.Context, templatePath string) ([]byte, error) {
+	if isBicepModule(templatePath) {
+		return cli.buildBicep(ctx, templatePath)
+	}
+
+	template, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading template: %w", err)
+	}
+
+	return template, nil
+}
+
+// Creates the request to deploy an ARM template with the parameters of an ARM parameters file
+func newArmDeploymentRequest(location string, template []byte, parametersPath string) (*armDeploymentRequest, error) {
+	parametersBytes, err := os.ReadFile(parametersPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading parameters: %w", err)
+	}
+
+	// Parameters files wrap the parameter values with a schema & content version
+	var parametersFile struct {
+		Parameters json.RawMessage `json:"parameters"`
+	}
+
+	if err := json.Unmarshal(parametersBytes, &parametersFile); err != nil {
+		return nil, fmt.Errorf("could not unmarshal parameters file %s: %w", parametersPath, err)
+	}
+
+	parameters := parametersFile.Parameters
+	if parameters == nil {
+		parameters = json.RawMessage(parametersBytes)
+	}
+
+	return &armDeploymentRequest{
+		Location: location,
+		Properties: armDeploymentRequestProperties{
+			Mode:       "Incremental",
+			Template:   json.RawMessage(template),
+			Parameters: parameters,
+		},
+	}, nil
+}
+
+// Parses the resource provider namespace & resource type from a resource id,
+// e.g. Microsoft.Web & sites/slots from /subscriptions/.../providers/Microsoft.Web/sites/app/slots/staging
+func parseResourceType(resourceId string) (string, string, error) {
+	providersIndex := strings.LastIndex(strings.ToLower(resourceId), "/providers/")
+	if providersIndex < 0 {
+		return "", "", fmt.Errorf("resource id '%s' does not contain a resource provider", resourceId)
+	}
+
+	segments := strings.Split(strings.Trim(resourceId[providersIndex+len("/providers/"):], "/"), "/")
+	if len(segments) < 3 {
+		return "", "", fmt.Errorf("resource id '%s' does not contain a resource type", resourceId)
+	}
+
+	typeSegments := []string{}
+	for i := 1; i < len(segments); i += 2 {
+		typeSegments = append(typeSegments, segments[i])
+	}
+
+	return segments[0], strings.Join(typeSegments, "/"), nil
+}
+
+func isBicepModule(templatePath string) bool {
+	return strings.EqualFold(filepath.Ext(templatePath), ".bicep")
+}
+
+func armPath(path string, apiVersion string) string {
+	return fmt.Sprintf("%s?api-version=%s", path, apiVersion)
+}
+
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,733 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azcli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/httpUtil"
+	"github.com/stretchr/testify/require"
+)
+
+type armTestHttpClient struct {
+	requests []*httpUtil.HttpRequestMessage
+	sendFn   func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error)
+}
+
+func (c *armTestHttpClient) Send(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+	c.requests = append(c.requests, req)
+	return c.sendFn(req)
+}
+
+func newTestArmClient(httpClient httpUtil.HttpUtil, azCli AzCli) AzCli {
+	if azCli == nil {
+		azCli = NewAzCli(NewAzCliArgs{})
+	}
+
+	return NewArmClient(NewArmClientArgs{
+		AzCli: azCli,
+		TokenSource: TokenSourceFunc(func(ctx context.Context) (AzCliAccessToken, error) {
+			return AzCliAccessToken{AccessToken: "ACCESS_TOKEN"}, nil
+		}),
+		HttpClient:   httpClient,
+		PollInterval: time.Millisecond,
+	})
+}
+
+func jsonResponse(status int, body string) *httpUtil.HttpResponseMessage {
+	return &httpUtil.HttpResponseMessage{
+		Status:  status,
+		Headers: map[string]string{},
+		Body:    []byte(body),
+	}
+}
+
+func Test_ArmClient_GetSubscriptionDeployment(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				return jsonResponse(http.StatusOK, `{"id":"DEPLOYMENT_ID","name":"test-env","properties":{"outputs":{"WEBSITE_URL":{"type":"String","value":"http://myapp"}}}}`), nil
+			},
+		}
+
+		deployment, err := newTestArmClient(httpClient, nil).GetSubscriptionDeployment(context.Background(), "SUBSCRIPTION_ID", "test-env")
+		require.NoError(t, err)
+		require.Equal(t, "DEPLOYMENT_ID", deployment.Id)
+		require.Equal(t, "http://myapp", deployment.Properties.Outputs["WEBSITE_URL"].Value)
+
+		require.Len(t, httpClient.requests, 1)
+		require.Equal(t, http.MethodGet, httpClient.requests[0].Method)
+		require.Equal(t, "https://management.azure.com/subscriptions/SUBSCRIPTION_ID/providers/Microsoft.Resources/deployments/test-env?api-version=2021-04-01", httpClient.requests[0].Url)
+		require.Equal(t, "Bearer ACCESS_TOKEN", httpClient.requests[0].Headers["Authorization"])
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				return jsonResponse(http.StatusNotFound, `{"error":{"code":"DeploymentNotFound","message":"Deployment 'test-env' could not be found."}}`), nil
+			},
+		}
+
+		_, err := newTestArmClient(httpClient, nil).GetSubscriptionDeployment(context.Background(), "SUBSCRIPTION_ID", "test-env")
+		require.True(t, errors.Is(err, ErrDeploymentNotFound))
+
+		var armErr *ArmError
+		require.True(t, errors.As(err, &armErr))
+		require.Equal(t, http.StatusNotFound, armErr.StatusCode)
+		require.Equal(t, "DeploymentNotFound", armErr.Code)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				return jsonResponse(http.StatusUnauthorized, `{"error":{"code":"InvalidAuthenticationToken","message":"The access token is invalid."}}`), nil
+			},
+		}
+
+		_, err := newTestArmClient(httpClient, nil).GetSubscriptionDeployment(context.Background(), "SUBSCRIPTION_ID", "test-env")
+		require.True(t, errors.Is(err, ErrAzCliNotLoggedIn))
+		require.False(t, errors.Is(err, ErrDeploymentNotFound))
+	})
+}
+
+func Test_ArmClient_ListResourceGroupResources(t *testing.T) {
+	const nextLink = "https://management.azure.com/subscriptions/SUBSCRIPTION_ID/resourceGroups/rg/resources?api-version=2021-04-01&$skiptoken=PAGE2"
+
+	httpClient := &armTestHttpClient{
+		sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+			if req.Url == nextLink {
+				return jsonResponse(http.StatusOK, `{"value":[{"id":"ID2","name":"app","type":"Microsoft.Web/sites"}]}`), nil
+			}
+
+			return jsonResponse(http.StatusOK, `{"value":[{"id":"ID1","name":"plan","type":"Microsoft.Web/serverFarms"}],"nextLink":"`+nextLink+`"}`), nil
+		},
+	}
+
+	resources, err := newTestArmClient(httpClient, nil).ListResourceGroupResources(context.Background(), "SUBSCRIPTION_ID", "rg")
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+	require.Equal(t, "plan", resources[0].Name)
+	require.Equal(t, "app", resources[1].Name)
+}
+
+func Test_ArmClient_GetResource(t *testing.T) {
+	const resourceId = "/subscriptions/SUBSCRIPTION_ID/resourceGroups/rg/providers/Microsoft.Web/sites/app/slots/staging"
+
+	httpClient := &armTestHttpClient{
+		sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+			if strings.Contains(req.Url, "/subscriptions/SUBSCRIPTION_ID/providers/Microsoft.Web?") {
+				return jsonResponse(http.StatusOK, `{"resourceTypes":[
+					{"resourceType":"sites","apiVersions":["2022-03-01"]},
+					{"resourceType":"sites/slots","apiVersions":["2022-09-01-preview","2022-03-01"]}
+				]}`), nil
+			}
+
+			return jsonResponse(http.StatusOK, `{"id":"`+resourceId+`","name":"app/staging","type":"Microsoft.Web/sites/slots","kind":"app"}`), nil
+		},
+	}
+
+	resource, err := newTestArmClient(httpClient, nil).GetResource(context.Background(), "SUBSCRIPTION_ID", resourceId)
+	require.NoError(t, err)
+	require.Equal(t, "app", resource.Kind)
+	require.Equal(t, "https://management.azure.com"+resourceId+"?api-version=2022-03-01", httpClient.requests[1].Url)
+}
+
+func Test_ArmClient_DeployToSubscription(t *testing.T) {
+	templatePath, parametersPath := createArmTemplateFiles(t)
+
+	t.Run("Success", func(t *testing.T) {
+		deploymentStatusChecks := 0
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				if req.Method == http.MethodPut {
+					return jsonResponse(http.StatusCreated, `{"properties":{"provisioningState":"Accepted"}}`), nil
+				}
+
+				deploymentStatusChecks++
+				if deploymentStatusChecks == 1 {
+					return jsonResponse(http.StatusOK, `{"properties":{"provisioningState":"Running"}}`), nil
+				}
+
+				return jsonResponse(http.StatusOK, `{"properties":{"provisioningState":"Succeeded","outputs":{"WEBSITE_URL":{"type":"String","value":"http://myapp"}}}}`), nil
+			},
+		}
+
+		result, err := newTestArmClient(httpClient, nil).DeployToSubscription(context.Background(), "SUBSCRIPTION_ID", "test-env", templatePath, parametersPath, "westus2")
+		require.NoError(t, err)
+		require.Equal(t, "http://myapp", result.Properties.Outputs["WEBSITE_URL"].Value)
+		require.Equal(t, 2, deploymentStatusChecks)
+
+		var request armDeploymentRequest
+		require.NoError(t, json.Unmarshal([]byte(httpClient.requests[0].Body), &request))
+		require.Equal(t, "westus2", request.Location)
+		require.Equal(t, "Incremental", request.Properties.Mode)
+		require.JSONEq(t, `{"name":{"value":"test-env"}}`, string(request.Properties.Parameters))
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				if req.Method == http.MethodPut {
+					return jsonResponse(http.StatusCreated, `{}`), nil
+				}
+
+				return jsonResponse(http.StatusOK, `{"properties":{"provisioningState":"Failed","error":{
+					"code":"DeploymentFailed",
+					"message":"At least one resource deployment operation failed.",
+					"details":[{"code":"Conflict","message":"Website with given name already exists."}]
+				}}}`), nil
+			},
+		}
+
+		_, err := newTestArmClient(httpClient, nil).DeployToSubscription(context.Background(), "SUBSCRIPTION_ID", "test-env", templatePath, parametersPath, "westus2")
+
+		var armErr *ArmError
+		require.True(t, errors.As(err, &armErr))
+		require.Equal(t, "DeploymentFailed", armErr.Code)
+		require.Len(t, armErr.Details, 1)
+		require.Contains(t, err.Error(), "Website with given name already exists.")
+	})
+
+	t.Run("BicepModuleUsesAzCli", func(t *testing.T) {
+		azCliArgs := []string{}
+		runWithResultFn := func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			azCliArgs = args.Args
+			return executil.RunResult{Stdout: `{"properties":{"outputs":{}}}`}, nil
+		}
+
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				return nil, errors.New("unexpected request")
+			},
+		}
+
+		azCli := NewAzCli(NewAzCliArgs{RunWithResultFn: runWithResultFn})
+		_, err := newTestArmClient(httpClient, azCli).DeployToSubscription(context.Background(), "SUBSCRIPTION_ID", "test-env", "main.bicep", parametersPath, "westus2")
+		require.NoError(t, err)
+		require.Empty(t, httpClient.requests)
+		require.Contains(t, strings.Join(azCliArgs, " "), "deployment sub create")
+	})
+}
+
+func Test_ArmClient_WhatIfDeployToResourceGroup(t *testing.T) {
+	templatePath, parametersPath := createArmTemplateFiles(t)
+	const operationUrl = "https://management.azure.com/subscriptions/SUBSCRIPTION_ID/operationresults/OPERATION_ID?api-version=2021-04-01"
+
+	operationChecks := 0
+	httpClient := &armTestHttpClient{
+		sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+			if req.Method == http.MethodPost {
+				require.True(t, strings.HasSuffix(req.Url, "/resourceGroups/rg/providers/Microsoft.Resources/deployments/test-env/whatIf?api-version=2021-04-01"))
+
+				response := jsonResponse(http.StatusAccepted, "")
+				response.Headers["Location"] = operationUrl
+				return response, nil
+			}
+
+			require.Equal(t, operationUrl, req.Url)
+			operationChecks++
+			if operationChecks == 1 {
+				response := jsonResponse(http.StatusAccepted, "")
+				response.Headers["Location"] = operationUrl
+				return response, nil
+			}
+
+			return jsonResponse(http.StatusOK, `{"status":"Succeeded","properties":{"changes":[
+				{"resourceId":"/subscriptions/SUBSCRIPTION_ID/resourceGroups/rg/providers/Microsoft.Web/sites/app","changeType":"Create"}
+			]}}`), nil
+		},
+	}
+
+	result, err := newTestArmClient(httpClient, nil).WhatIfDeployToResourceGroup(context.Background(), "SUBSCRIPTION_ID", "rg", "test-env", templatePath, parametersPath)
+	require.NoError(t, err)
+	require.Equal(t, 2, operationChecks)
+	require.Equal(t, "Succeeded", result.Status)
+	require.Len(t, result.Changes, 1)
+	require.Equal(t, "Create", result.Changes[0].ChangeType)
+}
+
+func Test_ArmClient_BuildAcrImage(t *testing.T) {
+	const registryId = "/subscriptions/SUBSCRIPTION_ID/resourceGroups/rg/providers/Microsoft.ContainerRegistry/registries/contoso"
+	const uploadUrl = "https://storage.blob.core.windows.net/source/context.tar.gz?sas"
+	const logUrl = "https://storage.blob.core.windows.net/logs/run1.log?sas"
+	const armEndpoint = "https://management.azure.com"
+
+	sourcePath := filepath.Join(t.TempDir(), "context.tar.gz")
+	require.NoError(t, os.WriteFile(sourcePath, []byte("TARBALL"), 0600))
+
+	statusPolls := 0
+	var scheduled map[string]any
+
+	httpClient := &armTestHttpClient{
+		sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+			switch {
+			case strings.Contains(req.Url, "/providers/Microsoft.ContainerRegistry/registries?"):
+				return jsonResponse(http.StatusOK, `{"value":[
+					{"id":"/subscriptions/SUBSCRIPTION_ID/resourceGroups/rg/providers/Microsoft.ContainerRegistry/registries/other","properties":{"loginServer":"other.azurecr.io"}},
+					{"id":"`+registryId+`","properties":{"loginServer":"contoso.azurecr.io"}}
+				]}`), nil
+			case req.Url == armEndpoint+registryId+"/listBuildSourceUploadUrl?api-version=2019-04-01" && req.Method == http.MethodPost:
+				return jsonResponse(http.StatusOK, `{"uploadUrl":"`+uploadUrl+`","relativePath":"source/context.tar.gz"}`), nil
+			case req.Url == uploadUrl:
+				require.Equal(t, http.MethodPut, req.Method)
+				require.Equal(t, "BlockBlob", req.Headers["x-ms-blob-type"])
+				require.Empty(t, req.Headers["Authorization"])
+				require.Equal(t, "TARBALL", req.Body)
+				return jsonResponse(http.StatusCreated, ""), nil
+			case req.Url == armEndpoint+registryId+"/scheduleRun?api-version=2019-04-01":
+				require.NoError(t, json.Unmarshal([]byte(req.Body), &scheduled))
+				return jsonResponse(http.StatusOK, `{"properties":{"runId":"run1","status":"Queued"}}`), nil
+			case req.Url == armEndpoint+registryId+"/runs/run1/listLogSasUrl?api-version=2019-04-01":
+				return jsonResponse(http.StatusOK, `{"logLink":"`+logUrl+`"}`), nil
+			case req.Url == armEndpoint+registryId+"/runs/run1?api-version=2019-04-01":
+				statusPolls++
+				if statusPolls < 3 {
+					return jsonResponse(http.StatusOK, `{"properties":{"runId":"run1","status":"Running"}}`), nil
+				}
+				return jsonResponse(http.StatusOK, `{"properties":{"runId":"run1","status":"Succeeded"}}`), nil
+			case req.Url == logUrl:
+				log := []string{"", "Step 1/2\n", "Step 1/2\nStep 2/2\n"}[statusPolls-1]
+				offset := 0
+				_, err := fmt.Sscanf(req.Headers["Range"], "bytes=%d-", &offset)
+				require.NoError(t, err)
+				if log == "" {
+					return jsonResponse(http.StatusNotFound, ""), nil
+				} else if offset >= len(log) {
+					return jsonResponse(http.StatusRequestedRangeNotSatisfiable, ""), nil
+				}
+				return jsonResponse(http.StatusPartialContent, log[offset:]), nil
+			}
+
+			return nil, fmt.Errorf("unexpected request %s %s", req.Method, req.Url)
+		},
+	}
+
+	var log strings.Builder
+	err := newTestArmClient(httpClient, nil).BuildAcrImage(context.Background(), "SUBSCRIPTION_ID", "contoso.azurecr.io", AcrBuildArgs{
+		SourcePath:     sourcePath,
+		DockerfilePath: "src/Dockerfile",
+		ImageNames:     []string{"app/web:azdev-deploy-1"},
+		Target:         "runtime",
+		BuildArgs:      map[string]string{"VERSION": "1.0"},
+		Platform:       "linux/arm64",
+	}, &log)
+	require.NoError(t, err)
+	require.Equal(t, "Step 1/2\nStep 2/2\n", log.String())
+
+	require.Equal(t, "DockerBuildRequest", scheduled["type"])
+	require.Equal(t, true, scheduled["isPushEnabled"])
+	require.Equal(t, "source/context.tar.gz", scheduled["sourceLocation"])
+	require.Equal(t, "src/Dockerfile", scheduled["dockerFilePath"])
+	require.Equal(t, "runtime", scheduled["target"])
+	require.Equal(t, []any{"app/web:azdev-deploy-1"}, scheduled["imageNames"])
+	require.Equal(t, []any{map[string]any{"name": "VERSION", "value": "1.0", "isSecret": false}}, scheduled["arguments"])
+	require.Equal(t, map[string]any{"os": "linux", "architecture": "arm64"}, scheduled["platform"])
+
+	t.Run("Failed", func(t *testing.T) {
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				switch {
+				case strings.Contains(req.Url, "/providers/Microsoft.ContainerRegistry/registries?"):
+					return jsonResponse(http.StatusOK, `{"value":[{"id":"`+registryId+`","properties":{"loginServer":"contoso.azurecr.io"}}]}`), nil
+				case strings.HasSuffix(req.Url, "/listBuildSourceUploadUrl?api-version=2019-04-01"):
+					return jsonResponse(http.StatusOK, `{"uploadUrl":"`+uploadUrl+`","relativePath":"source/context.tar.gz"}`), nil
+				case req.Url == uploadUrl:
+					return jsonResponse(http.StatusCreated, ""), nil
+				case strings.HasSuffix(req.Url, "/scheduleRun?api-version=2019-04-01"):
+					return jsonResponse(http.StatusOK, `{"properties":{"runId":"run2","status":"Queued"}}`), nil
+				case strings.HasSuffix(req.Url, "/listLogSasUrl?api-version=2019-04-01"):
+					return jsonResponse(http.StatusOK, `{"logLink":"`+logUrl+`"}`), nil
+				case req.Url == logUrl:
+					return jsonResponse(http.StatusOK, "error: failed to solve"), nil
+				}
+
+				return jsonResponse(http.StatusOK, `{"properties":{"runId":"run2","status":"Failed"}}`), nil
+			},
+		}
+
+		var log strings.Builder
+		err := newTestArmClient(httpClient, nil).BuildAcrImage(context.Background(), "SUBSCRIPTION_ID", "contoso.azurecr.io", AcrBuildArgs{
+			SourcePath:     sourcePath,
+			DockerfilePath: "Dockerfile",
+			ImageNames:     []string{"app/web:azdev-deploy-1"},
+		}, &log)
+		require.ErrorContains(t, err, "status: Failed")
+		require.Equal(t, "error: failed to solve", log.String())
+	})
+
+	t.Run("RegistryNotFound", func(t *testing.T) {
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				return jsonResponse(http.StatusOK, `{"value":[]}`), nil
+			},
+		}
+
+		err := newTestArmClient(httpClient, nil).BuildAcrImage(context.Background(), "SUBSCRIPTION_ID", "contoso.azurecr.io", AcrBuildArgs{}, io.Discard)
+		require.ErrorContains(t, err, "container registry 'contoso.azurecr.io' was not found")
+	})
+}
+
+func Test_ArmClient_GetAcrImageDigest(t *testing.T) {
+	const manifestUrl = "https://contoso.azurecr.io/v2/app/web/manifests/azdev-deploy-1"
+
+	newHttpClient := func(manifestStatus int) *armTestHttpClient {
+		return &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				switch req.Url {
+				case "https://contoso.azurecr.io/oauth2/exchange":
+					form, err := url.ParseQuery(req.Body)
+					require.NoError(t, err)
+					require.Equal(t, "ACCESS_TOKEN", form.Get("access_token"))
+					return jsonResponse(http.StatusOK, `{"refresh_token":"REFRESH_TOKEN"}`), nil
+				case "https://contoso.azurecr.io/oauth2/token":
+					form, err := url.ParseQuery(req.Body)
+					require.NoError(t, err)
+					require.Equal(t, "REFRESH_TOKEN", form.Get("refresh_token"))
+					require.Equal(t, "repository:app/web:pull", form.Get("scope"))
+					return jsonResponse(http.StatusOK, `{"access_token":"REGISTRY_TOKEN"}`), nil
+				case manifestUrl:
+					require.Equal(t, http.MethodHead, req.Method)
+					require.Equal(t, "Bearer REGISTRY_TOKEN", req.Headers["Authorization"])
+					response := jsonResponse(manifestStatus, "")
+					response.Headers["Docker-Content-Digest"] = "sha256:0123"
+					return response, nil
+				default:
+					return nil, fmt.Errorf("unexpected request %s %s", req.Method, req.Url)
+				}
+			},
+		}
+	}
+
+	t.Run("Found", func(t *testing.T) {
+		client := newTestArmClient(newHttpClient(http.StatusOK), nil)
+		digest, err := client.GetAcrImageDigest(context.Background(), "contoso.azurecr.io", "app/web:azdev-deploy-1")
+		require.NoError(t, err)
+		require.Equal(t, "sha256:0123", digest)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		client := newTestArmClient(newHttpClient(http.StatusNotFound), nil)
+		_, err := client.GetAcrImageDigest(context.Background(), "contoso.azurecr.io", "app/web:azdev-deploy-1")
+		require.True(t, errors.Is(err, ErrAcrImageNotFound))
+	})
+}
+
+func Test_CachingTokenSource(t *testing.T) {
+	now := time.Now()
+	tokenRequests := 0
+
+	source := NewCachingTokenSource(TokenSourceFunc(func(ctx context.Context) (AzCliAccessToken, error) {
+		tokenRequests++
+		expiresOn := now.Add(10 * time.Minute)
+		return AzCliAccessToken{AccessToken: "ACCESS_TOKEN", ExpiresOn: &expiresOn}, nil
+	}))
+	cachingSource := source.(*cachingTokenSource)
+	cachingSource.now = func() time.Time { return now }
+
+	_, err := source.GetToken(context.Background())
+	require.NoError(t, err)
+	_, err = source.GetToken(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, tokenRequests)
+
+	// Tokens are refreshed shortly before they expire
+	cachingSource.now = func() time.Time { return now.Add(9 * time.Minute) }
+	_, err = source.GetToken(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, tokenRequests)
+}
+
+func createArmTemplateFiles(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "main.json")
+	parametersPath := filepath.Join(dir, "main.parameters.json")
+
+	require.NoError(t, os.WriteFile(templatePath, []byte(`{"$schema":"https://schema.management.azure.com/schemas/2018-05-01/subscriptionDeploymentTemplate.json#","resources":[]}`), 0600))
+	require.NoError(t, os.WriteFile(parametersPath, []byte(`{"$schema":"https://schema.management.azure.com/schemas/2019-04-01/deploymentParameters.json#","contentVersion":"1.0.0.0","parameters":{"name":{"value":"test-env"}}}`), 0600))
+
+	return templatePath, parametersPath
+}
+
+func newTestArmClientWithoutAzCli(t *testing.T, httpClient httpUtil.HttpUtil, runWithResultFn func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error)) AzCli {
+	azCli := NewAzCli(NewAzCliArgs{
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			require.Fail(t, "the Azure CLI must not be run", "az %s", strings.Join(args.Args, " "))
+			return executil.RunResult{}, nil
+		},
+	})
+
+	return NewArmClient(NewArmClientArgs{
+		AzCli: azCli,
+		TokenSource: TokenSourceFunc(func(ctx context.Context) (AzCliAccessToken, error) {
+			return AzCliAccessToken{AccessToken: "ACCESS_TOKEN"}, nil
+		}),
+		KeyVaultTokenSource: TokenSourceFunc(func(ctx context.Context) (AzCliAccessToken, error) {
+			return AzCliAccessToken{AccessToken: "KEYVAULT_TOKEN"}, nil
+		}),
+		HttpClient:      httpClient,
+		PollInterval:    time.Millisecond,
+		WithoutAzCli:    true,
+		RunWithResultFn: runWithResultFn,
+	})
+}
+
+func Test_ArmClient_WithoutAzCli(t *testing.T) {
+	unexpectedRequests := &armTestHttpClient{
+		sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+			return nil, fmt.Errorf("unexpected request %s %s", req.Method, req.Url)
+		},
+	}
+
+	t.Run("CheckInstalled", func(t *testing.T) {
+		client := newTestArmClientWithoutAzCli(t, unexpectedRequests, nil)
+		installed, err := client.CheckInstalled(context.Background())
+		require.NoError(t, err)
+		require.True(t, installed)
+		require.False(t, UsesAzCli(client))
+		require.True(t, UsesAzCli(newTestArmClient(unexpectedRequests, nil)))
+	})
+
+	t.Run("ListAccountLocations", func(t *testing.T) {
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				require.Equal(t, "Bearer ACCESS_TOKEN", req.Headers["Authorization"])
+
+				switch req.Url {
+				case "https://management.azure.com/subscriptions?api-version=2020-01-01":
+					return jsonResponse(http.StatusOK, `{"value":[{"subscriptionId":"SUBSCRIPTION_ID","displayName":"Contoso"}]}`), nil
+				case "https://management.azure.com/subscriptions/SUBSCRIPTION_ID/locations?api-version=2020-01-01":
+					return jsonResponse(http.StatusOK, `{"value":[
+						{"name":"westus2","displayName":"West US 2","regionalDisplayName":"(US) West US 2","metadata":{"regionType":"Physical"}},
+						{"name":"unitedstates","displayName":"United States","regionalDisplayName":"United States","metadata":{"regionType":"Logical"}}
+					]}`), nil
+				default:
+					return nil, fmt.Errorf("unexpected request %s %s", req.Method, req.Url)
+				}
+			},
+		}
+
+		client := newTestArmClientWithoutAzCli(t, httpClient, nil)
+
+		subscriptions, err := client.ListAccounts(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []AzCliSubscriptionInfo{{Name: "Contoso", Id: "SUBSCRIPTION_ID"}}, subscriptions)
+
+		locations, err := client.ListAccountLocations(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []AzCliLocation{{Name: "westus2", DisplayName: "West US 2", RegionalDisplayName: "(US) West US 2"}}, locations)
+
+		_, err = client.GetCliConfigValue(context.Background(), "defaults.location")
+		require.True(t, errors.Is(err, ErrNoConfigurationValue))
+	})
+
+	t.Run("KeyVaultSecrets", func(t *testing.T) {
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				require.Equal(t, "https://contoso.vault.azure.net/secrets/db-password?api-version=7.3", req.Url)
+				require.Equal(t, "Bearer KEYVAULT_TOKEN", req.Headers["Authorization"])
+
+				if req.Method == http.MethodPut {
+					require.JSONEq(t, `{"value":"s3cr3t"}`, req.Body)
+				}
+
+				return jsonResponse(http.StatusOK, `{"id":"https://contoso.vault.azure.net/secrets/db-password/1","value":"s3cr3t"}`), nil
+			},
+		}
+
+		client := newTestArmClientWithoutAzCli(t, httpClient, nil)
+		require.NoError(t, client.SetKeyVaultSecret(context.Background(), "contoso", "db-password", "s3cr3t"))
+
+		secret, err := client.GetKeyVaultSecret(context.Background(), "contoso", "db-password")
+		require.NoError(t, err)
+		require.Equal(t, "db-password", secret.Name)
+		require.Equal(t, "s3cr3t", secret.Value)
+	})
+
+	t.Run("LoginAcr", func(t *testing.T) {
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				require.Equal(t, "https://contoso.azurecr.io/oauth2/exchange", req.Url)
+				return jsonResponse(http.StatusOK, `{"refresh_token":"REFRESH_TOKEN"}`), nil
+			},
+		}
+
+		var loginArgs executil.RunArgs
+		password := ""
+		runWithResultFn := func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			loginArgs = args
+			stdin, err := io.ReadAll(args.Stdin)
+			require.NoError(t, err)
+			password = string(stdin)
+			return executil.RunResult{}, nil
+		}
+
+		client := newTestArmClientWithoutAzCli(t, httpClient, runWithResultFn)
+		require.NoError(t, client.LoginAcr(context.Background(), "SUBSCRIPTION_ID", "contoso.azurecr.io"))
+		require.Equal(t, "docker", loginArgs.Cmd)
+		require.Equal(t, []string{"login", "contoso.azurecr.io", "--username", "00000000-0000-0000-0000-000000000000", "--password-stdin"}, loginArgs.Args)
+		require.Equal(t, "REFRESH_TOKEN", password)
+	})
+
+	t.Run("DeployAppServiceZip", func(t *testing.T) {
+		const siteUrl = "https://management.azure.com/subscriptions/SUBSCRIPTION_ID/resourceGroups/RESOURCE_GROUP/providers/Microsoft.Web/sites/app?api-version=2022-03-01"
+		const statusUrl = "https://app.scm.azurewebsites.net/api/deployments/latest"
+
+		zipPath := filepath.Join(t.TempDir(), "app.zip")
+		require.NoError(t, os.WriteFile(zipPath, []byte("PK\x03\x04"), 0600))
+
+		newHttpClient := func(finalStatus string) *armTestHttpClient {
+			statusChecks := 0
+			return &armTestHttpClient{
+				sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+					require.Equal(t, "Bearer ACCESS_TOKEN", req.Headers["Authorization"])
+
+					switch req.Url {
+					case siteUrl:
+						return jsonResponse(http.StatusOK, `{"properties":{"hostNameSslStates":[
+							{"name":"app.azurewebsites.net","hostType":"Standard"},
+							{"name":"app.scm.azurewebsites.net","hostType":"Repository"}
+						]}}`), nil
+					case "https://app.scm.azurewebsites.net/api/zipdeploy?isAsync=true":
+						require.Equal(t, http.MethodPost, req.Method)
+						require.Equal(t, "application/zip", req.Headers["Content-Type"])
+						require.Equal(t, "PK\x03\x04", req.Body)
+						response := jsonResponse(http.StatusAccepted, "")
+						response.Headers["Location"] = statusUrl
+						return response, nil
+					case statusUrl:
+						statusChecks++
+						if statusChecks == 1 {
+							return jsonResponse(http.StatusAccepted, `{"status":1,"complete":false}`), nil
+						}
+
+						return jsonResponse(http.StatusOK, finalStatus), nil
+					default:
+						return nil, fmt.Errorf("unexpected request %s %s", req.Method, req.Url)
+					}
+				},
+			}
+		}
+
+		httpClient := newHttpClient(`{"status":4,"complete":true}`)
+		res, err := newTestArmClientWithoutAzCli(t, httpClient, nil).
+			DeployAppServiceZip(context.Background(), "SUBSCRIPTION_ID", "RESOURCE_GROUP", "app", zipPath)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"status":4,"complete":true}`, res)
+		require.Len(t, httpClient.requests, 4)
+
+		_, err = newTestArmClientWithoutAzCli(t, newHttpClient(`{"status":3,"status_text":"Build failed","complete":true}`), nil).
+			DeployAppServiceZip(context.Background(), "SUBSCRIPTION_ID", "RESOURCE_GROUP", "app", zipPath)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Build failed")
+	})
+
+	t.Run("DeployFunctionAppEnablesRemoteBuild", func(t *testing.T) {
+		const appSettingsUrl = "https://management.azure.com/subscriptions/SUBSCRIPTION_ID/resourceGroups/RESOURCE_GROUP/providers/Microsoft.Web/sites/func/config/appsettings"
+
+		zipPath := filepath.Join(t.TempDir(), "func.zip")
+		require.NoError(t, os.WriteFile(zipPath, []byte("PK\x03\x04"), 0600))
+
+		var updatedSettings string
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				switch {
+				case req.Url == appSettingsUrl+"/list?api-version=2022-03-01":
+					return jsonResponse(http.StatusOK, `{"properties":{"FUNCTIONS_WORKER_RUNTIME":"python"}}`), nil
+				case req.Url == appSettingsUrl+"?api-version=2022-03-01":
+					require.Equal(t, http.MethodPut, req.Method)
+					updatedSettings = req.Body
+					return jsonResponse(http.StatusOK, req.Body), nil
+				case strings.Contains(req.Url, "/sites/func?"):
+					return jsonResponse(http.StatusOK, `{"properties":{"hostNameSslStates":[{"name":"func.scm.azurewebsites.net","hostType":"Repository"}]}}`), nil
+				case req.Url == "https://func.scm.azurewebsites.net/api/zipdeploy?isAsync=true":
+					return jsonResponse(http.StatusOK, `{"status":4,"complete":true}`), nil
+				default:
+					return nil, fmt.Errorf("unexpected request %s %s", req.Method, req.Url)
+				}
+			},
+		}
+
+		_, err := newTestArmClientWithoutAzCli(t, httpClient, nil).
+			DeployFunctionAppUsingZipFile(context.Background(), "SUBSCRIPTION_ID", "RESOURCE_GROUP", "func", zipPath)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"properties":{
+			"FUNCTIONS_WORKER_RUNTIME":"python",
+			"SCM_DO_BUILD_DURING_DEPLOYMENT":"true",
+			"ENABLE_ORYX_BUILD":"true"
+		}}`, updatedSettings)
+	})
+
+	t.Run("GetManagedClusterCredentials", func(t *testing.T) {
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				require.Equal(t, http.MethodPost, req.Method)
+				require.Equal(t, "https://management.azure.com/subscriptions/SUBSCRIPTION_ID/resourceGroups/RESOURCE_GROUP/providers/Microsoft.ContainerService/managedClusters/aks/listClusterUserCredential?api-version=2022-09-01", req.Url)
+				// "apiVersion: v1" encoded in base64
+				return jsonResponse(http.StatusOK, `{"kubeconfigs":[{"name":"clusterUser","value":"YXBpVmVyc2lvbjogdjE="}]}`), nil
+			},
+		}
+
+		kubeConfigPath := filepath.Join(t.TempDir(), "kubeconfig")
+		err := newTestArmClientWithoutAzCli(t, httpClient, nil).
+			GetManagedClusterCredentials(context.Background(), "SUBSCRIPTION_ID", "RESOURCE_GROUP", "aks", kubeConfigPath)
+		require.NoError(t, err)
+
+		kubeConfig, err := os.ReadFile(kubeConfigPath)
+		require.NoError(t, err)
+		require.Equal(t, "apiVersion: v1", string(kubeConfig))
+	})
+
+	t.Run("BicepModuleUsesBicepCli", func(t *testing.T) {
+		_, parametersPath := createArmTemplateFiles(t)
+
+		var bicepArgs executil.RunArgs
+		runWithResultFn := func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			bicepArgs = args
+			return executil.RunResult{Stdout: `{"resources":[]}`}, nil
+		}
+
+		httpClient := &armTestHttpClient{
+			sendFn: func(req *httpUtil.HttpRequestMessage) (*httpUtil.HttpResponseMessage, error) {
+				if req.Method == http.MethodPut {
+					return jsonResponse(http.StatusCreated, `{}`), nil
+				}
+
+				return jsonResponse(http.StatusOK, `{"properties":{"provisioningState":"Succeeded"}}`), nil
+			},
+		}
+
+		_, err := newTestArmClientWithoutAzCli(t, httpClient, runWithResultFn).
+			DeployToSubscription(context.Background(), "SUBSCRIPTION_ID", "test-env", "main.bicep", parametersPath, "westus2")
+		require.NoError(t, err)
+		require.Equal(t, "bicep", bicepArgs.Cmd)
+		require.Equal(t, []string{"build", "main.bicep", "--stdout"}, bicepArgs.Args)
+
+		var request armDeploymentRequest
+		require.NoError(t, json.Unmarshal([]byte(httpClient.requests[0].Body), &request))
+		require.JSONEq(t, `{"resources":[]}`, string(request.Properties.Template))
+	})
+
+	t.Run("NoRestEquivalent", func(t *testing.T) {
+		client := newTestArmClientWithoutAzCli(t, unexpectedRequests, nil)
+
+		_, err := client.CreateOrUpdateServicePrincipal(context.Background(), "SUBSCRIPTION_ID", "app", "Contributor")
+		require.True(t, errors.Is(err, ErrAzCliRequired))
+		require.Contains(t, err.Error(), "creating service principals")
+
+		err = client.CreateOrUpdateFederatedCredential(context.Background(), "CLIENT_ID", AzCliFederatedCredential{})
+		require.True(t, errors.Is(err, ErrAzCliRequired))
+	})
+}
@@ -0,0 +1,441 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azcli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/azure"
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/httpUtil"
+)
+
+// ErrAzCliRequired is returned by the operations without a REST equivalent when the client runs without the Azure CLI
+var ErrAzCliRequired = errors.New("requires the Azure CLI")
+
+const (
+	armContainerServiceApiVersion = "2022-09-01"
+	keyVaultApiVersion            = "7.3"
+
+	// The user name Docker signs in to container registries with, when the password is a refresh token of the registry
+	acrRefreshTokenUserName = "00000000-0000-0000-0000-000000000000"
+
+	// The status of a failed deployment of Kudu, the SCM site of App Service
+	kuduDeploymentFailed = 3
+)
+
+// UsesAzCli reports whether the operations of the client run the Azure CLI,
+// which is not the case for the clients created with NewArmClientArgs.WithoutAzCli.
+func UsesAzCli(cli AzCli) bool {
+	armClient, ok := cli.(*armClient)
+	return !ok || !armClient.withoutAzCli
+}
+
+func azCliRequiredError(operation string) error {
+	return fmt.Errorf("%s %w, which is not used when auth.mode is builtin", operation, ErrAzCliRequired)
+}
+
+// CheckInstalled reports the Azure CLI as installed when the client runs without it, so commands don't require it
+func (cli *armClient) CheckInstalled(ctx context.Context) (bool, error) {
+	if cli.withoutAzCli {
+		return true, nil
+	}
+
+	return cli.AzCli.CheckInstalled(ctx)
+}
+
+func (cli *armClient) Login(ctx context.Context, useDeviceCode bool, deviceCodeWriter io.Writer) error {
+	if cli.withoutAzCli {
+		return azCliRequiredError("signing in with `az login`")
+	}
+
+	return cli.AzCli.Login(ctx, useDeviceCode, deviceCodeWriter)
+}
+
+func (cli *armClient) LoginAcr(ctx context.Context, subscriptionId string, loginServer string) error {
+	if !cli.withoutAzCli {
+		return cli.AzCli.LoginAcr(ctx, subscriptionId, loginServer)
+	}
+
+	// Like `az acr login`, Docker signs in to the registry with a refresh token of the registry
+	refreshToken, err := cli.acrRefreshToken(ctx, loginServer)
+	if err != nil {
+		return err
+	}
+
+	res, err := cli.runWithResultFn(ctx, executil.RunArgs{
+		Cmd:         "docker",
+		Args:        []string{"login", loginServer, "--username", acrRefreshTokenUserName, "--password-stdin"},
+		Stdin:       strings.NewReader(refreshToken),
+		EnrichError: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed registry login for %s: %s: %w", loginServer, res.String(), err)
+	}
+
+	return nil
+}
+
+type armSubscription struct {
+	SubscriptionId string `json:"subscriptionId"`
+	DisplayName    string `json:"displayName"`
+}
+
+func (cli *armClient) ListAccounts(ctx context.Context) ([]AzCliSubscriptionInfo, error) {
+	if !cli.withoutAzCli {
+		return cli.AzCli.ListAccounts(ctx)
+	}
+
+	subscriptions, err := armList[armSubscription](ctx, cli, armPath("/subscriptions", armSubscriptionsApiVersion))
+	if err != nil {
+		return nil, fmt.Errorf("failed listing subscriptions: %w", err)
+	}
+
+	// Without the Azure CLI, no subscription is the default one
+	subscriptionInfos := make([]AzCliSubscriptionInfo, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscriptionInfos = append(subscriptionInfos, AzCliSubscriptionInfo{
+			Name: subscription.DisplayName,
+			Id:   subscription.SubscriptionId,
+		})
+	}
+
+	return subscriptionInfos, nil
+}
+
+type armLocation struct {
+	AzCliLocation
+	Metadata struct {
+		RegionType string `json:"regionType"`
+	} `json:"metadata"`
+}
+
+func (cli *armClient) ListAccountLocations(ctx context.Context) ([]AzCliLocation, error) {
+	if !cli.withoutAzCli {
+		return cli.AzCli.ListAccountLocations(ctx)
+	}
+
+	// The Azure CLI lists the locations of its default subscription. All subscriptions have the same locations,
+	// those of the first subscription are listed.
+	subscriptions, err := cli.ListAccounts(ctx)
+	if err != nil {
+		return nil, err
+	} else if len(subscriptions) == 0 {
+		return nil, errors.New("failed listing locations: no subscriptions were found")
+	}
+
+	locationsPath := fmt.Sprintf("%s/locations", azure.SubscriptionRID(subscriptions[0].Id))
+	locations, err := armList[armLocation](ctx, cli, armPath(locationsPath, armSubscriptionsApiVersion))
+	if err != nil {
+		return nil, fmt.Errorf("failed listing locations: %w", err)
+	}
+
+	physicalLocations := []AzCliLocation{}
+	for _, location := range locations {
+		if location.Metadata.RegionType == "Physical" {
+			physicalLocations = append(physicalLocations, location.AzCliLocation)
+		}
+	}
+
+	return physicalLocations, nil
+}
+
+func (cli *armClient) ListExtensions(ctx context.Context) ([]AzCliExtensionInfo, error) {
+	if cli.withoutAzCli {
+		return nil, azCliRequiredError("listing extensions")
+	}
+
+	return cli.AzCli.ListExtensions(ctx)
+}
+
+func (cli *armClient) GetCliConfigValue(ctx context.Context, name string) (AzCliConfigValue, error) {
+	// Without the Azure CLI, nothing is configured
+	if cli.withoutAzCli {
+		return AzCliConfigValue{}, ErrNoConfigurationValue
+	}
+
+	return cli.AzCli.GetCliConfigValue(ctx, name)
+}
+
+func (cli *armClient) GetKeyVaultSecret(ctx context.Context, vaultName string, secretName string) (AzCliKeyVaultSecret, error) {
+	if !cli.withoutAzCli {
+		return cli.AzCli.GetKeyVaultSecret(ctx, vaultName, secretName)
+	}
+
+	response, err := cli.sendKeyVault(ctx, http.MethodGet, vaultName, secretName, "")
+	if err != nil {
+		return AzCliKeyVaultSecret{}, fmt.Errorf("failed getting key vault secret: %w", err)
+	}
+
+	var secret AzCliKeyVaultSecret
+	if err := json.Unmarshal(response.Body, &secret); err != nil {
+		return AzCliKeyVaultSecret{}, fmt.Errorf("could not unmarshal response as an AzCliKeyVaultSecret: %w", err)
+	}
+
+	secret.Name = secretName
+	return secret, nil
+}
+
+func (cli *armClient) SetKeyVaultSecret(ctx context.Context, vaultName string, secretName string, value string) error {
+	if !cli.withoutAzCli {
+		return cli.AzCli.SetKeyVaultSecret(ctx, vaultName, secretName, value)
+	}
+
+	body, err := json.Marshal(map[string]string{"value": value})
+	if err != nil {
+		return fmt.Errorf("marshalling JSON body: %w", err)
+	}
+
+	if _, err := cli.sendKeyVault(ctx, http.MethodPut, vaultName, secretName, string(body)); err != nil {
+		return fmt.Errorf("failed setting key vault secret: %w", err)
+	}
+
+	return nil
+}
+
+// Sends a request for a secret to the data plane of Key Vault
+func (cli *armClient) sendKeyVault(ctx context.Context, method string, vaultName string, secretName string, body string) (*httpUtil.HttpResponseMessage, error) {
+	if cli.keyVaultTokenSource == nil {
+		return nil, errors.New("no token source for Key Vault was configured")
+	}
+
+	secretUrl := fmt.Sprintf("https://%s.vault.azure.net/secrets/%s?api-version=%s", vaultName, url.PathEscape(secretName), keyVaultApiVersion)
+	return cli.sendWithToken(ctx, cli.keyVaultTokenSource, method, secretUrl, nil, body)
+}
+
+func (cli *armClient) DeployAppServiceZip(ctx context.Context, subscriptionId string, resourceGroup string, appName string, deployZipPath string) (string, error) {
+	if !cli.withoutAzCli {
+		return cli.AzCli.DeployAppServiceZip(ctx, subscriptionId, resourceGroup, appName, deployZipPath)
+	}
+
+	res, err := cli.zipDeploy(ctx, azure.WebsiteRID(subscriptionId, resourceGroup, appName), deployZipPath)
+	if err != nil {
+		return "", fmt.Errorf("failed deploying webapp: %w", err)
+	}
+
+	return res, nil
+}
+
+func (cli *armClient) DeployFunctionAppUsingZipFile(ctx context.Context, subscriptionID string, resourceGroup string, funcName string, deployZipPath string) (string, error) {
+	if !cli.withoutAzCli {
+		return cli.AzCli.DeployFunctionAppUsingZipFile(ctx, subscriptionID, resourceGroup, funcName, deployZipPath)
+	}
+
+	siteId := azure.WebsiteRID(subscriptionID, resourceGroup, funcName)
+	if err := cli.enableRemoteBuild(ctx, siteId); err != nil {
+		return "", fmt.Errorf("failed deploying function app: %w", err)
+	}
+
+	res, err := cli.zipDeploy(ctx, siteId, deployZipPath)
+	if err != nil {
+		return "", fmt.Errorf("failed deploying function app: %w", err)
+	}
+
+	return res, nil
+}
+
+// Enables the build of zip packages by the app, like the `--build-remote` flag of `az functionapp deployment source config-zip`
+func (cli *armClient) enableRemoteBuild(ctx context.Context, siteId string) error {
+	response, err := cli.send(ctx, http.MethodPost, armPath(siteId+"/config/appsettings/list", armWebApiVersion), nil)
+	if err != nil {
+		return fmt.Errorf("listing app settings: %w", err)
+	}
+
+	var appSettings struct {
+		Properties map[string]string `json:"properties"`
+	}
+
+	if err := json.Unmarshal(response.Body, &appSettings); err != nil {
+		return fmt.Errorf("could not unmarshal response %s: %w", string(response.Body), err)
+	}
+
+	remoteBuildSettings := map[string]string{
+		"SCM_DO_BUILD_DURING_DEPLOYMENT": "true",
+		"ENABLE_ORYX_BUILD":              "true",
+	}
+
+	if appSettings.Properties == nil {
+		appSettings.Properties = map[string]string{}
+	}
+
+	changed := false
+	for name, value := range remoteBuildSettings {
+		if !strings.EqualFold(appSettings.Properties[name], value) {
+			appSettings.Properties[name] = value
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if _, err := cli.send(ctx, http.MethodPut, armPath(siteId+"/config/appsettings", armWebApiVersion), appSettings); err != nil {
+		return fmt.Errorf("updating app settings: %w", err)
+	}
+
+	return nil
+}
+
+// Deploys a zip package to the app through the zip deployment API of Kudu, the SCM site of the app,
+// and waits for the deployment to complete
+func (cli *armClient) zipDeploy(ctx context.Context, siteId string, deployZipPath string) (string, error) {
+	var site struct {
+		Properties struct {
+			HostNameSslStates []struct {
+				Name     string `json:"name"`
+				HostType string `json:"hostType"`
+			} `json:"hostNameSslStates"`
+		} `json:"properties"`
+	}
+
+	if err := cli.get(ctx, armPath(siteId, armWebApiVersion), &site); err != nil {
+		return "", fmt.Errorf("getting app: %w", err)
+	}
+
+	scmHostName := ""
+	for _, hostName := range site.Properties.HostNameSslStates {
+		if strings.EqualFold(hostName.HostType, "Repository") {
+			scmHostName = hostName.Name
+			break
+		}
+	}
+
+	if scmHostName == "" {
+		return "", fmt.Errorf("app '%s' has no SCM site", siteId)
+	}
+
+	zipPackage, err := os.ReadFile(deployZipPath)
+	if err != nil {
+		return "", fmt.Errorf("reading zip package: %w", err)
+	}
+
+	response, err := cli.sendWithToken(
+		ctx,
+		cli.tokenSource,
+		http.MethodPost,
+		fmt.Sprintf("https://%s/api/zipdeploy?isAsync=true", scmHostName),
+		map[string]string{"Content-Type": "application/zip"},
+		string(zipPackage),
+	)
+	if err != nil {
+		return "", fmt.Errorf("starting zip deployment: %w", err)
+	}
+
+	// The status of the deployment is accepted until the deployment completes
+	statusUrl := headerValue(response.Headers, "Location")
+	for response.Status == http.StatusAccepted && statusUrl != "" {
+		if err := cli.wait(ctx); err != nil {
+			return "", err
+		}
+
+		response, err = cli.sendWithToken(ctx, cli.tokenSource, http.MethodGet, statusUrl, nil, "")
+		if err != nil {
+			return "", fmt.Errorf("getting zip deployment status: %w", err)
+		}
+	}
+
+	var status struct {
+		Status     int    `json:"status"`
+		StatusText string `json:"status_text"`
+	}
+
+	if len(response.Body) > 0 {
+		if err := json.Unmarshal(response.Body, &status); err != nil {
+			return "", fmt.Errorf("could not unmarshal response %s: %w", string(response.Body), err)
+		}
+	}
+
+	if status.Status == kuduDeploymentFailed {
+		return "", fmt.Errorf("zip deployment failed: %s", string(response.Body))
+	}
+
+	return string(response.Body), nil
+}
+
+func (cli *armClient) GetManagedClusterCredentials(ctx context.Context, subscriptionId string, resourceGroup string, clusterName string, kubeConfigPath string) error {
+	if !cli.withoutAzCli {
+		return cli.AzCli.GetManagedClusterCredentials(ctx, subscriptionId, resourceGroup, clusterName, kubeConfigPath)
+	}
+
+	credentialsPath := azure.ManagedClusterRID(subscriptionId, resourceGroup, clusterName) + "/listClusterUserCredential"
+	response, err := cli.send(ctx, http.MethodPost, armPath(credentialsPath, armContainerServiceApiVersion), nil)
+	if err != nil {
+		return fmt.Errorf("failed getting aks cluster credentials: %w", err)
+	}
+
+	// The kubeconfig files are base64 encoded, which is decoded when unmarshalled to bytes
+	var credentials struct {
+		Kubeconfigs []struct {
+			Name  string `json:"name"`
+			Value []byte `json:"value"`
+		} `json:"kubeconfigs"`
+	}
+
+	if err := json.Unmarshal(response.Body, &credentials); err != nil {
+		return fmt.Errorf("could not unmarshal response %s: %w", string(response.Body), err)
+	} else if len(credentials.Kubeconfigs) == 0 {
+		return fmt.Errorf("failed getting aks cluster credentials: cluster '%s' has no kubeconfig", clusterName)
+	}
+
+	if err := os.WriteFile(kubeConfigPath, credentials.Kubeconfigs[0].Value, 0600); err != nil {
+		return fmt.Errorf("writing kubeconfig: %w", err)
+	}
+
+	return nil
+}
+
+func (cli *armClient) CreateOrUpdateServicePrincipal(ctx context.Context, subscriptionId string, applicationName string, roleToAssign string) (json.RawMessage, error) {
+	if cli.withoutAzCli {
+		return nil, azCliRequiredError("creating service principals")
+	}
+
+	return cli.AzCli.CreateOrUpdateServicePrincipal(ctx, subscriptionId, applicationName, roleToAssign)
+}
+
+func (cli *armClient) CreateOrUpdateServicePrincipalWithoutSecret(ctx context.Context, subscriptionId string, applicationName string, roleToAssign string) (AzureCredentials, error) {
+	if cli.withoutAzCli {
+		return AzureCredentials{}, azCliRequiredError("creating service principals")
+	}
+
+	return cli.AzCli.CreateOrUpdateServicePrincipalWithoutSecret(ctx, subscriptionId, applicationName, roleToAssign)
+}
+
+func (cli *armClient) CreateOrUpdateFederatedCredential(ctx context.Context, applicationId string, credential AzCliFederatedCredential) error {
+	if cli.withoutAzCli {
+		return azCliRequiredError("creating federated identity credentials")
+	}
+
+	return cli.AzCli.CreateOrUpdateFederatedCredential(ctx, applicationId, credential)
+}
+
+func (cli *armClient) GetSignedInUserId(ctx context.Context) (string, error) {
+	if cli.withoutAzCli {
+		return "", azCliRequiredError("getting the signed in user")
+	}
+
+	return cli.AzCli.GetSignedInUserId(ctx)
+}
+
+// Compiles a Bicep module to an ARM template with the Bicep CLI
+func (cli *armClient) buildBicep(ctx context.Context, file string) ([]byte, error) {
+	res, err := cli.runWithResultFn(ctx, executil.RunArgs{
+		Cmd:         "bicep",
+		Args:        []string{"build", file, "--stdout"},
+		EnrichError: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed running bicep build: %s: %w", res.String(), err)
+	}
+
+	return []byte(res.Stdout), nil
+}
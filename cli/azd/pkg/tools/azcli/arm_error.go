@@ -0,0 +1,77 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ArmError is an error returned by Azure Resource Manager.
+// The well known error codes are mapped to the errors returned by the Azure CLI implementation,
+// so callers can use errors.Is regardless of the AzCli implementation being used.
+type ArmError struct {
+	// The HTTP status code of the response. Zero when the error was reported by a long running operation.
+	StatusCode int
+	Code       string
+	Message    string
+	Target     string
+	Details    []AzCliDeploymentErrorResponse
+}
+
+func (e *ArmError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s: %s", e.Code, e.Message))
+
+	var writeDetails func(details []AzCliDeploymentErrorResponse, indent string)
+	writeDetails = func(details []AzCliDeploymentErrorResponse, indent string) {
+		for _, detail := range details {
+			sb.WriteString(fmt.Sprintf("\n%s- %s: %s", indent, detail.Code, detail.Message))
+			writeDetails(detail.Details, indent+"  ")
+		}
+	}
+
+	writeDetails(e.Details, "")
+	return sb.String()
+}
+
+func (e *ArmError) Is(target error) bool {
+	switch target {
+	case ErrAzCliNotLoggedIn:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrDeploymentNotFound:
+		return e.Code == "DeploymentNotFound"
+	}
+
+	return false
+}
+
+// newArmErrorFromResponse creates an ArmError from the body of a failed ARM response
+func newArmErrorFromResponse(statusCode int, body []byte) *ArmError {
+	var errorResponse struct {
+		Error AzCliDeploymentErrorResponse `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &errorResponse); err != nil || errorResponse.Error.Code == "" {
+		return &ArmError{
+			StatusCode: statusCode,
+			Code:       http.StatusText(statusCode),
+			Message:    strings.TrimSpace(string(body)),
+		}
+	}
+
+	return newArmError(statusCode, errorResponse.Error)
+}
+
+func newArmError(statusCode int, errorResponse AzCliDeploymentErrorResponse) *ArmError {
+	return &ArmError{
+		StatusCode: statusCode,
+		Code:       errorResponse.Code,
+		Message:    errorResponse.Message,
+		Target:     errorResponse.Target,
+		Details:    errorResponse.Details,
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"regexp"
 	"strings"
 	"time"
@@ -30,6 +31,7 @@ var (
 	ErrClientAssertionExpired    = errors.New("client assertion expired")
 	ErrDeploymentNotFound        = errors.New("deployment not found")
 	ErrNoConfigurationValue      = errors.New("no value configured")
+	ErrAcrImageNotFound          = errors.New("image not found in container registry")
 )
 
 const (
@@ -53,6 +55,12 @@ type AzCli interface {
 	// `deviceCodeWriter`.
 	Login(ctx context.Context, useDeviceCode bool, deviceCodeWriter io.Writer) error
 	LoginAcr(ctx context.Context, subscriptionId string, loginServer string) error
+	// BuildAcrImage uploads the build context & builds the image in the container registry with ACR Tasks, without a
+	// local Docker daemon. The log of the build is written to logWriter while the build runs.
+	BuildAcrImage(ctx context.Context, subscriptionId string, loginServer string, args AcrBuildArgs, logWriter io.Writer) error
+	// GetAcrImageDigest returns the digest of the manifest of an image in the container registry, ex) for
+	// app/web:azdev-deploy-1662140000. ErrAcrImageNotFound is returned when the registry has no such image.
+	GetAcrImageDigest(ctx context.Context, loginServer string, image string) (string, error)
 	ListAccounts(ctx context.Context) ([]AzCliSubscriptionInfo, error)
 	ListExtensions(ctx context.Context) ([]AzCliExtensionInfo, error)
 	GetCliConfigValue(ctx context.Context, name string) (AzCliConfigValue, error)
@@ -62,11 +70,17 @@ type AzCli interface {
 	GetResource(ctx context.Context, subscriptionId string, resourceId string) (AzCliResourceExtended, error)
 	GetKeyVault(ctx context.Context, subscriptionId string, vaultName string) (AzCliKeyVault, error)
 	PurgeKeyVault(ctx context.Context, subscriptionId string, vaultName string) error
+	GetKeyVaultSecret(ctx context.Context, vaultName string, secretName string) (AzCliKeyVaultSecret, error)
+	SetKeyVaultSecret(ctx context.Context, vaultName string, secretName string, value string) error
 	DeployAppServiceZip(ctx context.Context, subscriptionId string, resourceGroup string, appName string, deployZipPath string) (string, error)
 	DeployFunctionAppUsingZipFile(ctx context.Context, subscriptionID string, resourceGroup string, funcName string, deployZipPath string) (string, error)
 	GetFunctionAppProperties(ctx context.Context, subscriptionID string, resourceGroup string, funcName string) (AzCliFunctionAppProperties, error)
 	DeployToSubscription(ctx context.Context, subscriptionId string, deploymentName string, templatePath string, parametersPath string, location string) (AzCliDeploymentResult, error)
 	DeployToResourceGroup(ctx context.Context, subscriptionId string, resourceGroup string, deploymentName string, templatePath string, parametersPath string) (AzCliDeploymentResult, error)
+	// WhatIfDeployToSubscription previews the changes a subscription deployment would make without applying them
+	WhatIfDeployToSubscription(ctx context.Context, subscriptionId string, deploymentName string, templatePath string, parametersPath string, location string) (AzCliWhatIfResult, error)
+	// WhatIfDeployToResourceGroup previews the changes a resource group deployment would make without applying them
+	WhatIfDeployToResourceGroup(ctx context.Context, subscriptionId string, resourceGroup string, deploymentName string, templatePath string, parametersPath string) (AzCliWhatIfResult, error)
 	DeleteSubscriptionDeployment(ctx context.Context, subscriptionId string, deploymentName string) error
 	DeleteResourceGroup(ctx context.Context, subscriptionId string, resourceGroupName string) error
 	ListResourceGroupResources(ctx context.Context, subscriptionId string, resourceGroupName string) ([]AzCliResource, error)
@@ -79,11 +93,20 @@ type AzCli interface {
 	// principal is assigned a given role. If an existing principal exists with the given name,
 	// it is updated in place and its credentials are reset.
 	CreateOrUpdateServicePrincipal(ctx context.Context, subscriptionId string, applicationName string, roleToAssign string) (json.RawMessage, error)
+	// CreateOrUpdateServicePrincipalWithoutSecret creates a service principal assigned the given role like
+	// CreateOrUpdateServicePrincipal, without creating a client secret. The returned credentials have no client secret,
+	// the principal authenticates with federated identity credentials instead.
+	CreateOrUpdateServicePrincipalWithoutSecret(ctx context.Context, subscriptionId string, applicationName string, roleToAssign string) (AzureCredentials, error)
+	// CreateOrUpdateFederatedCredential adds a federated identity credential to the application, or updates the existing
+	// credential with the same name. Nothing is changed when a credential trusting the same issuer & subject already exists.
+	CreateOrUpdateFederatedCredential(ctx context.Context, applicationId string, credential AzCliFederatedCredential) error
 	GetAppServiceProperties(ctx context.Context, subscriptionId string, resourceGroupName string, applicationName string) (AzCliAppServiceProperties, error)
 	GetContainerAppProperties(ctx context.Context, subscriptionId string, resourceGroupName string, applicationName string) (AzCliContainerAppProperties, error)
 	GetStaticWebAppProperties(ctx context.Context, subscriptionID string, resourceGroup string, appName string) (AzCliStaticWebAppProperties, error)
 	GetStaticWebAppApiKey(ctx context.Context, subscriptionID string, resourceGroup string, appName string) (string, error)
 	GetStaticWebAppEnvironmentProperties(ctx context.Context, subscriptionID string, resourceGroup string, appName string, environmentName string) (AzCliStaticWebAppEnvironmentProperties, error)
+	// GetManagedClusterCredentials writes the credentials of the user for the AKS cluster to the kubeconfig file
+	GetManagedClusterCredentials(ctx context.Context, subscriptionId string, resourceGroup string, clusterName string, kubeConfigPath string) error
 
 	GetSignedInUserId(ctx context.Context) (string, error)
 
@@ -130,6 +153,33 @@ type AzCliDeploymentResultProperties struct {
 	Outputs map[string]AzCliDeploymentOutput `json:"outputs"`
 }
 
+// AzCliWhatIfResult is the result of an ARM what-if operation
+type AzCliWhatIfResult struct {
+	Status  string                        `json:"status"`
+	Changes []AzCliWhatIfChange           `json:"changes"`
+	Error   *AzCliDeploymentErrorResponse `json:"error"`
+}
+
+// AzCliWhatIfChange is the predicted change to a single resource
+type AzCliWhatIfChange struct {
+	ResourceId string `json:"resourceId"`
+	// One of Create, Delete, Deploy, Ignore, Modify, NoChange or Unsupported
+	ChangeType string                      `json:"changeType"`
+	Before     map[string]interface{}      `json:"before"`
+	After      map[string]interface{}      `json:"after"`
+	Delta      []AzCliWhatIfPropertyChange `json:"delta"`
+}
+
+// AzCliWhatIfPropertyChange is the predicted change to a single property of a resource
+type AzCliWhatIfPropertyChange struct {
+	Path string `json:"path"`
+	// One of Array, Create, Delete, Modify or NoEffect
+	PropertyChangeType string                      `json:"propertyChangeType"`
+	Before             interface{}                 `json:"before"`
+	After              interface{}                 `json:"after"`
+	Children           []AzCliWhatIfPropertyChange `json:"children"`
+}
+
 type AzCliDeploymentErrorResponse struct {
 	Code           string                         `json:"code"`
 	Message        string                         `json:"message"`
@@ -202,6 +252,12 @@ type AzCliKeyVault struct {
 	} `json:"properties"`
 }
 
+type AzCliKeyVaultSecret struct {
+	Id    string `json:"id"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
 type AzCliAppServiceProperties struct {
 	HostNames []string `json:"hostNames"`
 }
@@ -213,6 +269,12 @@ type AzCliContainerAppProperties struct {
 				Fqdn string `json:"fqdn"`
 			} `json:"ingress"`
 		} `json:"configuration"`
+		Template struct {
+			Containers []struct {
+				Name  string `json:"name"`
+				Image string `json:"image"`
+			} `json:"containers"`
+		} `json:"template"`
 	} `json:"properties"`
 }
 
@@ -239,6 +301,22 @@ type AzCliLocation struct {
 	RegionalDisplayName string `json:"regionalDisplayName"`
 }
 
+// AcrBuildArgs are the arguments of an image build run in a container registry
+type AcrBuildArgs struct {
+	// The path to the gzipped tarball of the build context
+	SourcePath string
+	// The path to the Dockerfile, relative to the root of the build context
+	DockerfilePath string
+	// The names of the images pushed to the registry once built, ex) app/web:azdev-deploy-1662140000
+	ImageNames []string
+	// The stage of a multi-stage Dockerfile to build
+	Target string
+	// The values of the ARG instructions of the Dockerfile
+	BuildArgs map[string]string
+	// The platform of the image, ex) amd64 or linux/arm64
+	Platform string
+}
+
 // AzCliConfigValue represents the value returned by `az config get`.
 type AzCliConfigValue struct {
 	Name   string `json:"name"`
@@ -472,6 +550,18 @@ func (cli *azCli) LoginAcr(ctx context.Context, subscriptionId string, loginServ
 	return nil
 }
 
+func (cli *azCli) BuildAcrImage(ctx context.Context, subscriptionId string, loginServer string, args AcrBuildArgs, logWriter io.Writer) error {
+	// `az acr build` only uploads local directories, the tarball is uploaded & built with the REST API of the registry
+	armClient := NewArmClient(NewArmClientArgs{AzCli: cli})
+	return armClient.BuildAcrImage(ctx, subscriptionId, loginServer, args, logWriter)
+}
+
+func (cli *azCli) GetAcrImageDigest(ctx context.Context, loginServer string, image string) (string, error) {
+	// The Azure CLI has no command returning the digest of an image that works with all registry versions
+	armClient := NewArmClient(NewArmClientArgs{AzCli: cli})
+	return armClient.GetAcrImageDigest(ctx, loginServer, image)
+}
+
 func (cli *azCli) GetCliConfigValue(ctx context.Context, name string) (AzCliConfigValue, error) {
 	res, err := cli.runAzCommand(ctx, "config", "get", name, "--output", "json")
 	if isConfigurationIsNotSetMessage(res.Stderr) {
@@ -646,6 +736,28 @@ func (cli *azCli) GetStaticWebAppApiKey(ctx context.Context, subscriptionID stri
 	return strings.TrimSpace(res.Stdout), nil
 }
 
+func (cli *azCli) GetManagedClusterCredentials(ctx context.Context, subscriptionId string, resourceGroup string, clusterName string, kubeConfigPath string) error {
+	res, err := cli.runAzCommandWithArgs(ctx, executil.RunArgs{
+		Args: []string{
+			"aks", "get-credentials",
+			"--subscription", subscriptionId,
+			"--resource-group", resourceGroup,
+			"--name", clusterName,
+			"--file", kubeConfigPath,
+			"--overwrite-existing",
+		},
+		EnrichError: true,
+	})
+
+	if isNotLoggedInMessage(res.Stderr) {
+		return ErrAzCliNotLoggedIn
+	} else if err != nil {
+		return fmt.Errorf("failed getting aks cluster credentials: %w", err)
+	}
+
+	return nil
+}
+
 func (cli *azCli) DeployToSubscription(ctx context.Context, subscriptionId string, deploymentName string, templateFile string, parametersFile string, location string) (AzCliDeploymentResult, error) {
 	res, err := cli.runAzCommand(ctx, "deployment", "sub", "create", "--subscription", subscriptionId, "--name", deploymentName, "--location", location, "--template-file", templateFile, "--parameters", fmt.Sprintf("@%s", parametersFile), "--output", "json")
 	if isNotLoggedInMessage(res.Stderr) {
@@ -667,6 +779,48 @@ func (cli *azCli) DeployToSubscription(ctx context.Context, subscriptionId strin
 	return deploymentResult, nil
 }
 
+func (cli *azCli) WhatIfDeployToSubscription(ctx context.Context, subscriptionId string, deploymentName string, templateFile string, parametersFile string, location string) (AzCliWhatIfResult, error) {
+	res, err := cli.runAzCommand(ctx, "deployment", "sub", "what-if", "--subscription", subscriptionId, "--name", deploymentName, "--location", location, "--template-file", templateFile, "--parameters", fmt.Sprintf("@%s", parametersFile), "--no-pretty-print", "--output", "json")
+	if isNotLoggedInMessage(res.Stderr) {
+		return AzCliWhatIfResult{}, ErrAzCliNotLoggedIn
+	} else if err != nil {
+		if isDeploymentError(res.Stderr) {
+			deploymentErrorJson := getDeploymentErrorJson(res.Stderr)
+			deploymentError := internal.NewAzureDeploymentError(deploymentErrorJson)
+			return AzCliWhatIfResult{}, fmt.Errorf("failed running az deployment sub what-if: \n%w", deploymentError)
+		}
+
+		return AzCliWhatIfResult{}, fmt.Errorf("failed running az deployment sub what-if: %s: %w", res.String(), err)
+	}
+
+	var whatIfResult AzCliWhatIfResult
+	if err := json.Unmarshal([]byte(res.Stdout), &whatIfResult); err != nil {
+		return AzCliWhatIfResult{}, fmt.Errorf("could not unmarshal output %s as an AzCliWhatIfResult: %w", res.Stdout, err)
+	}
+	return whatIfResult, nil
+}
+
+func (cli *azCli) WhatIfDeployToResourceGroup(ctx context.Context, subscriptionId string, resourceGroup string, deploymentName string, templateFile string, parametersFile string) (AzCliWhatIfResult, error) {
+	res, err := cli.runAzCommand(ctx, "deployment", "group", "what-if", "--subscription", subscriptionId, "--resource-group", resourceGroup, "--name", deploymentName, "--template-file", templateFile, "--parameters", fmt.Sprintf("@%s", parametersFile), "--no-pretty-print", "--output", "json")
+	if isNotLoggedInMessage(res.Stderr) {
+		return AzCliWhatIfResult{}, ErrAzCliNotLoggedIn
+	} else if err != nil {
+		if isDeploymentError(res.Stderr) {
+			deploymentErrorJson := getDeploymentErrorJson(res.Stderr)
+			deploymentError := internal.NewAzureDeploymentError(deploymentErrorJson)
+			return AzCliWhatIfResult{}, fmt.Errorf("failed running az deployment group what-if: \n%w", deploymentError)
+		}
+
+		return AzCliWhatIfResult{}, fmt.Errorf("failed running az deployment group what-if: %s: %w", res.String(), err)
+	}
+
+	var whatIfResult AzCliWhatIfResult
+	if err := json.Unmarshal([]byte(res.Stdout), &whatIfResult); err != nil {
+		return AzCliWhatIfResult{}, fmt.Errorf("could not unmarshal output %s as an AzCliWhatIfResult: %w", res.Stdout, err)
+	}
+	return whatIfResult, nil
+}
+
 func (cli *azCli) DeployToResourceGroup(ctx context.Context, subscriptionId string, resourceGroup string, deploymentName string, templateFile string, parametersFile string) (AzCliDeploymentResult, error) {
 	res, err := cli.runAzCommand(ctx, "deployment", "group", "create", "--subscription", subscriptionId, "--resource-group", resourceGroup, "--name", deploymentName, "--template-file", templateFile, "--parameters", fmt.Sprintf("@%s", parametersFile), "--output", "json")
 	if isNotLoggedInMessage(res.Stderr) {
@@ -861,6 +1015,16 @@ type AzureCredentials struct {
 	ResourceManagerEndpointUrl string `json:"resourceManagerEndpointUrl"`
 }
 
+// AzCliFederatedCredential is a federated identity credential of an application, trusting the tokens
+// of an external identity provider issued for a subject, ex) a branch of a GitHub repository
+type AzCliFederatedCredential struct {
+	Name        string   `json:"name"`
+	Issuer      string   `json:"issuer"`
+	Subject     string   `json:"subject"`
+	Description string   `json:"description,omitempty"`
+	Audiences   []string `json:"audiences"`
+}
+
 func (cli *azCli) CreateOrUpdateServicePrincipal(ctx context.Context, subscriptionId string, applicationName string, roleName string) (json.RawMessage, error) {
 	// By default the role assignment is tied to the root of the currently active subscription (in the az cli), which may not be the same
 	// subscription that the user has requested, so build the scope ourselves.
@@ -903,6 +1067,81 @@ func (cli *azCli) CreateOrUpdateServicePrincipal(ctx context.Context, subscripti
 	return resultWithAzureCredentialsModel, nil
 }
 
+func (cli *azCli) CreateOrUpdateServicePrincipalWithoutSecret(ctx context.Context, subscriptionId string, applicationName string, roleName string) (AzureCredentials, error) {
+	scopes := azure.SubscriptionRID(subscriptionId)
+	var result ServicePrincipalCredentials
+
+	res, err := cli.runAzCommand(ctx, "ad", "sp", "create-for-rbac", "--scopes", scopes, "--name", applicationName, "--role", roleName, "--create-password", "false", "--output", "json")
+	if isNotLoggedInMessage(res.Stderr) {
+		return AzureCredentials{}, ErrAzCliNotLoggedIn
+	} else if err != nil {
+		return AzureCredentials{}, fmt.Errorf("failed running az ad sp create-for-rbac: %s: %w", res.String(), err)
+	}
+
+	if err := json.Unmarshal([]byte(res.Stdout), &result); err != nil {
+		return AzureCredentials{}, fmt.Errorf("could not unmarshal output %s as a string: %w", res.Stdout, err)
+	}
+
+	return AzureCredentials{
+		ClientId:                   result.AppId,
+		SubscriptionId:             subscriptionId,
+		TenantId:                   result.Tenant,
+		ResourceManagerEndpointUrl: "https://management.azure.com/",
+	}, nil
+}
+
+func (cli *azCli) CreateOrUpdateFederatedCredential(ctx context.Context, applicationId string, credential AzCliFederatedCredential) error {
+	res, err := cli.runAzCommand(ctx, "ad", "app", "federated-credential", "list", "--id", applicationId, "--output", "json")
+	if isNotLoggedInMessage(res.Stderr) {
+		return ErrAzCliNotLoggedIn
+	} else if err != nil {
+		return fmt.Errorf("failed running az ad app federated-credential list: %s: %w", res.String(), err)
+	}
+
+	var existing []AzCliFederatedCredential
+	if err := json.Unmarshal([]byte(res.Stdout), &existing); err != nil {
+		return fmt.Errorf("could not unmarshal output %s as a []AzCliFederatedCredential: %w", res.Stdout, err)
+	}
+
+	parameters, err := json.Marshal(credential)
+	if err != nil {
+		return fmt.Errorf("marshalling federated credential: %w", err)
+	}
+
+	// Azure AD rejects credentials trusting the same issuer & subject as an existing credential, the tokens are already
+	// trusted when any of the credentials, named like the credential or not, trusts them
+	var named *AzCliFederatedCredential
+	for i, c := range existing {
+		if c.Issuer == credential.Issuer && c.Subject == credential.Subject {
+			return nil
+		}
+
+		if strings.EqualFold(c.Name, credential.Name) {
+			named = &existing[i]
+		}
+	}
+
+	args := []string{"ad", "app", "federated-credential", "create", "--id", applicationId, "--parameters", string(parameters), "--output", "none"}
+	if named != nil {
+		args = []string{
+			"ad", "app", "federated-credential", "update",
+			"--id", applicationId,
+			"--federated-credential-id", named.Name,
+			"--parameters", string(parameters),
+			"--output", "none",
+		}
+	}
+
+	res, err = cli.runAzCommand(ctx, args...)
+	if isNotLoggedInMessage(res.Stderr) {
+		return ErrAzCliNotLoggedIn
+	} else if err != nil {
+		return fmt.Errorf("failed running az ad app federated-credential %s: %s: %w", args[3], res.String(), err)
+	}
+
+	return nil
+}
+
 func (cli *azCli) GetAccessToken(ctx context.Context) (AzCliAccessToken, error) {
 	res, err := cli.runAzCommand(ctx, "account", "get-access-token", "--output", "json")
 	if isNotLoggedInMessage(res.Stderr) {
@@ -946,6 +1185,47 @@ func (cli *azCli) PurgeKeyVault(ctx context.Context, subscriptionId string, vaul
 	return nil
 }
 
+func (cli *azCli) GetKeyVaultSecret(ctx context.Context, vaultName string, secretName string) (AzCliKeyVaultSecret, error) {
+	res, err := cli.runAzCommand(ctx, "keyvault", "secret", "show", "--vault-name", vaultName, "--name", secretName, "--output", "json")
+	if isNotLoggedInMessage(res.Stderr) {
+		return AzCliKeyVaultSecret{}, ErrAzCliNotLoggedIn
+	} else if err != nil {
+		return AzCliKeyVaultSecret{}, fmt.Errorf("failed running az keyvault secret show: %s: %w", res.String(), err)
+	}
+
+	var secret AzCliKeyVaultSecret
+	if err := json.Unmarshal([]byte(res.Stdout), &secret); err != nil {
+		return AzCliKeyVaultSecret{}, fmt.Errorf("could not unmarshal output as an AzCliKeyVaultSecret: %w", err)
+	}
+	return secret, nil
+}
+
+func (cli *azCli) SetKeyVaultSecret(ctx context.Context, vaultName string, secretName string, value string) error {
+	// The value is passed through a file so the secret is not visible in the arguments of the process
+	valueFile, err := os.CreateTemp("", "azdsecret")
+	if err != nil {
+		return fmt.Errorf("creating secret value file: %w", err)
+	}
+	defer os.Remove(valueFile.Name())
+
+	_, err = valueFile.WriteString(value)
+	if closeErr := valueFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing secret value file: %w", err)
+	}
+
+	res, err := cli.runAzCommand(ctx, "keyvault", "secret", "set", "--vault-name", vaultName, "--name", secretName, "--file", valueFile.Name(), "--encoding", "utf-8", "--output", "none")
+	if isNotLoggedInMessage(res.Stderr) {
+		return ErrAzCliNotLoggedIn
+	} else if err != nil {
+		return fmt.Errorf("failed running az keyvault secret set: %s: %w", res.String(), err)
+	}
+
+	return nil
+}
+
 type GraphQueryRequest struct {
 	Subscriptions []string `json:"subscriptions"`
 	Query         string   `json:"query"`
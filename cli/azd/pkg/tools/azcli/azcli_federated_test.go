@@ -0,0 +1,108 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azcli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreateOrUpdateServicePrincipalWithoutSecret(t *testing.T) {
+	azcli := NewAzCli(NewAzCliArgs{}).(*azCli)
+
+	azcli.runWithResultFn = func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+		require.Equal(t, []string{
+			"ad", "sp", "create-for-rbac",
+			"--scopes", "/subscriptions/SUBSCRIPTION_ID",
+			"--name", "az-dev-app",
+			"--role", "Contributor",
+			"--create-password", "false",
+			"--output", "json",
+		}, args.Args)
+
+		return executil.NewRunResult(0, `{"appId":"APP_ID","displayName":"az-dev-app","password":null,"tenant":"TENANT_ID"}`, ""), nil
+	}
+
+	credentials, err := azcli.CreateOrUpdateServicePrincipalWithoutSecret(context.Background(), "SUBSCRIPTION_ID", "az-dev-app", "Contributor")
+	require.NoError(t, err)
+	require.Equal(t, "APP_ID", credentials.ClientId)
+	require.Equal(t, "TENANT_ID", credentials.TenantId)
+	require.Equal(t, "SUBSCRIPTION_ID", credentials.SubscriptionId)
+	require.Empty(t, credentials.ClientSecret)
+}
+
+func Test_CreateOrUpdateFederatedCredential(t *testing.T) {
+	credential := AzCliFederatedCredential{
+		Name:      "azd-pull-request",
+		Issuer:    "https://token.actions.githubusercontent.com",
+		Subject:   "repo:contoso/todo:pull_request",
+		Audiences: []string{"api://AzureADTokenExchange"},
+	}
+
+	cases := []struct {
+		name     string
+		existing string
+		command  string
+	}{
+		{name: "Create", existing: `[]`, command: "create"},
+		{
+			name:     "Update",
+			existing: `[{"name":"azd-pull-request","issuer":"https://token.actions.githubusercontent.com","subject":"repo:contoso/old:pull_request"}]`,
+			command:  "update",
+		},
+		{
+			name:     "SameSubject",
+			existing: `[{"name":"manual","issuer":"https://token.actions.githubusercontent.com","subject":"repo:contoso/todo:pull_request"}]`,
+		},
+		{
+			name:     "UpToDate",
+			existing: `[{"name":"azd-pull-request","issuer":"https://token.actions.githubusercontent.com","subject":"repo:contoso/todo:pull_request"}]`,
+		},
+		{
+			name: "SameSubjectAfterName",
+			existing: `[{"name":"azd-pull-request","issuer":"https://token.actions.githubusercontent.com","subject":"repo:contoso/old:pull_request"},` +
+				`{"name":"manual","issuer":"https://token.actions.githubusercontent.com","subject":"repo:contoso/todo:pull_request"}]`,
+		},
+		{
+			name: "UpdateAfterOtherSubject",
+			existing: `[{"name":"other","issuer":"https://token.actions.githubusercontent.com","subject":"repo:contoso/todo:push"},` +
+				`{"name":"azd-pull-request","issuer":"https://token.actions.githubusercontent.com","subject":"repo:contoso/old:pull_request"}]`,
+			command: "update",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			azcli := NewAzCli(NewAzCliArgs{}).(*azCli)
+			commands := []string{}
+
+			azcli.runWithResultFn = func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+				commands = append(commands, args.Args[3])
+				require.Equal(t, "APP_ID", args.Args[5])
+
+				if args.Args[3] == "list" {
+					return executil.NewRunResult(0, c.existing, ""), nil
+				}
+
+				parameters := args.Args[len(args.Args)-3]
+				require.True(t, strings.Contains(parameters, `"subject":"repo:contoso/todo:pull_request"`), parameters)
+				return executil.NewRunResult(0, "", ""), nil
+			}
+
+			err := azcli.CreateOrUpdateFederatedCredential(context.Background(), "APP_ID", credential)
+			require.NoError(t, err)
+
+			expected := []string{"list"}
+			if c.command != "" {
+				expected = append(expected, c.command)
+			}
+			require.Equal(t, expected, commands)
+		})
+	}
+}
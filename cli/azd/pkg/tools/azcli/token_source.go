@@ -0,0 +1,70 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azcli
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenSource provides the access tokens used to authenticate requests to Azure Resource Manager.
+type TokenSource interface {
+	GetToken(ctx context.Context) (AzCliAccessToken, error)
+}
+
+// TokenSourceFunc adapts a function to the TokenSource interface.
+type TokenSourceFunc func(ctx context.Context) (AzCliAccessToken, error)
+
+func (fn TokenSourceFunc) GetToken(ctx context.Context) (AzCliAccessToken, error) {
+	return fn(ctx)
+}
+
+// NewAzCliTokenSource creates a token source that gets access tokens from the signed in account of the Azure CLI.
+func NewAzCliTokenSource(azCli AzCli) TokenSource {
+	return TokenSourceFunc(func(ctx context.Context) (AzCliAccessToken, error) {
+		return azCli.GetAccessToken(ctx)
+	})
+}
+
+// The amount of time before a token expires at which a new token is requested
+const tokenRefreshWindow = 2 * time.Minute
+
+// cachingTokenSource reuses the token of the inner token source until it is about to expire.
+type cachingTokenSource struct {
+	source TokenSource
+	now    func() time.Time
+
+	mu    sync.Mutex
+	token *AzCliAccessToken
+}
+
+func (s *cachingTokenSource) GetToken(ctx context.Context) (AzCliAccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && s.token.ExpiresOn != nil && s.now().Add(tokenRefreshWindow).Before(*s.token.ExpiresOn) {
+		return *s.token, nil
+	}
+
+	token, err := s.source.GetToken(ctx)
+	if err != nil {
+		return AzCliAccessToken{}, err
+	}
+
+	s.token = &token
+	return token, nil
+}
+
+// NewCachingTokenSource creates a token source that caches the tokens of the specified token source until they expire.
+func NewCachingTokenSource(source TokenSource) TokenSource {
+	if _, ok := source.(*cachingTokenSource); ok {
+		return source
+	}
+
+	return &cachingTokenSource{
+		source: source,
+		now:    time.Now,
+	}
+}
@@ -29,6 +29,8 @@ func NewBicepCli(args NewBicepCliArgs) BicepCli {
 	return &bicepCli{
 		cli:             args.AzCli,
 		runWithResultFn: args.RunWithResultFn,
+		// Without the Azure CLI, the Bicep CLI is run directly rather than through `az bicep`
+		standalone: !azcli.UsesAzCli(args.AzCli),
 	}
 }
 
@@ -40,6 +42,7 @@ type NewBicepCliArgs struct {
 type bicepCli struct {
 	cli             azcli.AzCli
 	runWithResultFn func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error)
+	standalone      bool
 }
 
 var isBicepNotFoundRegex = regexp.MustCompile(`Bicep CLI not found\.`)
@@ -67,6 +70,10 @@ func (cli *bicepCli) versionInfo() tools.VersionInfo {
 }
 
 func (cli *bicepCli) CheckInstalled(ctx context.Context) (bool, error) {
+	if cli.standalone {
+		return cli.checkStandaloneInstalled(ctx)
+	}
+
 	hasCli, err := cli.cli.CheckInstalled(ctx)
 	if err != nil || !hasCli {
 		return hasCli, err
@@ -101,7 +108,46 @@ func (cli *bicepCli) CheckInstalled(ctx context.Context) (bool, error) {
 	return true, nil
 }
 
+// Checks the version of the Bicep CLI installed on its own
+func (cli *bicepCli) checkStandaloneInstalled(ctx context.Context) (bool, error) {
+	found, err := tools.ToolInPath("bicep")
+	if !found {
+		return false, err
+	}
+
+	bicepRes, err := tools.ExecuteCommand(ctx, "bicep", "--version")
+	if err != nil {
+		return false, fmt.Errorf("checking %s version: %w", cli.Name(), err)
+	}
+	bicepSemver, err := tools.ExtractSemver(bicepRes)
+	if err != nil {
+		return false, fmt.Errorf("converting to semver version fails: %w", err)
+	}
+	updateDetail := cli.versionInfo()
+	if bicepSemver.LT(updateDetail.MinimumVersion) {
+		updateDetail.UpdateCommand = fmt.Sprintf("Visit %s to upgrade", cli.InstallUrl())
+		return false, &tools.ErrSemver{ToolName: cli.Name(), VersionInfo: updateDetail}
+	}
+
+	return true, nil
+}
+
 func (cli *bicepCli) Build(ctx context.Context, file string) (string, error) {
+	if cli.standalone {
+		buildRes, err := cli.runWithResultFn(ctx, executil.RunArgs{
+			Cmd:  "bicep",
+			Args: []string{"build", file, "--stdout"},
+		})
+		if err != nil {
+			return "", fmt.Errorf(
+				"failed running bicep build: %s (%w)",
+				buildRes.String(),
+				err,
+			)
+		}
+		return buildRes.Stdout, nil
+	}
+
 	sniffCliVersion := func() (string, error) {
 		verRes, err := cli.runCommand(ctx, "version", "--out", "json")
 		if err != nil {
@@ -2,8 +2,10 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -30,14 +32,106 @@ type Docker struct {
 	runWithResultFn func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error)
 }
 
+// BuildOptions are the options of a Docker build
+type BuildOptions struct {
+	// The path to the Dockerfile
+	DockerfilePath string
+	// The build context
+	BuildContext string
+	// The platform of the image, defaults to amd64 when empty
+	Platform string
+	// The stage of a multi-stage Dockerfile to build
+	Target string
+	// The values of the ARG instructions of the Dockerfile
+	BuildArgs map[string]string
+	// The secrets mounted by the RUN instructions of the Dockerfile
+	Secrets []BuildSecret
+	// The metadata of the image
+	Labels map[string]string
+	// The images or the cache exports used as cache sources, ex) type=registry,ref=myregistry.azurecr.io/app:cache
+	CacheFrom []string
+	// The destinations the build cache is exported to
+	CacheTo []string
+	// Additional names of the image
+	Tags []string
+	// Additional environment variables of the build, ex) the values of the secrets read from the environment
+	Env []string
+}
+
+// BuildSecret is a BuildKit secret, read from a file or from an environment variable
+type BuildSecret struct {
+	Id  string
+	Src string
+	Env string
+}
+
+func (s BuildSecret) String() string {
+	value := "id=" + s.Id
+	if s.Src != "" {
+		value += ",src=" + s.Src
+	}
+
+	if s.Env != "" {
+		value += ",env=" + s.Env
+	}
+
+	return value
+}
+
 // Runs a Docker build for a given Dockerfile. If the platform is not specified (empty), it defaults to amd64. If the build is successful, the function
 // returns the image id of the built image.
-func (d *Docker) Build(ctx context.Context, cwd string, dockerFilePath string, platform string, buildContext string) (string, error) {
+func (d *Docker) Build(ctx context.Context, cwd string, options BuildOptions) (string, error) {
+	platform := options.Platform
 	if strings.TrimSpace(platform) == "" {
 		platform = "amd64"
 	}
 
-	res, err := d.executeCommand(ctx, cwd, "build", "-q", "-f", dockerFilePath, "--platform", platform, buildContext)
+	args := []string{"build", "-q", "-f", options.DockerfilePath, "--platform", platform}
+
+	if options.Target != "" {
+		args = append(args, "--target", options.Target)
+	}
+
+	for _, name := range sortedKeys(options.BuildArgs) {
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", name, options.BuildArgs[name]))
+	}
+
+	for _, secret := range options.Secrets {
+		args = append(args, "--secret", secret.String())
+	}
+
+	for _, name := range sortedKeys(options.Labels) {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", name, options.Labels[name]))
+	}
+
+	for _, cacheFrom := range options.CacheFrom {
+		args = append(args, "--cache-from", cacheFrom)
+	}
+
+	for _, cacheTo := range options.CacheTo {
+		args = append(args, "--cache-to", cacheTo)
+	}
+
+	for _, tag := range options.Tags {
+		args = append(args, "-t", tag)
+	}
+
+	args = append(args, options.BuildContext)
+
+	// Copied so that the environment of the caller is never appended to
+	env := append([]string{}, options.Env...)
+	if len(options.Secrets) > 0 || len(options.CacheTo) > 0 {
+		// Secrets and cache exports are only supported by BuildKit
+		env = append(env, "DOCKER_BUILDKIT=1")
+	}
+
+	res, err := d.runWithResultFn(ctx, executil.RunArgs{
+		Cmd:         "docker",
+		Args:        args,
+		Cwd:         cwd,
+		Env:         env,
+		EnrichError: true,
+	})
 	if err != nil {
 		return "", fmt.Errorf("building image: %s: %w", res.String(), err)
 	}
@@ -63,6 +157,41 @@ func (d *Docker) Push(ctx context.Context, cwd string, tag string) error {
 	return nil
 }
 
+func (d *Docker) Pull(ctx context.Context, cwd string, imageName string) error {
+	res, err := d.executeCommand(ctx, cwd, "pull", imageName)
+	if err != nil {
+		return fmt.Errorf("pulling image: %s: %w", res.String(), err)
+	}
+
+	return nil
+}
+
+// ImageId returns the id of a local image, the digest of the configuration of the image, ex) sha256:e3b0c442...
+func (d *Docker) ImageId(ctx context.Context, cwd string, imageName string) (string, error) {
+	res, err := d.executeCommand(ctx, cwd, "image", "inspect", "--format", "{{.Id}}", imageName)
+	if err != nil {
+		return "", fmt.Errorf("inspecting image: %s: %w", res.String(), err)
+	}
+
+	return strings.TrimSpace(res.Stdout), nil
+}
+
+// RepoDigests returns the digests of the manifests a local image was pushed or pulled as,
+// ex) registry.azurecr.io/app/web@sha256:e3b0c442...
+func (d *Docker) RepoDigests(ctx context.Context, cwd string, imageName string) ([]string, error) {
+	res, err := d.executeCommand(ctx, cwd, "image", "inspect", "--format", "{{json .RepoDigests}}", imageName)
+	if err != nil {
+		return nil, fmt.Errorf("inspecting image: %s: %w", res.String(), err)
+	}
+
+	var digests []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(res.Stdout)), &digests); err != nil {
+		return nil, fmt.Errorf("could not unmarshal repo digests %s: %w", res.Stdout, err)
+	}
+
+	return digests, nil
+}
+
 func (d *Docker) versionInfo() tools.VersionInfo {
 	return tools.VersionInfo{
 		MinimumVersion: semver.Version{
@@ -122,6 +251,17 @@ func (d *Docker) Name() string {
 	return "Docker"
 }
 
+// sortedKeys returns the keys of the map in order, so the arguments of a build are the same from one run to the next
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
 func (d *Docker) executeCommand(ctx context.Context, cwd string, args ...string) (executil.RunResult, error) {
 	return d.runWithResultFn(ctx, executil.RunArgs{
 		Cmd:         "docker",
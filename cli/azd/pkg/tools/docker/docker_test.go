@@ -41,7 +41,11 @@ func Test_DockerBuild(t *testing.T) {
 			}, nil
 		}
 
-		result, err := docker.Build(context.Background(), cwd, dockerFile, platform, dockerContext)
+		result, err := docker.Build(context.Background(), cwd, BuildOptions{
+			DockerfilePath: dockerFile,
+			Platform:       platform,
+			BuildContext:   dockerContext,
+		})
 
 		require.Equal(t, true, ran)
 		require.Nil(t, err)
@@ -73,7 +77,11 @@ func Test_DockerBuild(t *testing.T) {
 			}, errors.New(customErrorMessage)
 		}
 
-		result, err := docker.Build(context.Background(), cwd, dockerFile, platform, dockerContext)
+		result, err := docker.Build(context.Background(), cwd, BuildOptions{
+			DockerfilePath: dockerFile,
+			Platform:       platform,
+			BuildContext:   dockerContext,
+		})
 
 		require.Equal(t, true, ran)
 		require.NotNil(t, err)
@@ -111,13 +119,94 @@ func Test_DockerBuildEmptyPlatform(t *testing.T) {
 		}, nil
 	}
 
-	result, err := docker.Build(context.Background(), cwd, dockerFile, "", dockerContext)
+	result, err := docker.Build(context.Background(), cwd, BuildOptions{
+		DockerfilePath: dockerFile,
+		BuildContext:   dockerContext,
+	})
 
 	require.Equal(t, true, ran)
 	require.Nil(t, err)
 	require.Equal(t, "Docker build output", result)
 }
 
+func Test_DockerBuildWithOptions(t *testing.T) {
+	docker := NewDocker(DockerArgs{})
+
+	ran := false
+	docker.runWithResultFn = func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+		ran = true
+
+		require.Equal(t, []string{
+			"build",
+			"-q",
+			"-f", "./Dockerfile",
+			"--platform", "arm64",
+			"--target", "runtime",
+			"--build-arg", "API_URL=https://api.contoso.com",
+			"--build-arg", "VERSION=1.2.0",
+			"--secret", "id=npmrc,src=.npmrc",
+			"--secret", "id=token,env=API_TOKEN",
+			"--label", "org.opencontainers.image.source=https://github.com/contoso/app",
+			"--cache-from", "contoso.azurecr.io/app:cache",
+			"--cache-to", "type=inline",
+			"-t", "app:latest",
+			".",
+		}, args.Args)
+		require.Equal(t, []string{"API_TOKEN=secret", "DOCKER_BUILDKIT=1"}, args.Env)
+
+		return executil.RunResult{
+			Stdout:   "sha256:abc\n",
+			ExitCode: 0,
+		}, nil
+	}
+
+	result, err := docker.Build(context.Background(), ".", BuildOptions{
+		DockerfilePath: "./Dockerfile",
+		BuildContext:   ".",
+		Platform:       "arm64",
+		Target:         "runtime",
+		BuildArgs: map[string]string{
+			"VERSION": "1.2.0",
+			"API_URL": "https://api.contoso.com",
+		},
+		Secrets: []BuildSecret{
+			{Id: "npmrc", Src: ".npmrc"},
+			{Id: "token", Env: "API_TOKEN"},
+		},
+		Labels:    map[string]string{"org.opencontainers.image.source": "https://github.com/contoso/app"},
+		CacheFrom: []string{"contoso.azurecr.io/app:cache"},
+		CacheTo:   []string{"type=inline"},
+		Tags:      []string{"app:latest"},
+		Env:       []string{"API_TOKEN=secret"},
+	})
+
+	require.True(t, ran)
+	require.NoError(t, err)
+	require.Equal(t, "sha256:abc", result)
+}
+
+func Test_DockerBuildDoesNotModifyEnv(t *testing.T) {
+	docker := NewDocker(DockerArgs{
+		RunWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			return executil.RunResult{Stdout: "sha256:abc\n"}, nil
+		},
+	})
+
+	// The environment has spare capacity, which appending to it in place would overwrite
+	env := make([]string, 1, 2)
+	env[0] = "API_TOKEN=secret"
+	spare := env[:2]
+	spare[1] = "CALLER=value"
+
+	_, err := docker.Build(context.Background(), ".", BuildOptions{
+		BuildContext: ".",
+		Secrets:      []BuildSecret{{Id: "token", Env: "API_TOKEN"}},
+		Env:          env,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"API_TOKEN=secret", "CALLER=value"}, spare)
+}
+
 func Test_DockerTag(t *testing.T) {
 	docker := NewDocker(DockerArgs{})
 
@@ -244,3 +333,37 @@ func Test_DockerPush(t *testing.T) {
 		require.Equal(t, fmt.Sprintf("pushing image: exit code: 1, stdout: , stderr: %s: %s", stdErr, customErrorMessage), err.Error())
 	})
 }
+
+func Test_DockerImageId(t *testing.T) {
+	docker := NewDocker(DockerArgs{})
+
+	docker.runWithResultFn = func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+		require.Equal(t, []string{"image", "inspect", "--format", "{{.Id}}", "imageName"}, args.Args)
+
+		return executil.RunResult{
+			Stdout:   "sha256:8a9d2b7c\n",
+			ExitCode: 0,
+		}, nil
+	}
+
+	id, err := docker.ImageId(context.Background(), ".", "imageName")
+	require.NoError(t, err)
+	require.Equal(t, "sha256:8a9d2b7c", id)
+}
+
+func Test_DockerRepoDigests(t *testing.T) {
+	docker := NewDocker(DockerArgs{})
+
+	docker.runWithResultFn = func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+		require.Equal(t, []string{"image", "inspect", "--format", "{{json .RepoDigests}}", "imageName"}, args.Args)
+
+		return executil.RunResult{
+			Stdout:   `["registry.azurecr.io/app/web@sha256:0123"]` + "\n",
+			ExitCode: 0,
+		}, nil
+	}
+
+	digests, err := docker.RepoDigests(context.Background(), ".", "imageName")
+	require.NoError(t, err)
+	require.Equal(t, []string{"registry.azurecr.io/app/web@sha256:0123"}, digests)
+}
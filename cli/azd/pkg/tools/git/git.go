@@ -24,6 +24,7 @@ type GitCli interface {
 	InitRepo(ctx context.Context, repositoryPath string) error
 	AddRemote(ctx context.Context, repositoryPath string, remoteName string, remoteUrl string) error
 	GetCurrentBranch(ctx context.Context, repositoryPath string) (string, error)
+	GetCurrentCommit(ctx context.Context, repositoryPath string) (string, error)
 	AddFile(ctx context.Context, repositoryPath string, filespec string) error
 	Commit(ctx context.Context, repositoryPath string, message string) error
 	PushUpstream(ctx context.Context, repositoryPath string, origin string, branch string) error
@@ -127,6 +128,17 @@ func (cli *gitCli) GetCurrentBranch(ctx context.Context, repositoryPath string)
 	return strings.TrimSpace(res.Stdout), nil
 }
 
+func (cli *gitCli) GetCurrentCommit(ctx context.Context, repositoryPath string) (string, error) {
+	res, err := executil.RunCommand(ctx, "git", "-C", repositoryPath, "rev-parse", "HEAD")
+	if notGitRepositoryRegex.MatchString(res.Stderr) {
+		return "", ErrNotRepository
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get current commit: %s: %w", res.String(), err)
+	}
+
+	return strings.TrimSpace(res.Stdout), nil
+}
+
 func (cli *gitCli) InitRepo(ctx context.Context, repositoryPath string) error {
 	res, err := executil.RunCommand(ctx, "git", "-C", repositoryPath, "init")
 	if err != nil {
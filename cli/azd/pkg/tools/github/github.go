@@ -20,6 +20,8 @@ type GitHubCli interface {
 	tools.ExternalTool
 	CheckAuth(ctx context.Context, hostname string) (bool, error)
 	SetSecret(ctx context.Context, repo string, name string, value string) error
+	// SetVariable sets a GitHub Actions configuration variable of the repository, readable by workflows as ${{ vars.<name> }}
+	SetVariable(ctx context.Context, repo string, name string, value string) error
 	Login(ctx context.Context, hostname string) error
 	ListRepositories(ctx context.Context) ([]GhCliRepository, error)
 	ViewRepository(ctx context.Context, name string) (GhCliRepository, error)
@@ -41,11 +43,12 @@ var (
 
 type ghCli struct{}
 
+// The minimum version is the first version with `gh variable set`, used to store the values of the pipeline variables
 func (cli *ghCli) versionInfo() tools.VersionInfo {
 	return tools.VersionInfo{
 		MinimumVersion: semver.Version{
 			Major: 2,
-			Minor: 4,
+			Minor: 31,
 			Patch: 0},
 		UpdateCommand: "Visit https://github.com/cli/cli/releases to upgrade",
 	}
@@ -114,6 +117,16 @@ func (cli *ghCli) SetSecret(ctx context.Context, repoSlug string, name string, v
 	return nil
 }
 
+func (cli *ghCli) SetVariable(ctx context.Context, repoSlug string, name string, value string) error {
+	res, err := executil.RunCommand(ctx, "gh", "-R", repoSlug, "variable", "set", name, "--body", value)
+	if isGhCliNotLoggedInMessageRegex.MatchString(res.Stderr) {
+		return ErrGitHubCliNotLoggedIn
+	} else if err != nil {
+		return fmt.Errorf("failed running gh variable set %s: %w", res.String(), err)
+	}
+	return nil
+}
+
 type GhCliRepository struct {
 	// The slug for a repository (formatted as "<owner>/<name>")
 	NameWithOwner string
@@ -0,0 +1,175 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package kubectl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+)
+
+type KubectlCli interface {
+	tools.ExternalTool
+	// Kustomize renders the kustomization in the directory and returns the resulting manifests
+	Kustomize(ctx context.Context, directory string) (string, error)
+	// Apply creates or updates the resources declared in the manifests file in the cluster of the kubeconfig file.
+	// When namespace is not empty, it is used for the resources which do not declare a namespace.
+	Apply(ctx context.Context, kubeConfigPath string, namespace string, manifestsPath string) error
+	// Get returns the current state of the resources declared in the manifests file
+	Get(ctx context.Context, kubeConfigPath string, namespace string, manifestsPath string) ([]Resource, error)
+}
+
+// Resource is the state of a Kubernetes resource, limited to the properties of services and ingresses
+// that expose the endpoints of an application
+type Resource struct {
+	Kind     string           `json:"kind"`
+	Metadata ResourceMetadata `json:"metadata"`
+	Spec     ResourceSpec     `json:"spec"`
+	Status   ResourceStatus   `json:"status"`
+}
+
+type ResourceMetadata struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type ResourceSpec struct {
+	// The type of a service, ex) ClusterIP, LoadBalancer
+	Type  string        `json:"type"`
+	Ports []ServicePort `json:"ports"`
+	// The rules & TLS configuration of an ingress
+	Rules []IngressRule `json:"rules"`
+	Tls   []IngressTls  `json:"tls"`
+}
+
+type ServicePort struct {
+	Name string `json:"name"`
+	Port int    `json:"port"`
+}
+
+type IngressRule struct {
+	Host string           `json:"host"`
+	Http *IngressRuleHttp `json:"http"`
+}
+
+type IngressRuleHttp struct {
+	Paths []IngressPath `json:"paths"`
+}
+
+type IngressPath struct {
+	Path string `json:"path"`
+}
+
+type IngressTls struct {
+	Hosts []string `json:"hosts"`
+}
+
+type ResourceStatus struct {
+	LoadBalancer LoadBalancerStatus `json:"loadBalancer"`
+}
+
+type LoadBalancerStatus struct {
+	Ingress []LoadBalancerIngress `json:"ingress"`
+}
+
+type LoadBalancerIngress struct {
+	Ip       string `json:"ip"`
+	Hostname string `json:"hostname"`
+}
+
+type resourceList struct {
+	Kind  string     `json:"kind"`
+	Items []Resource `json:"items"`
+}
+
+type kubectlCli struct {
+	// runWithResultFn allows us to stub out the executil.RunWithResult, for testing.
+	runWithResultFn func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error)
+}
+
+func NewKubectlCli() KubectlCli {
+	return &kubectlCli{
+		runWithResultFn: executil.RunWithResult,
+	}
+}
+
+func (cli *kubectlCli) Kustomize(ctx context.Context, directory string) (string, error) {
+	res, err := cli.executeCommand(ctx, directory, "kustomize", directory)
+	if err != nil {
+		return "", fmt.Errorf("kubectl kustomize: %s: %w", res.String(), err)
+	}
+
+	return res.Stdout, nil
+}
+
+func (cli *kubectlCli) Apply(ctx context.Context, kubeConfigPath string, namespace string, manifestsPath string) error {
+	args := cli.resourceArgs("apply", kubeConfigPath, namespace, manifestsPath)
+
+	res, err := cli.executeCommand(ctx, "", args...)
+	if err != nil {
+		return fmt.Errorf("kubectl apply: %s: %w", res.String(), err)
+	}
+
+	return nil
+}
+
+func (cli *kubectlCli) Get(ctx context.Context, kubeConfigPath string, namespace string, manifestsPath string) ([]Resource, error) {
+	args := cli.resourceArgs("get", kubeConfigPath, namespace, manifestsPath)
+	args = append(args, "--output", "json")
+
+	res, err := cli.executeCommand(ctx, "", args...)
+	if err != nil {
+		return nil, fmt.Errorf("kubectl get: %s: %w", res.String(), err)
+	}
+
+	// A single resource is returned as is, multiple resources are returned as a list
+	var list resourceList
+	if err := json.Unmarshal([]byte(res.Stdout), &list); err != nil {
+		return nil, fmt.Errorf("could not unmarshal output %s as resources: %w", res.Stdout, err)
+	}
+
+	if list.Kind != "List" {
+		var resource Resource
+		if err := json.Unmarshal([]byte(res.Stdout), &resource); err != nil {
+			return nil, fmt.Errorf("could not unmarshal output %s as a resource: %w", res.Stdout, err)
+		}
+
+		return []Resource{resource}, nil
+	}
+
+	return list.Items, nil
+}
+
+func (cli *kubectlCli) CheckInstalled(_ context.Context) (bool, error) {
+	return tools.ToolInPath("kubectl")
+}
+
+func (cli *kubectlCli) Name() string {
+	return "kubectl"
+}
+
+func (cli *kubectlCli) InstallUrl() string {
+	return "https://kubernetes.io/docs/tasks/tools/"
+}
+
+func (cli *kubectlCli) resourceArgs(command string, kubeConfigPath string, namespace string, manifestsPath string) []string {
+	args := []string{command, "--kubeconfig", kubeConfigPath, "--filename", manifestsPath}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+
+	return args
+}
+
+func (cli *kubectlCli) executeCommand(ctx context.Context, cwd string, args ...string) (executil.RunResult, error) {
+	return cli.runWithResultFn(ctx, executil.RunArgs{
+		Cmd:         "kubectl",
+		Args:        args,
+		Cwd:         cwd,
+		EnrichError: true,
+	})
+}
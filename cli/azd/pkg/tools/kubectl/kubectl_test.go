@@ -0,0 +1,83 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package kubectl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubectlApply(t *testing.T) {
+	ran := false
+	cli := &kubectlCli{
+		runWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+			ran = true
+
+			require.Equal(t, "kubectl", args.Cmd)
+			require.Equal(t, []string{
+				"apply",
+				"--kubeconfig", "/tmp/kubeconfig",
+				"--filename", "/tmp/manifests.yaml",
+				"--namespace", "apps",
+			}, args.Args)
+
+			return executil.NewRunResult(0, "", ""), nil
+		},
+	}
+
+	err := cli.Apply(context.Background(), "/tmp/kubeconfig", "apps", "/tmp/manifests.yaml")
+	require.NoError(t, err)
+	require.True(t, ran)
+}
+
+func TestKubectlGet(t *testing.T) {
+	t.Run("List", func(t *testing.T) {
+		cli := &kubectlCli{
+			runWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+				require.Equal(t, []string{
+					"get",
+					"--kubeconfig", "/tmp/kubeconfig",
+					"--filename", "/tmp/manifests.yaml",
+					"--output", "json",
+				}, args.Args)
+
+				return executil.NewRunResult(0, `{
+					"kind": "List",
+					"items": [
+						{"kind": "Deployment", "metadata": {"name": "api"}},
+						{
+							"kind": "Service",
+							"metadata": {"name": "api"},
+							"spec": {"type": "LoadBalancer", "ports": [{"port": 80}]},
+							"status": {"loadBalancer": {"ingress": [{"ip": "20.1.2.3"}]}}
+						}
+					]
+				}`, ""), nil
+			},
+		}
+
+		resources, err := cli.Get(context.Background(), "/tmp/kubeconfig", "", "/tmp/manifests.yaml")
+		require.NoError(t, err)
+		require.Len(t, resources, 2)
+		require.Equal(t, "Deployment", resources[0].Kind)
+		require.Equal(t, "LoadBalancer", resources[1].Spec.Type)
+		require.Equal(t, "20.1.2.3", resources[1].Status.LoadBalancer.Ingress[0].Ip)
+	})
+
+	t.Run("SingleResource", func(t *testing.T) {
+		cli := &kubectlCli{
+			runWithResultFn: func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error) {
+				return executil.NewRunResult(0, `{"kind": "Ingress", "metadata": {"name": "web"}}`, ""), nil
+			},
+		}
+
+		resources, err := cli.Get(context.Background(), "/tmp/kubeconfig", "", "/tmp/manifests.yaml")
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+		require.Equal(t, "web", resources[0].Metadata.Name)
+	})
+}
@@ -0,0 +1,242 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package pulumi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/blang/semver/v4"
+)
+
+// StackOptions identifies the pulumi stack a command is run against
+type StackOptions struct {
+	// The directory containing the Pulumi.yaml project file
+	ProjectPath string
+	// The name of the stack
+	StackName string
+	// The path to the stack configuration file, ex) Pulumi.<stack>.yaml
+	ConfigFilePath string
+}
+
+// ConfigValue is a value of the stack configuration
+type ConfigValue struct {
+	Value string
+	// Secret values are encrypted in the stack configuration and are passed to pulumi through stdin, so they don't
+	// appear on the command line
+	Secret bool
+}
+
+type PulumiCli interface {
+	tools.ExternalTool
+	// SetEnv sets additional environment variables (ex. PULUMI_BACKEND_URL) that are passed to every pulumi command.
+	SetEnv(envVars []string)
+	SelectStack(ctx context.Context, stack StackOptions) error
+	SetConfig(ctx context.Context, stack StackOptions, values map[string]ConfigValue) error
+	// GetConfig returns the JSON representation of the stack configuration
+	GetConfig(ctx context.Context, stack StackOptions) (string, error)
+	// Preview returns the JSON representation of the changes that would be applied to the stack
+	Preview(ctx context.Context, stack StackOptions) (string, error)
+	// Up applies the stack, writing the engine events as JSON lines to the specified event log file
+	Up(ctx context.Context, stack StackOptions, eventLogPath string) (string, error)
+	// StackOutput returns the JSON representation of the stack outputs
+	StackOutput(ctx context.Context, stack StackOptions) (string, error)
+	Destroy(ctx context.Context, stack StackOptions) (string, error)
+}
+
+func NewPulumiCli(args NewPulumiCliArgs) PulumiCli {
+	if args.RunWithResultFn == nil {
+		args.RunWithResultFn = executil.RunWithResult
+	}
+
+	return &pulumiCli{
+		runWithResultFn: args.RunWithResultFn,
+	}
+}
+
+type NewPulumiCliArgs struct {
+	// RunWithResultFn allows us to stub out the command execution for testing
+	RunWithResultFn func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error)
+}
+
+type pulumiCli struct {
+	env             []string
+	runWithResultFn func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error)
+}
+
+func (cli *pulumiCli) Name() string {
+	return "Pulumi CLI"
+}
+
+func (cli *pulumiCli) InstallUrl() string {
+	return "https://aka.ms/azure-dev/pulumi-install"
+}
+
+func (cli *pulumiCli) versionInfo() tools.VersionInfo {
+	return tools.VersionInfo{
+		MinimumVersion: semver.Version{
+			Major: 3,
+			Minor: 35,
+			Patch: 0},
+		UpdateCommand: "Visit https://www.pulumi.com/docs/get-started/install/ to upgrade",
+	}
+}
+
+func (cli *pulumiCli) CheckInstalled(ctx context.Context) (bool, error) {
+	found, err := tools.ToolInPath("pulumi")
+	if !found {
+		return false, err
+	}
+	pulumiRes, err := tools.ExecuteCommand(ctx, "pulumi", "version")
+	if err != nil {
+		return false, fmt.Errorf("checking %s version: %w", cli.Name(), err)
+	}
+	pulumiSemver, err := tools.ExtractSemver(pulumiRes)
+	if err != nil {
+		return false, fmt.Errorf("converting to semver version fails: %w", err)
+	}
+	updateDetail := cli.versionInfo()
+	if pulumiSemver.LT(updateDetail.MinimumVersion) {
+		return false, &tools.ErrSemver{ToolName: cli.Name(), VersionInfo: updateDetail}
+	}
+
+	return true, nil
+}
+
+func (cli *pulumiCli) SetEnv(envVars []string) {
+	cli.env = envVars
+}
+
+// SelectStack selects the stack, creating it when it does not exist yet
+func (cli *pulumiCli) SelectStack(ctx context.Context, stack StackOptions) error {
+	res, err := cli.runCommand(ctx, stack, nil, "stack", "select", "--create", stack.StackName)
+	if err != nil {
+		return fmt.Errorf("failed running pulumi stack select: %s: %w", res.String(), err)
+	}
+
+	return nil
+}
+
+func (cli *pulumiCli) SetConfig(ctx context.Context, stack StackOptions, values map[string]ConfigValue) error {
+	// Sort the keys so the generated commands are stable
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := append([]string{"config", "set-all", "--stack", stack.StackName}, configFileArgs(stack)...)
+	plaintextCount := 0
+	for _, key := range keys {
+		if !values[key].Secret {
+			args = append(args, "--plaintext", fmt.Sprintf("%s=%s", key, values[key].Value))
+			plaintextCount++
+		}
+	}
+
+	if plaintextCount > 0 {
+		res, err := cli.runCommand(ctx, stack, nil, args...)
+		if err != nil {
+			return fmt.Errorf("failed running pulumi config set-all: %s: %w", res.String(), err)
+		}
+	}
+
+	// pulumi reads the value from stdin when it is not on the command line
+	for _, key := range keys {
+		if !values[key].Secret {
+			continue
+		}
+
+		args := append([]string{"config", "set", "--secret", "--stack", stack.StackName}, configFileArgs(stack)...)
+		args = append(args, key)
+
+		res, err := cli.runCommand(ctx, stack, strings.NewReader(values[key].Value), args...)
+		if err != nil {
+			return fmt.Errorf("failed running pulumi config set for secret '%s': %s: %w", key, res.String(), err)
+		}
+	}
+
+	return nil
+}
+
+func (cli *pulumiCli) GetConfig(ctx context.Context, stack StackOptions) (string, error) {
+	args := append([]string{"config", "--stack", stack.StackName, "--json", "--show-secrets"}, configFileArgs(stack)...)
+
+	res, err := cli.runCommand(ctx, stack, nil, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed running pulumi config: %s: %w", res.String(), err)
+	}
+
+	return res.Stdout, nil
+}
+
+func (cli *pulumiCli) Preview(ctx context.Context, stack StackOptions) (string, error) {
+	args := append([]string{"preview", "--stack", stack.StackName, "--json"}, configFileArgs(stack)...)
+
+	res, err := cli.runCommand(ctx, stack, nil, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed running pulumi preview: %s: %w", res.String(), err)
+	}
+
+	return res.Stdout, nil
+}
+
+func (cli *pulumiCli) Up(ctx context.Context, stack StackOptions, eventLogPath string) (string, error) {
+	args := []string{"up", "--stack", stack.StackName, "--yes", "--skip-preview", "--event-log", eventLogPath}
+	args = append(args, configFileArgs(stack)...)
+
+	res, err := cli.runCommand(ctx, stack, nil, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed running pulumi up: %s: %w", res.String(), err)
+	}
+
+	return res.Stdout, nil
+}
+
+func (cli *pulumiCli) StackOutput(ctx context.Context, stack StackOptions) (string, error) {
+	res, err := cli.runCommand(ctx, stack, nil, "stack", "output", "--stack", stack.StackName, "--json", "--show-secrets")
+	if err != nil {
+		return "", fmt.Errorf("failed running pulumi stack output: %s: %w", res.String(), err)
+	}
+
+	return res.Stdout, nil
+}
+
+func (cli *pulumiCli) Destroy(ctx context.Context, stack StackOptions) (string, error) {
+	args := append([]string{"destroy", "--stack", stack.StackName, "--yes", "--skip-preview"}, configFileArgs(stack)...)
+
+	res, err := cli.runCommand(ctx, stack, nil, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed running pulumi destroy: %s: %w", res.String(), err)
+	}
+
+	return res.Stdout, nil
+}
+
+func (cli *pulumiCli) runCommand(ctx context.Context, stack StackOptions, stdin io.Reader, args ...string) (executil.RunResult, error) {
+	args = append(args, "--non-interactive", "--cwd", stack.ProjectPath)
+
+	runArgs := executil.RunArgs{
+		Cmd:   "pulumi",
+		Args:  args,
+		Env:   append([]string{"PULUMI_SKIP_UPDATE_CHECK=true"}, cli.env...),
+		Stdin: stdin,
+	}
+
+	return cli.runWithResultFn(ctx, runArgs)
+}
+
+// Gets the arguments that point pulumi to the stack configuration file when one is specified
+func configFileArgs(stack StackOptions) []string {
+	if stack.ConfigFilePath == "" {
+		return []string{}
+	}
+
+	return []string{"--config-file", stack.ConfigFilePath}
+}
@@ -0,0 +1,199 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package python
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/osutil"
+	"github.com/pelletier/go-toml"
+)
+
+// DependencyManager is the tool restoring the dependencies of a Python project
+type DependencyManager string
+
+const (
+	// Pip installs the dependencies listed in requirements.txt
+	Pip DependencyManager = "pip"
+	// PyProject is pip installing the dependencies listed in the [project] table of pyproject.toml (PEP 621)
+	PyProject DependencyManager = "pyproject"
+	// Poetry installs the dependencies of poetry.lock or of the pyproject.toml file configuring Poetry
+	Poetry DependencyManager = "poetry"
+	// Pipenv installs the dependencies of Pipfile.lock or Pipfile
+	Pipenv DependencyManager = "pipenv"
+)
+
+const (
+	RequirementsFileName = "requirements.txt"
+	PyProjectFileName    = "pyproject.toml"
+	PoetryLockFileName   = "poetry.lock"
+	PipfileName          = "Pipfile"
+	PipfileLockName      = "Pipfile.lock"
+)
+
+// DetectDependencyManager detects the dependency manager of the project from its lock and manifest files.
+// Lock files are looked for first, then the manifests of Poetry and Pipenv, requirements.txt and finally the
+// dependencies of pyproject.toml. Pip is returned when the project has none of these files.
+func DetectDependencyManager(projectDir string) (DependencyManager, error) {
+	if fileExists(filepath.Join(projectDir, PoetryLockFileName)) {
+		return Poetry, nil
+	}
+
+	if fileExists(filepath.Join(projectDir, PipfileLockName)) {
+		return Pipenv, nil
+	}
+
+	project, err := readPyProject(projectDir)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case project != nil && project.poetry:
+		return Poetry, nil
+	case fileExists(filepath.Join(projectDir, PipfileName)):
+		return Pipenv, nil
+	case fileExists(filepath.Join(projectDir, RequirementsFileName)):
+		return Pip, nil
+	case project != nil && project.project:
+		return PyProject, nil
+	default:
+		return Pip, nil
+	}
+}
+
+// ReadPyProjectDependencies returns the dependencies listed in the [project] table of the pyproject.toml file of
+// the project, as specified by PEP 621
+func ReadPyProjectDependencies(projectDir string) ([]string, error) {
+	project, err := readPyProject(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if project == nil || !project.project {
+		return nil, fmt.Errorf("%s of project '%s' has no [project] table", PyProjectFileName, projectDir)
+	}
+
+	if project.dynamicDependencies {
+		return nil, fmt.Errorf(
+			"the dependencies in %s of project '%s' are dynamic, list them in the [project] table or in %s",
+			PyProjectFileName,
+			projectDir,
+			RequirementsFileName,
+		)
+	}
+
+	return project.dependencies, nil
+}
+
+// WriteRequirements writes the dependencies to a requirements file
+func WriteRequirements(path string, dependencies []string) error {
+	content := strings.Join(dependencies, "\n")
+	if len(dependencies) > 0 {
+		content += "\n"
+	}
+
+	if err := os.WriteFile(path, []byte(content), osutil.PermissionFile); err != nil {
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
+
+// The settings of pyproject.toml azd reads
+type pyProject struct {
+	// Whether the file configures Poetry, in the [tool.poetry] tables
+	poetry bool
+	// Whether the file has the [project] table of PEP 621
+	project bool
+	// The dependencies of the [project] table
+	dependencies []string
+	// Whether the dependencies of the [project] table are provided by the build backend
+	dynamicDependencies bool
+}
+
+// readPyProject reads the pyproject.toml file of the project, nil is returned when the project has none
+func readPyProject(projectDir string) (*pyProject, error) {
+	content, err := os.ReadFile(filepath.Join(projectDir, PyProjectFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", PyProjectFileName, err)
+	}
+
+	project, err := parsePyProject(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s of project '%s': %w", PyProjectFileName, projectDir, err)
+	}
+
+	return project, nil
+}
+
+func parsePyProject(content string) (*pyProject, error) {
+	tree, err := toml.Load(content)
+	if err != nil {
+		return nil, err
+	}
+
+	project := &pyProject{
+		poetry:  tree.HasPath([]string{"tool", "poetry"}),
+		project: tree.Has("project"),
+	}
+
+	if !project.project {
+		return project, nil
+	}
+
+	project.dependencies, err = stringArray(tree, "dependencies")
+	if err != nil {
+		return nil, err
+	}
+
+	dynamic, err := stringArray(tree, "dynamic")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range dynamic {
+		if value == "dependencies" {
+			project.dynamicDependencies = true
+		}
+	}
+
+	return project, nil
+}
+
+// stringArray returns the array of strings of the key of the [project] table, nil when the key is not set
+func stringArray(tree *toml.Tree, key string) ([]string, error) {
+	value := tree.GetPath([]string{"project", key})
+	if value == nil {
+		return nil, nil
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid value for project.%s: expected an array of strings", key)
+	}
+
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for project.%s: expected an array of strings", key)
+		}
+
+		values = append(values, str)
+	}
+
+	return values, nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,123 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package python
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const pep621PyProject = `
+[build-system]
+requires = ["setuptools"] # the build backend
+
+[project]
+name = "api"
+description = """
+[tool.poetry]
+is not a table in a string
+"""
+dependencies = [
+    "fastapi>=0.95",  # web framework
+    'uvicorn[standard]',
+    "azure-identity; python_version >= \"3.8\"",
+]
+
+[project.optional-dependencies]
+dev = ["pytest"]
+`
+
+const poetryPyProject = `
+[tool.poetry]
+name = "api"
+
+[tool.poetry.dependencies]
+python = "^3.10"
+flask = "^2.3"
+`
+
+func TestDetectDependencyManager(t *testing.T) {
+	tests := map[string]struct {
+		files    map[string]string
+		expected DependencyManager
+	}{
+		"Empty":        {map[string]string{}, Pip},
+		"Requirements": {map[string]string{RequirementsFileName: "flask"}, Pip},
+		"PyProject":    {map[string]string{PyProjectFileName: pep621PyProject}, PyProject},
+		"PyProjectAndRequirements": {
+			map[string]string{PyProjectFileName: pep621PyProject, RequirementsFileName: "flask"},
+			Pip,
+		},
+		"PoetryManifest": {map[string]string{PyProjectFileName: poetryPyProject}, Poetry},
+		"PoetryLock": {
+			map[string]string{PyProjectFileName: pep621PyProject, PoetryLockFileName: "", RequirementsFileName: "flask"},
+			Poetry,
+		},
+		"Pipfile":     {map[string]string{PipfileName: "[packages]\nflask = \"*\""}, Pipenv},
+		"PipfileLock": {map[string]string{PipfileLockName: "{}", RequirementsFileName: "flask"}, Pipenv},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for file, content := range test.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0600))
+			}
+
+			manager, err := DetectDependencyManager(dir)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, manager)
+		})
+	}
+}
+
+func TestReadPyProjectDependencies(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PyProjectFileName), []byte(pep621PyProject), 0600))
+
+	dependencies, err := ReadPyProjectDependencies(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"fastapi>=0.95",
+		"uvicorn[standard]",
+		"azure-identity; python_version >= \"3.8\"",
+	}, dependencies)
+
+	requirementsPath := filepath.Join(dir, RequirementsFileName)
+	require.NoError(t, WriteRequirements(requirementsPath, dependencies))
+	content, err := os.ReadFile(requirementsPath)
+	require.NoError(t, err)
+	require.Equal(t, "fastapi>=0.95\nuvicorn[standard]\nazure-identity; python_version >= \"3.8\"\n", string(content))
+
+	dynamic := "[project]\nname = \"api\"\ndynamic = [\"version\", \"dependencies\"]\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PyProjectFileName), []byte(dynamic), 0600))
+	_, err = ReadPyProjectDependencies(dir)
+	require.ErrorContains(t, err, "are dynamic")
+
+	invalid := "[project]\ndependencies = [\"flask\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PyProjectFileName), []byte(invalid), 0600))
+	_, err = ReadPyProjectDependencies(dir)
+	require.ErrorContains(t, err, "parsing pyproject.toml")
+
+	// Any TOML syntax is supported, ex) dotted keys and multi-line strings holding table headers
+	dotted := "project.name = \"api\"\nproject.dependencies = [\n  \"flask\", # web\n]\n" +
+		"[tool.other]\ndescription = \"\"\"\n[tool.poetry]\n\"\"\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PyProjectFileName), []byte(dotted), 0600))
+	dependencies, err = ReadPyProjectDependencies(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"flask"}, dependencies)
+
+	require.NoError(t, os.Remove(requirementsPath))
+	manager, err := DetectDependencyManager(dir)
+	require.NoError(t, err)
+	require.Equal(t, PyProject, manager)
+
+	wrongType := "[project]\ndependencies = \"flask\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PyProjectFileName), []byte(wrongType), 0600))
+	_, err = ReadPyProjectDependencies(dir)
+	require.ErrorContains(t, err, "expected an array of strings")
+}
@@ -0,0 +1,90 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package python
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/osutil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/blang/semver/v4"
+)
+
+// PipenvCli restores the dependencies of the projects managed with Pipenv
+type PipenvCli struct{}
+
+func NewPipenvCli() *PipenvCli {
+	return &PipenvCli{}
+}
+
+func (cli *PipenvCli) versionInfo() tools.VersionInfo {
+	return tools.VersionInfo{
+		// The first version with the requirements command
+		MinimumVersion: semver.Version{
+			Major: 2022,
+			Minor: 4,
+			Patch: 8},
+		UpdateCommand: "Run \"pip install --user --upgrade pipenv\" to upgrade",
+	}
+}
+
+func (cli *PipenvCli) CheckInstalled(ctx context.Context) (bool, error) {
+	found, err := tools.ToolInPath("pipenv")
+	if !found {
+		return false, err
+	}
+	pipenvRes, err := tools.ExecuteCommand(ctx, "pipenv", "--version")
+	if err != nil {
+		return false, fmt.Errorf("checking %s version: %w", cli.Name(), err)
+	}
+	pipenvSemver, err := tools.ExtractSemver(pipenvRes)
+	if err != nil {
+		return false, fmt.Errorf("converting to semver version fails: %w", err)
+	}
+	updateDetail := cli.versionInfo()
+	if pipenvSemver.LT(updateDetail.MinimumVersion) {
+		return false, &tools.ErrSemver{ToolName: cli.Name(), VersionInfo: updateDetail}
+	}
+	return true, nil
+}
+
+func (cli *PipenvCli) InstallUrl() string {
+	return "https://pipenv.pypa.io/en/latest/installation.html"
+}
+
+func (cli *PipenvCli) Name() string {
+	return "Pipenv"
+}
+
+// Install restores the dependencies of the project in the virtual environment managed by Pipenv. When the project
+// has a Pipfile.lock, the locked versions are installed and the install fails if the lock file is out of date.
+func (cli *PipenvCli) Install(ctx context.Context, projectDir string) error {
+	args := []string{"install"}
+	if fileExists(filepath.Join(projectDir, PipfileLockName)) {
+		args = append(args, "--deploy")
+	}
+
+	res, err := executil.RunCommandWithShellAndEnvAndCwd(ctx, "pipenv", args, nil, projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to install dependencies of project '%s' with pipenv: %w (%s)", projectDir, err, res.String())
+	}
+	return nil
+}
+
+// ExportRequirements writes the default dependencies of the project to the requirements file at outputPath
+func (cli *PipenvCli) ExportRequirements(ctx context.Context, projectDir string, outputPath string) error {
+	res, err := executil.RunCommandWithShellAndEnvAndCwd(ctx, "pipenv", []string{"requirements"}, nil, projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to export requirements of project '%s' with pipenv: %w (%s)", projectDir, err, res.String())
+	}
+
+	if err := os.WriteFile(outputPath, []byte(res.Stdout), osutil.PermissionFile); err != nil {
+		return fmt.Errorf("writing %s: %w", RequirementsFileName, err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package python
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/blang/semver/v4"
+)
+
+// PoetryCli restores the dependencies of the projects managed with Poetry
+type PoetryCli struct{}
+
+// The plugin providing `poetry export`, which is no longer bundled with Poetry since Poetry 2.0
+const poetryExportPlugin = "poetry-plugin-export"
+
+func NewPoetryCli() *PoetryCli {
+	return &PoetryCli{}
+}
+
+func (cli *PoetryCli) versionInfo() tools.VersionInfo {
+	return tools.VersionInfo{
+		MinimumVersion: semver.Version{
+			Major: 1,
+			Minor: 2,
+			Patch: 0},
+		UpdateCommand: "Run \"poetry self update\" to upgrade",
+	}
+}
+
+func (cli *PoetryCli) CheckInstalled(ctx context.Context) (bool, error) {
+	found, err := tools.ToolInPath("poetry")
+	if !found {
+		return false, err
+	}
+	poetryRes, err := tools.ExecuteCommand(ctx, "poetry", "--version")
+	if err != nil {
+		return false, fmt.Errorf("checking %s version: %w", cli.Name(), err)
+	}
+	poetrySemver, err := tools.ExtractSemver(poetryRes)
+	if err != nil {
+		return false, fmt.Errorf("converting to semver version fails: %w", err)
+	}
+	updateDetail := cli.versionInfo()
+	if poetrySemver.LT(updateDetail.MinimumVersion) {
+		return false, &tools.ErrSemver{ToolName: cli.Name(), VersionInfo: updateDetail}
+	}
+
+	if poetrySemver.Major >= 2 {
+		plugins, err := tools.ExecuteCommand(ctx, "poetry", "self", "show", "plugins")
+		if err != nil {
+			return false, fmt.Errorf("listing %s plugins: %w", cli.Name(), err)
+		}
+
+		if !hasPoetryPlugin(plugins, poetryExportPlugin) {
+			return false, fmt.Errorf(
+				"%s %s requires the %s plugin to export the dependencies of the projects, run \"poetry self add %s\" to install it",
+				cli.Name(),
+				poetrySemver,
+				poetryExportPlugin,
+				poetryExportPlugin,
+			)
+		}
+	}
+
+	return true, nil
+}
+
+// hasPoetryPlugin returns true when the plugin is listed by the output of `poetry self show plugins`. The plugins are
+// the items of the outermost list, ex) "- poetry-plugin-export (1.8.0) Poetry plugin to export the dependencies", the
+// dependencies of each plugin are listed further indented under it.
+func hasPoetryPlugin(plugins string, name string) bool {
+	pluginIndent := -1
+	for _, line := range strings.Split(plugins, "\n") {
+		item := strings.TrimLeft(line, " \t")
+		if !strings.HasPrefix(item, "- ") {
+			continue
+		}
+
+		indent := len(line) - len(item)
+		if pluginIndent < 0 {
+			pluginIndent = indent
+		}
+
+		fields := strings.Fields(strings.TrimPrefix(item, "- "))
+		if indent == pluginIndent && len(fields) > 0 && fields[0] == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cli *PoetryCli) InstallUrl() string {
+	return "https://python-poetry.org/docs/#installation"
+}
+
+func (cli *PoetryCli) Name() string {
+	return "Poetry"
+}
+
+// Install restores the dependencies of the project in the virtual environment managed by Poetry.
+// The project itself is not installed, services don't need to be installable packages.
+func (cli *PoetryCli) Install(ctx context.Context, projectDir string) error {
+	res, err := executil.RunCommandWithShellAndEnvAndCwd(ctx, "poetry", []string{
+		"install", "--no-interaction", "--no-root",
+	}, nil, projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to install dependencies of project '%s' with poetry: %w (%s)", projectDir, err, res.String())
+	}
+	return nil
+}
+
+// ExportRequirements writes the main dependencies of the project to the requirements file at outputPath
+func (cli *PoetryCli) ExportRequirements(ctx context.Context, projectDir string, outputPath string) error {
+	res, err := executil.RunCommandWithShellAndEnvAndCwd(ctx, "poetry", []string{
+		"export", "--format", "requirements.txt", "--only", "main", "--without-hashes", "--output", outputPath,
+	}, nil, projectDir)
+	if err != nil {
+		return fmt.Errorf(
+			"failed to export requirements of project '%s' with poetry, Poetry 2.0 and later require the "+
+				"poetry-plugin-export plugin: %w (%s)",
+			projectDir,
+			err,
+			res.String(),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package python
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasPoetryPlugin(t *testing.T) {
+	const plugins = `
+  - poetry-plugin-export (1.8.0) Poetry plugin to export the dependencies to various formats
+      1 application plugin
+
+      Dependencies
+        - poetry (>=2.0.0,<3.0.0)
+        - poetry-core (>=1.7.0,<3.0.0)
+
+  - poetry-plugin-shell (1.0.1) Poetry plugin to run subshell with virtual environment activated
+`
+
+	require.True(t, hasPoetryPlugin(plugins, "poetry-plugin-export"))
+	require.True(t, hasPoetryPlugin(plugins, "poetry-plugin-shell"))
+	require.False(t, hasPoetryPlugin(plugins, "poetry"))
+	require.False(t, hasPoetryPlugin("", "poetry-plugin-export"))
+}
@@ -0,0 +1,179 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package terraform
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/azure/azure-dev/cli/azd/pkg/executil"
+	"github.com/azure/azure-dev/cli/azd/pkg/tools"
+	"github.com/blang/semver/v4"
+)
+
+type TerraformCli interface {
+	tools.ExternalTool
+	// SetEnv sets additional environment variables (ex. TF_DATA_DIR) that are passed to every terraform command.
+	SetEnv(envVars []string)
+	Init(ctx context.Context, modulePath string, additionalArgs ...string) (string, error)
+	Validate(ctx context.Context, modulePath string) (string, error)
+	Plan(ctx context.Context, modulePath string, planFilePath string, additionalArgs ...string) (string, error)
+	Show(ctx context.Context, modulePath string, planFilePath string) (string, error)
+	Apply(ctx context.Context, modulePath string, additionalArgs ...string) (string, error)
+	Output(ctx context.Context, modulePath string, additionalArgs ...string) (string, error)
+	Destroy(ctx context.Context, modulePath string, additionalArgs ...string) (string, error)
+}
+
+func NewTerraformCli(args NewTerraformCliArgs) TerraformCli {
+	if args.RunWithResultFn == nil {
+		args.RunWithResultFn = executil.RunWithResult
+	}
+
+	return &terraformCli{
+		runWithResultFn: args.RunWithResultFn,
+	}
+}
+
+type NewTerraformCliArgs struct {
+	// RunWithResultFn allows us to stub out the command execution for testing
+	RunWithResultFn func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error)
+}
+
+type terraformCli struct {
+	env             []string
+	runWithResultFn func(ctx context.Context, args executil.RunArgs) (executil.RunResult, error)
+}
+
+func (cli *terraformCli) Name() string {
+	return "Terraform CLI"
+}
+
+func (cli *terraformCli) InstallUrl() string {
+	return "https://aka.ms/azure-dev/terraform-install"
+}
+
+func (cli *terraformCli) versionInfo() tools.VersionInfo {
+	return tools.VersionInfo{
+		MinimumVersion: semver.Version{
+			Major: 1,
+			Minor: 1,
+			Patch: 7},
+		UpdateCommand: "Visit https://www.terraform.io/downloads.html to upgrade",
+	}
+}
+
+func (cli *terraformCli) CheckInstalled(ctx context.Context) (bool, error) {
+	found, err := tools.ToolInPath("terraform")
+	if !found {
+		return false, err
+	}
+	tfRes, err := tools.ExecuteCommand(ctx, "terraform", "version")
+	if err != nil {
+		return false, fmt.Errorf("checking %s version: %w", cli.Name(), err)
+	}
+	tfSemver, err := tools.ExtractSemver(tfRes)
+	if err != nil {
+		return false, fmt.Errorf("converting to semver version fails: %w", err)
+	}
+	updateDetail := cli.versionInfo()
+	if tfSemver.LT(updateDetail.MinimumVersion) {
+		return false, &tools.ErrSemver{ToolName: cli.Name(), VersionInfo: updateDetail}
+	}
+
+	return true, nil
+}
+
+func (cli *terraformCli) SetEnv(envVars []string) {
+	cli.env = envVars
+}
+
+func (cli *terraformCli) Init(ctx context.Context, modulePath string, additionalArgs ...string) (string, error) {
+	args := []string{fmt.Sprintf("-chdir=%s", modulePath), "init", "-input=false", "-upgrade"}
+	args = append(args, additionalArgs...)
+
+	res, err := cli.runCommand(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed running terraform init: %s: %w", res.String(), err)
+	}
+
+	return res.Stdout, nil
+}
+
+func (cli *terraformCli) Validate(ctx context.Context, modulePath string) (string, error) {
+	res, err := cli.runCommand(ctx, fmt.Sprintf("-chdir=%s", modulePath), "validate", "-no-color")
+	if err != nil {
+		return "", fmt.Errorf("failed running terraform validate: %s: %w", res.String(), err)
+	}
+
+	return res.Stdout, nil
+}
+
+func (cli *terraformCli) Plan(ctx context.Context, modulePath string, planFilePath string, additionalArgs ...string) (string, error) {
+	args := []string{fmt.Sprintf("-chdir=%s", modulePath), "plan", "-input=false", "-no-color", fmt.Sprintf("-out=%s", planFilePath)}
+	args = append(args, additionalArgs...)
+
+	res, err := cli.runCommand(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed running terraform plan: %s: %w", res.String(), err)
+	}
+
+	return res.Stdout, nil
+}
+
+// Show returns the JSON representation of the specified plan file
+func (cli *terraformCli) Show(ctx context.Context, modulePath string, planFilePath string) (string, error) {
+	res, err := cli.runCommand(ctx, fmt.Sprintf("-chdir=%s", modulePath), "show", "-json", planFilePath)
+	if err != nil {
+		return "", fmt.Errorf("failed running terraform show: %s: %w", res.String(), err)
+	}
+
+	return res.Stdout, nil
+}
+
+func (cli *terraformCli) Apply(ctx context.Context, modulePath string, additionalArgs ...string) (string, error) {
+	args := []string{fmt.Sprintf("-chdir=%s", modulePath), "apply", "-input=false", "-no-color", "-auto-approve"}
+	args = append(args, additionalArgs...)
+
+	res, err := cli.runCommand(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed running terraform apply: %s: %w", res.String(), err)
+	}
+
+	return res.Stdout, nil
+}
+
+// Output returns the JSON representation of the outputs stored in the terraform state
+func (cli *terraformCli) Output(ctx context.Context, modulePath string, additionalArgs ...string) (string, error) {
+	args := []string{fmt.Sprintf("-chdir=%s", modulePath), "output", "-json"}
+	args = append(args, additionalArgs...)
+
+	res, err := cli.runCommand(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed running terraform output: %s: %w", res.String(), err)
+	}
+
+	return res.Stdout, nil
+}
+
+func (cli *terraformCli) Destroy(ctx context.Context, modulePath string, additionalArgs ...string) (string, error) {
+	args := []string{fmt.Sprintf("-chdir=%s", modulePath), "destroy", "-input=false", "-no-color", "-auto-approve"}
+	args = append(args, additionalArgs...)
+
+	res, err := cli.runCommand(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed running terraform destroy: %s: %w", res.String(), err)
+	}
+
+	return res.Stdout, nil
+}
+
+func (cli *terraformCli) runCommand(ctx context.Context, args ...string) (executil.RunResult, error) {
+	runArgs := executil.RunArgs{
+		Cmd:  "terraform",
+		Args: args,
+		Env:  append([]string{"TF_IN_AUTOMATION=1"}, cli.env...),
+	}
+
+	return cli.runWithResultFn(ctx, runArgs)
+}
@@ -16,6 +16,7 @@ require (
 	github.com/microsoft/ApplicationInsights-Go v0.4.4
 	github.com/otiai10/copy v1.7.0
 	github.com/pbnj/go-open v0.1.1
+	github.com/pelletier/go-toml v1.9.4
 	github.com/sethvargo/go-retry v0.2.3
 	github.com/spf13/cobra v1.3.0
 	github.com/spf13/pflag v1.0.5
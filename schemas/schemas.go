@@ -0,0 +1,14 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+// Package schemas embeds the JSON schemas of the files read by azd.
+package schemas
+
+import (
+	_ "embed"
+)
+
+// AzureYamlV1 is the JSON schema of azure.yaml
+//
+//go:embed v1.0/azure.yaml.json
+var AzureYamlV1 []byte